@@ -0,0 +1,113 @@
+package api_test // prevent import cycles
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/github/git-lfs/api"
+	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const gzipBatchOid = "988881adc9fc3655077dc2d4d757d480b5ea0e11"
+
+// gzipBatchServer returns a server whose batch API gzips its response, passing
+// the compressed body through mangle first.
+func gzipBatchServer(t *testing.T, mangle func([]byte) []byte) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc("/media/objects/batch", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			t.Errorf("Batch request doesn't accept gzip: %q", r.Header.Get("Accept-Encoding"))
+		}
+
+		by, err := json.Marshal(map[string]interface{}{
+			"objects": []*api.ObjectResource{{
+				Oid:  gzipBatchOid,
+				Size: 4,
+				Actions: map[string]*api.LinkRelation{
+					"download": {Href: server.URL + "/download"},
+				},
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		buf := &bytes.Buffer{}
+		gz := gzip.NewWriter(buf)
+		gz.Write(by)
+		gz.Close()
+
+		w.Header().Set("Content-Type", api.MediaType)
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(200)
+		w.Write(mangle(buf.Bytes()))
+	})
+
+	return server
+}
+
+func gzipBatch(t *testing.T, mangle func([]byte) []byte) ([]*api.ObjectResource, error) {
+	SetupTestCredentialsFunc()
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+		RestoreCredentialsFunc()
+	}()
+
+	server := gzipBatchServer(t, mangle)
+	defer server.Close()
+
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.url", server.URL+"/media")
+
+	objs, _, err := api.Batch([]*api.ObjectResource{{Oid: gzipBatchOid, Size: 4}}, "download", []string{"basic"})
+	return objs, err
+}
+
+func TestBatchGzippedResponse(t *testing.T) {
+	objs, err := gzipBatch(t, func(by []byte) []byte { return by })
+	if isDockerConnectionError(err) {
+		return
+	}
+
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(objs)) {
+		assert.Equal(t, gzipBatchOid, objs[0].Oid)
+		_, ok := objs[0].Rel("download")
+		assert.True(t, ok)
+	}
+}
+
+func TestBatchTruncatedGzippedResponse(t *testing.T) {
+	// Drop the gzip trailer, leaving the JSON itself complete
+	_, err := gzipBatch(t, func(by []byte) []byte { return by[:len(by)-4] })
+	if isDockerConnectionError(err) {
+		return
+	}
+
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "Unable to decompress HTTP response")
+	}
+}
+
+func TestBatchCorruptGzippedResponse(t *testing.T) {
+	_, err := gzipBatch(t, func(by []byte) []byte { return []byte("not gzip") })
+	if isDockerConnectionError(err) {
+		return
+	}
+
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "Unable to decompress HTTP response")
+	}
+}
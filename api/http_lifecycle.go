@@ -77,6 +77,7 @@ func (l *HttpLifecycle) Build(schema *RequestSchema) (*http.Request, error) {
 	if err != nil {
 		return nil, err
 	}
+	httputil.AcceptCompressedResponse(req)
 
 	if _, err = auth.GetCreds(req); err != nil {
 		return nil, err
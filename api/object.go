@@ -9,6 +9,9 @@ import (
 	"github.com/github/git-lfs/httputil"
 )
 
+// maxActionTimeout caps the timeout hints that servers give for actions.
+const maxActionTimeout = 24 * time.Hour
+
 type ObjectError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
@@ -73,8 +76,33 @@ func (o *ObjectResource) IsExpired(now time.Time) bool {
 	return false
 }
 
+// ActionTimeout returns how long the transfer using the named action may take,
+// from the action's timeout hint, for objects which the server knows are slow
+// to transfer, such as those in cold storage. Hints are capped at 24 hours.
+// Returns 0 if the action has no hint.
+func (o *ObjectResource) ActionTimeout(name string) time.Duration {
+	rel, ok := o.Rel(name)
+	if !ok || rel == nil || rel.Timeout <= 0 {
+		return 0
+	}
+
+	// compared in seconds, so that a huge hint can't overflow
+	if rel.Timeout > int(maxActionTimeout/time.Second) {
+		return maxActionTimeout
+	}
+	return time.Duration(rel.Timeout) * time.Second
+}
+
 type LinkRelation struct {
 	Href      string            `json:"href"`
 	Header    map[string]string `json:"header,omitempty"`
 	ExpiresAt time.Time         `json:"expires_at,omitempty"`
+
+	// Form holds the fields of a presigned POST form, for object stores
+	// which take uploads as multipart/form-data rather than a PUT.
+	Form map[string]string `json:"form,omitempty"`
+
+	// Timeout is how long, in seconds, the transfer may take, overriding
+	// lfs.transfer.timeout; see ObjectResource.ActionTimeout.
+	Timeout int `json:"timeout,omitempty"`
 }
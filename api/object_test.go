@@ -1,6 +1,7 @@
 package api_test
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -47,3 +48,28 @@ func TestObjectsWithExpirationDatesAreExpired(t *testing.T) {
 
 	assert.True(t, o.IsExpired(now))
 }
+
+func TestObjectActionTimeout(t *testing.T) {
+	o := &api.ObjectResource{
+		Oid: "some-oid",
+		Actions: map[string]*api.LinkRelation{
+			"download": &api.LinkRelation{Href: "http://your-lfs-server.com", Timeout: 3600},
+			"upload":   &api.LinkRelation{Href: "http://your-lfs-server.com", Timeout: 7 * 24 * 3600},
+			"verify":   &api.LinkRelation{Href: "http://your-lfs-server.com"},
+			"huge":     &api.LinkRelation{Href: "http://your-lfs-server.com", Timeout: int(^uint(0) >> 1)},
+		},
+	}
+
+	assert.Equal(t, time.Hour, o.ActionTimeout("download"))
+	assert.Equal(t, 24*time.Hour, o.ActionTimeout("upload"), "capped at 24 hours")
+	assert.Equal(t, 24*time.Hour, o.ActionTimeout("huge"), "capped without overflowing")
+	assert.Equal(t, time.Duration(0), o.ActionTimeout("verify"))
+	assert.Equal(t, time.Duration(0), o.ActionTimeout("missing"))
+}
+
+func TestObjectActionTimeoutFromJSON(t *testing.T) {
+	var o api.ObjectResource
+	err := json.Unmarshal([]byte(`{"oid":"some-oid","size":1,"actions":{"download":{"href":"http://your-lfs-server.com","timeout":900}}}`), &o)
+	assert.Nil(t, err)
+	assert.Equal(t, 15*time.Minute, o.ActionTimeout("download"))
+}
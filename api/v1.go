@@ -113,6 +113,7 @@ func NewRequest(method, oid string) (*http.Request, error) {
 	}
 
 	req.Header.Set("Accept", MediaType)
+	httputil.AcceptCompressedResponse(req)
 	return req, nil
 }
 
@@ -142,6 +143,7 @@ func NewBatchRequest(operation string) (*http.Request, error) {
 	}
 
 	req.Header.Set("Accept", MediaType)
+	httputil.AcceptCompressedResponse(req)
 	if res.Header != nil {
 		for key, value := range res.Header {
 			req.Header.Set(key, value)
@@ -93,7 +93,8 @@ func setCredURLFromNetrc(req *http.Request) bool {
 	hostname := req.URL.Host
 	var host string
 
-	if strings.Contains(hostname, ":") {
+	// IPv6 addresses are in brackets, with or without a port
+	if strings.Contains(hostname, ":") && !strings.HasSuffix(hostname, "]") {
 		var err error
 		host, _, err = net.SplitHostPort(hostname)
 		if err != nil {
@@ -101,7 +102,7 @@ func setCredURLFromNetrc(req *http.Request) bool {
 			return false
 		}
 	} else {
-		host = hostname
+		host = strings.Trim(hostname, "[]")
 	}
 
 	machine, err := config.Config.FindNetrcHost(host)
@@ -194,9 +195,20 @@ type CredentialFunc func(Creds, string) (Creds, error)
 
 func execCredsCommand(input Creds, subCommand string) (Creds, error) {
 	output := new(bytes.Buffer)
-	cmd := exec.Command("git", "credential", subCommand)
+	prompt := subCommand != "fill" || config.Config.CredentialPrompt()
+	args := credentialArgs(input, subCommand)
+	if !prompt {
+		// Git asks with GIT_ASKPASS, core.askPass or SSH_ASKPASS before
+		// the terminal, and ignores them if they're empty.
+		args = append([]string{"-c", "core.askPass="}, args...)
+	}
+
+	cmd := exec.Command("git", args...)
 	cmd.Stdin = input.Buffer()
 	cmd.Stdout = output
+	if !prompt {
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+	}
 	/*
 		There is a reason we don't hook up stderr here:
 		Git's credential cache daemon helper does not close its stderr, so if this
@@ -212,6 +224,11 @@ func execCredsCommand(input Creds, subCommand string) (Creds, error) {
 	}
 
 	if _, ok := err.(*exec.ExitError); ok {
+		if !prompt {
+			return nil, fmt.Errorf("No credential helper has credentials for %s://%s, and prompting for them is disabled by lfs.credential.prompt or GIT_LFS_SKIP_CREDENTIAL_PROMPT.",
+				input["protocol"], input["host"])
+		}
+
 		if !config.Config.GetenvBool("GIT_TERMINAL_PROMPT", true) {
 			return nil, fmt.Errorf("Change the GIT_TERMINAL_PROMPT env var to be prompted to enter your credentials for %s://%s.",
 				input["protocol"], input["host"])
@@ -240,6 +257,23 @@ func execCredsCommand(input Creds, subCommand string) (Creds, error) {
 	return creds, nil
 }
 
+// credentialArgs returns the arguments for 'git credential'. If a helper has
+// been configured for the url with `lfs.<url>.credentialhelper`, it replaces
+// Git's own helper chain for this call.
+func credentialArgs(input Creds, subCommand string) []string {
+	helper, ok := config.Config.CredentialHelper(input["protocol"], input["host"], input["path"])
+	if !ok {
+		return []string{"credential", subCommand}
+	}
+
+	tracerx.Printf("creds: using credential helper %q for %s://%s", helper, input["protocol"], input["host"])
+
+	// An empty credential.helper value resets the list of helpers that Git
+	// has read from its config files.
+	return []string{"-c", "credential.helper=", "-c", "credential.helper=" + helper,
+		"credential", subCommand}
+}
+
 func setRequestAuthFromUrl(req *http.Request, u *url.URL) bool {
 	if !config.Config.NtlmAccess(GetOperationForRequest(req)) && u.User != nil {
 		if pass, ok := u.User.Password(); ok {
@@ -120,7 +120,7 @@ func TestGetCredentialsForApi(t *testing.T) {
 type fakeNetrc struct{}
 
 func (n *fakeNetrc) FindMachine(host string) *netrc.Machine {
-	if host == "some-host" {
+	if host == "some-host" || host == "::1" {
 		return &netrc.Machine{Login: "abc", Password: "def"}
 	}
 	return nil
@@ -178,6 +178,33 @@ func TestNetrcWithHost(t *testing.T) {
 	RestoreCredentialsFunc()
 }
 
+func TestNetrcWithIPv6Host(t *testing.T) {
+	SetupTestCredentialsFunc()
+	defer RestoreCredentialsFunc()
+
+	config.Config.SetNetrc(&fakeNetrc{})
+	for _, rawurl := range []string{"http://[::1]/foo/bar", "http://[::1]:123/foo/bar"} {
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := &http.Request{
+			URL:    u,
+			Header: http.Header{},
+		}
+
+		if !setCredURLFromNetrc(req) {
+			t.Fatalf("no netrc match for %s", rawurl)
+		}
+
+		auth := req.Header.Get("Authorization")
+		if auth != "Basic YWJjOmRlZg==" {
+			t.Fatalf("bad basic auth for %s: %q", rawurl, auth)
+		}
+	}
+}
+
 func TestNetrcWithBadHost(t *testing.T) {
 	SetupTestCredentialsFunc()
 
@@ -204,6 +231,35 @@ func TestNetrcWithBadHost(t *testing.T) {
 	RestoreCredentialsFunc()
 }
 
+func TestCredentialHelperPerHost(t *testing.T) {
+	config.Config.SetConfig("lfs.https://git-server.com.credentialhelper",
+		"!f() { echo username=one; echo password=monkey1; }; f")
+	config.Config.SetConfig("lfs.https://other-server.com.credentialhelper",
+		"!f() { echo username=two; echo password=monkey2; }; f")
+	defer config.Config.ResetConfig()
+
+	tests := map[string][]string{
+		"git-server.com":   {"one", "monkey1"},
+		"other-server.com": {"two", "monkey2"},
+	}
+
+	for host, expected := range tests {
+		input := Creds{"protocol": "https", "host": host}
+		creds, err := execCredsCommand(input, "fill")
+		if err != nil {
+			t.Fatalf("%s: %s", host, err)
+		}
+
+		if creds["username"] != expected[0] {
+			t.Errorf("%s: bad username: %q, expected: %q", host, creds["username"], expected[0])
+		}
+
+		if creds["password"] != expected[1] {
+			t.Errorf("%s: bad password: %q, expected: %q", host, creds["password"], expected[1])
+		}
+	}
+}
+
 func checkGetCredentials(t *testing.T, getCredsFunc func(*http.Request) (Creds, error), checks []*getCredentialCheck) {
 	existingRemote := config.Config.CurrentRemote
 	for _, check := range checks {
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"encoding/hex"
 	"io"
 	"os"
 	"os/exec"
@@ -10,7 +11,7 @@ import (
 	"github.com/github/git-lfs/errutil"
 	"github.com/github/git-lfs/git"
 	"github.com/github/git-lfs/lfs"
-	"github.com/github/git-lfs/progress"
+	"github.com/github/git-lfs/tools"
 	"github.com/rubyist/tracerx"
 	"github.com/spf13/cobra"
 )
@@ -20,10 +21,15 @@ var (
 		Use: "checkout",
 		Run: checkoutCommand,
 	}
+
+	checkoutStage       = false
+	checkoutProgressArg string
 )
 
 func checkoutCommand(cmd *cobra.Command, args []string) {
 	requireInRepo()
+	warnIfNotInstalled()
+	setProgressStyle(checkoutProgressArg)
 
 	// Parameters are filters
 	// firstly convert any pathspecs to the root of the repo, in case this is being executed in a sub-folder
@@ -39,13 +45,104 @@ func checkoutCommand(cmd *cobra.Command, args []string) {
 		rootedpaths = append(rootedpaths, <-outchan)
 	}
 	close(inchan)
+
+	if checkoutStage {
+		checkoutStaged(rootedpaths)
+		return
+	}
+
 	checkoutWithIncludeExclude(rootedpaths, nil)
 }
 
 func init() {
+	checkoutCmd.Flags().BoolVarP(&checkoutStage, "stage", "", false, "Check out the version of files in the index instead of HEAD")
+	checkoutCmd.Flags().StringVarP(&checkoutProgressArg, "progress", "", "", "Progress output: bar, plain or none")
 	RootCmd.AddCommand(checkoutCmd)
 }
 
+// checkoutStaged populates the working copy from the pointers in the index,
+// downloading any objects that aren't local. The index itself is unchanged.
+func checkoutStaged(include []string) {
+	pointers, err := lfs.ScanIndexTree()
+	if err != nil {
+		Panic(err, "Could not scan the index for Git LFS files")
+	}
+	pointers = withoutPathCollisions(withoutDeletedFiles(pointers), include, nil)
+
+	if depth := config.Config.CheckoutReadAhead(); depth > 0 {
+		checkoutWithReadAhead(pointers, include, nil, depth)
+		return
+	}
+
+	fetchPointers(pointers, include, nil)
+	checkoutPointersWithIncludeExclude(pointers, include, nil)
+}
+
+// checkoutWithReadAhead checks out the pointers that pass the include/exclude
+// filter, downloading the next depth objects while the current ones are being
+// written to the working copy.
+func checkoutWithReadAhead(pointers []*lfs.WrappedPointer, include []string, exclude []string, depth int) {
+	wanted := make([]*lfs.WrappedPointer, 0, len(pointers))
+	for _, p := range pointers {
+		if lfs.FilenamePassesIncludeExcludeFilter(p.Name, include, exclude) {
+			wanted = append(wanted, p)
+		}
+	}
+
+	var wait sync.WaitGroup
+	wait.Add(1)
+
+	c := make(chan *lfs.WrappedPointer)
+	go func() {
+		checkoutWithChan(c)
+		wait.Done()
+	}()
+
+	readAhead(wanted, depth,
+		func(group []*lfs.WrappedPointer) { fetchPointers(group, nil, nil) },
+		func(p *lfs.WrappedPointer) { c <- p })
+
+	close(c)
+	wait.Wait()
+}
+
+// readAhead calls write for each of pointers in order. Pointers are passed to
+// fetch in groups of depth, and each group is fetched while the one before it
+// is being written.
+func readAhead(pointers []*lfs.WrappedPointer, depth int, fetch func([]*lfs.WrappedPointer), write func(*lfs.WrappedPointer)) {
+	var groups [][]*lfs.WrappedPointer
+	for len(pointers) > 0 {
+		n := depth
+		if n > len(pointers) {
+			n = len(pointers)
+		}
+		groups = append(groups, pointers[:n])
+		pointers = pointers[n:]
+	}
+
+	if len(groups) == 0 {
+		return
+	}
+
+	fetched := make(chan struct{})
+	fetchGroup := func(group []*lfs.WrappedPointer) {
+		fetch(group)
+		fetched <- struct{}{}
+	}
+
+	go fetchGroup(groups[0])
+	for i, group := range groups {
+		<-fetched
+		if i+1 < len(groups) {
+			go fetchGroup(groups[i+1])
+		}
+
+		for _, p := range group {
+			write(p)
+		}
+	}
+}
+
 // Checkout from items reported from the fetch process (in parallel)
 func checkoutAllFromFetchChan(c chan *lfs.WrappedPointer) {
 	tracerx.Printf("starting fetch/parallel checkout")
@@ -62,6 +159,7 @@ func checkoutFromFetchChan(include []string, exclude []string, in chan *lfs.Wrap
 	if err != nil {
 		Panic(err, "Could not scan for Git LFS files")
 	}
+	pointers = withoutPathCollisions(withoutDeletedFiles(pointers), include, exclude)
 
 	// Map oid to multiple pointers
 	mapping := make(map[string][]*lfs.WrappedPointer)
@@ -104,6 +202,62 @@ func checkoutWithIncludeExclude(include []string, exclude []string) {
 		Panic(err, "Could not scan for Git LFS files")
 	}
 
+	pointers = withoutPathCollisions(withoutDeletedFiles(pointers), include, exclude)
+	checkoutPointersWithIncludeExclude(pointers, include, exclude)
+}
+
+// withoutDeletedFiles returns pointers without those for files which are in
+// the index but have been deleted from the working copy, if lfs.checkoutdeleted
+// is "skip", so that they're neither downloaded nor written again.
+func withoutDeletedFiles(pointers []*lfs.WrappedPointer) []*lfs.WrappedPointer {
+	if config.Config.CheckoutDeleted() != "skip" {
+		return pointers
+	}
+
+	deleted, err := git.DeletedFiles()
+	if err != nil {
+		Panic(err, "Could not list deleted files")
+	}
+	if len(deleted) == 0 {
+		return pointers
+	}
+
+	deletedSet := lfs.NewStringSetFromSlice(deleted)
+	kept := make([]*lfs.WrappedPointer, 0, len(pointers))
+	for _, p := range pointers {
+		if deletedSet.Contains(p.Name) {
+			tracerx.Printf("Skipping %v [%v], deleted from the working copy", p.Name, p.Oid)
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
+
+// withoutPathCollisions returns pointers without those which pass the
+// include/exclude filter but would overwrite the file of an earlier one, on a
+// filesystem which doesn't tell their paths apart because it ignores case or
+// Unicode normalization. The skipped paths are reported.
+func withoutPathCollisions(pointers []*lfs.WrappedPointer, include, exclude []string) []*lfs.WrappedPointer {
+	collisions := lfs.NewPathCollisionsFromConfig()
+	if collisions == nil {
+		return pointers
+	}
+
+	kept := make([]*lfs.WrappedPointer, 0, len(pointers))
+	for _, p := range pointers {
+		if lfs.FilenamePassesIncludeExcludeFilter(p.Name, include, exclude) {
+			if other, ok := collisions.Add(p.Name); ok {
+				Error("Not checking out %s: it would overwrite %s, as this filesystem doesn't tell their paths apart.", p.Name, other)
+				continue
+			}
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
+
+func checkoutPointersWithIncludeExclude(pointers []*lfs.WrappedPointer, include []string, exclude []string) {
 	var wait sync.WaitGroup
 	wait.Add(1)
 
@@ -117,22 +271,22 @@ func checkoutWithIncludeExclude(include []string, exclude []string) {
 	// Count bytes for progress
 	var totalBytes int64
 	for _, pointer := range pointers {
-		totalBytes += pointer.Size
+		totalBytes += lfs.KnownSize(pointer.Size)
 	}
-	progress := progress.NewProgressMeter(len(pointers), totalBytes, false, config.Config.Getenv("GIT_LFS_PROGRESS"))
+	progress := lfs.NewProgressMeter(len(pointers), totalBytes, false)
 	progress.Start()
 	totalBytes = 0
 	for _, pointer := range pointers {
-		totalBytes += pointer.Size
+		totalBytes += lfs.KnownSize(pointer.Size)
 		if lfs.FilenamePassesIncludeExcludeFilter(pointer.Name, include, exclude) {
 			progress.Add(pointer.Name)
 			c <- pointer
 			// not strictly correct (parallel) but we don't have a callback & it's just local
 			// plus only 1 slot in channel so it'll block & be close
-			progress.TransferBytes("checkout", pointer.Name, pointer.Size, totalBytes, int(pointer.Size))
+			progress.TransferBytes("checkout", pointer.Name, lfs.KnownSize(pointer.Size), totalBytes, lfs.KnownSize(pointer.Size))
 			progress.FinishTransfer(pointer.Name)
 		} else {
-			progress.Skip(pointer.Size)
+			progress.Skip(lfs.KnownSize(pointer.Size))
 		}
 	}
 	close(c)
@@ -168,6 +322,8 @@ func checkoutWithChan(in <-chan *lfs.WrappedPointer) {
 	var cmd *exec.Cmd
 	var updateIdxStdin io.WriteCloser
 
+	overwrite := config.Config.CheckoutOverwrite()
+
 	// From this point on, git update-index is running. Code in this loop MUST
 	// NOT Panic() or otherwise cause the process to exit. If the process exits
 	// while update-index is in the middle of updating, the index can remain in a
@@ -179,12 +335,22 @@ func checkoutWithChan(in <-chan *lfs.WrappedPointer) {
 		// Check the content - either missing or still this pointer (not exist is ok)
 		filepointer, err := lfs.DecodePointerFromFile(pointer.Name)
 		if err != nil && !os.IsNotExist(err) {
-			if errutil.IsNotAPointerError(err) {
-				// File has non-pointer content, leave it alone
+			if !errutil.IsNotAPointerError(err) {
+				LoggedError(err, "Problem accessing %v", pointer.Name)
+				continue
+			}
+
+			if fileMatchesPointer(pointer.Name, pointer.Pointer) {
+				// Already checked out
+				continue
+			}
+
+			if !overwrite {
+				// File has changes which aren't in the object, leave
+				// them alone
+				Error("Not checking out %s: it has local changes. Set lfs.checkoutoverwrite to overwrite them.", pointer.Name)
 				continue
 			}
-			LoggedError(err, "Problem accessing %v", pointer.Name)
-			continue
 		}
 
 		if filepointer != nil && filepointer.Oid != pointer.Oid {
@@ -233,3 +399,24 @@ func checkoutWithChan(in <-chan *lfs.WrappedPointer) {
 		}
 	}
 }
+
+// fileMatchesPointer returns whether the file at path has the content of the
+// object for p, i.e. whether it's already checked out.
+func fileMatchesPointer(path string, p *lfs.Pointer) bool {
+	stat, err := os.Stat(path)
+	if err != nil || stat.Size() != p.Size {
+		return false
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	hasher := tools.NewLfsContentHash()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return false
+	}
+	return hex.EncodeToString(hasher.Sum(nil)) == p.Oid
+}
@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/github/git-lfs/lfs"
+	"github.com/stretchr/testify/assert"
+)
+
+func readAheadTestPointers(n int) []*lfs.WrappedPointer {
+	pointers := make([]*lfs.WrappedPointer, n)
+	for i := range pointers {
+		pointers[i] = &lfs.WrappedPointer{Name: fmt.Sprintf("%d.dat", i)}
+	}
+	return pointers
+}
+
+func TestReadAheadWritesInOrderAfterFetching(t *testing.T) {
+	var mutex sync.Mutex
+	fetched := make(map[string]bool)
+	var groups [][]string
+	var written []string
+
+	readAhead(readAheadTestPointers(7), 3,
+		func(group []*lfs.WrappedPointer) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			var names []string
+			for _, p := range group {
+				names = append(names, p.Name)
+				fetched[p.Name] = true
+			}
+			groups = append(groups, names)
+		},
+		func(p *lfs.WrappedPointer) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			assert.True(t, fetched[p.Name], "%s written before it was fetched", p.Name)
+			written = append(written, p.Name)
+		})
+
+	assert.Equal(t, [][]string{
+		{"0.dat", "1.dat", "2.dat"},
+		{"3.dat", "4.dat", "5.dat"},
+		{"6.dat"},
+	}, groups)
+	assert.Equal(t, []string{"0.dat", "1.dat", "2.dat", "3.dat", "4.dat", "5.dat", "6.dat"}, written)
+}
+
+func TestReadAheadFetchesWhileWriting(t *testing.T) {
+	secondFetch := make(chan struct{})
+	fetches := 0
+
+	readAhead(readAheadTestPointers(4), 2,
+		func(group []*lfs.WrappedPointer) {
+			fetches++
+			if fetches == 2 {
+				close(secondFetch)
+			}
+		},
+		func(p *lfs.WrappedPointer) {
+			if p.Name != "0.dat" {
+				return
+			}
+
+			// The second group must be fetched while the first is written
+			select {
+			case <-secondFetch:
+			case <-time.After(5 * time.Second):
+				t.Fatal("next group was not fetched while writing")
+			}
+		})
+
+	assert.Equal(t, 2, fetches)
+}
+
+func TestReadAheadNoPointers(t *testing.T) {
+	readAhead(nil, 2,
+		func([]*lfs.WrappedPointer) { t.Fatal("unexpected fetch") },
+		func(*lfs.WrappedPointer) { t.Fatal("unexpected write") })
+}
+
+func BenchmarkReadAheadDepth20(b *testing.B) { benchmarkReadAhead(b, 20) }
+func BenchmarkReadAheadDepth5(b *testing.B)  { benchmarkReadAhead(b, 5) }
+func BenchmarkReadAheadDepth1(b *testing.B)  { benchmarkReadAhead(b, 1) }
+
+func benchmarkReadAhead(b *testing.B, depth int) {
+	pointers := readAheadTestPointers(20)
+	fetch := func(group []*lfs.WrappedPointer) { time.Sleep(time.Duration(len(group)) * time.Millisecond) }
+	write := func(*lfs.WrappedPointer) { time.Sleep(time.Millisecond) }
+
+	for i := 0; i < b.N; i++ {
+		readAhead(pointers, depth, fetch, write)
+	}
+}
@@ -1,9 +1,18 @@
 package commands
 
 import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 
+	"github.com/github/git-lfs/api"
+	"github.com/github/git-lfs/config"
 	"github.com/github/git-lfs/errutil"
+	"github.com/github/git-lfs/git"
 	"github.com/github/git-lfs/lfs"
 	"github.com/github/git-lfs/progress"
 	"github.com/spf13/cobra"
@@ -27,6 +36,11 @@ func cleanCommand(cmd *cobra.Command, args []string) {
 	if len(args) > 0 {
 		fileName = args[0]
 
+		if kind := lfs.NonFileKind(fileName); kind != "" {
+			cleanNonFile(fileName, kind)
+			return
+		}
+
 		stat, err := os.Stat(fileName)
 		if err == nil && stat != nil {
 			fileSize = stat.Size()
@@ -59,6 +73,11 @@ func cleanCommand(cmd *cobra.Command, args []string) {
 		Panic(err, "Error cleaning asset.")
 	}
 
+	if err := lockOnClean(fileName, cleaned.Pointer); err != nil {
+		cleaned.Teardown()
+		Exit("Unable to lock %s: %s", fileName, err)
+	}
+
 	tmpfile := cleaned.Filename
 	mediafile, err := lfs.LocalMediaPath(cleaned.Oid)
 	if err != nil {
@@ -67,9 +86,22 @@ func cleanCommand(cmd *cobra.Command, args []string) {
 
 	if stat, _ := os.Stat(mediafile); stat != nil {
 		if stat.Size() != cleaned.Size && len(cleaned.Pointer.Extensions) == 0 {
-			Exit("Files don't match:\n%s\n%s", mediafile, tmpfile)
+			// Only an object which is the start of the content, as
+			// an interrupted write leaves, is replaced. Anything else
+			// means local storage is corrupt.
+			if config.Config.CleanMismatch() == "error" || !isIncompleteCopy(mediafile, stat.Size(), tmpfile, cleaned.Size) {
+				Exit("Files don't match:\n%s\n%s", mediafile, tmpfile)
+			}
+
+			// The content was hashed as it was cleaned, so it's the
+			// stored object that's incomplete
+			Error("Git LFS: replacing %s, which is %d bytes rather than %d", mediafile, stat.Size(), cleaned.Size)
+			if err := os.Rename(tmpfile, mediafile); err != nil {
+				Panic(err, "Unable to move %s to %s\n", tmpfile, mediafile)
+			}
+		} else {
+			Debug("%s exists", mediafile)
 		}
-		Debug("%s exists", mediafile)
 	} else {
 		if err := os.Rename(tmpfile, mediafile); err != nil {
 			Panic(err, "Unable to move %s to %s\n", tmpfile, mediafile)
@@ -78,9 +110,149 @@ func cleanCommand(cmd *cobra.Command, args []string) {
 		Debug("Writing %s", mediafile)
 	}
 
+	lfs.CurrentSizeIndex().Add(cleaned.Pointer)
 	lfs.EncodePointer(os.Stdout, cleaned.Pointer)
 }
 
+// isIncompleteCopy returns whether the object at mediafile, of size
+// mediaSize, is shorter than the cleaned content at tmpfile, of size
+// cleanedSize, and is the start of it.
+func isIncompleteCopy(mediafile string, mediaSize int64, tmpfile string, cleanedSize int64) bool {
+	if mediaSize >= cleanedSize {
+		return false
+	}
+
+	media, err := os.Open(mediafile)
+	if err != nil {
+		return false
+	}
+	defer media.Close()
+
+	tmp, err := os.Open(tmpfile)
+	if err != nil {
+		return false
+	}
+	defer tmp.Close()
+
+	mediaBuf := make([]byte, 32*1024)
+	tmpBuf := make([]byte, len(mediaBuf))
+	for {
+		n, err := io.ReadFull(media, mediaBuf)
+		if n > 0 {
+			if _, terr := io.ReadFull(tmp, tmpBuf[:n]); terr != nil || !bytes.Equal(mediaBuf[:n], tmpBuf[:n]) {
+				return false
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return true
+		}
+		if err != nil {
+			return false
+		}
+	}
+}
+
+// lockOnClean locks fileName if it is lockable, lfs.lockonclean is set and
+// its content has changed since it was staged, unless the current committer
+// already holds its lock. A lock which can't be created is only a warning,
+// unless lfs.lockonclean is "strict", when it is returned as an error.
+func lockOnClean(fileName string, p *lfs.Pointer) error {
+	mode := config.Config.LockOnClean()
+	if mode == "off" || len(fileName) == 0 {
+		return nil
+	}
+
+	if lockable, _ := git.CheckAttr("lockable", fileName); lockable != "set" {
+		return nil
+	}
+
+	// git also cleans files it can't tell are unmodified, such as ones
+	// written in the same second as the index
+	if stagedPointer(fileName, p) {
+		return nil
+	}
+
+	err := lockIfNotHeld(fileName)
+	if err != nil && mode == "warn" {
+		Error("Git LFS: unable to lock %s: %s", fileName, err)
+		return nil
+	}
+	return err
+}
+
+// stagedPointer returns whether p is already the blob staged for fileName.
+func stagedPointer(fileName string, p *lfs.Pointer) bool {
+	blob, err := git.IndexBlob(fileName)
+	if err != nil || len(blob) == 0 {
+		return false
+	}
+
+	encoded := p.Encoded()
+	hash := sha1.New()
+	fmt.Fprintf(hash, "blob %d\x00%s", len(encoded), encoded)
+	return hex.EncodeToString(hash.Sum(nil)) == blob
+}
+
+// lockIfNotHeld locks fileName for the current committer, unless they already
+// hold its lock. Output goes to stderr, as the clean filter's stdout is the
+// pointer.
+func lockIfNotHeld(fileName string) error {
+	path, err := lockPath(fileName)
+	if err != nil {
+		return err
+	}
+
+	s, resp := API.Locks.Search(&api.LockSearchRequest{
+		Filters: []api.Filter{{Property: "path", Value: path}},
+	})
+	if _, err := API.Do(s); err != nil {
+		return fmt.Errorf("Error communicating with LFS API: %s", err)
+	}
+
+	if len(resp.Err) > 0 {
+		return errors.New(resp.Err)
+	}
+
+	committer := api.CurrentCommitter()
+	for _, lock := range resp.Locks {
+		if lock.Path != path {
+			continue
+		}
+
+		if lock.Committer == committer {
+			return nil
+		}
+		return fmt.Errorf("it is locked by %s <%s>", lock.Committer.Name, lock.Committer.Email)
+	}
+
+	latest, err := git.CurrentRemoteRef()
+	if err != nil {
+		return fmt.Errorf("unable to determine latest remote ref for branch: %s", err)
+	}
+
+	id, err := lockFile(path, latest.Sha)
+	if err != nil {
+		return err
+	}
+
+	Error("Git LFS: locked %s (%s)", fileName, id)
+	return nil
+}
+
+// cleanNonFile handles a path matching a tracked pattern which isn't a regular
+// file, by either failing or passing its content through untouched, depending
+// on lfs.nonfilepaths.
+func cleanNonFile(fileName, kind string) {
+	if config.Config.NonFilePaths() == "error" {
+		Exit("Unable to clean %s: it is a %s, not a file.", fileName, kind)
+	}
+
+	Error("Git LFS: skipping %s: it is a %s, not a file", fileName, kind)
+	if _, err := io.Copy(os.Stdout, os.Stdin); err != nil {
+		Panic(err, "Error writing %s", fileName)
+	}
+}
+
 func init() {
 	RootCmd.AddCommand(cleanCmd)
 }
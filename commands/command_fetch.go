@@ -2,12 +2,18 @@ package commands
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/errutil"
 	"github.com/github/git-lfs/git"
 	"github.com/github/git-lfs/lfs"
+	"github.com/github/git-lfs/localstorage"
 	"github.com/github/git-lfs/progress"
+	"github.com/github/git-lfs/tools"
 	"github.com/rubyist/tracerx"
 	"github.com/spf13/cobra"
 )
@@ -17,29 +23,89 @@ var (
 		Use: "fetch",
 		Run: fetchCommand,
 	}
-	fetchIncludeArg string
-	fetchExcludeArg string
-	fetchRecentArg  bool
-	fetchAllArg     bool
-	fetchPruneArg   bool
+	fetchIncludeArg  string
+	fetchExcludeArg  string
+	fetchRecentArg   bool
+	fetchAllArg      bool
+	fetchPruneArg    bool
+	fetchProgressArg string
+	fetchMaxBytesArg string
+	fetchRateArg     string
+	fetchPriorityArg string
+	fetchReposArg    string
+
+	// fetchMaxBytes is the most that one fetch may download, from
+	// --max-bytes, and fetchBytesQueued is how much it has queued so far.
+	fetchMaxBytes    int64
+	fetchBytesQueued int64
 )
 
 func fetchCommand(cmd *cobra.Command, args []string) {
+	if len(fetchMaxBytesArg) > 0 {
+		n, err := tools.ParseByteSize(fetchMaxBytesArg)
+		if err != nil || n < 1 {
+			Exit("Invalid --max-bytes %q: give a size such as 500MB or 10GB", fetchMaxBytesArg)
+		}
+		fetchMaxBytes = n
+	}
+
+	if fetchAllArg {
+		if fetchRecentArg || len(args) > 1 {
+			Exit("Cannot combine --all with ref arguments or --recent")
+		}
+		if fetchIncludeArg != "" || fetchExcludeArg != "" {
+			Exit("Cannot combine --all with --include or --exclude")
+		}
+	}
+
+	if len(fetchReposArg) > 0 {
+		fetchRepos(strings.Split(fetchReposArg, ","), args)
+		return
+	}
+
 	requireInRepo()
+	setProgressStyle(fetchProgressArg)
+	setMaxBandwidth(fetchRateArg)
+	setPriorityPaths(fetchPriorityArg)
+
+	success := fetchForArgs(args)
+
+	if fetchPruneArg {
+		verify := config.Config.FetchPruneConfig().PruneVerifyRemoteAlways
+		// no dry-run or verbose options in fetch, assume false
+		prune(verify, false, false)
+	}
 
-	var refs []*git.Ref
+	if !success {
+		Exit("Warning: errors occurred")
+	}
+}
 
+// fetchForArgs fetches the objects for the remote and refs given as args, and
+// the --all and --recent options, in the current repository.
+func fetchForArgs(args []string) bool {
+	refs, err := fetchRefsForArgs(args)
+	if err != nil {
+		ExitWithError(err)
+	}
+	return fetchRefs(refs)
+}
+
+// fetchRefsForArgs sets the current remote to the one given as the first of
+// args, or the default remote, and returns the refs given as the rest of args,
+// or the current ref, in the current repository. It returns no refs for --all.
+func fetchRefsForArgs(args []string) ([]*git.Ref, error) {
 	if len(args) > 0 {
 		// Remote is first arg
 		if err := git.ValidateRemote(args[0]); err != nil {
-			Exit("Invalid remote name %q", args[0])
+			return nil, fmt.Errorf("Invalid remote name %q", args[0])
 		}
 		config.Config.CurrentRemote = args[0]
 	} else {
 		// Actively find the default remote, don't just assume origin
 		defaultRemote, err := git.DefaultRemote()
 		if err != nil {
-			Exit("No default remote")
+			return nil, fmt.Errorf("No default remote")
 		}
 		config.Config.CurrentRemote = defaultRemote
 	}
@@ -47,25 +113,24 @@ func fetchCommand(cmd *cobra.Command, args []string) {
 	if len(args) > 1 {
 		resolvedrefs, err := git.ResolveRefs(args[1:])
 		if err != nil {
-			Panic(err, "Invalid ref argument: %v", args[1:])
+			return nil, errutil.Errorf(err, "Invalid ref argument: %v", args[1:])
 		}
-		refs = resolvedrefs
+		return resolvedrefs, nil
 	} else if !fetchAllArg {
 		ref, err := git.CurrentRef()
 		if err != nil {
-			Panic(err, "Could not fetch")
+			return nil, errutil.Errorf(err, "Could not fetch")
 		}
-		refs = []*git.Ref{ref}
+		return []*git.Ref{ref}, nil
 	}
+	return nil, nil
+}
 
+// fetchRefs fetches the objects for refs, and the --all and --recent options,
+// from the current remote.
+func fetchRefs(refs []*git.Ref) bool {
 	success := true
 	if fetchAllArg {
-		if fetchRecentArg || len(args) > 1 {
-			Exit("Cannot combine --all with ref arguments or --recent")
-		}
-		if fetchIncludeArg != "" || fetchExcludeArg != "" {
-			Exit("Cannot combine --all with --include or --exclude")
-		}
 		if len(config.Config.FetchIncludePaths()) > 0 || len(config.Config.FetchExcludePaths()) > 0 {
 			Print("Ignoring global include / exclude paths to fulfil --all")
 		}
@@ -87,15 +152,7 @@ func fetchCommand(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	if fetchPruneArg {
-		verify := config.Config.FetchPruneConfig().PruneVerifyRemoteAlways
-		// no dry-run or verbose options in fetch, assume false
-		prune(verify, false, false)
-	}
-
-	if !success {
-		Exit("Warning: errors occurred")
-	}
+	return success
 }
 
 func init() {
@@ -104,9 +161,167 @@ func init() {
 	fetchCmd.Flags().BoolVarP(&fetchRecentArg, "recent", "r", false, "Fetch recent refs & commits")
 	fetchCmd.Flags().BoolVarP(&fetchAllArg, "all", "a", false, "Fetch all LFS files ever referenced")
 	fetchCmd.Flags().BoolVarP(&fetchPruneArg, "prune", "p", false, "After fetching, prune old data")
+	fetchCmd.Flags().StringVarP(&fetchProgressArg, "progress", "", "", "Progress output: bar, plain or none")
+	fetchCmd.Flags().StringVarP(&fetchMaxBytesArg, "max-bytes", "", "", "Refuse to download more than this many bytes, e.g. 10GB")
+	fetchCmd.Flags().StringVarP(&fetchRateArg, "max-bandwidth", "", "", "Limit the combined rate of transfers, e.g. 2m for 2 MB/s")
+	fetchCmd.Flags().StringVarP(&fetchPriorityArg, "priority", "", "", "Fetch the objects of a list of paths first")
+	fetchCmd.Flags().StringVarP(&fetchReposArg, "repos", "", "", "Fetch in each of a comma-separated list of repositories")
 	RootCmd.AddCommand(fetchCmd)
 }
 
+// fetchRepo is a repository that `fetch --repos` fetches for, with the
+// objects it needs which aren't local yet.
+type fetchRepo struct {
+	path     string
+	dir      string
+	config   *config.Configuration
+	pointers []*lfs.WrappedPointer
+	oids     lfs.StringSet
+}
+
+// enter makes r the current repository, for its config and local storage.
+func (r *fetchRepo) enter() error {
+	if err := os.Chdir(r.dir); err != nil {
+		return err
+	}
+	config.Config = r.config
+	localstorage.ResolveDirs()
+	if !lfs.InRepo() {
+		return fmt.Errorf("not in a git repository")
+	}
+	return nil
+}
+
+// fetchCollector, when set, is given the pointers that fetchPointers would
+// fetch, instead of fetching them, so that `fetch --repos` can fetch for all
+// its repositories at once.
+var fetchCollector func(pointers []*lfs.WrappedPointer, include, exclude []string)
+
+// fetchRepos fetches with the same options and arguments in each of repos. It
+// finds the objects that each needs first, so that objects which they have in
+// common are only downloaded once.
+func fetchRepos(paths []string, args []string) {
+	wd, err := os.Getwd()
+	if err != nil {
+		Exit("Unable to get the current directory: %s", err)
+	}
+	defer os.Chdir(wd)
+
+	var repos []*fetchRepo
+	count, failed := 0, 0
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if len(path) == 0 {
+			continue
+		}
+		count++
+
+		Print("Fetching in %s", path)
+		dir := path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(wd, dir)
+		}
+		repo := &fetchRepo{
+			path:   path,
+			dir:    dir,
+			config: config.NewConfig(),
+			oids:   lfs.NewStringSet(),
+		}
+		if err := repo.enter(); err != nil {
+			Error("Unable to fetch in %s: %s", path, err)
+			failed++
+			continue
+		}
+
+		refs, err := fetchRefsForArgs(args)
+		if err != nil {
+			Error("Unable to fetch in %s: %s", path, err)
+			failed++
+			continue
+		}
+
+		fetchCollector = func(pointers []*lfs.WrappedPointer, include, exclude []string) {
+			for _, p := range pointers {
+				if !lfs.FilenamePassesIncludeExcludeFilter(p.Name, include, exclude) {
+					continue
+				}
+				lfs.LinkOrCopyFromReference(p.Oid, p.Size)
+				if !lfs.ObjectExistsOfSize(p.Oid, p.Size) && repo.oids.Add(p.Oid) {
+					repo.pointers = append(repo.pointers, p)
+				}
+			}
+		}
+		fetchRefs(refs)
+		fetchCollector = nil
+
+		repos = append(repos, repo)
+	}
+
+	success := fetchReposObjects(repos)
+
+	if fetchPruneArg {
+		for _, repo := range repos {
+			if err := repo.enter(); err != nil {
+				continue
+			}
+			verify := config.Config.FetchPruneConfig().PruneVerifyRemoteAlways
+			prune(verify, false, false)
+		}
+	}
+
+	if failed > 0 {
+		Exit("Fetching failed in %d of %d repositories", failed, count)
+	}
+	if !success {
+		Exit("Warning: errors occurred")
+	}
+}
+
+// fetchReposObjects downloads each object that repos need once, in the first
+// repository which needs it, and links or copies it into the others. The
+// repositories usually each have their own Git LFS server endpoint, so each
+// downloads the objects which no repository before it has.
+func fetchReposObjects(repos []*fetchRepo) bool {
+	success := true
+	stored := make(map[string]*localstorage.LocalStorage) // oid to where it was downloaded
+	for _, repo := range repos {
+		if err := repo.enter(); err != nil {
+			Error("Unable to fetch in %s: %s", repo.path, err)
+			success = false
+			continue
+		}
+		setProgressStyle(fetchProgressArg)
+		setMaxBandwidth(fetchRateArg)
+		setPriorityPaths(fetchPriorityArg)
+
+		var download []*lfs.WrappedPointer
+		for _, p := range repo.pointers {
+			if objects, ok := stored[p.Oid]; ok {
+				dst, err := lfs.LocalMediaPath(p.Oid)
+				if err == nil && lfs.LinkOrCopy(objects.ObjectPath(p.Oid), dst) == nil {
+					tracerx.Printf("fetch: linked %v [%v] from %v", p.Name, p.Oid, objects.RootDir)
+					continue
+				}
+			}
+			download = append(download, p)
+		}
+		if len(download) == 0 {
+			continue
+		}
+
+		Print("Fetching %d object(s) for %s", len(download), repo.path)
+		s := fetchAndReportToChan(download, nil, nil, nil)
+		success = success && s
+
+		for _, p := range download {
+			if _, ok := stored[p.Oid]; !ok && lfs.ObjectExistsOfSize(p.Oid, p.Size) {
+				stored[p.Oid] = localstorage.Objects()
+			}
+		}
+	}
+	return success
+}
+
 func pointersToFetchForRef(ref string) ([]*lfs.WrappedPointer, error) {
 	// Use SkipDeletedBlobs to avoid fetching ALL previous versions of modified files
 	opts := lfs.NewScanRefsOptions()
@@ -122,7 +337,7 @@ func fetchRefToChan(ref string, include, exclude []string) chan *lfs.WrappedPoin
 		Panic(err, "Could not scan for Git LFS files")
 	}
 
-	go fetchAndReportToChan(pointers, include, exclude, c)
+	go fetchAndReportToChan(withoutDeletedFiles(pointers), include, exclude, c)
 
 	return c
 }
@@ -240,17 +455,26 @@ func scanAll() []*lfs.WrappedPointer {
 }
 
 func fetchPointers(pointers []*lfs.WrappedPointer, include, exclude []string) bool {
+	if fetchCollector != nil {
+		fetchCollector(pointers, include, exclude)
+		return true
+	}
 	return fetchAndReportToChan(pointers, include, exclude, nil)
 }
 
 // Fetch and report completion of each OID to a channel (optional, pass nil to skip)
 // Returns true if all completed with no errors, false if errors were written to stderr/log
 func fetchAndReportToChan(pointers []*lfs.WrappedPointer, include, exclude []string, out chan<- *lfs.WrappedPointer) bool {
+	if fetchMaxBytes > 0 {
+		checkFetchLimit(pointers, include, exclude)
+	}
+
 	totalSize := int64(0)
 	for _, p := range pointers {
-		totalSize += p.Size
+		totalSize += lfs.KnownSize(p.Size)
 	}
 	q := lfs.NewDownloadQueue(len(pointers), totalSize, false)
+	pointers = prioritizePointers(q, pointers)
 
 	if out != nil {
 		dlwatch := q.Watch()
@@ -277,6 +501,11 @@ func fetchAndReportToChan(pointers []*lfs.WrappedPointer, include, exclude []str
 		}()
 	}
 
+	var revalidate []*lfs.WrappedPointer
+	unknownSize := lfs.NewStringSet()
+	honorCacheControl := config.Config.CacheControl() == "honor"
+	now := time.Now()
+
 	for _, p := range pointers {
 		// Only add to download queue if local file is not the right size already
 		// This avoids previous case of over-reporting a requirement for files we already have
@@ -288,13 +517,19 @@ func fetchAndReportToChan(pointers []*lfs.WrappedPointer, include, exclude []str
 		if !lfs.ObjectExistsOfSize(p.Oid, p.Size) && passFilter {
 			tracerx.Printf("fetch %v [%v]", p.Name, p.Oid)
 			q.Add(lfs.NewDownloadable(p))
+			if p.Size == lfs.UnknownSize {
+				unknownSize.Add(p.Oid)
+			}
 		} else {
 			// Ensure progress matches
-			q.Skip(p.Size)
+			q.Skip(lfs.KnownSize(p.Size))
 			if !passFilter {
 				tracerx.Printf("Skipping %v [%v], include/exclude filters applied", p.Name, p.Oid)
 			} else {
 				tracerx.Printf("Skipping %v [%v], already exists", p.Name, p.Oid)
+				if honorCacheControl && needsRevalidation(p.Oid, now) {
+					revalidate = append(revalidate, p)
+				}
 			}
 
 			// If we already have it, or it won't be fetched
@@ -310,10 +545,125 @@ func fetchAndReportToChan(pointers []*lfs.WrappedPointer, include, exclude []str
 	q.Wait()
 	tracerx.PerformanceSince("process queue", processQueue)
 
+	// objects whose size wasn't known count against --max-bytes once
+	// they've been fetched
+	for oid := range unknownSize {
+		if stat, err := os.Stat(lfs.LocalMediaPathReadOnly(oid)); err == nil {
+			fetchBytesQueued += stat.Size()
+		}
+	}
+
 	ok := true
 	for _, err := range q.Errors() {
 		ok = false
 		ExitWithError(err)
 	}
+
+	revalidateObjects(revalidate)
 	return ok
 }
+
+// checkFetchLimit exits, listing what would have been fetched, if downloading
+// the objects of pointers which aren't local yet would take this fetch over
+// --max-bytes.
+func checkFetchLimit(pointers []*lfs.WrappedPointer, include, exclude []string) {
+	var missing []*lfs.WrappedPointer
+	var size int64
+	seen := lfs.NewStringSet()
+	for _, p := range pointers {
+		if !lfs.FilenamePassesIncludeExcludeFilter(p.Name, include, exclude) {
+			continue
+		}
+
+		lfs.LinkOrCopyFromReference(p.Oid, p.Size)
+		if lfs.ObjectExistsOfSize(p.Oid, p.Size) || !seen.Add(p.Oid) {
+			continue
+		}
+
+		missing = append(missing, p)
+		size += lfs.KnownSize(p.Size)
+	}
+
+	if fetchBytesQueued+size <= fetchMaxBytes {
+		fetchBytesQueued += size
+		return
+	}
+
+	Error("Would have fetched:")
+	for _, p := range missing {
+		Error("  %s (%s)", p.Name, humanizePointerSize(p.Size))
+	}
+
+	msg := fmt.Sprintf("Not fetching %d object(s) (%s): that's over --max-bytes (%s)", len(missing), humanizeBytes(size), humanizeBytes(fetchMaxBytes))
+	if fetchBytesQueued > 0 {
+		msg += fmt.Sprintf(", with %s already fetched", humanizeBytes(fetchBytesQueued))
+	}
+	Exit("%s.", msg)
+}
+
+// needsRevalidation returns whether the recorded cache policy of the local copy
+// of oid says it should be checked with the server again.
+func needsRevalidation(oid string, now time.Time) bool {
+	policy, err := lfs.LoadCachePolicy(oid)
+	if err != nil {
+		tracerx.Printf("Unable to load cache policy of %v: %v", oid, err)
+		return false
+	}
+
+	return policy != nil && policy.NeedsRevalidation(now)
+}
+
+// revalidateObjects asks the server whether it still has the objects whose
+// local copies are stale by their cache policy, and refreshes the policies of
+// the ones it has. Local copies are kept either way, since their content
+// matches their oid.
+func revalidateObjects(pointers []*lfs.WrappedPointer) {
+	if len(pointers) == 0 {
+		return
+	}
+
+	checkQueue := lfs.NewDownloadCheckQueue(0, 0)
+	availc := checkQueue.Watch()
+
+	available := lfs.NewStringSet()
+	done := make(chan int)
+	go func() {
+		for oid := range availc {
+			available.Add(oid)
+		}
+		done <- 1
+	}()
+
+	checked := lfs.NewStringSet()
+	for _, p := range pointers {
+		if checked.Add(p.Oid) {
+			tracerx.Printf("Revalidating %v [%v], its cache policy is stale", p.Name, p.Oid)
+			checkQueue.Add(lfs.NewDownloadable(p))
+		}
+	}
+	checkQueue.Wait()
+	<-done
+
+	now := time.Now()
+	for _, p := range pointers {
+		if !checked.Contains(p.Oid) {
+			continue
+		}
+		checked.Remove(p.Oid)
+
+		if !available.Contains(p.Oid) {
+			Error("Object %s (%s) is no longer available from the server, but its local copy was kept", p.Name, p.Oid)
+			continue
+		}
+
+		policy, err := lfs.LoadCachePolicy(p.Oid)
+		if err != nil || policy == nil {
+			continue
+		}
+
+		policy.FetchedAt = now
+		if err := lfs.RecordCachePolicy(p.Oid, policy); err != nil {
+			tracerx.Printf("Unable to record cache policy of %v: %v", p.Oid, err)
+		}
+	}
+}
@@ -15,6 +15,7 @@ import (
 
 var (
 	fsckDryRun bool
+	fsckRemote bool
 
 	fsckCmd = &cobra.Command{
 		Use: "fsck",
@@ -30,9 +31,13 @@ func doFsck() (bool, error) {
 		return false, err
 	}
 
-	// The LFS scanner methods return unexported *lfs.wrappedPointer objects.
-	// All we care about is the pointer OID and file name
-	pointerIndex := make(map[string]string)
+	if fsckRemote {
+		return fsckRemoteObjects(ref)
+	}
+
+	// All we care about is the pointer OID, size and file name. Every pointer
+	// to an object is kept, so that each one's size can be checked.
+	pointerIndex := make(map[string][]*lfs.WrappedPointer)
 
 	pointers, err := lfs.ScanRefs(ref.Sha, "", nil)
 	if err != nil {
@@ -40,7 +45,7 @@ func doFsck() (bool, error) {
 	}
 
 	for _, p := range pointers {
-		pointerIndex[p.Oid] = p.Name
+		indexFsckPointer(pointerIndex, p)
 	}
 
 	// TODO(zeroshirts): do we want to look for LFS stuff in past commits?
@@ -50,12 +55,13 @@ func doFsck() (bool, error) {
 	}
 
 	for _, p := range p2 {
-		pointerIndex[p.Oid] = p.Name
+		indexFsckPointer(pointerIndex, p)
 	}
 
 	ok := true
 
-	for oid, name := range pointerIndex {
+	for oid, ptrs := range pointerIndex {
+		name := ptrs[0].Name
 		path := lfs.LocalMediaPathReadOnly(oid)
 
 		Debug("Examining %v (%v)", name, path)
@@ -71,35 +77,115 @@ func doFsck() (bool, error) {
 		}
 
 		oidHash := sha256.New()
-		_, err = io.Copy(oidHash, f)
+		size, err := io.Copy(oidHash, f)
 		f.Close()
 		if err != nil {
 			return false, err
 		}
 
 		recalculatedOid := hex.EncodeToString(oidHash.Sum(nil))
-		if recalculatedOid != oid {
-			ok = false
-			Print("Object %s (%s) is corrupt", name, oid)
-			if fsckDryRun {
-				continue
+		if recalculatedOid == oid {
+			// The object is fine, but pointers to it may not be
+			for _, p := range ptrs {
+				if p.Size != size {
+					ok = false
+					Print("Pointer for %s (%s) has size %d, but its object is %d bytes", p.Name, oid, p.Size, size)
+				}
 			}
+			continue
+		}
 
-			badDir := filepath.Join(config.LocalGitStorageDir, "lfs", "bad")
-			if err := os.MkdirAll(badDir, 0755); err != nil {
-				return false, err
-			}
+		ok = false
+		Print("Object %s (%s) is corrupt", name, oid)
+		if size != ptrs[0].Size {
+			Print("  its size is %d bytes, but the pointer says %d bytes", size, ptrs[0].Size)
+		}
+		if fsckDryRun {
+			continue
+		}
 
-			badFile := filepath.Join(badDir, oid)
-			if err := os.Rename(path, badFile); err != nil {
-				return false, err
-			}
-			Print("  moved to %s", badFile)
+		badDir := filepath.Join(config.LocalGitStorageDir, "lfs", "bad")
+		if err := os.MkdirAll(badDir, 0755); err != nil {
+			return false, err
 		}
+
+		badFile := filepath.Join(badDir, oid)
+		if err := os.Rename(path, badFile); err != nil {
+			return false, err
+		}
+		Print("  moved to %s", badFile)
 	}
 	return ok, nil
 }
 
+// fsckRemoteObjects asks the current remote for every object referenced by ref,
+// and reports the ones it doesn't have.
+func fsckRemoteObjects(ref *git.Ref) (bool, error) {
+	pointers, err := lfs.ScanRefs(ref.Sha, "", nil)
+	if err != nil {
+		return false, err
+	}
+
+	// Check each object once, but report it by the first name it has
+	byOid := make(map[string]*lfs.WrappedPointer, len(pointers))
+	var totalSize int64
+	for _, p := range pointers {
+		if _, ok := byOid[p.Oid]; !ok {
+			byOid[p.Oid] = p
+			totalSize += lfs.KnownSize(p.Size)
+		}
+	}
+
+	if len(byOid) == 0 {
+		return true, nil
+	}
+
+	checkQueue := lfs.NewDownloadCheckQueue(len(byOid), totalSize)
+	availc := checkQueue.Watch()
+
+	available := lfs.NewStringSet()
+	done := make(chan int)
+	go func() {
+		for oid := range availc {
+			available.Add(oid)
+		}
+		done <- 1
+	}()
+
+	for _, p := range byOid {
+		checkQueue.Add(lfs.NewDownloadable(p))
+	}
+	checkQueue.Wait()
+	<-done
+
+	missing := 0
+	for _, p := range pointers {
+		if available.Contains(p.Oid) || byOid[p.Oid] != p {
+			continue
+		}
+
+		Print("Object %s (%s) is missing from the remote", p.Name, p.Oid)
+		missing++
+	}
+
+	if missing > 0 {
+		remote := config.Config.CurrentRemote
+		Exit("%d of %d objects are missing from %s: run `git lfs push --all %s` to upload them from a copy that has them.", missing, len(byOid), remote, remote)
+	}
+	return true, nil
+}
+
+// indexFsckPointer adds p to the pointers to its object, unless a pointer with
+// the same name and size is already there.
+func indexFsckPointer(index map[string][]*lfs.WrappedPointer, p *lfs.WrappedPointer) {
+	for _, existing := range index[p.Oid] {
+		if existing.Name == p.Name && existing.Size == p.Size {
+			return
+		}
+	}
+	index[p.Oid] = append(index[p.Oid], p)
+}
+
 // TODO(zeroshirts): 'git fsck' reports status (percentage, current#/total) as
 // it checks... we should do the same, as we are rehashing potentially gigs and
 // gigs of content.
@@ -121,5 +207,6 @@ func fsckCommand(cmd *cobra.Command, args []string) {
 
 func init() {
 	fsckCmd.Flags().BoolVarP(&fsckDryRun, "dry-run", "d", false, "List corrupt objects without deleting them.")
+	fsckCmd.Flags().BoolVarP(&fsckRemote, "remote", "", false, "Check that the remote has every object referenced by the current ref.")
 	RootCmd.AddCommand(fsckCmd)
 }
@@ -16,9 +16,10 @@ var (
 		Run: installHooksCommand,
 	}
 
-	forceInstall      = false
-	localInstall      = false
-	skipSmudgeInstall = false
+	forceInstall       = false
+	localInstall       = false
+	skipSmudgeInstall  = false
+	mergeDriverInstall = false
 )
 
 func installCommand(cmd *cobra.Command, args []string) {
@@ -37,6 +38,13 @@ func installCommand(cmd *cobra.Command, args []string) {
 		Exit("Run `git lfs install --force` to reset git config.")
 	}
 
+	if mergeDriverInstall {
+		if err := lfs.InstallMergeDriver(opt); err != nil {
+			Error(err.Error())
+			Exit("Run `git lfs install --force --merge-driver` to reset git config.")
+		}
+	}
+
 	if localInstall || lfs.InRepo() {
 		installHooksCommand(cmd, args)
 	}
@@ -53,6 +61,7 @@ func init() {
 	installCmd.Flags().BoolVarP(&forceInstall, "force", "f", false, "Set the Git LFS global config, overwriting previous values.")
 	installCmd.Flags().BoolVarP(&localInstall, "local", "l", false, "Set the Git LFS config for the local Git repository only.")
 	installCmd.Flags().BoolVarP(&skipSmudgeInstall, "skip-smudge", "s", false, "Skip automatic downloading of objects on clone or pull.")
+	installCmd.Flags().BoolVarP(&mergeDriverInstall, "merge-driver", "", false, "Set up the Git LFS merge driver, which reports conflicting objects.")
 	installCmd.AddCommand(installHooksCmd)
 	RootCmd.AddCommand(installCmd)
 }
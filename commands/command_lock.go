@@ -1,10 +1,12 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/github/git-lfs/api"
 	"github.com/github/git-lfs/config"
@@ -33,7 +35,7 @@ func lockCommand(cmd *cobra.Command, args []string) {
 	setLockRemoteFor(config.Config)
 
 	if len(args) == 0 {
-		Print("Usage: git lfs lock <path>")
+		Print("Usage: git lfs lock <path>...")
 		return
 	}
 
@@ -43,28 +45,82 @@ func lockCommand(cmd *cobra.Command, args []string) {
 		Exit("Unable to determine lastest remote ref for branch.")
 	}
 
-	path, err := lockPath(args[0])
-	if err != nil {
-		Exit(err.Error())
+	results := eachLockPath(args, func(file string) (string, error) {
+		path, err := lockPath(file)
+		if err != nil {
+			return "", err
+		}
+
+		return lockFile(path, latest.Sha)
+	})
+
+	failed := 0
+	for _, r := range results {
+		if r.err != nil {
+			failed++
+			Error("'%s' could not be locked: %s", r.file, r.err)
+			continue
+		}
+		Print("\n'%s' was locked (%s)", r.file, r.id)
+	}
+
+	if failed > 0 {
+		Exit("Server unable to create %d of %d lock(s).", failed, len(results))
 	}
+}
 
+// lockFile asks the server to lock path, relative to the root of the
+// repository, for the current committer, and returns the new lock's ID.
+func lockFile(path, latestSha string) (string, error) {
 	s, resp := API.Locks.Lock(&api.LockRequest{
 		Path:               path,
 		Committer:          api.CurrentCommitter(),
-		LatestRemoteCommit: latest.Sha,
+		LatestRemoteCommit: latestSha,
 	})
 
 	if _, err := API.Do(s); err != nil {
-		Error(err.Error())
-		Exit("Error communicating with LFS API.")
+		return "", fmt.Errorf("Error communicating with LFS API: %s", err)
 	}
 
 	if len(resp.Err) > 0 {
-		Error(resp.Err)
-		Exit("Server unable to create lock.")
+		return "", errors.New(resp.Err)
+	}
+
+	return resp.Lock.Id, nil
+}
+
+// lockResult is the outcome of locking or unlocking one of the paths given to
+// `git lfs lock` or `git lfs unlock`.
+type lockResult struct {
+	file string
+	id   string
+	err  error
+}
+
+// eachLockPath calls fn for each of files, running up to lfs.concurrentlocks
+// calls at once, and returns the lock ID or error from each call in the order
+// of files. A failure for one file doesn't stop the others.
+func eachLockPath(files []string, fn func(file string) (string, error)) []lockResult {
+	results := make([]lockResult, len(files))
+	sem := make(chan struct{}, config.Config.ConcurrentLocks())
+
+	var wg sync.WaitGroup
+	for i, file := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, file string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			id, err := fn(file)
+			results[i] = lockResult{file: file, id: id, err: err}
+		}(i, file)
 	}
+	wg.Wait()
 
-	Print("\n'%s' was locked (%s)", args[0], resp.Lock.Id)
+	return results
 }
 
 // lockPaths relativizes the given filepath such that it is relative to the root
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/github/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEachLockPathReportsEachPath(t *testing.T) {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.concurrentlocks", "4")
+
+	files := make([]string, 20)
+	for i := range files {
+		files[i] = fmt.Sprintf("%d.dat", i)
+	}
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+
+	results := eachLockPath(files, func(file string) (string, error) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		if file == "3.dat" || file == "17.dat" {
+			return "", errors.New("lock already created")
+		}
+		return "id-" + file, nil
+	})
+
+	assert.True(t, maxRunning <= 4, "ran %d at once", maxRunning)
+	assert.True(t, maxRunning > 1, "ran %d at once", maxRunning)
+
+	assert.Len(t, results, len(files))
+	for i, r := range results {
+		assert.Equal(t, files[i], r.file)
+		if r.file == "3.dat" || r.file == "17.dat" {
+			assert.NotNil(t, r.err, r.file)
+			assert.Equal(t, "", r.id)
+		} else {
+			assert.Nil(t, r.err, r.file)
+			assert.Equal(t, "id-"+r.file, r.id)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 var (
 	longOIDs   = false
+	showSizes  = false
 	lsFilesCmd = &cobra.Command{
 		Use: "ls-files",
 		Run: lsFilesCommand,
@@ -43,7 +44,11 @@ func lsFilesCommand(cmd *cobra.Command, args []string) {
 	}
 
 	for _, p := range files {
-		Print("%s %s %s", p.Oid[0:showOidLen], lsFilesMarker(p), p.Name)
+		if showSizes {
+			Print("%s %s %s (%s)", p.Oid[0:showOidLen], lsFilesMarker(p), p.Name, humanizeBytes(p.Size))
+		} else {
+			Print("%s %s %s", p.Oid[0:showOidLen], lsFilesMarker(p), p.Name)
+		}
 	}
 }
 
@@ -58,5 +63,6 @@ func lsFilesMarker(p *lfs.WrappedPointer) string {
 
 func init() {
 	lsFilesCmd.Flags().BoolVarP(&longOIDs, "long", "l", false, "")
+	lsFilesCmd.Flags().BoolVarP(&showSizes, "size", "s", false, "")
 	RootCmd.AddCommand(lsFilesCmd)
 }
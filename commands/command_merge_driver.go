@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/github/git-lfs/lfs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mergeDriverAncestor   string
+	mergeDriverCurrent    string
+	mergeDriverOther      string
+	mergeDriverPath       string
+	mergeDriverMarkerSize = 7
+	mergeDriverCmd        = &cobra.Command{
+		Use: "merge-driver",
+		Run: mergeDriverCommand,
+	}
+)
+
+// mergeDriverCommand is run by Git's "lfs" merge driver, which `git lfs
+// install --merge-driver` configures, when both sides of a merge change a file
+// with the merge=lfs attribute. Git passes the pointers from the merge base,
+// the current branch and the other branch in temporary files, and takes the
+// result from the current branch's file.
+//
+// If each side's pointer is for a different object, there's no way to merge
+// their content, so the current branch's pointer is kept, and the objects on
+// both sides are reported so that the user can choose between them. Files
+// which aren't pointers on both sides are merged as text by `git merge-file`.
+func mergeDriverCommand(cmd *cobra.Command, args []string) {
+	if len(mergeDriverCurrent) == 0 || len(mergeDriverOther) == 0 {
+		Exit("Usage: %s", "git lfs merge-driver --ancestor %O --current %A --other %B [--marker-size %L] [--path %P]")
+	}
+
+	ours, err := lfs.DecodePointerFromFile(mergeDriverCurrent)
+	if err != nil {
+		mergeDriverText()
+		return
+	}
+
+	theirs, err := lfs.DecodePointerFromFile(mergeDriverOther)
+	if err != nil {
+		mergeDriverText()
+		return
+	}
+
+	// the file may have been added on both sides, without a merge base
+	var base *lfs.Pointer
+	if len(mergeDriverAncestor) > 0 {
+		base, _ = lfs.DecodePointerFromFile(mergeDriverAncestor)
+	}
+
+	merged, ok := mergePointers(base, ours, theirs)
+	if !ok {
+		reportPointerConflict(mergeDriverPath, base, ours, theirs)
+		os.Exit(1)
+	}
+
+	if merged != ours {
+		f, err := os.Create(mergeDriverCurrent)
+		if err != nil {
+			Panic(err, "Error writing merged pointer")
+		}
+		defer f.Close()
+
+		if _, err := lfs.EncodePointer(f, merged); err != nil {
+			Panic(err, "Error writing merged pointer")
+		}
+	}
+}
+
+// mergePointers returns the pointer resulting from merging ours and theirs,
+// which both changed base, or false if they point to different objects and
+// neither side kept base's object. base is nil if there isn't a merge base, or
+// if it isn't a pointer.
+func mergePointers(base, ours, theirs *lfs.Pointer) (*lfs.Pointer, bool) {
+	switch {
+	case ours.Oid == theirs.Oid:
+		return ours, true
+	case base != nil && base.Oid == ours.Oid:
+		return theirs, true
+	case base != nil && base.Oid == theirs.Oid:
+		return ours, true
+	default:
+		return nil, false
+	}
+}
+
+// reportPointerConflict tells the user which objects each side of the merge
+// has for path, and how to pick one of them.
+func reportPointerConflict(path string, base, ours, theirs *lfs.Pointer) {
+	if len(path) == 0 {
+		path = "<path>"
+	}
+
+	Error("Git LFS: %s has conflicting changes to a Git LFS object:", path)
+	Error("  ours:   %s (%s)", ours.Oid, humanizeBytes(ours.Size))
+	Error("  theirs: %s (%s)", theirs.Oid, humanizeBytes(theirs.Size))
+	if base != nil {
+		Error("  base:   %s (%s)", base.Oid, humanizeBytes(base.Size))
+	}
+	Error("Keeping ours in the working copy. To use theirs instead, run:")
+	Error("  git checkout --theirs -- %s", path)
+	Error("Then run `git add %s` to mark the conflict resolved.", path)
+}
+
+// mergeDriverText merges the files as text, as Git would without the merge
+// driver, exiting with an error if they conflict.
+func mergeDriverText() {
+	args := []string{"merge-file", "--marker-size=" + strconv.Itoa(mergeDriverMarkerSize)}
+	if len(mergeDriverPath) > 0 {
+		args = append(args, "-L", mergeDriverPath, "-L", mergeDriverPath, "-L", mergeDriverPath)
+	}
+	args = append(args, mergeDriverCurrent, emptyIfMissing(mergeDriverAncestor), mergeDriverOther)
+
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			Error("Error running git merge-file: %s", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// emptyIfMissing returns path, or /dev/null if it's empty.
+func emptyIfMissing(path string) string {
+	if len(path) == 0 {
+		return os.DevNull
+	}
+	return path
+}
+
+func init() {
+	mergeDriverCmd.Flags().StringVarP(&mergeDriverAncestor, "ancestor", "", "", "file with the merge base's version")
+	mergeDriverCmd.Flags().StringVarP(&mergeDriverCurrent, "current", "", "", "file with the current branch's version, which is replaced with the result")
+	mergeDriverCmd.Flags().StringVarP(&mergeDriverOther, "other", "", "", "file with the other branch's version")
+	mergeDriverCmd.Flags().StringVarP(&mergeDriverPath, "path", "", "", "path of the file being merged")
+	mergeDriverCmd.Flags().IntVarP(&mergeDriverMarkerSize, "marker-size", "", 7, "length of conflict markers")
+	RootCmd.AddCommand(mergeDriverCmd)
+}
@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/github/git-lfs/lfs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePointers(t *testing.T) {
+	base := lfs.NewPointer(strings.Repeat("a", 64), 1, nil)
+	ours := lfs.NewPointer(strings.Repeat("b", 64), 2, nil)
+	theirs := lfs.NewPointer(strings.Repeat("c", 64), 3, nil)
+	same := lfs.NewPointer(strings.Repeat("b", 64), 2, nil)
+
+	merged, ok := mergePointers(base, ours, same)
+	assert.True(t, ok)
+	assert.Equal(t, ours, merged)
+
+	merged, ok = mergePointers(base, base, theirs)
+	assert.True(t, ok)
+	assert.Equal(t, theirs, merged)
+
+	merged, ok = mergePointers(base, ours, base)
+	assert.True(t, ok)
+	assert.Equal(t, ours, merged)
+
+	_, ok = mergePointers(base, ours, theirs)
+	assert.False(t, ok)
+
+	// added on both sides
+	_, ok = mergePointers(nil, ours, theirs)
+	assert.False(t, ok)
+	merged, ok = mergePointers(nil, ours, same)
+	assert.True(t, ok)
+	assert.Equal(t, ours, merged)
+}
@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/github/git-lfs/lfs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateCmd = &cobra.Command{
+		Use: "migrate",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Usage()
+		},
+	}
+
+	migrateInfoCmd = &cobra.Command{
+		Use: "info",
+		Run: migrateInfoCommand,
+	}
+
+	migrateInfoGroupBy   = "author"
+	migrateInfoJSON      = false
+	migrateInfoLowMemory = false
+)
+
+// migrateInfoMemoryOids is how many oids --low-memory keeps in memory before
+// writing them to disk.
+const migrateInfoMemoryOids = 1 << 16
+
+// footprintGroup is the LFS content added by an author, or on a date.
+type footprintGroup struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	Objects int    `json:"objects"`
+	Commits int    `json:"commits"`
+
+	// lastCommit is the last commit counted, as each commit's pointers are
+	// scanned together
+	lastCommit string
+}
+
+type footprint struct {
+	GroupBy      string            `json:"group_by"`
+	Groups       []*footprintGroup `json:"groups"`
+	TotalSize    int64             `json:"total_size"`
+	TotalObjects int               `json:"total_objects"`
+}
+
+// migrateInfoCommand attributes the size of each LFS object in the history of
+// the given refs to the commit which first added it, and summarizes the sizes
+// by the commits' author or author date.
+func migrateInfoCommand(cmd *cobra.Command, args []string) {
+	requireInRepo()
+
+	var groupName func(c *lfs.LogCommit) string
+	switch migrateInfoGroupBy {
+	case "author":
+		groupName = func(c *lfs.LogCommit) string { return c.Author }
+	case "date":
+		groupName = func(c *lfs.LogCommit) string { return c.Date.Format("2006-01-02") }
+	default:
+		Exit("Invalid --group-by %q: use author or date", migrateInfoGroupBy)
+	}
+
+	var seen lfs.OidSet = lfs.NewStringSet()
+	if migrateInfoLowMemory {
+		set, err := lfs.NewDiskOidSet(lfs.TempDir(), migrateInfoMemoryOids)
+		if err != nil {
+			ExitWithError(err)
+		}
+		defer set.Close()
+		seen = set
+	}
+
+	f := &footprint{GroupBy: migrateInfoGroupBy, Groups: make([]*footprintGroup, 0)}
+	groups := make(map[string]*footprintGroup)
+	err := lfs.ScanAddedPointersFunc(args, seen, func(p *lfs.AddedPointer) {
+		name := groupName(p.Commit)
+		g, ok := groups[name]
+		if !ok {
+			g = &footprintGroup{Name: name}
+			groups[name] = g
+			f.Groups = append(f.Groups, g)
+		}
+
+		g.Size += lfs.KnownSize(p.Size)
+		g.Objects++
+		if g.lastCommit != p.Commit.Sha {
+			g.lastCommit = p.Commit.Sha
+			g.Commits++
+		}
+
+		f.TotalSize += lfs.KnownSize(p.Size)
+		f.TotalObjects++
+	})
+	if set, ok := seen.(*lfs.DiskOidSet); ok && err == nil {
+		err = set.Err()
+	}
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	if migrateInfoGroupBy == "date" {
+		sort.Sort(footprintByName(f.Groups))
+	} else {
+		sort.Sort(footprintBySize(f.Groups))
+	}
+
+	if migrateInfoJSON {
+		by, err := json.MarshalIndent(f, "", "  ")
+		if err != nil {
+			ExitWithError(err)
+		}
+		// Authors are "Name <email>", so don't escape angle brackets
+		OutputWriter.Write(unescapeJSONHTML(by))
+		fmt.Fprintln(OutputWriter)
+		return
+	}
+
+	width := len("Total")
+	for _, g := range f.Groups {
+		if len(g.Name) > width {
+			width = len(g.Name)
+		}
+	}
+
+	for _, g := range f.Groups {
+		Print("%-*s  %10s  %s, %s", width, g.Name, humanizeBytes(g.Size), pluralize(g.Objects, "object"), pluralize(g.Commits, "commit"))
+	}
+	Print("%-*s  %10s  %s", width, "Total", humanizeBytes(f.TotalSize), pluralize(f.TotalObjects, "object"))
+}
+
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// unescapeJSONHTML undoes the escaping of <, > and & which encoding/json does
+// in strings, skipping escaped backslashes so that a literal "\u003c" is kept.
+func unescapeJSONHTML(by []byte) []byte {
+	out := make([]byte, 0, len(by))
+	for i := 0; i < len(by); i++ {
+		if by[i] != '\\' || i+1 >= len(by) {
+			out = append(out, by[i])
+			continue
+		}
+
+		if by[i+1] == 'u' && i+6 <= len(by) {
+			switch string(by[i+2 : i+6]) {
+			case "003c":
+				out = append(out, '<')
+				i += 5
+				continue
+			case "003e":
+				out = append(out, '>')
+				i += 5
+				continue
+			case "0026":
+				out = append(out, '&')
+				i += 5
+				continue
+			}
+		}
+
+		out = append(out, by[i], by[i+1])
+		i++
+	}
+	return out
+}
+
+// footprintBySize sorts groups by size, largest first.
+type footprintBySize []*footprintGroup
+
+func (s footprintBySize) Len() int      { return len(s) }
+func (s footprintBySize) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s footprintBySize) Less(i, j int) bool {
+	if s[i].Size == s[j].Size {
+		return s[i].Name < s[j].Name
+	}
+	return s[i].Size > s[j].Size
+}
+
+// footprintByName sorts groups by name, which sorts dates oldest first.
+type footprintByName []*footprintGroup
+
+func (s footprintByName) Len() int           { return len(s) }
+func (s footprintByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s footprintByName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+
+func init() {
+	migrateInfoCmd.Flags().StringVarP(&migrateInfoGroupBy, "group-by", "", "author", "Summarize by author or date")
+	migrateInfoCmd.Flags().BoolVarP(&migrateInfoJSON, "json", "", false, "Give the output as JSON")
+	migrateInfoCmd.Flags().BoolVarP(&migrateInfoLowMemory, "low-memory", "", false, "Keep the objects found on disk, for very large histories")
+
+	migrateCmd.AddCommand(migrateInfoCmd)
+	RootCmd.AddCommand(migrateCmd)
+}
@@ -0,0 +1,17 @@
+package commands
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnescapeJSONHTML(t *testing.T) {
+	by, err := json.Marshal([]string{"Alice <alice@example.com>", "a & b", `\u003c`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, `["Alice <alice@example.com>","a & b","\\u003c"]`, string(unescapeJSONHTML(by)))
+}
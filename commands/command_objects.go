@@ -0,0 +1,284 @@
+package commands
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/lfs"
+	"github.com/github/git-lfs/tools"
+	"github.com/spf13/cobra"
+)
+
+var (
+	objectsCmd = &cobra.Command{
+		Use: "objects",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Usage()
+		},
+	}
+
+	objectsPinCmd = &cobra.Command{
+		Use: "pin",
+		Run: objectsPinCommand,
+	}
+
+	objectsUnpinCmd = &cobra.Command{
+		Use: "unpin",
+		Run: objectsUnpinCommand,
+	}
+
+	objectsListCmd = &cobra.Command{
+		Use: "list",
+		Run: objectsListCommand,
+	}
+
+	objectsPathCmd = &cobra.Command{
+		Use: "path",
+		Run: objectsPathCommand,
+	}
+
+	objectsImportAnnexCmd = &cobra.Command{
+		Use: "import-annex",
+		Run: objectsImportAnnexCommand,
+	}
+
+	objectsListPinned        = false
+	objectsAnnexRewriteLinks = false
+
+	objectOidRE = regexp.MustCompile(`\A[0-9a-f]{64}\z`)
+)
+
+func objectsPinCommand(cmd *cobra.Command, args []string) {
+	requireInRepo()
+
+	if len(args) == 0 {
+		Print("Usage: git lfs objects pin <oid> [oid]...")
+		return
+	}
+
+	for _, oid := range requireObjectOids(args) {
+		added, err := lfs.PinObject(oid)
+		if err != nil {
+			ExitWithError(err)
+		}
+
+		if added {
+			Print("Pinned %s", oid)
+		} else {
+			Print("%s is already pinned", oid)
+		}
+	}
+}
+
+func objectsUnpinCommand(cmd *cobra.Command, args []string) {
+	requireInRepo()
+
+	if len(args) == 0 {
+		Print("Usage: git lfs objects unpin <oid> [oid]...")
+		return
+	}
+
+	for _, oid := range requireObjectOids(args) {
+		removed, err := lfs.UnpinObject(oid)
+		if err != nil {
+			ExitWithError(err)
+		}
+
+		if removed {
+			Print("Unpinned %s", oid)
+		} else {
+			Print("%s is not pinned", oid)
+		}
+	}
+}
+
+func objectsListCommand(cmd *cobra.Command, args []string) {
+	requireInRepo()
+
+	pinned, err := lfs.PinnedObjects()
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	if objectsListPinned {
+		oids := make([]string, 0, pinned.Cardinality())
+		for oid := range pinned.Iter() {
+			oids = append(oids, oid)
+		}
+		sort.Strings(oids)
+
+		for _, oid := range oids {
+			Print("%s", oid)
+		}
+		return
+	}
+
+	for obj := range lfs.ScanObjectsChan() {
+		if pinned.Contains(obj.Oid) {
+			Print("%s %s (pinned)", obj.Oid, humanizeBytes(obj.Size))
+		} else {
+			Print("%s %s", obj.Oid, humanizeBytes(obj.Size))
+		}
+	}
+}
+
+// objectsPathCommand prints the path in the local store of the object for each
+// argument, which is either an oid, or a file whose object is the one its
+// pointer names or, if it isn't a pointer, the one its content would be
+// stored as. The object doesn't have to be in the store.
+func objectsPathCommand(cmd *cobra.Command, args []string) {
+	requireInRepo()
+
+	if len(args) == 0 {
+		Print("Usage: git lfs objects path <file|oid>...")
+		return
+	}
+
+	for _, arg := range args {
+		oid := arg
+		if !objectOidRE.MatchString(arg) {
+			var err error
+			if oid, err = fileObjectOid(arg); err != nil {
+				Exit("Unable to find the object for %q: %s", arg, err)
+			}
+		}
+
+		path, err := filepath.Abs(lfs.LocalMediaPathReadOnly(oid))
+		if err != nil {
+			ExitWithError(err)
+		}
+		Print("%s", path)
+	}
+}
+
+// fileObjectOid returns the oid of the object for the file at path: the one
+// named by its pointer, or the one its content hashes to.
+func fileObjectOid(path string) (string, error) {
+	if p, err := lfs.DecodePointerFromFile(path); err == nil {
+		return p.Oid, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hasher := tools.NewHashingReader(f)
+	if _, err := io.Copy(ioutil.Discard, hasher); err != nil {
+		return "", err
+	}
+	return hasher.Hash(), nil
+}
+
+func objectsImportAnnexCommand(cmd *cobra.Command, args []string) {
+	requireInRepo()
+
+	dir := filepath.Join(config.LocalGitDir, "annex", "objects")
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	objects, err := lfs.ScanAnnexObjects(dir)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	pointers := make(map[string]*lfs.Pointer, len(objects))
+	for _, obj := range objects {
+		p, err := lfs.ImportAnnexObject(obj)
+		if err != nil {
+			Error("Unable to import %s: %s", obj.Key.Name, err)
+			continue
+		}
+
+		pointers[obj.Key.Name] = p
+		Print("%s %s", obj.Key.Name, p.Oid)
+	}
+
+	if objectsAnnexRewriteLinks {
+		if err := rewriteAnnexLinks(config.LocalWorkingDir, pointers); err != nil {
+			ExitWithError(err)
+		}
+	}
+
+	if len(pointers) < len(objects) {
+		Exit("Imported %d of %d annex object(s)", len(pointers), len(objects))
+	}
+	Print("Imported %d of %d annex object(s)", len(pointers), len(objects))
+}
+
+// rewriteAnnexLinks replaces symbolic links to imported annex objects in the
+// working copy with pointer files for those objects.
+func rewriteAnnexLinks(root string, pointers map[string]*lfs.Pointer) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			return nil
+		}
+
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+
+		p, ok := pointers[filepath.Base(target)]
+		if !ok {
+			return nil
+		}
+
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+		if err != nil {
+			return err
+		}
+
+		_, err = lfs.EncodePointer(file, p)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+
+		if rel, err := filepath.Rel(root, path); err == nil {
+			path = rel
+		}
+		Print("Rewrote %s", path)
+		return nil
+	})
+}
+
+// requireObjectOids exits if any of oids isn't a valid LFS object id.
+func requireObjectOids(oids []string) []string {
+	for _, oid := range oids {
+		if !objectOidRE.MatchString(oid) {
+			Exit("Invalid object ID: %q", oid)
+		}
+	}
+	return oids
+}
+
+func init() {
+	objectsListCmd.Flags().BoolVarP(&objectsListPinned, "pinned", "", false, "Only list pinned objects")
+
+	objectsImportAnnexCmd.Flags().BoolVarP(&objectsAnnexRewriteLinks, "rewrite-links", "", false, "Replace symbolic links to imported objects with pointer files")
+
+	objectsCmd.AddCommand(objectsPinCmd, objectsUnpinCmd, objectsListCmd, objectsPathCmd, objectsImportAnnexCmd)
+	RootCmd.AddCommand(objectsCmd)
+}
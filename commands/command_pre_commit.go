@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/git"
+	"github.com/spf13/cobra"
+)
+
+var (
+	preCommitCmd = &cobra.Command{
+		Use: "pre-commit",
+		Run: preCommitCommand,
+	}
+)
+
+// preCommitCommand is run through Git's pre-commit hook, installed by
+// `git lfs update --pre-commit`. It warns about staged files over lfs.warnsize
+// which aren't tracked by Git LFS, and so would be committed as regular blobs.
+// The commit only fails if lfs.warnsizestrict is set.
+func preCommitCommand(cmd *cobra.Command, args []string) {
+	requireInRepo()
+
+	threshold := config.Config.WarnSize()
+	if threshold == 0 {
+		return
+	}
+
+	files, err := git.StagedFiles()
+	if err != nil {
+		Panic(err, "Error listing staged files")
+	}
+
+	large := 0
+	for _, f := range files {
+		if f.Size <= threshold {
+			continue
+		}
+
+		filter, err := git.CheckAttr("filter", f.Path)
+		if err != nil {
+			Panic(err, "Error checking attributes of %s", f.Path)
+		}
+		if filter == "lfs" {
+			continue
+		}
+
+		Error("Git LFS: %s is %s, which is over lfs.warnsize (%s), but isn't tracked by Git LFS.", f.Path, humanizeBytes(f.Size), humanizeBytes(threshold))
+		large++
+	}
+
+	if large == 0 {
+		return
+	}
+
+	Error("Git LFS: track large files with `git lfs track <pattern>`, then `git add` them again.")
+	if config.Config.WarnSizeStrict() {
+		Exit("Commit aborted: %d file(s) over lfs.warnsize aren't tracked by Git LFS. Use `git commit --no-verify` to commit them anyway.", large)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(preCommitCmd)
+}
@@ -81,6 +81,8 @@ func prePushCommand(cmd *cobra.Command, args []string) {
 
 		upload(ctx, pointers)
 	}
+
+	ctx.Finish()
 }
 
 // decodeRefs pulls the sha1s out of the line read from the pre-push
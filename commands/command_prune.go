@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -45,9 +46,10 @@ func pruneCommand(cmd *cobra.Command, args []string) {
 type PruneProgressType int
 
 const (
-	PruneProgressTypeLocal  = PruneProgressType(iota)
-	PruneProgressTypeRetain = PruneProgressType(iota)
-	PruneProgressTypeVerify = PruneProgressType(iota)
+	PruneProgressTypeLocal        = PruneProgressType(iota)
+	PruneProgressTypeRetain       = PruneProgressType(iota)
+	PruneProgressTypeVerify       = PruneProgressType(iota)
+	PruneProgressTypeVerifyQueued = PruneProgressType(iota)
 )
 
 // Progress from a sub-task of prune
@@ -66,9 +68,9 @@ func prune(verifyRemote, dryRun, verbose bool) {
 	// Add all the base funcs to the waitgroup before starting them, in case
 	// one completes really fast & hits 0 unexpectedly
 	// each main process can Add() to the wg itself if it subdivides the task
-	taskwait.Add(4) // 1..4: localObjects, current & recent refs, unpushed, worktree
+	taskwait.Add(5) // 1..5: localObjects, current & recent refs, unpushed, worktree, pinned
 	if verifyRemote {
-		taskwait.Add(1) // 5
+		taskwait.Add(1) // 6
 	}
 
 	progressChan := make(PruneProgressChan, 100)
@@ -89,6 +91,11 @@ func prune(verifyRemote, dryRun, verbose bool) {
 	go pruneTaskGetRetainedCurrentAndRecentRefs(retainChan, errorChan, &taskwait)
 	go pruneTaskGetRetainedUnpushed(retainChan, errorChan, &taskwait)
 	go pruneTaskGetRetainedWorktree(retainChan, errorChan, &taskwait)
+	go pruneTaskGetRetainedPinned(retainChan, errorChan, &taskwait)
+	if keepPaths := config.Config.PruneKeepPaths(); len(keepPaths) > 0 {
+		taskwait.Add(1)
+		go pruneTaskGetRetainedKeepPaths(keepPaths, retainChan, errorChan, &taskwait)
+	}
 	if verifyRemote {
 		reachableObjects = lfs.NewStringSetWithCapacity(100)
 		go pruneTaskGetReachableObjects(&reachableObjects, errorChan, &taskwait)
@@ -120,6 +127,8 @@ func prune(verifyRemote, dryRun, verbose bool) {
 	var totalSize int64
 	var verboseOutput bytes.Buffer
 	var verifyc chan string
+	var prunableFiles []localstorage.Object
+	verifyCDN := verifyRemote && config.Config.FetchPruneConfig().PruneVerifyCDN && config.Config.CDNUrl() != ""
 
 	if verifyRemote {
 		config.Config.CurrentRemote = config.Config.FetchPruneConfig().PruneRemoteName
@@ -135,6 +144,9 @@ func prune(verifyRemote, dryRun, verbose bool) {
 		if !retainedObjects.Contains(file.Oid) {
 			prunableObjects = append(prunableObjects, file.Oid)
 			totalSize += file.Size
+			if verifyCDN {
+				prunableFiles = append(prunableFiles, file)
+			}
 			if verbose {
 				// Save up verbose output for the end, spinner still going
 				verboseOutput.WriteString(fmt.Sprintf(" * %v (%v)\n", file.Oid, humanizeBytes(file.Size)))
@@ -144,6 +156,7 @@ func prune(verifyRemote, dryRun, verbose bool) {
 				tracerx.Printf("VERIFYING: %v", file.Oid)
 				pointer := lfs.NewPointer(file.Oid, file.Size, nil)
 				verifyQueue.Add(lfs.NewDownloadable(&lfs.WrappedPointer{Pointer: pointer}))
+				progressChan <- PruneProgress{PruneProgressTypeVerifyQueued, 1}
 			}
 		}
 	}
@@ -163,6 +176,9 @@ func prune(verifyRemote, dryRun, verbose bool) {
 		verifywait.Wait()
 		close(progressChan) // after verify (uses spinner) but before check
 		progresswait.Wait()
+		if verifyCDN {
+			pruneVerifyOnCDN(prunableFiles, reachableObjects, verifiedObjects)
+		}
 		pruneCheckVerified(prunableObjects, reachableObjects, verifiedObjects)
 	} else {
 		close(progressChan)
@@ -211,6 +227,27 @@ func pruneCheckVerified(prunableObjects []string, reachableObjects, verifiedObje
 	}
 }
 
+// pruneVerifyOnCDN adds the reachable objects which the remote doesn't have to
+// verifiedObjects if the CDN set by lfs.cdn.url has them, as the CDN is their
+// durable store.
+func pruneVerifyOnCDN(prunableFiles []localstorage.Object, reachableObjects, verifiedObjects lfs.StringSet) {
+	for _, file := range prunableFiles {
+		if verifiedObjects.Contains(file.Oid) || !reachableObjects.Contains(file.Oid) {
+			continue
+		}
+
+		ok, err := lfs.ObjectOnCDN(file.Oid, file.Size)
+		if err != nil {
+			tracerx.Printf("CDN ERROR: %v: %v", file.Oid, err)
+			continue
+		}
+		if ok {
+			verifiedObjects.Add(file.Oid)
+			tracerx.Printf("VERIFIED ON CDN: %v", file.Oid)
+		}
+	}
+}
+
 func pruneCheckErrors(taskErrors []error) {
 	if len(taskErrors) > 0 {
 		for _, err := range taskErrors {
@@ -227,6 +264,7 @@ func pruneTaskDisplayProgress(progressChan PruneProgressChan, waitg *sync.WaitGr
 	localCount := 0
 	retainCount := 0
 	verifyCount := 0
+	verifyTotal := 0
 	var msg string
 	for p := range progressChan {
 		switch p.ProgressType {
@@ -236,10 +274,12 @@ func pruneTaskDisplayProgress(progressChan PruneProgressChan, waitg *sync.WaitGr
 			retainCount++
 		case PruneProgressTypeVerify:
 			verifyCount++
+		case PruneProgressTypeVerifyQueued:
+			verifyTotal++
 		}
 		msg = fmt.Sprintf("%d local objects, %d retained", localCount, retainCount)
-		if verifyCount > 0 {
-			msg += fmt.Sprintf(", %d verified with remote", verifyCount)
+		if verifyTotal > 0 {
+			msg += fmt.Sprintf(", %d of %d verified with remote", verifyCount, verifyTotal)
 		}
 		spinner.Print(OutputWriter, msg)
 	}
@@ -401,6 +441,60 @@ func pruneTaskGetRetainedCurrentAndRecentRefs(retainChan chan string, errorChan
 	}
 }
 
+// Background task, must call waitg.Done() once at end
+func pruneTaskGetRetainedPinned(retainChan chan string, errorChan chan error, waitg *sync.WaitGroup) {
+	defer waitg.Done()
+
+	pinned, err := lfs.PinnedObjects()
+	if err != nil {
+		errorChan <- err
+		return
+	}
+	for oid := range pinned.Iter() {
+		retainChan <- oid
+		tracerx.Printf("RETAIN: %v pinned", oid)
+	}
+}
+
+// Background task, must call waitg.Done() once at end
+func pruneTaskGetRetainedKeepPaths(keepPaths []string, retainChan chan string, errorChan chan error, waitg *sync.WaitGroup) {
+	defer waitg.Done()
+
+	patterns := pruneKeepPatterns(keepPaths)
+
+	// Objects anywhere in reachable history are kept, not only recent ones
+	opts := lfs.NewScanRefsOptions()
+	opts.ScanMode = lfs.ScanAllMode
+	opts.SkipDeletedBlobs = false
+
+	pointerchan, err := lfs.ScanRefsToChan("", "", opts)
+	if err != nil {
+		errorChan <- fmt.Errorf("Error scanning for objects to keep: %v", err)
+		return
+	}
+	for p := range pointerchan.Results {
+		if lfs.FilenamePassesIncludeExcludeFilter(p.Name, patterns, nil) {
+			retainChan <- p.Oid
+			tracerx.Printf("RETAIN: %v via path %v", p.Oid, p.Name)
+		}
+	}
+	err = pointerchan.Wait()
+	if err != nil {
+		errorChan <- err
+	}
+}
+
+// pruneKeepPatterns returns the lfs.prune.keep patterns to match paths with.
+// A trailing "/**" matches everything in a directory, which the directory
+// itself already does.
+func pruneKeepPatterns(keepPaths []string) []string {
+	patterns := make([]string, 0, len(keepPaths))
+	for _, path := range keepPaths {
+		patterns = append(patterns, strings.TrimSuffix(path, "/**"))
+	}
+	return patterns
+}
+
 // Background task, must call waitg.Done() once at end
 func pruneTaskGetRetainedUnpushed(retainChan chan string, errorChan chan error, waitg *sync.WaitGroup) {
 	defer waitg.Done()
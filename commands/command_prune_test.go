@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/lfs"
+	"github.com/github/git-lfs/localstorage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneVerifyOnCDN(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "HEAD" || r.URL.Path != "/oncdn" {
+			w.WriteHeader(404)
+			return
+		}
+		w.Header().Set("Content-Length", "5")
+	}))
+	defer srv.Close()
+
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.cdn.url", srv.URL)
+
+	prunable := []localstorage.Object{
+		{Oid: "oncdn", Size: 5},
+		{Oid: "missing", Size: 5},
+		{Oid: "unreachable", Size: 5},
+		{Oid: "onremote", Size: 5},
+	}
+	reachable := lfs.NewStringSet()
+	reachable.Add("oncdn")
+	reachable.Add("missing")
+	reachable.Add("onremote")
+	verified := lfs.NewStringSet()
+	verified.Add("onremote")
+
+	pruneVerifyOnCDN(prunable, reachable, verified)
+
+	assert.True(t, verified.Contains("oncdn"))
+	assert.True(t, verified.Contains("onremote"))
+	assert.False(t, verified.Contains("missing"))
+	assert.False(t, verified.Contains("unreachable"))
+}
+
+func TestPruneKeepPatterns(t *testing.T) {
+	patterns := pruneKeepPatterns([]string{"release/**", "*.psd", "docs"})
+
+	for path, keep := range map[string]bool{
+		"release/app.bin":       true,
+		"release/1.0/app.bin":   true,
+		"releases/app.bin":      false,
+		"art.psd":               true,
+		"docs/manual.pdf":       true,
+		"src/release/app.bin":   false,
+		"other/docs/manual.pdf": false,
+	} {
+		assert.Equal(t, keep, lfs.FilenamePassesIncludeExcludeFilter(path, patterns, nil), path)
+	}
+}
@@ -13,12 +13,19 @@ var (
 		Use: "pull",
 		Run: pullCommand,
 	}
-	pullIncludeArg string
-	pullExcludeArg string
+	pullIncludeArg  string
+	pullExcludeArg  string
+	pullProgressArg string
+	pullRateArg     string
+	pullPriorityArg string
 )
 
 func pullCommand(cmd *cobra.Command, args []string) {
 	requireInRepo()
+	warnIfNotInstalled()
+	setProgressStyle(pullProgressArg)
+	setMaxBandwidth(pullRateArg)
+	setPriorityPaths(pullPriorityArg)
 
 	if len(args) > 0 {
 		// Remote is first arg
@@ -54,5 +61,8 @@ func pull(includePaths, excludePaths []string) {
 func init() {
 	pullCmd.Flags().StringVarP(&pullIncludeArg, "include", "I", "", "Include a list of paths")
 	pullCmd.Flags().StringVarP(&pullExcludeArg, "exclude", "X", "", "Exclude a list of paths")
+	pullCmd.Flags().StringVarP(&pullProgressArg, "progress", "", "", "Progress output: bar, plain or none")
+	pullCmd.Flags().StringVarP(&pullRateArg, "max-bandwidth", "", "", "Limit the combined rate of transfers, e.g. 2m for 2 MB/s")
+	pullCmd.Flags().StringVarP(&pullPriorityArg, "priority", "", "", "Fetch the objects of a list of paths first")
 	RootCmd.AddCommand(pullCmd)
 }
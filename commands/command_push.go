@@ -16,10 +16,14 @@ var (
 		Use: "push",
 		Run: pushCommand,
 	}
-	pushDryRun    = false
-	pushObjectIDs = false
-	pushAll       = false
-	useStdin      = false
+	pushDryRun      = false
+	pushAskServer   = false
+	pushObjectIDs   = false
+	pushAll         = false
+	pushVerify      = false
+	useStdin        = false
+	pushProgressArg = ""
+	pushRateArg     = ""
 
 	// shares some global vars and functions with command_pre_push.go
 )
@@ -113,6 +117,9 @@ func refsByNames(refnames []string) ([]*git.Ref, error) {
 // pushCommand calculates the git objects to send by looking comparing the range
 // of commits between the local and remote git servers.
 func pushCommand(cmd *cobra.Command, args []string) {
+	setProgressStyle(pushProgressArg)
+	setMaxBandwidth(pushRateArg)
+
 	if len(args) == 0 {
 		Print("Specify a remote and a remote branch name (`git lfs push origin master`)")
 		os.Exit(1)
@@ -123,8 +130,13 @@ func pushCommand(cmd *cobra.Command, args []string) {
 		Exit("Invalid remote name %q", args[0])
 	}
 
+	if pushAskServer && !pushDryRun {
+		Exit("--ask-server can only be used with --dry-run")
+	}
+
 	config.Config.CurrentRemote = args[0]
 	ctx := newUploadContext(pushDryRun)
+	ctx.AskServer = pushAskServer
 
 	if useStdin {
 		requireStdin("Run this command from the Git pre-push hook, or leave the --stdin flag off.")
@@ -163,13 +175,24 @@ func pushCommand(cmd *cobra.Command, args []string) {
 
 		uploadsBetweenRefAndRemote(ctx, args[1:])
 	}
+
+	ctx.Finish()
+	ctx.printDryRunTotal()
+
+	if pushVerify {
+		ctx.verifyPushed()
+	}
 }
 
 func init() {
 	pushCmd.Flags().BoolVarP(&pushDryRun, "dry-run", "d", false, "Do everything except actually send the updates")
+	pushCmd.Flags().BoolVar(&pushAskServer, "ask-server", false, "With --dry-run, list only the objects that the server needs, and their size")
 	pushCmd.Flags().BoolVarP(&useStdin, "stdin", "s", false, "Take refs on stdin (for pre-push hook)")
 	pushCmd.Flags().BoolVarP(&pushObjectIDs, "object-id", "o", false, "Push LFS object ID(s)")
 	pushCmd.Flags().BoolVarP(&pushAll, "all", "a", false, "Push all objects for the current ref to the remote.")
+	pushCmd.Flags().BoolVarP(&pushVerify, "verify", "", false, "Check that the server can return every pushed object.")
+	pushCmd.Flags().StringVarP(&pushProgressArg, "progress", "", "", "Progress output: bar, plain or none")
+	pushCmd.Flags().StringVarP(&pushRateArg, "max-bandwidth", "", "", "Limit the combined rate of transfers, e.g. 2m for 2 MB/s")
 
 	RootCmd.AddCommand(pushCmd)
 }
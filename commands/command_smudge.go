@@ -42,11 +42,7 @@ func smudgeCommand(cmd *cobra.Command, args []string) {
 	lfs.LinkOrCopyFromReference(ptr.Oid, ptr.Size)
 
 	if smudgeInfo {
-		localPath, err := lfs.LocalMediaPath(ptr.Oid)
-		if err != nil {
-			Exit(err.Error())
-		}
-
+		localPath := lfs.LocalMediaPathReadOnly(ptr.Oid)
 		stat, err := os.Stat(localPath)
 		if err != nil {
 			Print("%d --", ptr.Size)
@@ -75,13 +71,22 @@ func smudgeCommand(cmd *cobra.Command, args []string) {
 	}
 
 	if err != nil {
-		ptr.Encode(os.Stdout)
 		// Download declined error is ok to skip if we weren't requesting download
-		if !(errutil.IsDownloadDeclinedError(err) && !download) {
-			LoggedError(err, "Error downloading object: %s (%s)", filename, ptr.Oid)
-			if !cfg.SkipDownloadErrors() {
-				os.Exit(2)
-			}
+		if errutil.IsDownloadDeclinedError(err) && !download {
+			ptr.Encode(os.Stdout)
+			return
+		}
+
+		if cfg.SmudgeFallback() == "placeholder" {
+			lfs.EncodePlaceholder(os.Stdout, ptr)
+			LoggedError(err, "Error downloading object: %s (%s), wrote a placeholder instead", filename, ptr.Oid)
+			return
+		}
+
+		ptr.Encode(os.Stdout)
+		LoggedError(err, "Error downloading object: %s (%s)", filename, ptr.Oid)
+		if !cfg.SkipDownloadErrors() {
+			os.Exit(2)
 		}
 	}
 }
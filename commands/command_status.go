@@ -2,7 +2,10 @@ package commands
 
 import (
 	"fmt"
+	"path/filepath"
+	"sort"
 
+	"github.com/github/git-lfs/config"
 	"github.com/github/git-lfs/git"
 	"github.com/github/git-lfs/lfs"
 	"github.com/spf13/cobra"
@@ -13,11 +16,13 @@ var (
 		Use: "status",
 		Run: statusCommand,
 	}
-	porcelain = false
+	porcelain     = false
+	statusSummary = false
 )
 
 func statusCommand(cmd *cobra.Command, args []string) {
 	requireInRepo()
+	warnIfNotInstalled()
 
 	ref, err := git.CurrentRef()
 	if err != nil {
@@ -46,16 +51,27 @@ func statusCommand(cmd *cobra.Command, args []string) {
 	Print("On branch %s", ref.Name)
 
 	remoteRef, err := git.CurrentRemoteRef()
-	if err == nil {
+	if err != nil {
+		remoteRef = nil
+	}
 
-		pointers, err := lfs.ScanRefs(ref.Sha, "^"+remoteRef.Sha, nil)
+	var unpushedPointers []*lfs.WrappedPointer
+	if remoteRef != nil {
+		unpushedPointers, err = lfs.ScanRefs(ref.Sha, "^"+remoteRef.Sha, nil)
 		if err != nil {
 			Panic(err, "Could not scan for Git LFS objects")
 		}
+	}
 
+	if statusSummary {
+		printStatusSummary(remoteRef, unpushedPointers, stagedPointers)
+		return
+	}
+
+	if remoteRef != nil {
 		Print("Git LFS objects to be pushed to %s:\n", remoteRef.Name)
-		for _, p := range pointers {
-			Print("\t%s (%s)", p.Name, humanizeBytes(p.Size))
+		for _, p := range unpushedPointers {
+			Print("\t%s (%s)", p.Name, humanizePointerSize(p.Size))
 		}
 	}
 
@@ -63,10 +79,10 @@ func statusCommand(cmd *cobra.Command, args []string) {
 	for _, p := range stagedPointers {
 		switch p.Status {
 		case "R", "C":
-			Print("\t%s -> %s (%s)", p.SrcName, p.Name, humanizeBytes(p.Size))
+			Print("\t%s -> %s (%s)", p.SrcName, p.Name, humanizePointerSize(p.Size))
 		case "M":
 		default:
-			Print("\t%s (%s)", p.Name, humanizeBytes(p.Size))
+			Print("\t%s (%s)", p.Name, humanizePointerSize(p.Size))
 		}
 	}
 
@@ -77,9 +93,131 @@ func statusCommand(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	printPathCollisions()
+	printPointerText()
+
 	Print("")
 }
 
+// printPointerText lists the Git LFS files in the index whose working copy is
+// the pointer text for an object which is in local storage, as Git must have
+// checked them out without running the smudge filter, such as when the filters
+// weren't configured at the time. Pointers for missing objects, which the
+// smudge filter leaves when downloads fail, aren't listed.
+func printPointerText() {
+	if config.Config.SetupCheck() == "off" {
+		return
+	}
+
+	pointers, err := lfs.ScanIndexTree()
+	if err != nil {
+		Panic(err, "Could not scan the index for Git LFS files")
+	}
+
+	var names []string
+	for _, p := range pointers {
+		wp, err := lfs.DecodePointerFromFile(filepath.Join(config.LocalWorkingDir, p.Name))
+		if err != nil || wp.Oid != p.Oid || !lfs.ObjectExistsOfSize(p.Oid, p.Size) {
+			continue
+		}
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+
+	if len(names) > 0 {
+		Print("\nGit LFS files checked out as pointer text (run `git lfs checkout` to replace them):\n")
+		for _, name := range names {
+			Print("\t%s", name)
+		}
+	}
+}
+
+// printPathCollisions lists the Git LFS files in the index whose paths name
+// the same file as another's on this filesystem, if it ignores case or Unicode
+// normalization.
+func printPathCollisions() {
+	collisions := lfs.NewPathCollisionsFromConfig()
+	if collisions == nil {
+		return
+	}
+
+	pointers, err := lfs.ScanIndexTree()
+	if err != nil {
+		Panic(err, "Could not scan the index for Git LFS files")
+	}
+
+	names := make([]string, 0, len(pointers))
+	for _, p := range pointers {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+
+	var found []string
+	for _, name := range names {
+		if other, ok := collisions.Add(name); ok {
+			found = append(found, fmt.Sprintf("\t%s and %s", other, name))
+		}
+	}
+
+	if len(found) > 0 {
+		Print("\nGit LFS files whose paths collide on this filesystem:\n")
+		for _, line := range found {
+			Print("%s", line)
+		}
+	}
+}
+
+// printStatusSummary prints counts and sizes, rather than paths, of the
+// pointers to be pushed, committed and staged, and of the objects for them that
+// aren't in the local store.
+func printStatusSummary(remoteRef *git.Ref, unpushed, staged []*lfs.WrappedPointer) {
+	var committed []*lfs.WrappedPointer
+	modified := 0
+	for _, p := range staged {
+		if p.Status == "M" {
+			modified++
+		} else {
+			committed = append(committed, p)
+		}
+	}
+
+	missing := 0
+	var missingSize int64
+	seen := lfs.NewStringSet()
+	for _, pointers := range [][]*lfs.WrappedPointer{unpushed, staged} {
+		for _, p := range pointers {
+			if seen.Add(p.Oid) && !lfs.ObjectExistsOfSize(p.Oid, p.Size) {
+				missing++
+				missingSize += lfs.KnownSize(p.Size)
+			}
+		}
+	}
+
+	if remoteRef != nil {
+		Print("Git LFS objects to be pushed to %s: %d (%s)", remoteRef.Name, len(unpushed), humanizeBytes(totalPointerSize(unpushed)))
+	}
+	Print("Git LFS objects to be committed: %d (%s)", len(committed), humanizeBytes(totalPointerSize(committed)))
+	Print("Git LFS objects not staged for commit: %d", modified)
+	Print("Git LFS objects missing locally: %d (%s)", missing, humanizeBytes(missingSize))
+}
+
+func totalPointerSize(pointers []*lfs.WrappedPointer) int64 {
+	var size int64
+	for _, p := range pointers {
+		size += lfs.KnownSize(p.Size)
+	}
+	return size
+}
+
+// humanizePointerSize is humanizeBytes for the size of a pointer's object,
+// which may not be known.
+func humanizePointerSize(size int64) string {
+	if size == lfs.UnknownSize {
+		return "unknown size"
+	}
+	return humanizeBytes(size)
+}
+
 var byteUnits = []string{"B", "KB", "MB", "GB", "TB"}
 
 func humanizeBytes(bytes int64) string {
@@ -102,5 +240,6 @@ func humanizeBytes(bytes int64) string {
 
 func init() {
 	statusCmd.Flags().BoolVarP(&porcelain, "porcelain", "p", false, "Give the output in an easy-to-parse format for scripts.")
+	statusCmd.Flags().BoolVarP(&statusSummary, "summary", "", false, "Only show the number and size of files.")
 	RootCmd.AddCommand(statusCmd)
 }
@@ -0,0 +1,296 @@
+package commands
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/github/git-lfs/api"
+	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/errutil"
+	"github.com/github/git-lfs/tools"
+	"github.com/github/git-lfs/transfer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	testServerCmd = &cobra.Command{
+		Use: "test-server",
+		Run: testServerCommand,
+	}
+
+	// testServerObjectSize is the size of the synthetic object that
+	// `git lfs test-server` uploads and downloads.
+	testServerObjectSize = 1024
+)
+
+func testServerCommand(cmd *cobra.Command, args []string) {
+	if len(args) > 1 {
+		Print("Usage: git lfs test-server [<endpoint>]")
+		return
+	}
+
+	if len(args) > 0 {
+		config.Config.SetManualEndpoint(config.NewEndpoint(args[0]))
+	}
+
+	endpoint := config.Config.Endpoint("upload")
+	if len(endpoint.Url) == 0 {
+		Exit("No Git LFS endpoint to test: give one, or run this in a repository with a remote.")
+	}
+
+	s, err := newServerSuite()
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	Print("Testing %s", endpoint.Url)
+	s.check("batch upload", "", s.batchUpload)
+	s.check("upload", "batch upload", s.upload)
+	s.check("verify", "upload", s.verify)
+	s.check("batch download", "upload", s.batchDownload)
+	s.check("download", "batch download", s.download)
+	s.check("checksum", "download", s.checksum)
+	s.check("lock", "", s.lock)
+	s.check("unlock", "lock", s.unlock)
+
+	if s.passed["upload"] {
+		Print("Left test object %s (%d bytes) on the server: the Git LFS API can't delete objects.", s.oid, s.size)
+	}
+
+	s.cleanup()
+
+	failed := s.counts["FAILED"]
+	if failed > 0 {
+		Exit("%d passed, %d failed, %d skipped", s.counts["ok"], failed, s.counts["skipped"])
+	}
+	Print("%d passed, %d failed, %d skipped", s.counts["ok"], failed, s.counts["skipped"])
+}
+
+// serverSuite holds the state of a run of `git lfs test-server`, whose checks
+// upload a synthetic object, download it again and lock a test path.
+type serverSuite struct {
+	dir  string
+	oid  string
+	size int64
+
+	uploadObj   *api.ObjectResource
+	downloadObj *api.ObjectResource
+	uploadVia   string
+	downloadVia string
+	lockId      string
+
+	passed map[string]bool
+	counts map[string]int
+}
+
+func newServerSuite() (*serverSuite, error) {
+	dir, err := ioutil.TempDir("", "git-lfs-test-server")
+	if err != nil {
+		return nil, err
+	}
+
+	content := make([]byte, testServerObjectSize)
+	if _, err := rand.Read(content); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	s := &serverSuite{
+		dir:    dir,
+		size:   int64(len(content)),
+		passed: make(map[string]bool),
+		counts: make(map[string]int),
+	}
+
+	hasher := tools.NewLfsContentHash()
+	hasher.Write(content)
+	s.oid = fmt.Sprintf("%x", hasher.Sum(nil))
+
+	if err := ioutil.WriteFile(s.contentPath(), content, 0644); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// check runs fn, which returns a reason if the check was skipped, and prints
+// the result. The check is skipped if the check named by after didn't pass.
+func (s *serverSuite) check(name, after string, fn func() (string, error)) {
+	result := "ok"
+	detail := ""
+	if len(after) > 0 && !s.passed[after] {
+		result = "skipped"
+		detail = fmt.Sprintf("%s didn't pass", after)
+	} else if skip, err := fn(); err != nil {
+		result = "FAILED"
+		detail = err.Error()
+	} else if len(skip) > 0 {
+		result = "skipped"
+		detail = skip
+	} else {
+		s.passed[name] = true
+	}
+
+	s.counts[result]++
+	if len(detail) > 0 {
+		Print("%-15s %s: %s", name, result, detail)
+	} else {
+		Print("%-15s %s", name, result)
+	}
+}
+
+func (s *serverSuite) batchUpload() (string, error) {
+	obj, via, err := s.batch("upload", transfer.GetUploadAdapterNames())
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := obj.Rel("upload"); !ok {
+		return "", errors.New("the server sent no upload action for a new object")
+	}
+
+	s.uploadObj = obj
+	s.uploadVia = via
+	return "", nil
+}
+
+func (s *serverSuite) upload() (string, error) {
+	adapter := transfer.NewUploadAdapter(s.uploadVia)
+	return "", runServerTransfer(adapter, transfer.NewTransfer("test-server", s.uploadObj, s.contentPath()))
+}
+
+func (s *serverSuite) verify() (string, error) {
+	if _, ok := s.uploadObj.Rel("verify"); !ok {
+		return "the server sent no verify action", nil
+	}
+
+	return "", api.VerifyUpload(s.uploadObj)
+}
+
+func (s *serverSuite) batchDownload() (string, error) {
+	obj, via, err := s.batch("download", transfer.GetDownloadAdapterNames())
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := obj.Rel("download"); !ok {
+		return "", errors.New("the server sent no download action for the uploaded object")
+	}
+
+	s.downloadObj = obj
+	s.downloadVia = via
+	return "", nil
+}
+
+func (s *serverSuite) download() (string, error) {
+	adapter := transfer.NewDownloadAdapter(s.downloadVia)
+	return "", runServerTransfer(adapter, transfer.NewTransfer("test-server", s.downloadObj, s.downloadPath()))
+}
+
+func (s *serverSuite) checksum() (string, error) {
+	f, err := os.Open(s.downloadPath())
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hasher := tools.NewHashingReader(f)
+	size, err := io.Copy(ioutil.Discard, hasher)
+	if err != nil {
+		return "", err
+	}
+
+	if oid := hasher.Hash(); oid != s.oid || size != s.size {
+		return "", fmt.Errorf("downloaded %d bytes with oid %s, expected %d bytes with oid %s", size, oid, s.size, s.oid)
+	}
+	return "", nil
+}
+
+func (s *serverSuite) lock() (string, error) {
+	path := fmt.Sprintf("git-lfs-test-server/%s.bin", s.oid[:12])
+
+	search, list := API.Locks.Search(&api.LockSearchRequest{
+		Filters: []api.Filter{{Property: "path", Value: path}},
+	})
+	if _, err := API.Do(search); err != nil {
+		return fmt.Sprintf("locking isn't supported: %s", err), nil
+	}
+	if len(list.Err) > 0 {
+		return "", errors.New(list.Err)
+	}
+
+	id, err := lockFile(path, "")
+	if err != nil {
+		return "", err
+	}
+
+	s.lockId = id
+	return "", nil
+}
+
+func (s *serverSuite) unlock() (string, error) {
+	_, err := unlockId(s.lockId)
+	return "", err
+}
+
+// batch makes a batch API request for the synthetic object, and returns the
+// server's response for it and the transfer adapter to use.
+func (s *serverSuite) batch(operation string, adapters []string) (*api.ObjectResource, string, error) {
+	objs, via, err := api.Batch([]*api.ObjectResource{{Oid: s.oid, Size: s.size}}, operation, adapters)
+	if err != nil {
+		if errutil.IsNotImplementedError(err) {
+			return nil, "", errors.New("the server doesn't support the batch API")
+		}
+		return nil, "", err
+	}
+
+	if len(objs) != 1 {
+		return nil, "", fmt.Errorf("expected 1 object in the batch response, got %d", len(objs))
+	}
+
+	if objs[0].Error != nil {
+		return nil, "", objs[0].Error
+	}
+
+	return objs[0], via, nil
+}
+
+func (s *serverSuite) contentPath() string {
+	return filepath.Join(s.dir, s.oid)
+}
+
+func (s *serverSuite) downloadPath() string {
+	return filepath.Join(s.dir, "download")
+}
+
+func (s *serverSuite) cleanup() {
+	os.RemoveAll(s.dir)
+}
+
+// runServerTransfer runs a single transfer with adapter and returns its error.
+func runServerTransfer(adapter transfer.TransferAdapter, t *transfer.Transfer) error {
+	results := make(chan transfer.TransferResult, 1)
+	if err := adapter.Begin(1, nil, results); err != nil {
+		return err
+	}
+
+	adapter.Add(t)
+	adapter.End()
+
+	for res := range results {
+		if res.Error != nil {
+			return res.Error
+		}
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(testServerCmd)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -52,8 +53,23 @@ func trackCommand(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	addTrailingLinebreak := needsTrailingLinebreak(".gitattributes")
-	attributesFile, err := os.OpenFile(".gitattributes", os.O_RDWR|os.O_APPEND|os.O_CREATE, 0660)
+	wd, _ := os.Getwd()
+	relpath, err := filepath.Rel(config.LocalWorkingDir, wd)
+	if err != nil {
+		Exit("Current directory %q outside of git working directory %q.", wd, config.LocalWorkingDir)
+	}
+
+	attributesPath := ".gitattributes"
+	atRoot := config.Config.TrackLocation() == "root"
+	if atRoot {
+		attributesPath = filepath.Join(config.LocalWorkingDir, ".gitattributes")
+	}
+
+	_, statErr := os.Stat(attributesPath)
+	created := os.IsNotExist(statErr)
+
+	addTrailingLinebreak := needsTrailingLinebreak(attributesPath)
+	attributesFile, err := os.OpenFile(attributesPath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0660)
 	if err != nil {
 		Print("Error opening .gitattributes file")
 		return
@@ -66,16 +82,21 @@ func trackCommand(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	wd, _ := os.Getwd()
-	relpath, err := filepath.Rel(config.LocalWorkingDir, wd)
-	if err != nil {
-		Exit("Current directory %q outside of git working directory %q.", wd, config.LocalWorkingDir)
+	if created && !trackDryRunFlag && config.Config.TrackTextAuto() {
+		if _, err := attributesFile.WriteString("* text=auto\n"); err != nil {
+			Print("Error writing to .gitattributes")
+		}
 	}
 
 ArgsLoop:
 	for _, pattern := range args {
+		written := pattern
+		if atRoot {
+			written = rootPattern(relpath, pattern)
+		}
+
 		for _, known := range knownPaths {
-			if known.Path == filepath.Join(relpath, pattern) {
+			if known.Path == filepath.Join(relpath, pattern) || known.Path == written {
 				Print("%s already supported", pattern)
 				continue ArgsLoop
 			}
@@ -116,7 +137,7 @@ ArgsLoop:
 		}
 
 		if !trackDryRunFlag {
-			encodedArg := strings.Replace(pattern, " ", "[[:space:]]", -1)
+			encodedArg := strings.Replace(written, " ", "[[:space:]]", -1)
 			_, err := attributesFile.WriteString(fmt.Sprintf("%s filter=lfs diff=lfs merge=lfs -text\n", encodedArg))
 			if err != nil {
 				Print("Error adding path %s", pattern)
@@ -126,6 +147,11 @@ ArgsLoop:
 		Print("Tracking %s", pattern)
 
 		for _, f := range gittracked {
+			if kind := lfs.NonFileKind(f); kind != "" {
+				Print("Git LFS: skipping %s: it is a %s, not a file", f, kind)
+				continue
+			}
+
 			if trackVerboseLoggingFlag || trackDryRunFlag {
 				Print("Git LFS: touching %s", f)
 			}
@@ -141,6 +167,21 @@ ArgsLoop:
 	}
 }
 
+// rootPattern returns the equivalent of pattern, given in the dir directory, for
+// the .gitattributes file at the root of the working directory. Patterns
+// without a slash match at any depth below the directory they're given in.
+func rootPattern(dir, pattern string) string {
+	if dir == "." {
+		return pattern
+	}
+
+	dir = filepath.ToSlash(dir)
+	if !strings.Contains(pattern, "/") {
+		return path.Join(dir, "**", pattern)
+	}
+	return path.Join(dir, strings.TrimPrefix(pattern, "/"))
+}
+
 type mediaPath struct {
 	Path   string
 	Source string
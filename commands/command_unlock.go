@@ -2,9 +2,14 @@ package commands
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/github/git-lfs/api"
 	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/git"
 	"github.com/spf13/cobra"
 )
 
@@ -31,40 +36,213 @@ type unlockFlags struct {
 	// with "--force", signifying the user's intent to break another
 	// individual's lock(s).
 	Force bool
+	// AllMine specifies whether or not the `lfs unlock` command was invoked
+	// with "--all-mine", to unlock every lock held by the current
+	// committer, optionally only those within the given paths.
+	AllMine bool
 }
 
 func unlockCommand(cmd *cobra.Command, args []string) {
 	setLockRemoteFor(config.Config)
 
-	var id string
-	if len(args) != 0 {
-		path, err := lockPath(args[0])
+	if unlockCmdFlags.AllMine {
+		unlockAllMine(args)
+	} else if len(args) != 0 {
+		unlockPaths(args)
+	} else if unlockCmdFlags.Id != "" {
+		id, err := unlockId(unlockCmdFlags.Id)
 		if err != nil {
 			Error(err.Error())
+			Exit("Server unable to unlock lock.")
+		}
+		Print("'%s' was unlocked", id)
+	} else {
+		Error("Usage: git lfs unlock (--id my-lock-id | <path>... | --all-mine [<path>...])")
+	}
+}
+
+// unlockPaths unlocks the locks on each of files, reporting the ones that
+// couldn't be unlocked without stopping the rest.
+func unlockPaths(files []string) {
+	results := eachLockPath(files, func(file string) (string, error) {
+		path, err := lockPath(file)
+		if err != nil {
+			return "", err
+		}
+
+		id, err := lockIdFromPath(path)
+		if err != nil {
+			return "", err
 		}
 
-		if id, err = lockIdFromPath(path); err != nil {
+		return unlockId(id)
+	})
+
+	reportUnlocks(results)
+}
+
+// unlockAllMine unlocks every lock held by the current committer, or if any
+// paths are given, only those on files within them. Like unlockPaths, a lock
+// that can't be unlocked doesn't stop the rest.
+func unlockAllMine(paths []string) {
+	scopes := make([]string, 0, len(paths))
+	for _, path := range paths {
+		scope, err := lockScope(path)
+		if err != nil {
 			Error(err.Error())
+			Exit("Unable to resolve path %q.", path)
 		}
-	} else if unlockCmdFlags.Id != "" {
-		id = unlockCmdFlags.Id
-	} else {
-		Error("Usage: git lfs unlock (--id my-lock-id | <path>)")
+		scopes = append(scopes, scope)
+	}
+
+	locks, err := searchAllLocks()
+	if err != nil {
+		Error(err.Error())
+		Exit("Error communicating with LFS API.")
+	}
+
+	ids := make(map[string]string)
+	var files []string
+	for _, lock := range ownLocks(locks, api.CurrentCommitter(), scopes) {
+		ids[lock.Path] = lock.Id
+		files = append(files, lock.Path)
+	}
+
+	if len(files) == 0 {
+		Print("No locks to unlock.")
+		return
+	}
+
+	results := eachLockPath(files, func(file string) (string, error) {
+		return unlockId(ids[file])
+	})
+
+	reportUnlocks(results)
+}
+
+// reportUnlocks prints the outcome of unlocking each path, exiting with an
+// error if any of them couldn't be unlocked.
+func reportUnlocks(results []lockResult) {
+	failed := 0
+	for _, r := range results {
+		if r.err != nil {
+			failed++
+			Error("'%s' could not be unlocked: %s", r.file, r.err)
+			continue
+		}
+		Print("'%s' was unlocked (%s)", r.file, r.id)
+	}
+
+	if failed > 0 {
+		Exit("Server unable to unlock %d of %d lock(s).", failed, len(results))
+	}
+}
+
+// searchAllLocks returns every lock on the remote, following the server's
+// cursor until there are no more.
+func searchAllLocks() ([]api.Lock, error) {
+	var locks []api.Lock
+
+	query := &api.LockSearchRequest{}
+	for {
+		s, resp := API.Locks.Search(query)
+		if _, err := API.Do(s); err != nil {
+			return nil, err
+		}
+
+		if len(resp.Err) > 0 {
+			return nil, errors.New(resp.Err)
+		}
+
+		locks = append(locks, resp.Locks...)
+
+		if resp.NextCursor == "" {
+			return locks, nil
+		}
+		query.Cursor = resp.NextCursor
+	}
+}
+
+// ownLocks returns the active locks held by committer, matched by email, or
+// by name if either has no email. If any scopes are given, only the locks on
+// paths equal to or within one of them are returned.
+func ownLocks(locks []api.Lock, committer api.Committer, scopes []string) []api.Lock {
+	var mine []api.Lock
+	for _, lock := range locks {
+		if !lock.Active() || !sameCommitter(lock.Committer, committer) {
+			continue
+		}
+
+		if len(scopes) > 0 && !lockInScopes(lock.Path, scopes) {
+			continue
+		}
+
+		mine = append(mine, lock)
+	}
+
+	return mine
+}
+
+func sameCommitter(a, b api.Committer) bool {
+	if len(a.Email) > 0 && len(b.Email) > 0 {
+		return strings.EqualFold(a.Email, b.Email)
+	}
+	return len(a.Name) > 0 && a.Name == b.Name
+}
+
+func lockInScopes(path string, scopes []string) bool {
+	for _, scope := range scopes {
+		if scope == "" || path == scope || strings.HasPrefix(path, scope+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// lockScope resolves path like lockPath, relative to the root of the
+// repository, but allows directories and paths which no longer exist, such as
+// files deleted by the branch being cleaned up. The root of the repository
+// resolves to "".
+func lockScope(path string) (string, error) {
+	repo, err := git.RootDir()
+	if err != nil {
+		return "", err
 	}
 
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(repo, filepath.Join(wd, path))
+	if err != nil {
+		return "", err
+	}
+
+	if rel == "." {
+		return "", nil
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("lfs: %s is outside repository", path)
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// unlockId unlocks the lock with the given id, returning the id of the lock
+// which was removed.
+func unlockId(id string) (string, error) {
 	s, resp := API.Locks.Unlock(id, unlockCmdFlags.Force)
 
 	if _, err := API.Do(s); err != nil {
-		Error(err.Error())
-		Exit("Error communicating with LFS API.")
+		return "", fmt.Errorf("Error communicating with LFS API: %s", err)
 	}
 
 	if len(resp.Err) > 0 {
-		Error(resp.Err)
-		Exit("Server unable to unlock lock.")
+		return "", errors.New(resp.Err)
 	}
 
-	Print("'%s' was unlocked (%s)", args[0], resp.Lock.Id)
+	return resp.Lock.Id, nil
 }
 
 // lockIdFromPath makes a call to the LFS API and resolves the ID for the locked
@@ -102,6 +280,7 @@ func init() {
 
 	unlockCmd.Flags().StringVarP(&unlockCmdFlags.Id, "id", "i", "", "unlock a lock by its ID")
 	unlockCmd.Flags().BoolVarP(&unlockCmdFlags.Force, "force", "f", false, "forcibly break another user's lock(s)")
+	unlockCmd.Flags().BoolVarP(&unlockCmdFlags.AllMine, "all-mine", "", false, "unlock all of your locks, optionally within the given paths")
 
 	RootCmd.AddCommand(unlockCmd)
 }
@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/github/git-lfs/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnLocks(t *testing.T) {
+	me := api.Committer{Name: "Me", Email: "me@example.com"}
+	other := api.Committer{Name: "Other", Email: "other@example.com"}
+
+	locks := []api.Lock{
+		{Id: "1", Path: "a.dat", Committer: me},
+		{Id: "2", Path: "art/b.dat", Committer: api.Committer{Name: "Me (laptop)", Email: "ME@example.com"}},
+		{Id: "3", Path: "art/c.dat", Committer: other},
+		{Id: "4", Path: "art/sub/d.dat", Committer: me},
+		{Id: "5", Path: "artwork/e.dat", Committer: me},
+		{Id: "6", Path: "f.dat", Committer: me, UnlockedAt: time.Now()},
+		{Id: "7", Path: "g.dat", Committer: api.Committer{Name: "Me"}},
+	}
+
+	ids := func(locks []api.Lock) []string {
+		var ids []string
+		for _, l := range locks {
+			ids = append(ids, l.Id)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"1", "2", "4", "5", "7"}, ids(ownLocks(locks, me, nil)))
+	assert.Equal(t, []string{"2", "4"}, ids(ownLocks(locks, me, []string{"art"})))
+	assert.Equal(t, []string{"1", "4"}, ids(ownLocks(locks, me, []string{"a.dat", "art/sub"})))
+	assert.Equal(t, []string{"1", "2", "4", "5", "7"}, ids(ownLocks(locks, me, []string{""})))
+	assert.Equal(t, []string{"3"}, ids(ownLocks(locks, other, nil)))
+}
@@ -15,8 +15,10 @@ var (
 		Run: updateCommand,
 	}
 
-	updateForce  = false
-	updateManual = false
+	updateForce     = false
+	updateManual    = false
+	updatePreCommit = false
+	updateRepair    = false
 )
 
 // updateCommand is used for updating parts of Git LFS that reside under
@@ -46,8 +48,20 @@ func updateCommand(cmd *cobra.Command, args []string) {
 		Exit("You cannot use --force and --manual options together")
 	}
 
+	if updateRepair && updateManual {
+		Exit("You cannot use --repair and --manual options together")
+	}
+
+	if updateRepair {
+		repairSetup()
+		return
+	}
+
 	if updateManual {
 		Print(lfs.GetHookInstallSteps())
+		if updatePreCommit {
+			Print("%s", lfs.GetPreCommitHookInstallSteps())
+		}
 	} else {
 		if err := lfs.InstallHooks(updateForce); err != nil {
 			Error(err.Error())
@@ -55,12 +69,47 @@ func updateCommand(cmd *cobra.Command, args []string) {
 		} else {
 			Print("Updated pre-push hook.")
 		}
+
+		if updatePreCommit {
+			if err := lfs.InstallPreCommitHook(updateForce); err != nil {
+				Error("%s", err)
+				Exit("To resolve this, either:\n  1: run `git lfs update --manual --pre-commit` for instructions on how to merge hooks.\n  2: run `git lfs update --force --pre-commit` to overwrite your hook.")
+			}
+			Print("Updated pre-commit hook.")
+		}
 	}
 
 }
 
+// repairSetup fixes the filter config and hooks Git LFS needs, printing each
+// fix. Hooks it doesn't know how to fix are reported, and left alone unless
+// --force is given.
+func repairSetup() {
+	changes := lfs.RepairFilters()
+
+	hookChanges, problems := lfs.RepairHooks(updateForce)
+	changes = append(changes, hookChanges...)
+
+	for _, change := range changes {
+		Print("%s", change)
+	}
+
+	if len(problems) > 0 {
+		for _, err := range problems {
+			Error("%s", err)
+		}
+		Exit("To resolve this, either:\n  1: run `git lfs update --manual` for instructions on how to merge hooks.\n  2: run `git lfs update --repair --force` to overwrite your hooks.")
+	}
+
+	if len(changes) == 0 {
+		Print("Git LFS is set up correctly: nothing to repair.")
+	}
+}
+
 func init() {
 	updateCmd.Flags().BoolVarP(&updateForce, "force", "f", false, "Overwrite existing hooks.")
 	updateCmd.Flags().BoolVarP(&updateManual, "manual", "m", false, "Print instructions for manual install.")
+	updateCmd.Flags().BoolVarP(&updatePreCommit, "pre-commit", "", false, "Also install the pre-commit hook, which warns about large untracked files.")
+	updateCmd.Flags().BoolVarP(&updateRepair, "repair", "", false, "Fix missing or incorrect filter config and hooks.")
 	RootCmd.AddCommand(updateCmd)
 }
@@ -107,6 +107,60 @@ func Run() {
 	RootCmd.Execute()
 }
 
+// setProgressStyle applies a --progress flag given to a transfer command,
+// exiting if it isn't a known style.
+func setProgressStyle(style string) {
+	if len(style) == 0 {
+		return
+	}
+
+	if !config.IsProgressStyle(style) {
+		Exit("Invalid --progress style %q: use bar, plain or none.", style)
+	}
+	config.Config.SetProgressStyle(style)
+}
+
+// setMaxBandwidth applies a --max-bandwidth flag given to a transfer command,
+// and exits if it, or lfs.transfer.maxbandwidth, isn't a valid rate.
+func setMaxBandwidth(value string) {
+	if len(value) > 0 {
+		config.Config.SetTransferMaxBandwidth(value)
+	}
+
+	if _, err := config.Config.TransferMaxBandwidth(); err != nil {
+		Exit("%s", err)
+	}
+}
+
+// priorityPaths are the paths given to a transfer command's --priority flag,
+// whose objects are transferred first.
+var priorityPaths []string
+
+// setPriorityPaths applies a --priority flag given to a transfer command.
+func setPriorityPaths(value string) {
+	priorityPaths = tools.CleanPaths(value, ",")
+}
+
+// prioritizePointers marks the objects of the pointers matching priorityPaths
+// as high priority in q, and returns pointers with those ones moved first.
+func prioritizePointers(q *lfs.TransferQueue, pointers []*lfs.WrappedPointer) []*lfs.WrappedPointer {
+	if len(priorityPaths) == 0 {
+		return pointers
+	}
+
+	sorted := make([]*lfs.WrappedPointer, 0, len(pointers))
+	var rest []*lfs.WrappedPointer
+	for _, p := range pointers {
+		if lfs.FilenamePassesIncludeExcludeFilter(p.Name, priorityPaths, nil) {
+			q.Prioritize(p.Oid)
+			sorted = append(sorted, p)
+		} else {
+			rest = append(rest, p)
+		}
+	}
+	return append(sorted, rest...)
+}
+
 func PipeMediaCommand(name string, args ...string) error {
 	return PipeCommand("bin/"+name, args...)
 }
@@ -142,6 +196,17 @@ func requireInRepo() {
 	}
 }
 
+// warnIfNotInstalled warns that Git checks out files tracked by Git LFS as
+// pointer text if the lfs filters aren't configured, unless lfs.setupcheck is
+// "off".
+func warnIfNotInstalled() {
+	if config.Config.SetupCheck() == "off" || lfs.FiltersInstalled() {
+		return
+	}
+
+	Error("Git LFS isn't installed for this repository, so Git checks out files tracked by Git LFS as pointer text. Run `git lfs install` to set it up.")
+}
+
 func handlePanic(err error) string {
 	if err == nil {
 		return ""
@@ -1,24 +1,60 @@
 package commands
 
 import (
+	"io"
+	"io/ioutil"
 	"os"
 
+	"github.com/github/git-lfs/config"
 	"github.com/github/git-lfs/errutil"
 	"github.com/github/git-lfs/lfs"
+	"github.com/github/git-lfs/progress"
+	"github.com/rubyist/tracerx"
 )
 
 var uploadMissingErr = "%s does not exist in .git/lfs/objects. Tried %s, which matches %s."
 
 type uploadContext struct {
-	DryRun       bool
+	DryRun bool
+	// AskServer makes dry runs ask the server which objects it needs, and
+	// list only those, with their sizes
+	AskServer    bool
 	uploadedOids lfs.StringSet
+
+	// dryRunCount and dryRunSize total the objects that a dry run which
+	// asks the server would upload
+	dryRunCount int
+	dryRunSize  int64
+
+	// pushed holds the pointers that were handed to an upload queue, so they
+	// can be checked with verifyPushed()
+	pushed []*lfs.WrappedPointer
+
+	// state records the objects uploaded to the current remote, so that
+	// an interrupted push can be resumed without uploading them again
+	state *lfs.TransferState
 }
 
 func newUploadContext(dryRun bool) *uploadContext {
-	return &uploadContext{
+	c := &uploadContext{
 		DryRun:       dryRun,
 		uploadedOids: lfs.NewStringSet(),
 	}
+
+	if !dryRun {
+		remote := config.Config.CurrentRemote
+		c.state = lfs.LoadTransferState(remote, config.Config.Endpoint("upload").Url, config.Config.TransferStateMaxAge())
+	}
+
+	return c
+}
+
+// Finish is called once everything has been pushed, after which there's no
+// push to resume.
+func (c *uploadContext) Finish() {
+	if err := c.state.Clear(); err != nil {
+		tracerx.Printf("transfer state: %s", err)
+	}
 }
 
 // AddUpload adds the given oid to the set of oids that have been uploaded in
@@ -49,8 +85,15 @@ func (c *uploadContext) prepareUpload(unfiltered []*lfs.WrappedPointer) (*lfs.Tr
 			continue
 		}
 
+		// or by an earlier push which was interrupted
+		if c.state.Completed(p.Oid) {
+			tracerx.Printf("transfer state: skipping %s, pushed already", p.Oid)
+			c.SetUploaded(p.Oid)
+			continue
+		}
+
 		numObjects += 1
-		totalSize += p.Size
+		totalSize += lfs.KnownSize(p.Size)
 
 		if lfs.ObjectExistsOfSize(p.Oid, p.Size) {
 			uploadables = append(uploadables, p)
@@ -58,7 +101,7 @@ func (c *uploadContext) prepareUpload(unfiltered []*lfs.WrappedPointer) (*lfs.Tr
 			// We think we need to push this but we don't have it
 			// Store for server checking later
 			missingLocalObjects = append(missingLocalObjects, p)
-			missingSize += p.Size
+			missingSize += lfs.KnownSize(p.Size)
 		}
 	}
 
@@ -70,7 +113,7 @@ func (c *uploadContext) prepareUpload(unfiltered []*lfs.WrappedPointer) (*lfs.Tr
 	uploadQueue := lfs.NewUploadQueue(numObjects, totalSize, c.DryRun)
 	for _, p := range missingLocalObjects {
 		if c.HasUploaded(p.Oid) {
-			uploadQueue.Skip(p.Size)
+			uploadQueue.Skip(lfs.KnownSize(p.Size))
 		} else {
 			uploadables = append(uploadables, p)
 		}
@@ -111,6 +154,11 @@ func (c *uploadContext) checkMissing(missing []*lfs.WrappedPointer, missingSize
 }
 
 func upload(c *uploadContext, unfiltered []*lfs.WrappedPointer) {
+	if c.DryRun && c.AskServer {
+		c.dryRunUpload(unfiltered)
+		return
+	}
+
 	if c.DryRun {
 		for _, p := range unfiltered {
 			if c.HasUploaded(p.Oid) {
@@ -125,6 +173,18 @@ func upload(c *uploadContext, unfiltered []*lfs.WrappedPointer) {
 	}
 
 	q, pointers := c.prepareUpload(unfiltered)
+
+	completedc := q.Watch()
+	done := make(chan int)
+	go func() {
+		for oid := range completedc {
+			if err := c.state.Add(oid); err != nil {
+				tracerx.Printf("transfer state: unable to record %s: %s", oid, err)
+			}
+		}
+		done <- 1
+	}()
+
 	for _, p := range pointers {
 		u, err := lfs.NewUploadable(p.Oid, p.Name)
 		if err != nil {
@@ -137,10 +197,73 @@ func upload(c *uploadContext, unfiltered []*lfs.WrappedPointer) {
 
 		q.Add(u)
 		c.SetUploaded(p.Oid)
+		c.pushed = append(c.pushed, p)
+	}
+
+	q.Wait()
+	<-done
+	exitOnUploadErrors(q)
+}
+
+// dryRunUpload sends the batch API upload request for the objects, and prints
+// those which the server doesn't have. The transfer queue is a dry run, so no
+// transfer adapter is started and no content is sent.
+func (c *uploadContext) dryRunUpload(unfiltered []*lfs.WrappedPointer) {
+	pointers := make([]*lfs.WrappedPointer, 0, len(unfiltered))
+	var totalSize int64
+	for _, p := range unfiltered {
+		if c.HasUploaded(p.Oid) {
+			continue
+		}
+
+		pointers = append(pointers, p)
+		totalSize += lfs.KnownSize(p.Size)
+		c.SetUploaded(p.Oid)
+	}
+
+	if len(pointers) == 0 {
+		return
+	}
+
+	q := lfs.NewUploadQueue(len(pointers), totalSize, true)
+	neededc := q.Watch()
+	for _, p := range pointers {
+		q.Add(lfs.NewDryRunUploadable(p))
 	}
 
+	needed := lfs.NewStringSet()
+	done := make(chan int)
+	go func() {
+		for oid := range neededc {
+			needed.Add(oid)
+		}
+		done <- 1
+	}()
+
 	q.Wait()
+	<-done
+	exitOnUploadErrors(q)
 
+	for _, p := range pointers {
+		if needed.Contains(p.Oid) {
+			Print("push %s => %s (%s)", p.Oid, p.Name, humanizePointerSize(p.Size))
+			c.dryRunCount++
+			c.dryRunSize += lfs.KnownSize(p.Size)
+		}
+	}
+}
+
+// printDryRunTotal prints the number and size of the objects that a dry run
+// which asks the server would upload.
+func (c *uploadContext) printDryRunTotal() {
+	if c.DryRun && c.AskServer {
+		Print("%d objects would be pushed (%s)", c.dryRunCount, humanizeBytes(c.dryRunSize))
+	}
+}
+
+// exitOnUploadErrors reports the errors of the upload queue, and exits if
+// there were any.
+func exitOnUploadErrors(q *lfs.TransferQueue) {
 	for _, err := range q.Errors() {
 		if Debugging || errutil.IsFatalError(err) {
 			LoggedError(err, err.Error())
@@ -156,3 +279,58 @@ func upload(c *uploadContext, unfiltered []*lfs.WrappedPointer) {
 		os.Exit(2)
 	}
 }
+
+// verifyPushed asks the server for download actions for every object pushed
+// with this context, and exits if any of them can't be retrieved.
+func (c *uploadContext) verifyPushed() {
+	if c.DryRun || len(c.pushed) == 0 {
+		return
+	}
+
+	var totalSize int64
+	for _, p := range c.pushed {
+		totalSize += lfs.KnownSize(p.Size)
+	}
+
+	checkQueue := lfs.NewDownloadCheckQueue(len(c.pushed), totalSize)
+	availc := checkQueue.Watch()
+
+	for _, p := range c.pushed {
+		checkQueue.Add(lfs.NewDownloadable(p))
+	}
+
+	var meterOut io.Writer = os.Stdout
+	if config.Config.ProgressStyle() == "none" {
+		meterOut = ioutil.Discard
+	}
+	meter := progress.NewVerifyMeter(len(c.pushed), meterOut)
+	meter.Start()
+
+	available := lfs.NewStringSet()
+	done := make(chan int)
+	go func() {
+		for oid := range availc {
+			available.Add(oid)
+			meter.Verify()
+		}
+		done <- 1
+	}()
+
+	checkQueue.Wait()
+	<-done
+	meter.Finish()
+
+	missing := 0
+	for _, p := range c.pushed {
+		if !available.Contains(p.Oid) {
+			Error("Object %s (%s) is not available from the server", p.Oid, p.Name)
+			missing++
+		}
+	}
+
+	if missing > 0 {
+		Exit("Verification failed: %d of %d pushed objects are not available", missing, len(c.pushed))
+	}
+
+	Print("Verified %d pushed objects", len(c.pushed))
+}
@@ -5,11 +5,13 @@ package config
 import (
 	"bytes"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ThomsonReutersEikon/go-ntlm/ntlm"
 	"github.com/bgentry/go-netrc/netrc"
@@ -44,6 +46,9 @@ type FetchPruneConfig struct {
 	PruneVerifyRemoteAlways bool
 	// Name of remote to check for unpushed and verify checks
 	PruneRemoteName string
+	// Whether objects missing on the remote are verified on the CDN set by
+	// lfs.cdn.url instead (default false)
+	PruneVerifyCDN bool
 }
 
 type Configuration struct {
@@ -62,9 +67,14 @@ type Configuration struct {
 	extensions        map[string]Extension
 	fetchIncludePaths []string
 	fetchExcludePaths []string
+	pruneKeepPaths    []string
 	fetchPruneConfig  *FetchPruneConfig
 	manualEndpoint    *Endpoint
+	progressStyle     string
+	maxBandwidth      string
 	parsedNetrc       netrcfinder
+	fileLimiter       *tools.FileLimiter
+	fileLimiterOnce   sync.Once
 }
 
 func NewConfig() *Configuration {
@@ -227,6 +237,576 @@ func (c *Configuration) ConcurrentTransfers() int {
 	return uploads
 }
 
+// ConcurrentLocks returns how many lock or unlock requests `git lfs lock` and
+// `git lfs unlock` make at once when given several paths, from
+// lfs.concurrentlocks. Default is 3.
+func (c *Configuration) ConcurrentLocks() int {
+	if c.NtlmAccess("download") {
+		return 1
+	}
+
+	return c.GitConfigInt("lfs.concurrentlocks", 3)
+}
+
+// MaxOpenFiles returns the maximum number of object files that git-lfs will
+// hold open at once while transferring and smudging, from lfs.maxopenfiles.
+// Default is 0, meaning no limit beyond lfs.concurrenttransfers.
+func (c *Configuration) MaxOpenFiles() int {
+	return c.GitConfigInt("lfs.maxopenfiles", 0)
+}
+
+// FileLimiter returns the limiter shared by everything that opens object
+// files, sized by MaxOpenFiles().
+func (c *Configuration) FileLimiter() *tools.FileLimiter {
+	c.fileLimiterOnce.Do(func() {
+		c.fileLimiter = tools.NewFileLimiter(c.MaxOpenFiles())
+	})
+	return c.fileLimiter
+}
+
+// TransferMethodOverride returns whether basic uploads should be sent as POST
+// requests with an "X-HTTP-Method-Override: PUT" header instead of as PUT
+// requests, from lfs.transfer.methodoverride. Default is false.
+func (c *Configuration) TransferMethodOverride() bool {
+	return c.GitConfigBool("lfs.transfer.methodoverride")
+}
+
+// UploadHeaders returns extra headers to send with object uploads, from
+// lfs.transfer.uploadheader.<name> settings, e.g.
+// lfs.transfer.uploadheader.x-amz-server-side-encryption = AES256. Values are
+// returned as set.
+func (c *Configuration) UploadHeaders() map[string]string {
+	const prefix = "lfs.transfer.uploadheader."
+
+	headers := make(map[string]string)
+	for key, value := range c.AllGitConfig() {
+		if strings.HasPrefix(key, prefix) && len(key) > len(prefix) {
+			headers[key[len(prefix):]] = value
+		}
+	}
+	return headers
+}
+
+// TransferBufferSize returns the size in bytes of the buffer used to copy
+// downloaded content to disk, from lfs.transfer.buffersize. Default is 0,
+// meaning the standard 32KB, including if the value is invalid.
+func (c *Configuration) TransferBufferSize() int {
+	return c.GitConfigInt("lfs.transfer.buffersize", 0)
+}
+
+// ConnectRetries returns how many times a failed attempt to connect to a server
+// is retried, from lfs.transfer.connectretries. These retries happen before
+// anything is sent, separately from retrying failed transfers. Default is 0.
+func (c *Configuration) ConnectRetries() int {
+	return c.GitConfigInt("lfs.transfer.connectretries", 0)
+}
+
+// TransferMaxRetries returns how many times a failed HTTP request which is
+// safe to repeat, such as a download, is retried, from lfs.transfer.maxretries.
+// These retries happen within a transfer, before the transfer itself is
+// retried. Default is 0.
+func (c *Configuration) TransferMaxRetries() int {
+	return c.GitConfigInt("lfs.transfer.maxretries", 0)
+}
+
+// TransferJitter returns the jitter strategy applied to the backoff between
+// connect and request retries, from lfs.transfer.jitter: "full", "equal", "decorrelated"
+// or "none". Default is "none", including if the value is invalid.
+func (c *Configuration) TransferJitter() string {
+	value, _ := c.GitConfig("lfs.transfer.jitter")
+	switch jitter := strings.ToLower(strings.TrimSpace(value)); jitter {
+	case "full", "equal", "decorrelated":
+		return jitter
+	default:
+		return "none"
+	}
+}
+
+// WarnSize returns the size in bytes over which `git lfs pre-commit` warns
+// about staged files which aren't tracked by Git LFS, from lfs.warnsize. Like
+// git config integers, the size may have a k, m or g suffix. Default is 0,
+// which turns the warning off.
+func (c *Configuration) WarnSize() int64 {
+	v, _ := c.GitConfig("lfs.warnsize")
+	n, err := tools.ParseByteSize(v)
+	if err != nil || n < 1 {
+		return 0
+	}
+	return n
+}
+
+// SetupCheck returns whether commands check that Git LFS is set up for the
+// repository, from lfs.setupcheck: "warn" warns when the lfs filters aren't
+// configured, and lists files which Git checked out as pointer text without
+// smudging them in `git lfs status`, while "off" doesn't check. Default is "warn",
+// including if the value is invalid.
+func (c *Configuration) SetupCheck() string {
+	value, _ := c.GitConfig("lfs.setupcheck")
+	switch mode := strings.ToLower(strings.TrimSpace(value)); mode {
+	case "off":
+		return mode
+	default:
+		return "warn"
+	}
+}
+
+// WarnSizeStrict returns whether `git lfs pre-commit` fails, instead of only
+// warning, when files over lfs.warnsize aren't tracked, from
+// lfs.warnsizestrict. Default is false.
+func (c *Configuration) WarnSizeStrict() bool {
+	return c.GitConfigBool("lfs.warnsizestrict")
+}
+
+// SizeIndex returns whether the oid and size of pointers are kept in a sidecar
+// index, so that scans don't have to read each pointer from git, from
+// lfs.sizeindex. Default is false.
+func (c *Configuration) SizeIndex() bool {
+	return c.GitConfigBool("lfs.sizeindex")
+}
+
+// TransferRampUpWindow returns how long transfers take to ramp up from
+// lfs.transfer.rampupstart to lfs.concurrenttransfers workers at once, from
+// lfs.transfer.rampupwindow in seconds. Default is 0, which starts every
+// worker at once.
+func (c *Configuration) TransferRampUpWindow() time.Duration {
+	return time.Duration(c.GitConfigInt("lfs.transfer.rampupwindow", 0)) * time.Second
+}
+
+// TransferRampUpStart returns how many transfers may start at once before the
+// rest are ramped up over the lfs.transfer.rampupwindow, from
+// lfs.transfer.rampupstart. Default is 1.
+func (c *Configuration) TransferRampUpStart() int {
+	return c.GitConfigInt("lfs.transfer.rampupstart", 1)
+}
+
+// ActivityTimeout returns how long an HTTP request may wait for the server's
+// response to start, or for more of its body, before it's cancelled, from
+// lfs.activitytimeout in seconds. 0 waits forever, as a server may take a long
+// time to verify a large upload before it responds. Default is 0, including if
+// the value is invalid.
+func (c *Configuration) ActivityTimeout() time.Duration {
+	value, _ := c.GitConfig("lfs.activitytimeout")
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 0 {
+		n = 0
+	}
+	return time.Duration(n) * time.Second
+}
+
+// TransferTimeout returns how long the transfer of each object may take before
+// it's abandoned and retried, from lfs.transfer.timeout in seconds. Default is
+// 0, which lets a transfer take as long as it keeps making progress.
+func (c *Configuration) TransferTimeout() time.Duration {
+	return time.Duration(c.GitConfigInt("lfs.transfer.timeout", 0)) * time.Second
+}
+
+// TransferHostMaxConnections returns how many transfers may be in flight at
+// once to host, which may include a port, from
+// lfs.transfer.<host>.maxconnections. A limit for the host without its port
+// applies to every port. Default is 0, meaning no limit beyond
+// lfs.concurrenttransfers.
+func (c *Configuration) TransferHostMaxConnections(host string) int {
+	host = strings.ToLower(host)
+	if n := c.GitConfigInt(fmt.Sprintf("lfs.transfer.%s.maxconnections", host), 0); n > 0 {
+		return n
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		// IPv6 addresses keep their brackets, as in urls
+		if strings.Contains(h, ":") {
+			h = "[" + h + "]"
+		}
+		return c.GitConfigInt(fmt.Sprintf("lfs.transfer.%s.maxconnections", h), 0)
+	}
+	return 0
+}
+
+// RateLimitThreshold returns how few requests may be left in a server's rate
+// limit, as given by its X-RateLimit-Remaining header, before Git LFS spreads
+// the rest out until the limit resets, from lfs.ratelimit.threshold. Default
+// is 10.
+func (c *Configuration) RateLimitThreshold() int {
+	return c.GitConfigInt("lfs.ratelimit.threshold", 10)
+}
+
+// SetTransferMaxBandwidth sets the limit on the combined throughput of
+// transfers, overriding lfs.transfer.maxbandwidth. It is used for the
+// --max-bandwidth flag.
+func (c *Configuration) SetTransferMaxBandwidth(value string) {
+	c.maxBandwidth = value
+}
+
+// TransferMaxBandwidth returns the most bytes per second that all of the
+// transfers in progress may move between them, from SetTransferMaxBandwidth
+// or lfs.transfer.maxbandwidth. The value is a size like "2m" for 2 MB/s, with
+// an optional "/s". Default is 0, meaning no limit, and an error is returned
+// if the value is invalid.
+func (c *Configuration) TransferMaxBandwidth() (int64, error) {
+	value := c.maxBandwidth
+	if len(value) == 0 {
+		value, _ = c.GitConfig("lfs.transfer.maxbandwidth")
+	}
+
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return 0, nil
+	}
+
+	n, err := tools.ParseByteSize(strings.TrimSuffix(strings.ToLower(value), "/s"))
+	if err != nil {
+		return 0, fmt.Errorf("Invalid transfer bandwidth limit %q: give a rate such as 500k or 2m for 2 MB/s", value)
+	}
+	return n, nil
+}
+
+// SetProgressStyle sets the style of transfer progress output, overriding
+// GIT_LFS_PROGRESS. It is used for the --progress flag.
+func (c *Configuration) SetProgressStyle(style string) {
+	c.progressStyle = style
+}
+
+// ProgressStyle returns how transfer progress is shown: "bar" for a progress
+// bar which is updated in place, "plain" for a line per update, or "none". It
+// comes from SetProgressStyle, or GIT_LFS_PROGRESS if that is one of these
+// styles. Default is "", meaning a bar if stdout is a terminal and plain
+// lines if not.
+func (c *Configuration) ProgressStyle() string {
+	if len(c.progressStyle) > 0 {
+		return c.progressStyle
+	}
+
+	if style := c.Getenv("GIT_LFS_PROGRESS"); IsProgressStyle(style) {
+		return style
+	}
+	return ""
+}
+
+// ProgressNonInteractive returns how transfer progress is shown when stdout
+// isn't a terminal and no style is given, from lfs.progress.noninteractive:
+// "plain" for a line per update, or "none". Default is "plain", including if
+// the value is invalid.
+func (c *Configuration) ProgressNonInteractive() string {
+	value, _ := c.GitConfig("lfs.progress.noninteractive")
+	if strings.ToLower(strings.TrimSpace(value)) == "none" {
+		return "none"
+	}
+	return "plain"
+}
+
+// ProgressInterval returns the shortest time between the lines of plain
+// progress output, from lfs.progress.interval in seconds. Default is 0, for a
+// line each time the progress changes.
+func (c *Configuration) ProgressInterval() time.Duration {
+	seconds := c.GitConfigInt("lfs.progress.interval", 0)
+	if seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// ProgressLogPath returns the file that transfer progress is logged to, from
+// GIT_LFS_PROGRESS, unless that is set to a progress style instead.
+func (c *Configuration) ProgressLogPath() string {
+	logPath := c.Getenv("GIT_LFS_PROGRESS")
+	if IsProgressStyle(logPath) {
+		return ""
+	}
+	return logPath
+}
+
+// ProgressFd returns the file descriptor that transfer progress events are
+// written to as JSON, from GIT_LFS_PROGRESS_FD. Default is -1, meaning no
+// events are written, including if the value isn't a file descriptor.
+func (c *Configuration) ProgressFd() int {
+	fd, err := strconv.Atoi(strings.TrimSpace(c.Getenv("GIT_LFS_PROGRESS_FD")))
+	if err != nil || fd < 0 {
+		return -1
+	}
+	return fd
+}
+
+// HarPath returns where HTTP requests and their responses are recorded in HAR
+// format, from GIT_LFS_HAR: a file, or a directory to write a file for each
+// git-lfs process to. Default is blank, meaning nothing is recorded.
+func (c *Configuration) HarPath() string {
+	return strings.TrimSpace(c.Getenv("GIT_LFS_HAR"))
+}
+
+// IsProgressStyle returns whether style is one of the progress styles returned
+// by ProgressStyle.
+func IsProgressStyle(style string) bool {
+	switch style {
+	case "bar", "plain", "none":
+		return true
+	}
+	return false
+}
+
+// TrackLocation returns where `git lfs track` writes new patterns, from
+// lfs.track.location: "current" for the .gitattributes file in the current
+// directory, or "root" for the one at the root of the working directory.
+// Default is "current", including if the value is invalid.
+func (c *Configuration) TrackLocation() string {
+	value, _ := c.GitConfig("lfs.track.location")
+	if strings.ToLower(strings.TrimSpace(value)) == "root" {
+		return "root"
+	}
+	return "current"
+}
+
+// TrackTextAuto returns whether `git lfs track` starts the .gitattributes files
+// it creates with "* text=auto", from lfs.track.textauto. Default is false.
+func (c *Configuration) TrackTextAuto() bool {
+	return c.GitConfigBool("lfs.track.textauto")
+}
+
+// LockOnClean returns whether the clean filter locks lockable files which
+// aren't already locked by the current committer, from lfs.lockonclean:
+// "off", "warn" (lock, but only warn if the lock can't be created) or
+// "strict" (fail the clean if the lock can't be created). True means "warn".
+// Default is "off", including if the value is invalid.
+func (c *Configuration) LockOnClean() string {
+	value, _ := c.GitConfig("lfs.lockonclean")
+	switch value = strings.ToLower(strings.TrimSpace(value)); value {
+	case "warn", "strict":
+		return value
+	}
+
+	if on, err := parseConfigBool(value); err == nil && on {
+		return "warn"
+	}
+	return "off"
+}
+
+// NonFilePaths returns what Git LFS does with paths matching a tracked pattern
+// which aren't regular files, such as submodules and directories, from
+// lfs.nonfilepaths: "skip" passes them through the clean filter untouched and
+// logs that they were skipped, and "error" makes the clean filter fail.
+// Default is "skip", including if the value is invalid.
+func (c *Configuration) NonFilePaths() string {
+	value, _ := c.GitConfig("lfs.nonfilepaths")
+	if strings.ToLower(strings.TrimSpace(value)) == "error" {
+		return "error"
+	}
+	return "skip"
+}
+
+// CleanMismatch returns what the clean filter does when the object it cleaned
+// is already stored as the start of its content, such as when an earlier clean
+// was interrupted while writing it, from lfs.clean.mismatch: "replace" stores
+// the newly cleaned content in its place, and "error" makes the clean filter
+// fail. A stored object of any other size always makes it fail. Default is
+// "replace", including if the value is invalid.
+func (c *Configuration) CleanMismatch() string {
+	value, _ := c.GitConfig("lfs.clean.mismatch")
+	if strings.ToLower(strings.TrimSpace(value)) == "error" {
+		return "error"
+	}
+	return "replace"
+}
+
+// PointerUnknownSize returns what Git LFS does with pointers whose size line
+// is missing or invalid, from lfs.pointer.unknownsize: "error" treats them as
+// invalid pointers, and "allow" reads them with an unknown size, which is
+// found when the object is downloaded. Default is "error", including if the
+// value is invalid.
+func (c *Configuration) PointerUnknownSize() string {
+	value, _ := c.GitConfig("lfs.pointer.unknownsize")
+	if strings.ToLower(strings.TrimSpace(value)) == "allow" {
+		return "allow"
+	}
+	return "error"
+}
+
+// ReadOnlyMirror returns the directory of a read-only object store to copy
+// objects from before downloading them, from lfs.storage.readonlymirror.
+// Default is "", meaning there is no mirror.
+func (c *Configuration) ReadOnlyMirror() string {
+	dir, _ := c.GitConfig("lfs.storage.readonlymirror")
+	return dir
+}
+
+// StorageReadOnly returns whether the local object store is read-only, such as
+// a pre-populated cache mounted read-only on a build agent, from
+// lfs.storage.readonly: "true" or "false" says so, while "auto" treats it as
+// read-only once writing to it fails with a permission or read-only file system
+// error. Smudging writes objects missing from a read-only store straight to the
+// working copy. Default is "auto", including
+// if the value is invalid.
+func (c *Configuration) StorageReadOnly() string {
+	value, _ := c.GitConfig("lfs.storage.readonly")
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "1", "on", "yes":
+		return "true"
+	case "false", "0", "off", "no":
+		return "false"
+	default:
+		return "auto"
+	}
+}
+
+// CDNUrl returns the base URL of a CDN which serves objects at <url>/<oid>,
+// from lfs.cdn.url. Default is "", meaning there is no CDN.
+func (c *Configuration) CDNUrl() string {
+	url, _ := c.GitConfig("lfs.cdn.url")
+	return strings.TrimRight(strings.TrimSpace(url), "/")
+}
+
+// ExternalCAS returns the command of an external content-addressed store,
+// from lfs.storage.externalcas, which downloaded objects are handed to, and
+// which objects are retrieved from before downloading them. Default is "",
+// meaning there is no external store.
+func (c *Configuration) ExternalCAS() string {
+	command, _ := c.GitConfig("lfs.storage.externalcas")
+	return strings.TrimSpace(command)
+}
+
+// DownloadHook returns the command which checks each downloaded object before
+// it's stored, from lfs.transfer.downloadhook. Default is "", meaning objects
+// are stored without a check.
+func (c *Configuration) DownloadHook() string {
+	command, _ := c.GitConfig("lfs.transfer.downloadhook")
+	return strings.TrimSpace(command)
+}
+
+// CheckoutOverwrite returns whether `git lfs checkout` and `git lfs pull` may
+// overwrite working tree files whose content doesn't match their pointer,
+// from lfs.checkoutoverwrite. Default is false, so local changes are kept.
+func (c *Configuration) CheckoutOverwrite() bool {
+	return c.GitConfigBool("lfs.checkoutoverwrite")
+}
+
+// CheckoutReadAhead returns how many objects `git lfs checkout --stage`
+// downloads ahead of the files it is writing, from lfs.checkoutreadahead.
+// Default is 0, meaning all objects are downloaded before any are written.
+func (c *Configuration) CheckoutReadAhead() int {
+	return c.GitConfigInt("lfs.checkoutreadahead", 0)
+}
+
+// CheckoutDeleted returns what `git lfs checkout` and `git lfs pull` do with
+// files which are in the index but have been deleted from the working copy,
+// from lfs.checkoutdeleted: "restore" writes them again, while "skip" leaves
+// them deleted, and doesn't download their objects. Default is "restore",
+// including if the value is invalid.
+func (c *Configuration) CheckoutDeleted() string {
+	value, _ := c.GitConfig("lfs.checkoutdeleted")
+	switch mode := strings.ToLower(strings.TrimSpace(value)); mode {
+	case "skip":
+		return mode
+	default:
+		return "restore"
+	}
+}
+
+// PathCollisions returns what `git lfs checkout` and `git lfs pull` do with
+// tracked paths which name the same file as another on a filesystem which
+// ignores case or Unicode normalization, as Git's core.ignorecase and
+// core.precomposeunicode describe, from lfs.pathcollisions: "skip" writes the
+// first such path and reports the rest, while "ignore" writes them all, so the
+// last wins. Default is "skip", including if the value is invalid.
+func (c *Configuration) PathCollisions() string {
+	value, _ := c.GitConfig("lfs.pathcollisions")
+	switch mode := strings.ToLower(strings.TrimSpace(value)); mode {
+	case "ignore":
+		return mode
+	default:
+		return "skip"
+	}
+}
+
+// TransferOrder returns the order in which objects are dispatched from each
+// batch for transfer, from lfs.transfer.order: "largest" (largest first),
+// "smallest" (smallest first) or "natural" (the order they were queued in).
+// Batches are still made in the order objects are queued, so it doesn't
+// order the whole transfer. Default is "natural", including if the value is
+// invalid.
+func (c *Configuration) TransferOrder() string {
+	value, _ := c.GitConfig("lfs.transfer.order")
+	switch order := strings.ToLower(strings.TrimSpace(value)); order {
+	case "largest", "smallest":
+		return order
+	default:
+		return "natural"
+	}
+}
+
+// TransferUploadConflict returns what to do when the server refuses an
+// upload with 409 Conflict, from lfs.transfer.uploadconflict: "verify" checks
+// that the server has the object, which it may if another client uploaded the
+// same oid at the same time, and treats the upload as done if so, while
+// "retry" treats it like any other failed upload. Default is "verify",
+// including if the value is invalid.
+func (c *Configuration) TransferUploadConflict() string {
+	value, _ := c.GitConfig("lfs.transfer.uploadconflict")
+	switch mode := strings.ToLower(strings.TrimSpace(value)); mode {
+	case "retry":
+		return mode
+	default:
+		return "verify"
+	}
+}
+
+// TransferUploadRetry returns what to do when an upload fails without a clear
+// answer from the server, such as a timeout or a 5xx response, from
+// lfs.transfer.uploadretry: "verify" first checks with a HEAD request whether
+// the object reached the object store anyway, and treats the upload as done if
+// so, while "retry" retries it straight away. Default is "retry", including if
+// the value is invalid.
+func (c *Configuration) TransferUploadRetry() string {
+	value, _ := c.GitConfig("lfs.transfer.uploadretry")
+	switch mode := strings.ToLower(strings.TrimSpace(value)); mode {
+	case "verify":
+		return mode
+	default:
+		return "retry"
+	}
+}
+
+// TransferStateMaxAge returns how long the record of the objects uploaded by
+// an unfinished push is kept for, so that the next push can skip them, from
+// lfs.transfer.statehours. Default is 24 hours, including if the value is
+// invalid, and 0 turns the record off.
+func (c *Configuration) TransferStateMaxAge() time.Duration {
+	value, ok := c.GitConfig("lfs.transfer.statehours")
+	if !ok {
+		return 24 * time.Hour
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(n) * time.Hour
+}
+
+// TransferExpectContinue returns the size in bytes from which uploads send an
+// "Expect: 100-continue" header, so that the server can reject them before
+// their content is sent, from lfs.transfer.expectcontinue. Like git config
+// integers, the size may have a k, m or g suffix. Default is 0, which never
+// sends the header, as some servers and proxies mishandle it.
+func (c *Configuration) TransferExpectContinue() int64 {
+	v, _ := c.GitConfig("lfs.transfer.expectcontinue")
+	n, err := tools.ParseByteSize(v)
+	if err != nil || n < 1 {
+		return 0
+	}
+	return n
+}
+
+// CacheControl returns what Git LFS does with the Cache-Control and Expires
+// headers of object downloads, from lfs.cachecontrol: "honor" records them, and
+// checks local copies with the server again once they say the copy is stale,
+// while "ignore" does neither. Default is "ignore".
+func (c *Configuration) CacheControl() string {
+	value, _ := c.GitConfig("lfs.cachecontrol")
+	switch mode := strings.ToLower(strings.TrimSpace(value)); mode {
+	case "honor", "honour":
+		return "honor"
+	default:
+		return "ignore"
+	}
+}
+
 // BasicTransfersOnly returns whether to only allow "basic" HTTP transfers
 // Default is false, including if the lfs.basictransfersonly is invalid
 func (c *Configuration) BasicTransfersOnly() bool {
@@ -310,6 +890,69 @@ func (c *Configuration) EndpointAccess(e Endpoint) string {
 	return "none"
 }
 
+// CredentialHelper returns the credential helper configured for the given
+// protocol, host and path with `lfs.<url>.credentialhelper`. The most specific
+// url wins, so "https://host/org/repo" is checked before "https://host/org"
+// and "https://host".
+func (c *Configuration) CredentialHelper(protocol, host, path string) (string, bool) {
+	return c.urlConfig(protocol, host, path, func(u string) []string {
+		return []string{fmt.Sprintf("lfs.%s.credentialhelper", u)}
+	})
+}
+
+// HttpProxy returns the proxy configured for the given protocol, host and path
+// with git's `http.<url>.proxy`, or `http.proxy` if no url matches. As with
+// CredentialHelper, the most specific url wins.
+func (c *Configuration) HttpProxy(protocol, host, path string) string {
+	if v, ok := c.urlConfig(protocol, host, path, func(u string) []string {
+		return []string{fmt.Sprintf("http.%s.proxy", u), fmt.Sprintf("http.%s/.proxy", u)}
+	}); ok {
+		return v
+	}
+
+	v, _ := c.GitConfig("http.proxy")
+	return v
+}
+
+// urlConfig returns the first non-empty value of the config keys which keys
+// gives for each url that's a prefix of the given protocol, host and path,
+// from the most specific url to the least.
+func (c *Configuration) urlConfig(protocol, host, path string, keys func(u string) []string) (string, bool) {
+	base := fmt.Sprintf("%s://%s", protocol, host)
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i := len(parts); i >= 0; i-- {
+		u := base
+		if i > 0 && len(parts[0]) > 0 {
+			u = base + "/" + strings.Join(parts[:i], "/")
+		}
+
+		for _, key := range keys(u) {
+			if v, ok := c.GitConfig(key); ok && len(v) > 0 {
+				return v, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// CredentialPrompt returns whether Git may prompt for credentials which no
+// credential helper has, from lfs.credential.prompt. It is false if
+// GIT_LFS_SKIP_CREDENTIAL_PROMPT is set, so that CI jobs fail rather than
+// hang. Default is true, including if the value is invalid.
+func (c *Configuration) CredentialPrompt() bool {
+	if c.GetenvBool("GIT_LFS_SKIP_CREDENTIAL_PROMPT", false) {
+		return false
+	}
+
+	value, _ := c.GitConfig("lfs.credential.prompt")
+	if prompt, err := parseConfigBool(value); err == nil && len(value) > 0 {
+		return prompt
+	}
+	return true
+}
+
 func (c *Configuration) SetEndpointAccess(e Endpoint, authType string) {
 	tracerx.Printf("setting repository access to %s", authType)
 	key := fmt.Sprintf("lfs.%s.access", e.Url)
@@ -342,6 +985,14 @@ func (c *Configuration) FetchExcludePaths() []string {
 	return c.fetchExcludePaths
 }
 
+// PruneKeepPaths returns the path patterns whose objects prune always keeps if
+// they're reachable, from lfs.prune.keep. It may be set more than once, and
+// each value may list patterns separated by commas. Default is none.
+func (c *Configuration) PruneKeepPaths() []string {
+	c.loadGitConfig()
+	return c.pruneKeepPaths
+}
+
 func (c *Configuration) RemoteEndpoint(remote, operation string) Endpoint {
 	if len(remote) == 0 {
 		remote = defaultRemote
@@ -439,6 +1090,7 @@ func (c *Configuration) FetchPruneConfig() *FetchPruneConfig {
 			PruneOffsetDays:               3,
 			PruneVerifyRemoteAlways:       false,
 			PruneRemoteName:               "origin",
+			PruneVerifyCDN:                false,
 		}
 		if v, ok := c.GitConfig("lfs.fetchrecentrefsdays"); ok {
 			n, err := strconv.Atoi(v)
@@ -476,6 +1128,11 @@ func (c *Configuration) FetchPruneConfig() *FetchPruneConfig {
 		if v, ok := c.GitConfig("lfs.pruneremotetocheck"); ok {
 			c.fetchPruneConfig.PruneRemoteName = v
 		}
+		if v, ok := c.GitConfig("lfs.pruneverifycdn"); ok {
+			if b, err := parseConfigBool(v); err == nil {
+				c.fetchPruneConfig.PruneVerifyCDN = b
+			}
+		}
 
 	}
 	return c.fetchPruneConfig
@@ -485,6 +1142,19 @@ func (c *Configuration) SkipDownloadErrors() bool {
 	return c.GetenvBool("GIT_LFS_SKIP_DOWNLOAD_ERRORS", false) || c.GitConfigBool("lfs.skipdownloaderrors")
 }
 
+// SmudgeFallback returns what the smudge filter writes when an object can't be
+// downloaded, from lfs.smudgefallback: "pointer" writes the pointer itself, and
+// "placeholder" writes the pointer with a comment explaining that the content
+// is missing, and doesn't fail the checkout. Default is "pointer", including
+// if the value is invalid.
+func (c *Configuration) SmudgeFallback() string {
+	value, _ := c.GitConfig("lfs.smudgefallback")
+	if strings.ToLower(strings.TrimSpace(value)) == "placeholder" {
+		return "placeholder"
+	}
+	return "pointer"
+}
+
 func parseConfigBool(str string) (bool, error) {
 	switch strings.ToLower(str) {
 	case "true", "1", "on", "yes", "t":
@@ -577,7 +1247,7 @@ func (c *Configuration) readGitConfig(output string, uniqRemotes map[string]bool
 		value := pieces[1]
 
 		if origKey, ok := uniqKeys[key]; ok {
-			if ShowConfigWarnings && c.gitConfig[key] != value && strings.HasPrefix(key, gitConfigWarningPrefix) {
+			if ShowConfigWarnings && !multiValueKeys[key] && c.gitConfig[key] != value && strings.HasPrefix(key, gitConfigWarningPrefix) {
 				fmt.Fprintf(os.Stderr, "WARNING: These git config values clash:\n")
 				fmt.Fprintf(os.Stderr, "  git config %q = %q\n", origKey, c.gitConfig[key])
 				fmt.Fprintf(os.Stderr, "  git config %q = %q\n", pieces[0], value)
@@ -637,9 +1307,18 @@ func (c *Configuration) readGitConfig(output string, uniqRemotes map[string]bool
 				c.fetchExcludePaths = tools.CleanPaths(value, ",")
 			}
 		}
+		if key == "lfs.prune.keep" {
+			c.pruneKeepPaths = append(c.pruneKeepPaths, tools.CleanPaths(value, ",")...)
+		}
 	}
 }
 
+// multiValueKeys are the keys which may be set more than once, so aren't
+// warned about when they are.
+var multiValueKeys = map[string]bool{
+	"lfs.prune.keep": true,
+}
+
 func keyIsUnsafe(key string) bool {
 	for _, safe := range safeKeys {
 		if safe == key {
@@ -653,6 +1332,7 @@ var safeKeys = []string{
 	"lfs.fetchexclude",
 	"lfs.fetchinclude",
 	"lfs.gitprotocol",
+	"lfs.prune.keep",
 	"lfs.url",
 }
 
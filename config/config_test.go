@@ -1,7 +1,9 @@
 package config
 
 import (
+	"crypto/tls"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -336,6 +338,24 @@ func TestConcurrentTransfersNegativeValue(t *testing.T) {
 	assert.Equal(t, 3, n)
 }
 
+func TestMaxOpenFiles(t *testing.T) {
+	tests := map[string]int{
+		"":         0,
+		"16":       16,
+		"0":        0,
+		"-1":       0,
+		"elephant": 0,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.maxopenfiles": value},
+		}
+
+		assert.Equal(t, expected, config.MaxOpenFiles(), "lfs.maxopenfiles %q", value)
+	}
+}
+
 func TestBasicTransfersOnlySetValue(t *testing.T) {
 	config := &Configuration{
 		gitConfig: map[string]string{
@@ -514,6 +534,7 @@ func TestFetchPruneConfigDefault(t *testing.T) {
 	assert.Equal(t, 3, fp.PruneOffsetDays)
 	assert.Equal(t, "origin", fp.PruneRemoteName)
 	assert.False(t, fp.PruneVerifyRemoteAlways)
+	assert.False(t, fp.PruneVerifyCDN)
 
 }
 func TestFetchPruneConfigCustom(t *testing.T) {
@@ -525,6 +546,7 @@ func TestFetchPruneConfigCustom(t *testing.T) {
 			"lfs.pruneoffsetdays":         "30",
 			"lfs.pruneverifyremotealways": "true",
 			"lfs.pruneremotetocheck":      "upstream",
+			"lfs.pruneverifycdn":          "true",
 		},
 	}
 	fp := config.FetchPruneConfig()
@@ -535,6 +557,7 @@ func TestFetchPruneConfigCustom(t *testing.T) {
 	assert.Equal(t, 30, fp.PruneOffsetDays)
 	assert.Equal(t, "upstream", fp.PruneRemoteName)
 	assert.True(t, fp.PruneVerifyRemoteAlways)
+	assert.True(t, fp.PruneVerifyCDN)
 }
 
 func TestFetchIncludeExcludesAreCleaned(t *testing.T) {
@@ -546,3 +569,874 @@ func TestFetchIncludeExcludesAreCleaned(t *testing.T) {
 	assert.Equal(t, []string{"/path/to/clean"}, config.FetchIncludePaths())
 	assert.Equal(t, []string{"/other/path/to/clean"}, config.FetchExcludePaths())
 }
+
+func TestPruneKeepPathsAreCollected(t *testing.T) {
+	config := &Configuration{gitConfig: make(map[string]string)}
+	config.readGitConfig("lfs.prune.keep=release/**\nLFS.PRUNE.KEEP=assets/,docs\n", map[string]bool{}, false)
+
+	assert.Equal(t, []string{"release/**", "assets", "docs"}, config.PruneKeepPaths())
+}
+
+func TestPruneKeepPathsDefault(t *testing.T) {
+	config := &Configuration{gitConfig: make(map[string]string)}
+
+	assert.Empty(t, config.PruneKeepPaths())
+}
+
+func TestCredentialHelperConfig(t *testing.T) {
+	config := &Configuration{
+		gitConfig: map[string]string{
+			"lfs.https://git-server.com.credentialhelper":          "one",
+			"lfs.https://git-server.com/org/repo.credentialhelper": "two",
+			"lfs.https://other-server.com.credentialhelper":        "three",
+		},
+	}
+
+	tests := []struct {
+		Host, Path, Helper string
+	}{
+		{"git-server.com", "", "one"},
+		{"git-server.com", "org", "one"},
+		{"git-server.com", "org/repo", "two"},
+		{"git-server.com", "org/repo/info/lfs", "two"},
+		{"git-server.com", "/org/repo/", "two"},
+		{"other-server.com", "org/repo", "three"},
+		{"git-server.com:8080", "", ""},
+	}
+
+	for _, test := range tests {
+		helper, ok := config.CredentialHelper("https", test.Host, test.Path)
+		assert.Equal(t, test.Helper, helper, "%s/%s", test.Host, test.Path)
+		assert.Equal(t, len(test.Helper) > 0, ok, "%s/%s", test.Host, test.Path)
+	}
+
+	helper, ok := config.CredentialHelper("http", "git-server.com", "")
+	assert.False(t, ok)
+	assert.Equal(t, "", helper)
+}
+
+func TestHttpProxyConfig(t *testing.T) {
+	config := &Configuration{
+		gitConfig: map[string]string{
+			"http.proxy":                             "proxy.corp:3128",
+			"http.https://objects.com.proxy":         "http://objects-proxy:8080",
+			"http.https://git-server.com/org/.proxy": "http://org-proxy:8080",
+		},
+	}
+
+	tests := []struct {
+		Protocol, Host, Path, Proxy string
+	}{
+		{"https", "objects.com", "", "http://objects-proxy:8080"},
+		{"https", "objects.com", "a/b/c", "http://objects-proxy:8080"},
+		{"http", "objects.com", "", "proxy.corp:3128"},
+		{"https", "git-server.com", "org/repo", "http://org-proxy:8080"},
+		{"https", "git-server.com", "other/repo", "proxy.corp:3128"},
+	}
+
+	for _, test := range tests {
+		proxy := config.HttpProxy(test.Protocol, test.Host, test.Path)
+		assert.Equal(t, test.Proxy, proxy, "%s://%s/%s", test.Protocol, test.Host, test.Path)
+	}
+
+	config = &Configuration{gitConfig: map[string]string{}}
+	assert.Equal(t, "", config.HttpProxy("https", "objects.com", ""))
+}
+
+func TestCredentialPrompt(t *testing.T) {
+	tests := map[string]bool{
+		"":      true,
+		"true":  true,
+		"false": false,
+		"0":     false,
+		"never": true,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.credential.prompt": value},
+			envVars:   map[string]string{},
+		}
+
+		assert.Equal(t, expected, config.CredentialPrompt(), "lfs.credential.prompt %q", value)
+	}
+
+	config := &Configuration{
+		gitConfig: map[string]string{"lfs.credential.prompt": "true"},
+		envVars:   map[string]string{"GIT_LFS_SKIP_CREDENTIAL_PROMPT": "1"},
+	}
+	assert.False(t, config.CredentialPrompt())
+}
+
+func TestTransferOrder(t *testing.T) {
+	tests := map[string]string{
+		"":         "natural",
+		"natural":  "natural",
+		"largest":  "largest",
+		"Smallest": "smallest",
+		"random":   "natural",
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.transfer.order": value},
+		}
+
+		assert.Equal(t, expected, config.TransferOrder(), "lfs.transfer.order %q", value)
+	}
+}
+
+func TestPathCollisions(t *testing.T) {
+	tests := map[string]string{
+		"":       "skip",
+		"skip":   "skip",
+		"IGNORE": "ignore",
+		"fail":   "skip",
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.pathcollisions": value},
+		}
+
+		assert.Equal(t, expected, config.PathCollisions(), "lfs.pathcollisions %q", value)
+	}
+}
+
+func TestTransferJitter(t *testing.T) {
+	tests := map[string]string{
+		"":             "none",
+		"none":         "none",
+		"full":         "full",
+		"Equal":        "equal",
+		"decorrelated": "decorrelated",
+		"random":       "none",
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.transfer.jitter": value},
+		}
+
+		assert.Equal(t, expected, config.TransferJitter(), "lfs.transfer.jitter %q", value)
+	}
+}
+
+func TestCheckoutDeleted(t *testing.T) {
+	tests := map[string]string{
+		"":        "restore",
+		"restore": "restore",
+		"Skip":    "skip",
+		"delete":  "restore",
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.checkoutdeleted": value},
+		}
+
+		assert.Equal(t, expected, config.CheckoutDeleted(), "lfs.checkoutdeleted %q", value)
+	}
+}
+
+func TestTransferUploadConflict(t *testing.T) {
+	tests := map[string]string{
+		"":       "verify",
+		"verify": "verify",
+		"Retry":  "retry",
+		"fail":   "verify",
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.transfer.uploadconflict": value},
+		}
+
+		assert.Equal(t, expected, config.TransferUploadConflict(), "lfs.transfer.uploadconflict %q", value)
+	}
+}
+
+func TestSetupCheck(t *testing.T) {
+	tests := map[string]string{
+		"":      "warn",
+		"warn":  "warn",
+		" Off":  "off",
+		"false": "warn",
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.setupcheck": value},
+		}
+
+		assert.Equal(t, expected, config.SetupCheck(), "lfs.setupcheck %q", value)
+	}
+}
+
+func TestTransferUploadRetry(t *testing.T) {
+	tests := map[string]string{
+		"":        "retry",
+		"retry":   "retry",
+		"Verify ": "verify",
+		"head":    "retry",
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.transfer.uploadretry": value},
+		}
+
+		assert.Equal(t, expected, config.TransferUploadRetry(), "lfs.transfer.uploadretry %q", value)
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	tests := map[string]string{
+		"":       "ignore",
+		"ignore": "ignore",
+		"honor":  "honor",
+		"Honour": "honor",
+		"always": "ignore",
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.cachecontrol": value},
+		}
+
+		assert.Equal(t, expected, config.CacheControl(), "lfs.cachecontrol %q", value)
+	}
+}
+
+func TestTransferBufferSize(t *testing.T) {
+	tests := map[string]int{
+		"":        0,
+		"1048576": 1048576,
+		"0":       0,
+		"-1":      0,
+		"big":     0,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.transfer.buffersize": value},
+		}
+
+		assert.Equal(t, expected, config.TransferBufferSize(), "lfs.transfer.buffersize %q", value)
+	}
+}
+
+func TestTLSMinVersion(t *testing.T) {
+	tests := map[string]uint16{
+		"":        0,
+		"1.0":     tls.VersionTLS10,
+		"1.2":     tls.VersionTLS12,
+		"TLSv1.2": tls.VersionTLS12,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.tls.minversion": value},
+		}
+
+		version, err := config.TLSMinVersion()
+		assert.Nil(t, err, "lfs.tls.minversion %q", value)
+		assert.Equal(t, expected, version, "lfs.tls.minversion %q", value)
+	}
+
+	config := &Configuration{
+		gitConfig: map[string]string{"lfs.tls.minversion": "1.3"},
+	}
+	_, err := config.TLSMinVersion()
+	assert.NotNil(t, err)
+}
+
+func TestTLSCipherSuites(t *testing.T) {
+	config := &Configuration{
+		gitConfig: map[string]string{
+			"lfs.tls.ciphers": "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls_ecdhe_ecdsa_with_aes_256_gcm_sha384",
+		},
+	}
+
+	suites, err := config.TLSCipherSuites()
+	assert.Nil(t, err)
+	assert.Equal(t, []uint16{
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	}, suites)
+
+	config = &Configuration{gitConfig: map[string]string{}}
+	suites, err = config.TLSCipherSuites()
+	assert.Nil(t, err)
+	assert.Nil(t, suites)
+
+	config = &Configuration{
+		gitConfig: map[string]string{"lfs.tls.ciphers": "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_MADE_UP"},
+	}
+	_, err = config.TLSCipherSuites()
+	assert.NotNil(t, err)
+}
+
+func TestTLSSessionCacheSize(t *testing.T) {
+	tests := map[string]int{
+		"":    64,
+		"0":   0,
+		"-1":  0,
+		"200": 200,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.tls.sessioncache": value},
+		}
+
+		assert.Equal(t, expected, config.TLSSessionCacheSize(), "lfs.tls.sessioncache %q", value)
+	}
+}
+
+func TestTLSWarmup(t *testing.T) {
+	config := &Configuration{gitConfig: map[string]string{}}
+	assert.False(t, config.TLSWarmup())
+
+	config = &Configuration{
+		gitConfig: map[string]string{"lfs.tls.warmup": "true"},
+	}
+	assert.True(t, config.TLSWarmup())
+}
+
+func TestUploadHeaders(t *testing.T) {
+	config := &Configuration{
+		gitConfig: map[string]string{
+			"lfs.transfer.uploadheader.x-amz-server-side-encryption": "AES256",
+			"lfs.transfer.uploadheader.":                             "ignored",
+			"lfs.transfer.order":                                     "largest",
+		},
+	}
+
+	assert.Equal(t, map[string]string{"x-amz-server-side-encryption": "AES256"}, config.UploadHeaders())
+}
+
+func TestCheckoutReadAhead(t *testing.T) {
+	tests := map[string]int{
+		"":    0,
+		"8":   8,
+		"-1":  0,
+		"all": 0,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.checkoutreadahead": value},
+		}
+
+		assert.Equal(t, expected, config.CheckoutReadAhead(), "lfs.checkoutreadahead %q", value)
+	}
+}
+
+func TestTransferMaxRetries(t *testing.T) {
+	tests := map[string]int{
+		"":     0,
+		"3":    3,
+		"0":    0,
+		"many": 0,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.transfer.maxretries": value},
+		}
+
+		assert.Equal(t, expected, config.TransferMaxRetries(), "lfs.transfer.maxretries %q", value)
+	}
+}
+
+func TestConnectRetries(t *testing.T) {
+	tests := map[string]int{
+		"":     0,
+		"5":    5,
+		"0":    0,
+		"-1":   0,
+		"many": 0,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.transfer.connectretries": value},
+		}
+
+		assert.Equal(t, expected, config.ConnectRetries(), "lfs.transfer.connectretries %q", value)
+	}
+}
+
+func TestTransferRampUp(t *testing.T) {
+	windows := map[string]time.Duration{
+		"":     0,
+		"5":    5 * time.Second,
+		"0":    0,
+		"-1":   0,
+		"slow": 0,
+	}
+
+	for value, expected := range windows {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.transfer.rampupwindow": value},
+		}
+
+		assert.Equal(t, expected, config.TransferRampUpWindow(), "lfs.transfer.rampupwindow %q", value)
+	}
+
+	starts := map[string]int{
+		"":    1,
+		"4":   4,
+		"0":   1,
+		"-2":  1,
+		"few": 1,
+	}
+
+	for value, expected := range starts {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.transfer.rampupstart": value},
+		}
+
+		assert.Equal(t, expected, config.TransferRampUpStart(), "lfs.transfer.rampupstart %q", value)
+	}
+}
+
+func TestActivityTimeout(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":     0,
+		"10":   10 * time.Second,
+		" 5 ":  5 * time.Second,
+		"0":    0,
+		"-1":   0,
+		"long": 0,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.activitytimeout": value},
+		}
+
+		assert.Equal(t, expected, config.ActivityTimeout(), "lfs.activitytimeout %q", value)
+	}
+}
+
+func TestTransferTimeout(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":     0,
+		"600":  600 * time.Second,
+		"0":    0,
+		"-1":   0,
+		"slow": 0,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.transfer.timeout": value},
+		}
+
+		assert.Equal(t, expected, config.TransferTimeout(), "lfs.transfer.timeout %q", value)
+	}
+}
+
+func TestTransferHostMaxConnections(t *testing.T) {
+	config := &Configuration{
+		gitConfig: map[string]string{
+			"lfs.transfer.cdn.example.com.maxconnections":          "4",
+			"lfs.transfer.primary.example.com.maxconnections":      "16",
+			"lfs.transfer.primary.example.com:8443.maxconnections": "2",
+			"lfs.transfer.bad.example.com.maxconnections":          "lots",
+			"lfs.transfer.[::1].maxconnections":                    "3",
+		},
+	}
+
+	assert.Equal(t, 4, config.TransferHostMaxConnections("cdn.example.com"))
+	assert.Equal(t, 4, config.TransferHostMaxConnections("CDN.example.com"))
+	assert.Equal(t, 4, config.TransferHostMaxConnections("cdn.example.com:443"))
+	assert.Equal(t, 16, config.TransferHostMaxConnections("primary.example.com"))
+	assert.Equal(t, 2, config.TransferHostMaxConnections("primary.example.com:8443"))
+	assert.Equal(t, 0, config.TransferHostMaxConnections("bad.example.com"))
+	assert.Equal(t, 0, config.TransferHostMaxConnections("other.example.com"))
+	assert.Equal(t, 0, config.TransferHostMaxConnections(""))
+	assert.Equal(t, 3, config.TransferHostMaxConnections("[::1]"))
+	assert.Equal(t, 3, config.TransferHostMaxConnections("[::1]:8080"))
+}
+
+func TestStorageReadOnly(t *testing.T) {
+	tests := map[string]string{
+		"":      "auto",
+		"auto":  "auto",
+		"true":  "true",
+		"Yes":   "true",
+		"1":     "true",
+		"false": "false",
+		"off":   "false",
+		"maybe": "auto",
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.storage.readonly": value},
+		}
+
+		assert.Equal(t, expected, config.StorageReadOnly(), "lfs.storage.readonly %q", value)
+	}
+}
+
+func TestTransferStateMaxAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"0":   0,
+		"1":   time.Hour,
+		" 48": 48 * time.Hour,
+		"-1":  24 * time.Hour,
+		"day": 24 * time.Hour,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.transfer.statehours": value},
+		}
+
+		assert.Equal(t, expected, config.TransferStateMaxAge(), "lfs.transfer.statehours %q", value)
+	}
+
+	config := &Configuration{gitConfig: map[string]string{}}
+	assert.Equal(t, 24*time.Hour, config.TransferStateMaxAge())
+}
+
+func TestTransferExpectContinue(t *testing.T) {
+	tests := map[string]int64{
+		"":     0,
+		"0":    0,
+		"1":    1,
+		"10m":  10 * 1024 * 1024,
+		"-1":   0,
+		"true": 0,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.transfer.expectcontinue": value},
+		}
+
+		assert.Equal(t, expected, config.TransferExpectContinue(), "lfs.transfer.expectcontinue %q", value)
+	}
+}
+
+func TestWarnSize(t *testing.T) {
+	tests := map[string]int64{
+		"":      0,
+		"0":     0,
+		"1000":  1000,
+		"10k":   10 * 1024,
+		"5M":    5 * 1024 * 1024,
+		" 2g ":  2 * 1024 * 1024 * 1024,
+		"-1":    0,
+		"k":     0,
+		"large": 0,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.warnsize": value},
+		}
+
+		assert.Equal(t, expected, config.WarnSize(), "lfs.warnsize %q", value)
+	}
+}
+
+func TestTransferMaxBandwidth(t *testing.T) {
+	tests := map[string]int64{
+		"":        0,
+		"0":       0,
+		"500k":    500 * 1024,
+		"2m":      2 * 1024 * 1024,
+		" 2MB/s ": 2 * 1024 * 1024,
+		"1000":    1000,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.transfer.maxbandwidth": value},
+		}
+
+		n, err := config.TransferMaxBandwidth()
+		assert.Nil(t, err, "lfs.transfer.maxbandwidth %q", value)
+		assert.Equal(t, expected, n, "lfs.transfer.maxbandwidth %q", value)
+	}
+
+	for _, value := range []string{"-1", "fast", "2m/h"} {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.transfer.maxbandwidth": value},
+		}
+
+		_, err := config.TransferMaxBandwidth()
+		assert.NotNil(t, err, "lfs.transfer.maxbandwidth %q", value)
+	}
+
+	// the --max-bandwidth flag takes precedence
+	config := &Configuration{
+		gitConfig: map[string]string{"lfs.transfer.maxbandwidth": "1m"},
+	}
+	config.SetTransferMaxBandwidth("100k")
+	n, err := config.TransferMaxBandwidth()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100*1024), n)
+}
+
+func TestCheckoutOverwrite(t *testing.T) {
+	tests := map[string]bool{
+		"":      false,
+		"true":  true,
+		"false": false,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.checkoutoverwrite": value},
+		}
+
+		assert.Equal(t, expected, config.CheckoutOverwrite(), "lfs.checkoutoverwrite %q", value)
+	}
+}
+
+func TestSizeIndex(t *testing.T) {
+	tests := map[string]bool{
+		"":      false,
+		"true":  true,
+		"false": false,
+		"maybe": false,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.sizeindex": value},
+		}
+
+		assert.Equal(t, expected, config.SizeIndex(), "lfs.sizeindex %q", value)
+	}
+}
+
+func TestProgressStyle(t *testing.T) {
+	tests := map[string][]string{
+		"":                  {"", ""},
+		"bar":               {"bar", ""},
+		"plain":             {"plain", ""},
+		"none":              {"none", ""},
+		"/tmp/progress.log": {"", "/tmp/progress.log"},
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			envVars: map[string]string{"GIT_LFS_PROGRESS": value},
+		}
+
+		assert.Equal(t, expected[0], config.ProgressStyle(), "GIT_LFS_PROGRESS %q", value)
+		assert.Equal(t, expected[1], config.ProgressLogPath(), "GIT_LFS_PROGRESS %q", value)
+	}
+}
+
+func TestProgressFd(t *testing.T) {
+	tests := map[string]int{
+		"":     -1,
+		"3":    3,
+		" 9 ":  9,
+		"-1":   -1,
+		"pipe": -1,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			envVars: map[string]string{"GIT_LFS_PROGRESS_FD": value},
+		}
+
+		assert.Equal(t, expected, config.ProgressFd(), "GIT_LFS_PROGRESS_FD %q", value)
+	}
+}
+
+func TestHarPath(t *testing.T) {
+	config := &Configuration{envVars: map[string]string{}}
+	assert.Equal(t, "", config.HarPath())
+
+	config = &Configuration{
+		envVars: map[string]string{"GIT_LFS_HAR": " /tmp/lfs.har "},
+	}
+	assert.Equal(t, "/tmp/lfs.har", config.HarPath())
+}
+
+func TestSetProgressStyleOverridesEnv(t *testing.T) {
+	config := &Configuration{
+		envVars: map[string]string{"GIT_LFS_PROGRESS": "/tmp/progress.log"},
+	}
+	config.SetProgressStyle("none")
+
+	assert.Equal(t, "none", config.ProgressStyle())
+	assert.Equal(t, "/tmp/progress.log", config.ProgressLogPath())
+}
+
+func TestProgressNonInteractive(t *testing.T) {
+	tests := map[string]string{
+		"":      "plain",
+		"plain": "plain",
+		"none":  "none",
+		"NONE ": "none",
+		"bar":   "plain",
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.progress.noninteractive": value},
+		}
+
+		assert.Equal(t, expected, config.ProgressNonInteractive(), "lfs.progress.noninteractive %q", value)
+	}
+}
+
+func TestProgressInterval(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":    0,
+		"5":   5 * time.Second,
+		"-1":  0,
+		"abc": 0,
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.progress.interval": value},
+		}
+
+		assert.Equal(t, expected, config.ProgressInterval(), "lfs.progress.interval %q", value)
+	}
+}
+
+func TestTrackLocation(t *testing.T) {
+	tests := map[string]string{
+		"":        "current",
+		"current": "current",
+		"root":    "root",
+		"Root":    "root",
+		"parent":  "current",
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.track.location": value},
+		}
+
+		assert.Equal(t, expected, config.TrackLocation(), "lfs.track.location %q", value)
+	}
+}
+
+func TestLockOnClean(t *testing.T) {
+	tests := map[string]string{
+		"":       "off",
+		"false":  "off",
+		"true":   "warn",
+		"warn":   "warn",
+		"strict": "strict",
+		"Strict": "strict",
+		"always": "off",
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.lockonclean": value},
+		}
+
+		assert.Equal(t, expected, config.LockOnClean(), "lfs.lockonclean %q", value)
+	}
+}
+
+func TestNonFilePaths(t *testing.T) {
+	tests := map[string]string{
+		"":       "skip",
+		"skip":   "skip",
+		"error":  "error",
+		" Error": "error",
+		"clean":  "skip",
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.nonfilepaths": value},
+		}
+
+		assert.Equal(t, expected, config.NonFilePaths(), "lfs.nonfilepaths %q", value)
+	}
+}
+
+func TestCleanMismatch(t *testing.T) {
+	tests := map[string]string{
+		"":        "replace",
+		"replace": "replace",
+		"error":   "error",
+		" Error":  "error",
+		"ignore":  "replace",
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.clean.mismatch": value},
+		}
+
+		assert.Equal(t, expected, config.CleanMismatch(), "lfs.clean.mismatch %q", value)
+	}
+}
+
+func TestPointerUnknownSize(t *testing.T) {
+	tests := map[string]string{
+		"":       "error",
+		"error":  "error",
+		"allow":  "allow",
+		" Allow": "allow",
+		"ignore": "error",
+	}
+
+	for value, expected := range tests {
+		config := &Configuration{
+			gitConfig: map[string]string{"lfs.pointer.unknownsize": value},
+		}
+
+		assert.Equal(t, expected, config.PointerUnknownSize(), "lfs.pointer.unknownsize %q", value)
+	}
+}
+
+func TestReadOnlyMirror(t *testing.T) {
+	config := &Configuration{
+		gitConfig: map[string]string{"lfs.storage.readonlymirror": "/mnt/lfs-objects"},
+	}
+	assert.Equal(t, "/mnt/lfs-objects", config.ReadOnlyMirror())
+
+	config = &Configuration{}
+	assert.Equal(t, "", config.ReadOnlyMirror())
+}
+
+func TestCDNUrl(t *testing.T) {
+	config := &Configuration{
+		gitConfig: map[string]string{"lfs.cdn.url": " https://cdn.example.com/lfs/ "},
+	}
+	assert.Equal(t, "https://cdn.example.com/lfs", config.CDNUrl())
+
+	config = &Configuration{}
+	assert.Equal(t, "", config.CDNUrl())
+}
+
+func TestExternalCAS(t *testing.T) {
+	config := &Configuration{
+		gitConfig: map[string]string{"lfs.storage.externalcas": " cas-tool --bucket objects "},
+	}
+	assert.Equal(t, "cas-tool --bucket objects", config.ExternalCAS())
+
+	config = &Configuration{}
+	assert.Equal(t, "", config.ExternalCAS())
+}
+
+func TestDownloadHook(t *testing.T) {
+	config := &Configuration{
+		gitConfig: map[string]string{"lfs.transfer.downloadhook": " av-scan --quiet "},
+	}
+	assert.Equal(t, "av-scan --quiet", config.DownloadHook())
+
+	config = &Configuration{}
+	assert.Equal(t, "", config.DownloadHook())
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+}
+
+// tlsCipherSuites are the cipher suites crypto/tls supports, by their standard
+// names.
+var tlsCipherSuites = map[string]uint16{
+	"TLS_RSA_WITH_RC4_128_SHA":                tls.TLS_RSA_WITH_RC4_128_SHA,
+	"TLS_RSA_WITH_3DES_EDE_CBC_SHA":           tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+	"TLS_RSA_WITH_AES_128_CBC_SHA":            tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	"TLS_RSA_WITH_AES_256_CBC_SHA":            tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	"TLS_ECDHE_ECDSA_WITH_RC4_128_SHA":        tls.TLS_ECDHE_ECDSA_WITH_RC4_128_SHA,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+	"TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_RC4_128_SHA":          tls.TLS_ECDHE_RSA_WITH_RC4_128_SHA,
+	"TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA":     tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256":   tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256": tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384":   tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384": tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+}
+
+// TLSMinVersion returns the minimum TLS version to allow for connections, from
+// lfs.tls.minversion, e.g. "1.2". Returns 0 if it isn't set, meaning the Go
+// default, and an error if the value isn't a known version.
+func (c *Configuration) TLSMinVersion() (uint16, error) {
+	value, _ := c.GitConfig("lfs.tls.minversion")
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return 0, nil
+	}
+
+	name := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(value), "tls"), "v")
+	if version, ok := tlsVersions[name]; ok {
+		return version, nil
+	}
+
+	return 0, fmt.Errorf("Invalid lfs.tls.minversion %q, expected one of 1.0, 1.1 or 1.2", value)
+}
+
+// TLSCipherSuites returns the cipher suites to allow for connections, from the comma-separated names in lfs.tls.ciphers, e.g.
+// "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". Returns nil if it isn't set, meaning
+// the Go defaults, and an error if any name isn't a known cipher suite.
+func (c *Configuration) TLSCipherSuites() ([]uint16, error) {
+	value, _ := c.GitConfig("lfs.tls.ciphers")
+	if len(strings.TrimSpace(value)) == 0 {
+		return nil, nil
+	}
+
+	var suites []uint16
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if len(name) == 0 {
+			continue
+		}
+
+		id, ok := tlsCipherSuites[name]
+		if !ok {
+			return nil, fmt.Errorf("Invalid lfs.tls.ciphers: unknown cipher suite %q", name)
+		}
+		suites = append(suites, id)
+	}
+
+	return suites, nil
+}
+
+// TLSSessionCacheSize returns how many TLS sessions to keep for resuming
+// connections to servers that were connected to before, skipping the full
+// handshake, from lfs.tls.sessioncache. Zero turns resumption off. Default is
+// 64.
+func (c *Configuration) TLSSessionCacheSize() int {
+	value, _ := c.GitConfig("lfs.tls.sessioncache")
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 64
+	}
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// TLSWarmup returns whether to connect to the API endpoint as soon as a
+// transfer starts, so that the connection and its TLS handshake are ready by
+// the time the first batch is sent, from lfs.tls.warmup. Default is false.
+func (c *Configuration) TLSWarmup() bool {
+	return c.GitConfigBool("lfs.tls.warmup")
+}
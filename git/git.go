@@ -269,6 +269,108 @@ func DefaultRemote() (string, error) {
 	return "", errors.New("Unable to pick default remote, too ambiguous")
 }
 
+// CheckAttr returns the value of the attr attribute for path, as given by `git
+// check-attr`: "set", "unset", "unspecified", or the value it is set to.
+func CheckAttr(attr, path string) (string, error) {
+	output, err := subprocess.SimpleExec("git", "check-attr", "-z", attr, "--", path)
+	if err != nil {
+		return "", err
+	}
+
+	// <path> NUL <attribute> NUL <value> NUL
+	fields := strings.Split(output, "\x00")
+	if len(fields) < 3 {
+		return "", fmt.Errorf("Unexpected git check-attr output: %q", output)
+	}
+	return fields[2], nil
+}
+
+// IndexBlob returns the sha of the blob staged for path in the index, or "" if
+// path isn't in the index.
+func IndexBlob(path string) (string, error) {
+	return subprocess.SimpleExec("git", "rev-parse", "--verify", "--quiet", ":"+path)
+}
+
+// StagedFile is a regular file which is added or modified in the index.
+type StagedFile struct {
+	Path string
+	// Sha is the sha of the staged blob, and Size its size in bytes.
+	Sha  string
+	Size int64
+}
+
+// StagedFiles returns the regular files which are added, copied, modified or
+// renamed in the index compared to HEAD, with the sizes of their staged blobs.
+func StagedFiles() ([]*StagedFile, error) {
+	output, err := subprocess.SimpleExec("git", "diff", "--cached", "--raw", "-z", "--no-abbrev", "--no-renames", "--diff-filter=ACM")
+	if err != nil {
+		return nil, err
+	}
+
+	// :<old mode> <new mode> <old sha> <new sha> <status> NUL <path> NUL
+	var files []*StagedFile
+	var shas bytes.Buffer
+	fields := strings.Split(strings.TrimSuffix(output, "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		info := strings.Fields(fields[i])
+		if len(info) < 5 || !strings.HasPrefix(info[1], "100") {
+			continue
+		}
+
+		files = append(files, &StagedFile{Path: fields[i+1], Sha: info[3]})
+		shas.WriteString(info[3] + "\n")
+	}
+
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	cmd := subprocess.ExecCommand("git", "cat-file", "--batch-check")
+	cmd.Stdin = &shas
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Error in git cat-file --batch-check: %v", err)
+	}
+
+	// <sha> SP <type> SP <size> LF, in the order of the shas given
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != len(files) {
+		return nil, fmt.Errorf("Expected %d objects from git cat-file --batch-check, got %d", len(files), len(lines))
+	}
+
+	for i, line := range lines {
+		parts := strings.Fields(line)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("Unexpected git cat-file --batch-check output: %q", line)
+		}
+
+		size, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		files[i].Size = size
+	}
+
+	return files, nil
+}
+
+// DeletedFiles returns the paths, relative to the root of the repository, of
+// files which are in the index but have been deleted from the working copy.
+func DeletedFiles() ([]string, error) {
+	output, err := subprocess.SimpleExec("git", "ls-files", "--deleted", "--full-name", "-z", "--", ":/")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, path := range strings.Split(output, "\x00") {
+		if len(path) > 0 {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
 func UpdateIndex(file string) error {
 	_, err := subprocess.SimpleExec("git", "update-index", "-q", "--refresh", file)
 	return err
@@ -297,6 +399,27 @@ func (c *gitConfig) FindLocal(val string) string {
 	return output
 }
 
+// FindAllGlobal returns every value of the key in the global config, of which
+// there is usually no more than one.
+func (c *gitConfig) FindAllGlobal(key string) []string {
+	output, _ := subprocess.SimpleExec("git", "config", "--global", "--get-all", key)
+	return splitConfigValues(output)
+}
+
+// FindAllLocal returns every value of the key in the local config, of which
+// there is usually no more than one.
+func (c *gitConfig) FindAllLocal(key string) []string {
+	output, _ := subprocess.SimpleExec("git", "config", "--local", "--get-all", key)
+	return splitConfigValues(output)
+}
+
+func splitConfigValues(output string) []string {
+	if len(output) == 0 {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
+
 // SetGlobal sets the git config value for the key in the global config
 func (c *gitConfig) SetGlobal(key, val string) {
 	subprocess.SimpleExec("git", "config", "--global", key, val)
@@ -307,6 +430,16 @@ func (c *gitConfig) UnsetGlobal(key string) {
 	subprocess.SimpleExec("git", "config", "--global", "--unset", key)
 }
 
+// UnsetAllGlobal removes every value of the key from the global config
+func (c *gitConfig) UnsetAllGlobal(key string) {
+	subprocess.SimpleExec("git", "config", "--global", "--unset-all", key)
+}
+
+// UnsetAllLocal removes every value of the key from the local config
+func (c *gitConfig) UnsetAllLocal(key string) {
+	subprocess.SimpleExec("git", "config", "--local", "--unset-all", key)
+}
+
 func (c *gitConfig) UnsetGlobalSection(key string) {
 	subprocess.SimpleExec("git", "config", "--global", "--remove-section", key)
 }
@@ -455,3 +455,71 @@ func TestLocalRefs(t *testing.T) {
 		t.Errorf("Unexpected local refs: %v", actual)
 	}
 }
+
+func TestStagedFiles(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	files, err := StagedFiles()
+	assert.Nil(t, err)
+	assert.Empty(t, files)
+
+	write := func(name, content string) {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(name), 0755))
+		assert.Nil(t, ioutil.WriteFile(name, []byte(content), 0644))
+	}
+
+	write("committed.txt", "committed")
+	write("deleted.txt", "deleted")
+	test.RunGitCommand(t, true, "add", "committed.txt", "deleted.txt")
+	test.RunGitCommand(t, true, "commit", "-m", "initial")
+
+	write("committed.txt", "modified and longer")
+	write("new dir/new file.txt", "new")
+	write("unstaged.txt", "unstaged")
+	assert.Nil(t, os.Symlink("committed.txt", "link"))
+	test.RunGitCommand(t, true, "add", "committed.txt", "new dir/new file.txt", "link")
+	test.RunGitCommand(t, true, "rm", "-q", "deleted.txt")
+
+	files, err = StagedFiles()
+	assert.Nil(t, err)
+
+	sizes := make(map[string]int64)
+	for _, f := range files {
+		assert.Len(t, f.Sha, 40)
+		sizes[f.Path] = f.Size
+	}
+	assert.Equal(t, map[string]int64{"committed.txt": 19, "new dir/new file.txt": 3}, sizes)
+}
+
+func TestDeletedFiles(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	for _, name := range []string{"kept.txt", "deleted.txt", "sub dir/deleted.txt", "sub dir/removed.txt"} {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(name), 0755))
+		assert.Nil(t, ioutil.WriteFile(name, []byte(name), 0644))
+	}
+	test.RunGitCommand(t, true, "add", ".")
+	test.RunGitCommand(t, true, "commit", "-m", "initial")
+
+	assert.Nil(t, os.Remove("deleted.txt"))
+	assert.Nil(t, os.Remove("sub dir/deleted.txt"))
+	test.RunGitCommand(t, true, "rm", "-q", "sub dir/removed.txt")
+
+	// paths are relative to the root, wherever it's run from
+	assert.Nil(t, os.Mkdir("other", 0755))
+	assert.Nil(t, os.Chdir("other"))
+
+	files, err := DeletedFiles()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"deleted.txt", "sub dir/deleted.txt"}, files)
+}
@@ -0,0 +1,71 @@
+package httputil
+
+import (
+	"math/rand"
+	"time"
+)
+
+// backoff works out the waits between connect or request retries, which double from base up to
+// max. Jitter is applied to them by the strategy from lfs.transfer.jitter, so
+// that clients which failed at the same time don't all retry at the same time:
+//
+//   - "full" waits a random time up to the backed off delay
+//   - "equal" waits half the delay, plus a random time up to the other half
+//   - "decorrelated" waits a random time from base up to three times the last
+//     wait, capped at max
+//   - "none" waits the delay itself
+type backoff struct {
+	jitter string
+	base   time.Duration
+	max    time.Duration
+
+	// delay is the backed off delay for the next retry, and last is the
+	// last wait, which decorrelated jitter grows from.
+	delay time.Duration
+	last  time.Duration
+
+	rand *rand.Rand
+}
+
+func newBackoff(jitter string, base, max time.Duration) *backoff {
+	return &backoff{
+		jitter: jitter,
+		base:   base,
+		max:    max,
+		delay:  base,
+		last:   base,
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Next returns how long to wait before the next retry.
+func (b *backoff) Next() time.Duration {
+	delay := b.delay
+	if b.delay *= 2; b.delay > b.max {
+		b.delay = b.max
+	}
+
+	switch b.jitter {
+	case "full":
+		return b.between(0, delay)
+	case "equal":
+		return delay/2 + b.between(0, delay-delay/2)
+	case "decorrelated":
+		wait := b.between(b.base, b.last*3)
+		if wait > b.max {
+			wait = b.max
+		}
+		b.last = wait
+		return wait
+	default:
+		return delay
+	}
+}
+
+// between returns a random duration from min up to, but not including, max.
+func (b *backoff) between(min, max time.Duration) time.Duration {
+	if max <= min {
+		return min
+	}
+	return min + time.Duration(b.rand.Int63n(int64(max-min)))
+}
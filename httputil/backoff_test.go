@@ -0,0 +1,94 @@
+package httputil
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffWithoutJitter(t *testing.T) {
+	b := newBackoff("none", 100*time.Millisecond, time.Second)
+
+	var waits []time.Duration
+	for i := 0; i < 6; i++ {
+		waits = append(waits, b.Next())
+	}
+
+	assert.Equal(t, []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}, waits)
+}
+
+func TestBackoffJitterDistributions(t *testing.T) {
+	base := 100 * time.Millisecond
+
+	tests := []struct {
+		jitter string
+		// retry is the retry to sample, from 1, and min and max bound
+		// its waits, whose mean should be about mean.
+		retry          int
+		min, max, mean time.Duration
+	}{
+		{"full", 1, 0, base, base / 2},
+		{"equal", 1, base / 2, base, base * 3 / 4},
+		{"decorrelated", 1, base, 3 * base, 2 * base},
+		{"full", 4, 0, 8 * base, 4 * base},
+		{"equal", 4, 4 * base, 8 * base, 6 * base},
+	}
+
+	for _, tt := range tests {
+		waits := sampleBackoff(tt.jitter, base, 10*time.Second, tt.retry, 2000)
+
+		var total time.Duration
+		for _, wait := range waits {
+			assert.True(t, wait >= tt.min && wait < tt.max, "%s retry %d: %s not in [%s, %s)", tt.jitter, tt.retry, wait, tt.min, tt.max)
+			total += wait
+		}
+
+		mean := total / time.Duration(len(waits))
+		assert.InDelta(t, float64(tt.mean), float64(mean), float64(tt.mean)/10, "%s retry %d: mean %s, expected about %s", tt.jitter, tt.retry, mean, tt.mean)
+	}
+}
+
+func TestBackoffDecorrelatedJitterStaysInBounds(t *testing.T) {
+	base, max := 100*time.Millisecond, 2*time.Second
+	b := newBackoff("decorrelated", base, max)
+	b.rand = rand.New(rand.NewSource(1))
+
+	var largest time.Duration
+	for i := 0; i < 200; i++ {
+		wait := b.Next()
+		assert.True(t, wait >= base && wait <= max, "%s not in [%s, %s]", wait, base, max)
+		if wait > largest {
+			largest = wait
+		}
+	}
+
+	// waits grow from the last wait rather than the retry count
+	assert.True(t, largest > time.Second, "largest wait %s", largest)
+}
+
+// sampleBackoff returns the wait before the given retry of n backoffs.
+func sampleBackoff(jitter string, base, max time.Duration, retry, n int) []time.Duration {
+	r := rand.New(rand.NewSource(1))
+
+	waits := make([]time.Duration, 0, n)
+	for i := 0; i < n; i++ {
+		b := newBackoff(jitter, base, max)
+		b.rand = r
+
+		var wait time.Duration
+		for j := 0; j < retry; j++ {
+			wait = b.Next()
+		}
+		waits = append(waits, wait)
+	}
+	return waits
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"strings"
 
 	"github.com/github/git-lfs/config"
 	"github.com/rubyist/tracerx"
@@ -13,7 +14,7 @@ import (
 // isCertVerificationDisabledForHost returns whether SSL certificate verification
 // has been disabled for the given host, or globally
 func isCertVerificationDisabledForHost(host string) bool {
-	hostSslVerify, _ := config.Config.GitConfig(fmt.Sprintf("http.https://%v/.sslverify", host))
+	hostSslVerify, _ := hostConfig(host, "sslverify")
 	if hostSslVerify == "false" {
 		return true
 	}
@@ -27,6 +28,36 @@ func isCertVerificationDisabledForHost(host string) bool {
 
 }
 
+// hostConfig returns the value of git's http.<url>.<key> setting for an https
+// host (which may be "host:port"). IPv6 addresses are in brackets, as they are
+// in urls, and like git the default port may be given or left out.
+func hostConfig(host, key string) (string, bool) {
+	hosts := []string{canonicalHost(host, "443")}
+	if _, port := splitHostPort(hosts[0]); len(port) == 0 {
+		hosts = append(hosts, hosts[0]+":443")
+	}
+
+	for _, h := range hosts {
+		if value, ok := config.Config.GitConfig(fmt.Sprintf("http.https://%s/.%s", h, key)); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// canonicalHost returns host in lower case, with IPv6 addresses in brackets,
+// and without its port if it's defaultPort.
+func canonicalHost(host, defaultPort string) string {
+	hostname, port := splitHostPort(strings.ToLower(host))
+	if strings.Contains(hostname, ":") {
+		hostname = "[" + hostname + "]"
+	}
+	if len(port) == 0 || port == defaultPort {
+		return hostname
+	}
+	return hostname + ":" + port
+}
+
 // getRootCAsForHost returns a certificate pool for that specific host (which may
 // be "host:port" loaded from either the gitconfig or from a platform-specific
 // source which is not included by default in the golang certificate search)
@@ -52,9 +83,7 @@ func appendRootCAsForHostFromGitconfig(pool *x509.CertPool, host string) *x509.C
 		return appendCertsFromFile(pool, cafile)
 	}
 	// http.<url>.sslcainfo
-	// we know we have simply "host" or "host:port"
-	key := fmt.Sprintf("http.https://%v/.sslcainfo", host)
-	if cafile, ok := config.Config.GitConfig(key); ok {
+	if cafile, ok := hostConfig(host, "sslcainfo"); ok {
 		return appendCertsFromFile(pool, cafile)
 	}
 	// http.sslcainfo
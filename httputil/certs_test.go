@@ -184,3 +184,33 @@ func TestCertVerifyDisabledHostConfig(t *testing.T) {
 	assert.True(t, isCertVerificationDisabledForHost("specifichost.com"))
 	assert.False(t, isCertVerificationDisabledForHost("otherhost.com"))
 }
+
+func TestCertVerifyDisabledIPv6HostConfig(t *testing.T) {
+	defer config.Config.ResetConfig()
+
+	config.Config.ClearConfig()
+	config.Config.SetConfig("http.https://[::1]/.sslverify", "false")
+	config.Config.SetConfig("http.https://[fe80::a]:8443/.sslverify", "false")
+
+	assert.True(t, isCertVerificationDisabledForHost("[::1]"))
+	assert.True(t, isCertVerificationDisabledForHost("[::1]:443"))
+	assert.True(t, isCertVerificationDisabledForHost("::1"))
+	assert.False(t, isCertVerificationDisabledForHost("[::1]:8443"))
+	assert.True(t, isCertVerificationDisabledForHost("[FE80::A]:8443"))
+	assert.False(t, isCertVerificationDisabledForHost("[fe80::a]"))
+	assert.False(t, isCertVerificationDisabledForHost("127.0.0.1"))
+}
+
+func TestHostConfigDefaultPort(t *testing.T) {
+	defer config.Config.ResetConfig()
+
+	config.Config.ClearConfig()
+	config.Config.SetConfig("http.https://127.0.0.1:443/.sslverify", "false")
+	config.Config.SetConfig("http.https://[::1]:443/.sslverify", "false")
+
+	assert.True(t, isCertVerificationDisabledForHost("127.0.0.1"))
+	assert.True(t, isCertVerificationDisabledForHost("127.0.0.1:443"))
+	assert.False(t, isCertVerificationDisabledForHost("127.0.0.1:8443"))
+	assert.True(t, isCertVerificationDisabledForHost("[::1]"))
+	assert.True(t, isCertVerificationDisabledForHost("[::1]:443"))
+}
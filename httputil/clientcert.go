@@ -0,0 +1,115 @@
+package httputil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/github/git-lfs/auth"
+	"github.com/github/git-lfs/config"
+	"github.com/rubyist/tracerx"
+)
+
+// getClientCertsForHost returns the client certificate to present to host
+// (which may be "host:port"), like Git does from GIT_SSL_CERT, then
+// http.<url>.sslcert, then http.sslcert. The key is read from the matching
+// GIT_SSL_KEY or sslkey setting, or from the certificate file if there isn't
+// one. Returns nil if no certificate is configured.
+func getClientCertsForHost(host string) ([]tls.Certificate, error) {
+	certfile, ok := sslSetting(host, "GIT_SSL_CERT", "sslcert")
+	if !ok {
+		return nil, nil
+	}
+
+	keyfile, ok := sslSetting(host, "GIT_SSL_KEY", "sslkey")
+	if !ok {
+		keyfile = certfile
+	}
+
+	certPEM, err := ioutil.ReadFile(certfile)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading client certificate %q: %v", certfile, err)
+	}
+
+	keyPEM, err := ioutil.ReadFile(keyfile)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading client certificate key %q: %v", keyfile, err)
+	}
+
+	if isClientCertPasswordProtected(host) {
+		if keyPEM, err = decryptClientCertKey(certfile, keyPEM); err != nil {
+			return nil, err
+		}
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("Error loading client certificate %q: %v", certfile, err)
+	}
+
+	tracerx.Printf("Using client certificate %q for %s", certfile, host)
+	return []tls.Certificate{cert}, nil
+}
+
+// sslSetting returns the value of the env var, then http.<url>.<key>, then
+// http.<key>.
+func sslSetting(host, env, key string) (string, bool) {
+	if value := config.Config.Getenv(env); len(value) > 0 {
+		return value, true
+	}
+	if value, ok := hostConfig(host, key); ok {
+		return value, true
+	}
+	return config.Config.GitConfig("http." + key)
+}
+
+func isClientCertPasswordProtected(host string) bool {
+	if config.Config.GetenvBool("GIT_SSL_CERT_PASSWORD_PROTECTED", false) {
+		return true
+	}
+	value, _ := sslSetting(host, "", "sslcertpasswordprotected")
+	protected, _ := strconv.ParseBool(value)
+	return protected
+}
+
+// decryptClientCertKey decrypts the private key in keyPEM with the password
+// for certfile, which is asked for with 'git credential' just like Git does.
+// Keys which aren't encrypted are returned as they are.
+func decryptClientCertKey(certfile string, keyPEM []byte) ([]byte, error) {
+	var out []byte
+	var creds auth.Creds
+
+	for rest := keyPEM; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if x509.IsEncryptedPEMBlock(block) {
+			if creds == nil {
+				var err error
+				creds, err = auth.GetCredentialsFunc()(auth.Creds{"protocol": "cert", "path": certfile}, "fill")
+				if err != nil {
+					return nil, err
+				}
+				if creds == nil {
+					return nil, fmt.Errorf("No password given for client certificate %q", certfile)
+				}
+			}
+
+			der, err := x509.DecryptPEMBlock(block, []byte(creds["password"]))
+			if err != nil {
+				return nil, fmt.Errorf("Error decrypting client certificate key for %q: %v", certfile, err)
+			}
+			block = &pem.Block{Type: block.Type, Bytes: der}
+		}
+
+		out = append(out, pem.EncodeToMemory(block)...)
+	}
+
+	return out, nil
+}
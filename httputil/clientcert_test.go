@@ -0,0 +1,161 @@
+package httputil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/github/git-lfs/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+// newClientCertServer starts a server which requires a client certificate.
+func newClientCertServer() *httptest.Server {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	return srv
+}
+
+// writeClientCert writes a self signed certificate and its key to dir,
+// encrypting the key with password if it isn't empty.
+func writeClientCert(t *testing.T, dir, password string) (certfile, keyfile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "git-lfs client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyBlock := &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}
+	if len(password) > 0 {
+		keyBlock, err = x509.EncryptPEMBlock(rand.Reader, keyBlock.Type, keyDer, []byte(password), x509.PEMCipherAES256)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	certfile = filepath.Join(dir, "client.crt")
+	keyfile = filepath.Join(dir, "client.key")
+	if err := ioutil.WriteFile(certfile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyfile, pem.EncodeToMemory(keyBlock), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certfile, keyfile
+}
+
+func TestClientCertRequiredByServer(t *testing.T) {
+	srv := newClientCertServer()
+	defer srv.Close()
+
+	err := getWithTLSConfig(t, srv, nil)
+	assert.NotNil(t, err)
+}
+
+func TestClientCertFromGitConfig(t *testing.T) {
+	srv := newClientCertServer()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "lfs-client-cert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certfile, keyfile := writeClientCert(t, dir, "")
+	err = getWithTLSConfig(t, srv, map[string]string{
+		"http.sslcert": certfile,
+		"http.sslkey":  keyfile,
+	})
+	assert.Nil(t, err)
+}
+
+func TestClientCertForHostFromGitConfig(t *testing.T) {
+	srv := newClientCertServer()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "lfs-client-cert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certfile, keyfile := writeClientCert(t, dir, "")
+	prefix := "http." + srv.URL + "/."
+	err = getWithTLSConfig(t, srv, map[string]string{
+		prefix + "sslcert": certfile,
+		prefix + "sslkey":  keyfile,
+	})
+	assert.Nil(t, err)
+}
+
+func TestClientCertPasswordProtected(t *testing.T) {
+	srv := newClientCertServer()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "lfs-client-cert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certfile, keyfile := writeClientCert(t, dir, "s3cret")
+
+	var asked auth.Creds
+	oldCreds := auth.SetCredentialsFunc(func(input auth.Creds, subCommand string) (auth.Creds, error) {
+		asked = input
+		return auth.Creds{"protocol": "cert", "path": certfile, "password": "s3cret"}, nil
+	})
+	defer auth.SetCredentialsFunc(oldCreds)
+
+	err = getWithTLSConfig(t, srv, map[string]string{
+		"http.sslcert":                  certfile,
+		"http.sslkey":                   keyfile,
+		"http.sslcertpasswordprotected": "true",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "cert", asked["protocol"])
+	assert.Equal(t, certfile, asked["path"])
+}
+
+func TestClientCertMissingFailsRequests(t *testing.T) {
+	srv := newClientCertServer()
+	defer srv.Close()
+
+	err := getWithTLSConfig(t, srv, map[string]string{
+		"http.sslcert": filepath.Join(os.TempDir(), "lfs-missing-client.crt"),
+	})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "client certificate")
+	}
+}
@@ -0,0 +1,92 @@
+package httputil
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// AcceptCompressedResponse asks for the response to req to be compressed with
+// gzip or deflate, which HttpClient decompresses as it's read. API responses
+// compress well, but object content usually doesn't, so object transfers
+// don't ask; Go's transport is stopped from asking on its own.
+func AcceptCompressedResponse(req *http.Request) {
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+}
+
+// decompressResponse makes the body of res decompress as it's read, if the
+// request asked for a compressed response and got one. Like Go's transport,
+// it removes the Content-Encoding and Content-Length headers, as they describe
+// the compressed body, and sets ContentLength to -1.
+func decompressResponse(res *http.Response) {
+	if res.Request == nil || len(res.Request.Header.Get("Accept-Encoding")) == 0 {
+		return
+	}
+
+	encoding := strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding")))
+	if encoding != "gzip" && encoding != "deflate" {
+		return
+	}
+
+	res.Body = &decompressingReader{body: res.Body, encoding: encoding}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+}
+
+// isDecompressed returns whether the body of res is being decompressed as it's
+// read.
+func isDecompressed(res *http.Response) bool {
+	_, ok := res.Body.(*decompressingReader)
+	return ok
+}
+
+// decompressingReader decompresses a response body, starting on the first
+// read so that a body which isn't compressed as its header says gives an error
+// from Read, rather than from the request.
+type decompressingReader struct {
+	body     io.ReadCloser
+	encoding string
+	r        io.Reader
+	err      error
+}
+
+func (d *decompressingReader) Read(p []byte) (int, error) {
+	if d.r == nil && d.err == nil {
+		d.r, d.err = newDecompressor(d.body, d.encoding)
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return d.r.Read(p)
+}
+
+func (d *decompressingReader) Close() error {
+	return d.body.Close()
+}
+
+// newDecompressor returns a reader decompressing r. The deflate encoding
+// should be zlib data, but some servers send raw deflate data instead, so that
+// is accepted too.
+func newDecompressor(r io.Reader, encoding string) (io.Reader, error) {
+	if encoding == "gzip" {
+		return gzip.NewReader(r)
+	}
+
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	// A zlib header's first byte gives the deflate method, and the two
+	// bytes are a multiple of 31
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
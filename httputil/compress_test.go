@@ -0,0 +1,142 @@
+package httputil
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/github/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const compressTestBody = `{"objects":[{"oid":"abc","size":3}]}`
+
+// compressingServer responds with compressTestBody, compressed with the
+// encoding in the "encoding" query parameter, and records the Accept-Encoding
+// header of each request.
+func compressingServer(acceptEncoding *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*acceptEncoding = r.Header.Get("Accept-Encoding")
+
+		buf := &bytes.Buffer{}
+		var cw io.WriteCloser
+		encoding := r.URL.Query().Get("encoding")
+		switch encoding {
+		case "gzip":
+			cw = gzip.NewWriter(buf)
+		case "deflate":
+			cw = zlib.NewWriter(buf)
+		case "raw-deflate":
+			cw, _ = flate.NewWriter(buf, flate.DefaultCompression)
+			encoding = "deflate"
+		}
+
+		if cw == nil {
+			buf.WriteString(compressTestBody)
+		} else {
+			cw.Write([]byte(compressTestBody))
+			cw.Close()
+			w.Header().Set("Content-Encoding", encoding)
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+		w.WriteHeader(200)
+		w.Write(buf.Bytes())
+	}))
+}
+
+func TestCompressedResponsesAreDecompressed(t *testing.T) {
+	var acceptEncoding string
+	srv := compressingServer(&acceptEncoding)
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	client := NewHttpClient(config.Config, u.Host)
+
+	for _, encoding := range []string{"gzip", "deflate", "raw-deflate"} {
+		req, err := http.NewRequest("GET", srv.URL+"/?encoding="+encoding, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		AcceptCompressedResponse(req)
+
+		res, err := client.Do(req)
+		if !assert.Nil(t, err, encoding) {
+			continue
+		}
+
+		by, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Nil(t, err, encoding)
+		assert.Equal(t, compressTestBody, string(by), encoding)
+		assert.Equal(t, "gzip, deflate", acceptEncoding, encoding)
+		assert.True(t, isDecompressed(res), encoding)
+		assert.EqualValues(t, -1, res.ContentLength, encoding)
+		assert.Empty(t, res.Header.Get("Content-Encoding"), encoding)
+		assert.Empty(t, res.Header.Get("Content-Length"), encoding)
+	}
+}
+
+func TestObjectRequestsDontAskForCompression(t *testing.T) {
+	var acceptEncoding string
+	srv := compressingServer(&acceptEncoding)
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	req, err := http.NewRequest("GET", srv.URL+"/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := NewHttpClient(config.Config, u.Host).Do(req)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	by, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, compressTestBody, string(by))
+	assert.Empty(t, acceptEncoding, "Go's transport doesn't ask for gzip")
+	assert.False(t, isDecompressed(res))
+	assert.EqualValues(t, len(compressTestBody), res.ContentLength)
+}
+
+func TestCorruptCompressedResponse(t *testing.T) {
+	res := &http.Response{
+		Request: &http.Request{Header: http.Header{"Accept-Encoding": []string{"gzip, deflate"}}},
+		Header:  http.Header{"Content-Encoding": []string{"gzip"}},
+		Body:    ioutil.NopCloser(bytes.NewBufferString("not gzip")),
+	}
+	decompressResponse(res)
+
+	_, err := ioutil.ReadAll(res.Body)
+	assert.NotNil(t, err)
+}
+
+func TestDecodeCompressedResponse(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(`{"message":"compressed"}`))
+	gz.Close()
+
+	res := &http.Response{
+		Request: &http.Request{Header: http.Header{"Accept-Encoding": []string{"gzip"}}},
+		Header: http.Header{
+			"Content-Type":     []string{"application/vnd.git-lfs+json"},
+			"Content-Encoding": []string{"gzip"},
+		},
+		Body: ioutil.NopCloser(&buf),
+	}
+
+	var obj struct{ Message string }
+	assert.Nil(t, DecodeResponse(res, &obj))
+	assert.Equal(t, "compressed", obj.Message)
+}
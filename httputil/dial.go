@@ -0,0 +1,43 @@
+package httputil
+
+import (
+	"net"
+	"time"
+
+	"github.com/rubyist/tracerx"
+)
+
+var (
+	// connectRetryDelay is how long to wait before the first connect retry.
+	// The wait doubles for each retry after that, up to connectRetryMaxDelay,
+	// before jitter is applied.
+	connectRetryDelay    = 250 * time.Millisecond
+	connectRetryMaxDelay = 8 * time.Second
+)
+
+type dialFunc func(network, addr string) (net.Conn, error)
+
+// retryDial wraps dial so that failed connection attempts, such as refused
+// connections or failed DNS lookups, are retried up to retries times with
+// exponential backoff, with the given jitter strategy. Nothing has been sent
+// when a dial fails, so this is safe for any request, unlike retrying a
+// transfer that has started.
+func retryDial(dial dialFunc, retries int, jitter string) dialFunc {
+	if retries < 1 {
+		return dial
+	}
+
+	return func(network, addr string) (net.Conn, error) {
+		b := newBackoff(jitter, connectRetryDelay, connectRetryMaxDelay)
+		for i := 1; ; i++ {
+			conn, err := dial(network, addr)
+			if err == nil || i > retries {
+				return conn, err
+			}
+
+			delay := b.Next()
+			tracerx.Printf("http: connect to %s failed, retry %d of %d in %s: %s", addr, i, retries, delay, err)
+			time.Sleep(delay)
+		}
+	}
+}
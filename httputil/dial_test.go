@@ -0,0 +1,120 @@
+package httputil
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDialStopsAfterRetries(t *testing.T) {
+	defer shortenConnectRetryDelay()()
+
+	calls := 0
+	dial := retryDial(func(network, addr string) (net.Conn, error) {
+		calls++
+		return nil, errors.New("connection refused")
+	}, 3, "none")
+
+	_, err := dial("tcp", "127.0.0.1:1")
+	assert.NotNil(t, err)
+	assert.Equal(t, 4, calls, "the first attempt and 3 retries")
+}
+
+func TestRetryDialWithoutRetries(t *testing.T) {
+	calls := 0
+	dial := retryDial(func(network, addr string) (net.Conn, error) {
+		calls++
+		return nil, errors.New("connection refused")
+	}, 0, "none")
+
+	_, err := dial("tcp", "127.0.0.1:1")
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestConnectRetriesRideOutServerRestart(t *testing.T) {
+	defer shortenConnectRetryDelay()()
+
+	addr := unusedAddr(t)
+	type started struct {
+		srv *httptest.Server
+		err error
+	}
+	startc := make(chan started, 1)
+	go func() {
+		// refuse connections for a while before accepting them
+		time.Sleep(200 * time.Millisecond)
+		srv, err := listenAndServe(addr)
+		startc <- started{srv, err}
+	}()
+
+	err := getWithConnectRetries(t, addr, "5")
+	s := <-startc
+	if s.err != nil {
+		t.Fatal(s.err)
+	}
+	defer s.srv.Close()
+
+	assert.Nil(t, err)
+}
+
+func TestConnectFailsWithoutRetries(t *testing.T) {
+	err := getWithConnectRetries(t, unusedAddr(t), "")
+	assert.NotNil(t, err)
+}
+
+func shortenConnectRetryDelay() func() {
+	oldDelay, oldMax := connectRetryDelay, connectRetryMaxDelay
+	connectRetryDelay, connectRetryMaxDelay = 20*time.Millisecond, 100*time.Millisecond
+	return func() {
+		connectRetryDelay, connectRetryMaxDelay = oldDelay, oldMax
+	}
+}
+
+// unusedAddr returns a local address that nothing is listening on.
+func unusedAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+func listenAndServe(addr string) (*httptest.Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	srv.Listener.Close()
+	srv.Listener = l
+	srv.Start()
+	return srv, nil
+}
+
+func getWithConnectRetries(t *testing.T, addr, retries string) error {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.transfer.connectretries", retries)
+
+	req, err := http.NewRequest("GET", "http://"+addr+"/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := NewHttpClient(config.Config, req.Host).Do(req)
+	if err == nil {
+		res.Body.Close()
+	}
+	return err
+}
@@ -0,0 +1,384 @@
+package httputil
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/github/git-lfs/config"
+	"github.com/rubyist/tracerx"
+)
+
+// harTail closes the entries array and the HAR document. It's always at the
+// end of the file, so that the file is valid HAR even if git-lfs exits
+// before it finishes.
+const harTail = "\n]}}\n"
+
+var (
+	harLog     *harRecorder
+	harLogOnce sync.Once
+)
+
+// currentHar returns the recorder writing to GIT_LFS_HAR, or nil if it isn't
+// set or the file can't be created.
+func currentHar() *harRecorder {
+	harLogOnce.Do(func() {
+		path := config.Config.HarPath()
+		if len(path) == 0 {
+			return
+		}
+
+		if stat, err := os.Stat(path); err == nil && stat.IsDir() {
+			path = filepath.Join(path, fmt.Sprintf("git-lfs-%d-%d.har", time.Now().Unix(), os.Getpid()))
+		}
+
+		h, err := newHarRecorder(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error recording HTTP requests to %s: %s\n", path, err)
+			return
+		}
+		tracerx.Printf("HTTP: recording requests to %s", path)
+		harLog = h
+	})
+	return harLog
+}
+
+// harRecorder writes HTTP requests and their responses to a file in HAR
+// (HTTP Archive) format, which browser developer tools and other HTTP tools
+// can load. Each entry is written as soon as its response body has been read
+// or closed. Headers are redacted as they are in traces, and bodies are left
+// out, only their sizes being recorded, so that the file is safe to share.
+type harRecorder struct {
+	mu      sync.Mutex
+	f       *os.File
+	entries int
+}
+
+func newHarRecorder(path string) (*harRecorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := json.Marshal(map[string]string{"name": "git-lfs", "version": config.Version})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if _, err := fmt.Fprintf(f, `{"log":{"version":"1.2","creator":%s,"entries":[%s`, header, harTail); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &harRecorder{f: f}, nil
+}
+
+// add writes e over the end of the file, followed by harTail again.
+func (h *harRecorder) add(e *harEntryJSON) {
+	by, err := json.Marshal(e)
+	if err != nil {
+		tracerx.Printf("HTTP: unable to record %s %s: %s", e.Request.Method, e.Request.URL, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, err := h.f.Seek(-int64(len(harTail)), os.SEEK_END); err != nil {
+		tracerx.Printf("HTTP: unable to record %s %s: %s", e.Request.Method, e.Request.URL, err)
+		return
+	}
+
+	sep := "\n"
+	if h.entries > 0 {
+		sep = ",\n"
+	}
+	fmt.Fprintf(h.f, "%s%s%s", sep, by, harTail)
+	h.entries++
+}
+
+// harEntry collects the timings and response of a request while it's made,
+// and records it in a harRecorder once it's finished. A nil *harEntry records
+// nothing.
+type harEntry struct {
+	recorder *harRecorder
+	req      *http.Request
+	reqBody  *CountingReadCloser
+	once     sync.Once
+
+	mu        sync.Mutex
+	start     time.Time
+	responded time.Time
+	res       *http.Response
+	resHeader http.Header
+}
+
+// newHarEntry starts recording req, whose body is counted by reqBody. Returns
+// nil if GIT_LFS_HAR isn't set.
+func newHarEntry(req *http.Request, reqBody *CountingReadCloser) *harEntry {
+	h := currentHar()
+	if h == nil {
+		return nil
+	}
+
+	return &harEntry{recorder: h, req: req, reqBody: reqBody, start: time.Now()}
+}
+
+// response records res as the response to the entry's request, before its
+// headers are changed by decompressing it.
+func (e *harEntry) response(res *http.Response) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	e.responded = time.Now()
+	e.res = res
+	e.resHeader = cloneHeader(res.Header)
+	e.mu.Unlock()
+}
+
+// finish records the entry, with the size of the response body read, or the
+// error if there's no response.
+func (e *harEntry) finish(resBodySize int64, err error) {
+	if e == nil {
+		return
+	}
+
+	e.once.Do(func() {
+		e.recorder.add(e.json(time.Now(), resBodySize, err))
+	})
+}
+
+func (e *harEntry) json(done time.Time, resBodySize int64, err error) *harEntryJSON {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var reqBodySize int64
+	if e.req.Body != nil {
+		reqBodySize = e.reqBody.Count
+	}
+
+	entry := &harEntryJSON{
+		StartedDateTime: e.start.Format("2006-01-02T15:04:05.000Z07:00"),
+		Request: harRequest{
+			Method:      e.req.Method,
+			URL:         redactURL(e.req.URL),
+			HTTPVersion: e.req.Proto,
+			Cookies:     []interface{}{},
+			Headers:     harHeaders(e.req.Header, e.req.Host),
+			QueryString: harQueryString(e.req.URL),
+			HeadersSize: -1,
+			BodySize:    reqBodySize,
+		},
+		Response: harResponse{
+			Cookies:     []interface{}{},
+			Headers:     []harNameValue{},
+			Content:     harContent{MimeType: "x-unknown"},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Cache:   struct{}{},
+		Timings: e.timings(done),
+	}
+	entry.Time = entry.Timings.total()
+
+	if e.res != nil {
+		entry.Response.Status = e.res.StatusCode
+		entry.Response.StatusText = http.StatusText(e.res.StatusCode)
+		entry.Response.HTTPVersion = e.res.Proto
+		entry.Response.Headers = harHeaders(e.resHeader, "")
+		entry.Response.RedirectURL = e.resHeader.Get("Location")
+		entry.Response.BodySize = resBodySize
+		entry.Response.Content.Size = resBodySize
+		if ctype := e.resHeader.Get("Content-Type"); len(ctype) > 0 {
+			entry.Response.Content.MimeType = ctype
+		}
+		entry.Response.Content.Comment = "content omitted"
+	}
+
+	if err != nil {
+		entry.Comment = err.Error()
+	}
+
+	return entry
+}
+
+// timings returns the HAR timings of the entry's request, finishing at done.
+// The client doesn't report when it connects or writes the request, so
+// blocked, dns, connect and ssl are -1, and the wait is the whole time until
+// the response headers arrived, including any connecting and sending.
+func (e *harEntry) timings(done time.Time) harTimings {
+	t := harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+
+	// send, wait and receive can't be left out
+	if e.responded.IsZero() {
+		t.Wait = nonNegative(millisBetween(e.start, done))
+		return t
+	}
+	t.Wait = nonNegative(millisBetween(e.start, e.responded))
+	t.Receive = nonNegative(millisBetween(e.responded, done))
+	return t
+}
+
+func nonNegative(ms float64) float64 {
+	if ms < 0 {
+		return 0
+	}
+	return ms
+}
+
+// millisBetween returns the milliseconds from start to end, or -1 if either
+// didn't happen.
+func millisBetween(start, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() {
+		return -1
+	}
+	return float64(end.Sub(start)) / float64(time.Millisecond)
+}
+
+// harHeaders returns h as HAR headers sorted by name, with values redacted as
+// in traces. A non-empty host is added as the Host header, which Go keeps out
+// of the request's headers.
+func harHeaders(h http.Header, host string) []harNameValue {
+	headers := make([]harNameValue, 0, len(h)+1)
+	if len(host) > 0 {
+		headers = append(headers, harNameValue{Name: "Host", Value: host})
+	}
+
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range h[name] {
+			if isRedactedHeader(name) {
+				value = redactHeaderValue(name, value)
+			}
+			headers = append(headers, harNameValue{Name: name, Value: value})
+		}
+	}
+	return headers
+}
+
+// redactURL returns u with the values of its query masked, as they're often
+// signatures or tokens, such as those of presigned object storage URLs.
+func redactURL(u *url.URL) string {
+	if len(u.RawQuery) == 0 {
+		return u.String()
+	}
+
+	redacted := *u
+	query := u.Query()
+	for name, values := range query {
+		for i := range values {
+			values[i] = "*****"
+		}
+		query[name] = values
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+func harQueryString(u *url.URL) []harNameValue {
+	query := u.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make([]harNameValue, 0, len(query))
+	for _, name := range names {
+		for range query[name] {
+			params = append(params, harNameValue{Name: name, Value: "*****"})
+		}
+	}
+	return params
+}
+
+func cloneHeader(h http.Header) http.Header {
+	clone := make(http.Header, len(h))
+	for name, values := range h {
+		clone[name] = append([]string(nil), values...)
+	}
+	return clone
+}
+
+// The HAR 1.2 entry format, as given at
+// http://www.softwareishard.com/blog/har-12-spec/, without bodies.
+
+type harEntryJSON struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []interface{}  `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []interface{}  `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// total returns the entry's time, the sum of its phases, which includes SSL
+// as part of Connect.
+func (t harTimings) total() float64 {
+	var total float64
+	for _, phase := range []float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait, t.Receive} {
+		if phase > 0 {
+			total += phase
+		}
+	}
+	return total
+}
@@ -0,0 +1,151 @@
+package httputil
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/github/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type testHar struct {
+	Log struct {
+		Version string            `json:"version"`
+		Creator map[string]string `json:"creator"`
+		Entries []*harEntryJSON   `json:"entries"`
+	} `json:"log"`
+}
+
+// recordTestHar makes requests record to a HAR file in a new temporary
+// directory, returning its path and a function to stop recording.
+func recordTestHar(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "lfs-har")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "lfs.har")
+	h, err := newHarRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	harLogOnce = sync.Once{}
+	harLogOnce.Do(func() {})
+	harLog = h
+
+	return path, func() {
+		harLog = nil
+		h.f.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func readTestHar(t *testing.T, path string) *testHar {
+	by, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	har := &testHar{}
+	if err := json.Unmarshal(by, har); err != nil {
+		t.Fatalf("invalid HAR: %s\n%s", err, by)
+	}
+	return har
+}
+
+func TestHarRecordsRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/vnd.git-lfs+json")
+		w.Header().Set("X-Request-Id", "req-1")
+		w.WriteHeader(200)
+		w.Write([]byte(`{"objects":[{"oid":"secret-response"}]}`))
+	}))
+	defer srv.Close()
+
+	path, stop := recordTestHar(t)
+	defer stop()
+
+	// empty until a request finishes
+	har := readTestHar(t, path)
+	assert.Equal(t, "1.2", har.Log.Version)
+	assert.Equal(t, "git-lfs", har.Log.Creator["name"])
+	assert.Empty(t, har.Log.Entries)
+
+	req, err := http.NewRequest("POST", srv.URL+"/objects/batch?token=abc", bytes.NewBufferString(`{"secret":"request"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+
+	res, err := NewHttpClient(config.Config, req.Host).Do(req)
+	if !assert.Nil(t, err) {
+		return
+	}
+	by, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Equal(t, 39, len(by))
+
+	har = readTestHar(t, path)
+	if !assert.Equal(t, 1, len(har.Log.Entries)) {
+		return
+	}
+
+	entry := har.Log.Entries[0]
+	assert.Equal(t, "POST", entry.Request.Method)
+	assert.Equal(t, srv.URL+"/objects/batch?token=%2A%2A%2A%2A%2A", entry.Request.URL)
+	assert.Equal(t, []harNameValue{{Name: "token", Value: "*****"}}, entry.Request.QueryString)
+	assert.Contains(t, entry.Request.Headers, harNameValue{Name: "Authorization", Value: "Basic * * * * *"})
+	assert.Equal(t, int64(20), entry.Request.BodySize)
+
+	assert.Equal(t, 200, entry.Response.Status)
+	assert.Equal(t, "OK", entry.Response.StatusText)
+	assert.Contains(t, entry.Response.Headers, harNameValue{Name: "X-Request-Id", Value: "req-1"})
+	assert.Equal(t, int64(39), entry.Response.BodySize)
+	assert.Equal(t, "application/vnd.git-lfs+json", entry.Response.Content.MimeType)
+
+	assert.Equal(t, float64(-1), entry.Timings.Connect)
+	assert.True(t, entry.Timings.Wait >= 0)
+	assert.True(t, entry.Timings.Receive >= 0)
+	assert.Equal(t, entry.Timings.Wait+entry.Timings.Receive, entry.Time)
+
+	// bodies and secrets are left out
+	file, _ := ioutil.ReadFile(path)
+	for _, secret := range []string{"secret-response", "secret", "dXNlcjpwYXNz", "abc"} {
+		assert.False(t, strings.Contains(string(file), secret), "HAR contains %q", secret)
+	}
+}
+
+func TestHarRecordsFailedRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+
+	path, stop := recordTestHar(t)
+	defer stop()
+
+	req, err := http.NewRequest("GET", url+"/objects/abc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewHttpClient(config.Config, req.Host).Do(req)
+	assert.NotNil(t, err)
+
+	har := readTestHar(t, path)
+	if assert.Equal(t, 1, len(har.Log.Entries)) {
+		entry := har.Log.Entries[0]
+		assert.Equal(t, 0, entry.Response.Status)
+		assert.Equal(t, int64(0), entry.Request.BodySize)
+		assert.Contains(t, entry.Comment, "refused")
+	}
+}
@@ -9,11 +9,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httputil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -24,7 +26,7 @@ import (
 
 type httpTransferStats struct {
 	HeaderSize int
-	BodySize   int
+	BodySize   int64
 	Start      time.Time
 	Stop       time.Time
 }
@@ -55,11 +57,38 @@ func LogTransfer(key string, res *http.Response) {
 
 type HttpClient struct {
 	*http.Client
+
+	// tlsErr is set if the lfs.tls.* settings are invalid, or the client
+	// certificate can't be loaded, in which case no requests are made
+	// rather than risk using weaker TLS than intended
+	tlsErr error
+
+	limiter *rateLimiter
+
+	// activityTimeout is how long a response body may go without being
+	// read from before its request is cancelled; see lfs.activitytimeout
+	activityTimeout time.Duration
 }
 
 func (c *HttpClient) Do(req *http.Request) (*http.Response, error) {
+	if c.tlsErr != nil {
+		return nil, c.tlsErr
+	}
+
+	if c.limiter != nil {
+		c.limiter.Wait()
+	}
+
 	traceHttpRequest(req)
 
+	// A fresh channel for each attempt, as a retried request may have been
+	// cancelled before
+	var cancel chan struct{}
+	if c.activityTimeout > 0 {
+		cancel = make(chan struct{})
+		req.Cancel = cancel
+	}
+
 	crc := countingRequest(req)
 	if req.Body != nil {
 		// Only set the body if we have a body, but create the countingRequest
@@ -67,17 +96,34 @@ func (c *HttpClient) Do(req *http.Request) (*http.Response, error) {
 		req.Body = crc
 	}
 
+	har := newHarEntry(req, crc)
+
 	start := time.Now()
 	res, err := c.Client.Do(req)
 	if err != nil {
+		har.finish(0, err)
 		return res, err
 	}
 
+	har.response(res)
 	traceHttpResponse(res)
+	if c.limiter != nil {
+		c.limiter.Update(res.Header, time.Now())
+	}
+
+	if cancel != nil {
+		res.Body = newActivityReader(res.Body, c.activityTimeout, cancel)
+	}
 
 	cresp := countingResponse(res)
+	if har != nil {
+		cresp.done = func() { har.finish(cresp.Count, nil) }
+	}
 	res.Body = cresp
 
+	// After counting, so that stats show the bytes that were sent
+	decompressResponse(res)
+
 	if config.Config.IsLoggingStats {
 		reqHeaderSize := 0
 		resHeaderSize := 0
@@ -104,6 +150,10 @@ func (c *HttpClient) Do(req *http.Request) (*http.Response, error) {
 	return res, err
 }
 
+// expectContinueTimeout is how long a request with an "Expect: 100-continue"
+// header waits for the server to accept it before sending its body.
+const expectContinueTimeout = 5 * time.Second
+
 // NewHttpClient returns a new HttpClient for the given host (which may be "host:port")
 func NewHttpClient(c *config.Configuration, host string) *HttpClient {
 	httpClientsMutex.Lock()
@@ -121,30 +171,91 @@ func NewHttpClient(c *config.Configuration, host string) *HttpClient {
 	tlstime := c.GitConfigInt("lfs.tlstimeout", 30)
 
 	tr := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		Dial: (&net.Dialer{
+		Proxy: proxyFromConfig(c),
+		Dial: retryDial((&net.Dialer{
 			Timeout:   time.Duration(dialtime) * time.Second,
 			KeepAlive: time.Duration(keepalivetime) * time.Second,
-		}).Dial,
-		TLSHandshakeTimeout: time.Duration(tlstime) * time.Second,
-		MaxIdleConnsPerHost: c.ConcurrentTransfers(),
+		}).Dial, c.ConnectRetries(), c.TransferJitter()),
+		TLSHandshakeTimeout:   time.Duration(tlstime) * time.Second,
+		ResponseHeaderTimeout: c.ActivityTimeout(),
+		MaxIdleConnsPerHost:   c.ConcurrentTransfers(),
+		// Uploads sending "Expect: 100-continue" send their body anyway
+		// if the server doesn't answer by then
+		ExpectContinueTimeout: expectContinueTimeout,
+		// Only API requests ask for compressed responses; see
+		// AcceptCompressedResponse
+		DisableCompression: true,
 	}
 
-	tr.TLSClientConfig = &tls.Config{}
+	tr.TLSClientConfig = &tls.Config{ClientSessionCache: sharedSessionCache(c)}
 	if isCertVerificationDisabledForHost(host) {
 		tr.TLSClientConfig.InsecureSkipVerify = true
 	} else {
 		tr.TLSClientConfig.RootCAs = getRootCAsForHost(host)
 	}
 
+	minVersion, tlsErr := c.TLSMinVersion()
+	tr.TLSClientConfig.MinVersion = minVersion
+	if tlsErr == nil {
+		tr.TLSClientConfig.CipherSuites, tlsErr = c.TLSCipherSuites()
+	}
+	if tlsErr == nil {
+		tr.TLSClientConfig.Certificates, tlsErr = getClientCertsForHost(host)
+	}
+
 	client := &HttpClient{
-		&http.Client{Transport: tr, CheckRedirect: CheckRedirect},
+		Client:  &http.Client{Transport: tr, CheckRedirect: CheckRedirect},
+		tlsErr:  tlsErr,
+		limiter: newRateLimiter(host, c.RateLimitThreshold()),
+
+		activityTimeout: c.ActivityTimeout(),
 	}
 	httpClients[host] = client
 
 	return client
 }
 
+var (
+	tlsSessionCache     tls.ClientSessionCache
+	tlsSessionCacheOnce sync.Once
+)
+
+// sharedSessionCache returns the TLS session cache shared by every client, so
+// that a new connection to a server can resume the session of any earlier
+// one instead of making a full handshake, or nil if lfs.tls.sessioncache turns
+// resumption off.
+func sharedSessionCache(c *config.Configuration) tls.ClientSessionCache {
+	tlsSessionCacheOnce.Do(func() {
+		if size := c.TLSSessionCacheSize(); size > 0 {
+			tlsSessionCache = tls.NewLRUClientSessionCache(size)
+		}
+	})
+	return tlsSessionCache
+}
+
+// WarmConnection sends a HEAD request to rawurl, an https URL, without
+// credentials, leaving its connection open for the requests that follow so
+// that they don't wait for it to be made or for its TLS handshake. Any
+// response will do, and failures are only traced, as the requests that follow
+// will report them.
+func WarmConnection(rawurl string) {
+	start := time.Now()
+	req, err := NewHttpRequest("HEAD", rawurl, nil)
+	if err != nil || req.URL.Scheme != "https" {
+		return
+	}
+
+	res, err := NewHttpClient(config.Config, req.Host).Do(req)
+	if err != nil {
+		tracerx.Printf("tls: unable to warm up a connection to %s: %s", req.Host, err)
+		return
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+
+	tracerx.Printf("tls: warmed up a connection to %s in %s", req.Host, time.Since(start))
+}
+
 func CheckRedirect(req *http.Request, via []*http.Request) error {
 	if len(via) >= 3 {
 		return errors.New("stopped after 3 redirects")
@@ -214,14 +325,71 @@ func traceHttpDump(direction string, dump []byte) {
 
 	for scanner.Scan() {
 		line := scanner.Text()
-		if !config.Config.IsDebuggingHttp && strings.HasPrefix(strings.ToLower(line), "authorization: basic") {
-			fmt.Fprintf(os.Stderr, "%s Authorization: Basic * * * * *\n", direction)
-		} else {
-			fmt.Fprintf(os.Stderr, "%s %s\n", direction, line)
+		if !config.Config.IsDebuggingHttp {
+			line = redactHeaderLine(line)
 		}
+		fmt.Fprintf(os.Stderr, "%s %s\n", direction, line)
 	}
 }
 
+// redactHeaderLine masks the value of a "Name: value" line from an HTTP dump
+// if isRedactedHeader(Name). The auth scheme of an Authorization header is
+// left visible, eg: "Authorization: Basic * * * * *".
+func redactHeaderLine(line string) string {
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) < 2 || !isRedactedHeader(parts[0]) {
+		return line
+	}
+
+	return fmt.Sprintf("%s: %s", parts[0], redactHeaderValue(parts[0], parts[1]))
+}
+
+// redactHeaderValue returns the masked value of the named header, keeping the
+// auth scheme of an Authorization header.
+func redactHeaderValue(name, value string) string {
+	if http.CanonicalHeaderKey(strings.TrimSpace(name)) == "Authorization" {
+		if fields := strings.Fields(value); len(fields) > 1 {
+			return fields[0] + " * * * * *"
+		}
+	}
+
+	return "* * * * *"
+}
+
+// isRedactedHeader returns true if the value of the named header should not be
+// logged. Besides the default hiddenHeaders, `lfs.trace.redact` may list
+// extra header names or regular expressions matching header names,
+// separated by commas. Matching is case insensitive.
+func isRedactedHeader(name string) bool {
+	name = strings.TrimSpace(name)
+	if hiddenHeaders[http.CanonicalHeaderKey(name)] {
+		return true
+	}
+
+	redact, _ := config.Config.GitConfig("lfs.trace.redact")
+	for _, pattern := range strings.Split(redact, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if len(pattern) == 0 {
+			continue
+		}
+
+		re, err := regexp.Compile(`(?i)\A(?:` + pattern + `)\z`)
+		if err != nil {
+			// not a valid regexp, so compare the name literally
+			if strings.EqualFold(pattern, name) {
+				return true
+			}
+			continue
+		}
+
+		if re.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func isTraceableContent(h http.Header) bool {
 	ctype := strings.ToLower(strings.SplitN(h.Get("Content-Type"), ";", 2)[0])
 	for _, tracedType := range tracedTypes {
@@ -251,12 +419,16 @@ func countingResponse(res *http.Response) *CountingReadCloser {
 }
 
 type CountingReadCloser struct {
-	Count           int
+	Count           int64
 	request         *http.Request
 	response        *http.Response
 	isTraceableType bool
 	useGitTrace     bool
 	io.ReadCloser
+
+	// done is called once the body has been read to the end or closed
+	done     func()
+	doneOnce sync.Once
 }
 
 func (c *CountingReadCloser) Read(b []byte) (int, error) {
@@ -265,7 +437,7 @@ func (c *CountingReadCloser) Read(b []byte) (int, error) {
 		return n, err
 	}
 
-	c.Count += n
+	c.Count += int64(n)
 
 	if c.isTraceableType {
 		chunk := string(b[0:n])
@@ -278,6 +450,10 @@ func (c *CountingReadCloser) Read(b []byte) (int, error) {
 		}
 	}
 
+	if err == io.EOF {
+		c.finish()
+	}
+
 	if err == io.EOF && config.Config.IsLoggingStats {
 		// This httpTransfer is done, we're checking it this way so we can also
 		// catch httpTransfers where the caller forgets to Close() the Body.
@@ -293,6 +469,18 @@ func (c *CountingReadCloser) Read(b []byte) (int, error) {
 	return n, err
 }
 
+func (c *CountingReadCloser) Close() error {
+	err := c.ReadCloser.Close()
+	c.finish()
+	return err
+}
+
+func (c *CountingReadCloser) finish() {
+	if c.done != nil {
+		c.doneOnce.Do(c.done)
+	}
+}
+
 // LogHttpStats is intended to be called after all HTTP operations for the
 // commmand have finished. It dumps k/v logs, one line per httpTransfer into
 // a log file with the current timestamp.
@@ -337,8 +525,14 @@ func statsLogFile() (*os.File, error) {
 	return os.Create(filepath.Join(logBase, logFile))
 }
 
+// TraceHttpReq returns the method and url of req without its query, for
+// tracing. The host is kept as it is in the url, so IPv6 addresses stay in
+// brackets.
 func TraceHttpReq(req *http.Request) string {
-	return fmt.Sprintf("%s %s", req.Method, strings.SplitN(req.URL.String(), "?", 2)[0])
+	u := *req.URL
+	u.RawQuery = ""
+	u.Fragment = ""
+	return fmt.Sprintf("%s %s", req.Method, u.String())
 }
 
 func init() {
@@ -0,0 +1,115 @@
+package httputil
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/errutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactHeaderLineDefaults(t *testing.T) {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.trace.redact", "")
+
+	assert.Equal(t, "Authorization: Basic * * * * *", redactHeaderLine("Authorization: Basic dXNlcjpwYXNz"))
+	assert.Equal(t, "authorization: Bearer * * * * *", redactHeaderLine("authorization: Bearer abc123"))
+	assert.Equal(t, "Authorization: * * * * *", redactHeaderLine("Authorization: abc123"))
+	assert.Equal(t, "X-Custom-Token: abc123", redactHeaderLine("X-Custom-Token: abc123"))
+	assert.Equal(t, "GET /foo HTTP/1.1", redactHeaderLine("GET /foo HTTP/1.1"))
+}
+
+func TestRedactHeaderLineCustomNames(t *testing.T) {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.trace.redact", "X-Custom-Token, x-api-key")
+
+	assert.Equal(t, "X-Custom-Token: * * * * *", redactHeaderLine("X-Custom-Token: abc123"))
+	assert.Equal(t, "X-Api-Key: * * * * *", redactHeaderLine("X-Api-Key: abc123"))
+	assert.Equal(t, "X-Custom-Token-Id: 1", redactHeaderLine("X-Custom-Token-Id: 1"))
+	assert.Equal(t, "Authorization: Basic * * * * *", redactHeaderLine("Authorization: Basic dXNlcjpwYXNz"))
+}
+
+func TestRedactHeaderLineCustomPatterns(t *testing.T) {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.trace.redact", "X-Secret-.*,[bad")
+
+	assert.Equal(t, "X-Secret-One: * * * * *", redactHeaderLine("X-Secret-One: abc"))
+	assert.Equal(t, "x-secret-two: * * * * *", redactHeaderLine("x-secret-two: abc"))
+	assert.Equal(t, "[bad: * * * * *", redactHeaderLine("[bad: abc"))
+	assert.Equal(t, "X-Not-Secret: abc", redactHeaderLine("X-Not-Secret: abc"))
+}
+
+func TestErrorHeaderContextRedactsCustomHeaders(t *testing.T) {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.trace.redact", "X-Custom-Token")
+
+	head := make(http.Header)
+	head.Set("Authorization", "Basic dXNlcjpwYXNz")
+	head.Set("X-Custom-Token", "abc123")
+	head.Set("Accept", "application/json")
+
+	err := errutil.Error(errors.New("boom"))
+	setErrorHeaderContext(err, "Request", head)
+
+	assert.Equal(t, "--", errutil.ErrorGetContext(err, "Request:Authorization"))
+	assert.Equal(t, "--", errutil.ErrorGetContext(err, "Request:X-Custom-Token"))
+	assert.Equal(t, "application/json", errutil.ErrorGetContext(err, "Request:Accept"))
+}
+
+func TestTraceHttpReq(t *testing.T) {
+	for rawurl, expected := range map[string]string{
+		"https://127.0.0.1:8080/objects/batch?token=abc": "GET https://127.0.0.1:8080/objects/batch",
+		"https://[::1]:8080/objects/batch?token=abc":     "GET https://[::1]:8080/objects/batch",
+		"https://[::1]/objects/abc#frag":                 "GET https://[::1]/objects/abc",
+		"https://[fe80::1%25en0]:8080/objects":           "GET https://[fe80::1%25en0]:8080/objects",
+	} {
+		req, err := NewHttpRequest("GET", rawurl, nil)
+		if assert.Nil(t, err, rawurl) {
+			assert.Equal(t, expected, TraceHttpReq(req))
+		}
+	}
+}
+
+func TestNewHttpRequestRejectsUnbracketedIPv6(t *testing.T) {
+	_, err := NewHttpRequest("GET", "https://::1:8080/objects", nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must be in brackets")
+	}
+}
+
+func TestNewHttpRequestIPv4AndIPv6(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1:0", "[::1]:0"} {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Logf("skipping %s: %s", addr, err)
+			continue
+		}
+
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.Host))
+		}))
+		srv.Listener.Close()
+		srv.Listener = l
+		srv.Start()
+
+		req, err := NewHttpRequest("GET", srv.URL+"/objects", nil)
+		if !assert.Nil(t, err, addr) {
+			srv.Close()
+			continue
+		}
+
+		res, err := NewHttpClient(config.Config, req.Host).Do(req)
+		if assert.Nil(t, err, addr) {
+			by, _ := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			assert.Equal(t, 200, res.StatusCode)
+			assert.Equal(t, l.Addr().String(), string(by))
+		}
+		srv.Close()
+	}
+}
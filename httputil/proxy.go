@@ -0,0 +1,108 @@
+package httputil
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/github/git-lfs/config"
+)
+
+// proxyFromConfig returns a proxy function for http.Transport which picks the
+// proxy for each request like Git does: git's `http.<url>.proxy` or
+// `http.proxy`, then the HTTPS_PROXY or HTTP_PROXY environment variables.
+// Hosts matching NO_PROXY, and loopback hosts, are never proxied. The request
+// url is checked rather than the client's host, so that object urls on another
+// host than the API are proxied too.
+func proxyFromConfig(c *config.Configuration) func(req *http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyForURL(c, req.URL)
+	}
+}
+
+func proxyForURL(c *config.Configuration, u *url.URL) (*url.URL, error) {
+	hostname, _ := splitHostPort(u.Host)
+	if isLoopback(hostname) || noProxy(c, u.Host) {
+		return nil, nil
+	}
+
+	proxy := c.HttpProxy(u.Scheme, u.Host, u.Path)
+	if len(proxy) == 0 && u.Scheme == "https" {
+		proxy = getenvAny(c, "HTTPS_PROXY", "https_proxy")
+	}
+	if len(proxy) == 0 {
+		proxy = getenvAny(c, "HTTP_PROXY", "http_proxy")
+	}
+	if len(proxy) == 0 {
+		return nil, nil
+	}
+
+	// Like Git, a proxy without a scheme is an http proxy
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+
+	proxyURL, err := url.Parse(proxy)
+	if err != nil || len(proxyURL.Host) == 0 {
+		return nil, fmt.Errorf("Invalid proxy address %q", proxy)
+	}
+	return proxyURL, nil
+}
+
+// noProxy returns whether host (which may be "host:port") matches the comma
+// separated list of hosts in NO_PROXY. An entry matches the host itself and
+// its subdomains, and only the given port if it has one. "*" matches every
+// host.
+func noProxy(c *config.Configuration, host string) bool {
+	hostname, port := splitHostPort(strings.ToLower(host))
+
+	for _, entry := range strings.Split(getenvAny(c, "NO_PROXY", "no_proxy"), ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if len(entry) == 0 {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+
+		entryHost, entryPort := splitHostPort(entry)
+		if len(entryPort) > 0 && entryPort != port {
+			continue
+		}
+
+		entryHost = strings.TrimPrefix(strings.TrimPrefix(entryHost, "*"), ".")
+		if hostname == entryHost || strings.HasSuffix(hostname, "."+entryHost) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitHostPort splits host into its hostname and port, if it has one.
+func splitHostPort(host string) (string, string) {
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		return h, p
+	}
+	return strings.Trim(host, "[]"), ""
+}
+
+func isLoopback(hostname string) bool {
+	if hostname == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(hostname)
+	return ip != nil && ip.IsLoopback()
+}
+
+// getenvAny returns the value of the first of keys which is set.
+func getenvAny(c *config.Configuration, keys ...string) string {
+	for _, key := range keys {
+		if v := c.Getenv(key); len(v) > 0 {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/github/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newProxyConfig(gitconfig, env map[string]string) *config.Configuration {
+	c := config.NewFromValues(gitconfig)
+	c.SetAllEnv(env)
+	return c
+}
+
+func assertProxy(t *testing.T, c *config.Configuration, rawurl, expected string) {
+	u, err := url.Parse(rawurl)
+	assert.Nil(t, err)
+
+	proxy, err := proxyForURL(c, u)
+	assert.Nil(t, err, rawurl)
+	if len(expected) == 0 {
+		assert.Nil(t, proxy, rawurl)
+		return
+	}
+	if assert.NotNil(t, proxy, rawurl) {
+		assert.Equal(t, expected, proxy.String(), rawurl)
+	}
+}
+
+func TestProxyFromEnvironment(t *testing.T) {
+	c := newProxyConfig(nil, map[string]string{
+		"HTTPS_PROXY": "http://secure-proxy:3128",
+		"http_proxy":  "http://proxy:3128",
+	})
+
+	assertProxy(t, c, "https://objects.com/oid", "http://secure-proxy:3128")
+	assertProxy(t, c, "http://objects.com/oid", "http://proxy:3128")
+	assertProxy(t, c, "http://localhost:8080/oid", "")
+	assertProxy(t, c, "http://127.0.0.1:8080/oid", "")
+}
+
+func TestProxyFromGitConfig(t *testing.T) {
+	c := newProxyConfig(map[string]string{
+		"http.proxy":                          "proxy.corp:3128",
+		"http.https://objects.internal.proxy": "http://objects-proxy:8080",
+	}, map[string]string{
+		"HTTPS_PROXY": "http://env-proxy:3128",
+	})
+
+	assertProxy(t, c, "https://objects.com/oid", "http://proxy.corp:3128")
+	assertProxy(t, c, "https://objects.internal/oid", "http://objects-proxy:8080")
+}
+
+func TestProxySkipsNoProxyHosts(t *testing.T) {
+	c := newProxyConfig(map[string]string{
+		"http.proxy": "http://proxy.corp:3128",
+	}, map[string]string{
+		"NO_PROXY": "internal.corp, .local,lfs.com:8443",
+	})
+
+	assertProxy(t, c, "https://internal.corp/oid", "")
+	assertProxy(t, c, "https://objects.internal.corp/oid", "")
+	assertProxy(t, c, "https://git-lfs.local/oid", "")
+	assertProxy(t, c, "https://lfs.com:8443/oid", "")
+	assertProxy(t, c, "https://lfs.com/oid", "http://proxy.corp:3128")
+	assertProxy(t, c, "https://notinternal.corp/oid", "http://proxy.corp:3128")
+	assertProxy(t, c, "https://objects.com/oid", "http://proxy.corp:3128")
+
+	c = newProxyConfig(nil, map[string]string{
+		"https_proxy": "http://proxy.corp:3128",
+		"no_proxy":    "*",
+	})
+	assertProxy(t, c, "https://objects.com/oid", "")
+}
+
+func TestProxyInvalid(t *testing.T) {
+	c := newProxyConfig(map[string]string{"http.proxy": "http://"}, nil)
+	u, _ := url.Parse("https://objects.com/oid")
+
+	_, err := proxyForURL(c, u)
+	assert.NotNil(t, err)
+}
+
+func TestHttpClientUsesProxyForObjectUrls(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.WriteHeader(200)
+	}))
+	defer proxy.Close()
+
+	c := newProxyConfig(map[string]string{"http.proxy": proxy.URL}, map[string]string{
+		"NO_PROXY": "lfs.internal",
+	})
+
+	req, err := http.NewRequest("GET", "http://objects.proxytest/oid", nil)
+	assert.Nil(t, err)
+
+	res, err := NewHttpClient(c, req.Host).Do(req)
+	if assert.Nil(t, err) {
+		res.Body.Close()
+		assert.Equal(t, 200, res.StatusCode)
+	}
+	assert.Equal(t, []string{"http://objects.proxytest/oid"}, proxied)
+}
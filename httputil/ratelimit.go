@@ -0,0 +1,91 @@
+package httputil
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rubyist/tracerx"
+)
+
+// rateLimitRelativeReset is the largest X-RateLimit-Reset value taken as a
+// number of seconds until the reset, rather than as a Unix time.
+const rateLimitRelativeReset = 365 * 24 * 60 * 60
+
+// rateLimiter paces requests to a host which sends X-RateLimit-Remaining and
+// X-RateLimit-Reset headers. Once no more than threshold requests are left,
+// the remaining ones are spread out until the limit resets, and once none are
+// left, requests wait for the reset, so that the limit isn't exceeded.
+type rateLimiter struct {
+	host      string
+	threshold int
+
+	mutex     sync.Mutex
+	remaining int // -1 if the server hasn't sent a limit
+	reset     time.Time
+	next      time.Time // earliest start of the next paced request
+}
+
+func newRateLimiter(host string, threshold int) *rateLimiter {
+	return &rateLimiter{host: host, threshold: threshold, remaining: -1}
+}
+
+// Wait blocks until a request may be made without exceeding the host's rate
+// limit.
+func (l *rateLimiter) Wait() {
+	if delay := l.reserve(time.Now()); delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// reserve takes one of the remaining requests and returns how long to wait
+// before making it.
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.remaining < 0 || l.remaining > l.threshold || !now.Before(l.reset) {
+		return 0
+	}
+
+	if l.remaining == 0 {
+		delay := l.reset.Sub(now)
+		tracerx.Printf("http: rate limit for %s used up, waiting %s for it to reset", l.host, delay)
+		return delay
+	}
+
+	start := now
+	if l.next.After(start) {
+		start = l.next
+	}
+	l.next = start.Add(l.reset.Sub(now) / time.Duration(l.remaining+1))
+	l.remaining--
+
+	delay := start.Sub(now)
+	tracerx.Printf("http: rate limit for %s has %d requests left, waiting %s", l.host, l.remaining+1, delay)
+	return delay
+}
+
+// Update records the rate limit sent in a response's headers, if any.
+func (l *rateLimiter) Update(h http.Header, now time.Time) {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil || remaining < 0 {
+		return
+	}
+
+	reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil || reset < 0 {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.remaining = remaining
+	if reset <= rateLimitRelativeReset {
+		l.reset = now.Add(time.Duration(reset) * time.Second)
+	} else {
+		l.reset = time.Unix(reset, 0)
+	}
+}
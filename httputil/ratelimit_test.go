@@ -0,0 +1,112 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/github/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func rateLimitHeader(remaining, reset int64) http.Header {
+	h := make(http.Header)
+	h.Set("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+	return h
+}
+
+func TestRateLimiterWithoutHeaders(t *testing.T) {
+	l := newRateLimiter("example.com", 10)
+	now := time.Now()
+	l.Update(make(http.Header), now)
+
+	assert.Equal(t, time.Duration(0), l.reserve(now))
+}
+
+func TestRateLimiterAboveThreshold(t *testing.T) {
+	l := newRateLimiter("example.com", 10)
+	now := time.Now()
+	l.Update(rateLimitHeader(11, 60), now)
+
+	assert.Equal(t, time.Duration(0), l.reserve(now))
+	assert.Equal(t, time.Duration(0), l.reserve(now))
+}
+
+func TestRateLimiterSpreadsRemainingRequests(t *testing.T) {
+	l := newRateLimiter("example.com", 10)
+	now := time.Now()
+	l.Update(rateLimitHeader(2, 3), now)
+
+	// 2 requests left over 3 seconds are a second apart, then the rest wait
+	// for the reset
+	assert.Equal(t, time.Duration(0), l.reserve(now))
+	assert.Equal(t, time.Second, l.reserve(now))
+	assert.Equal(t, 3*time.Second, l.reserve(now))
+	assert.Equal(t, 3*time.Second, l.reserve(now))
+
+	// nothing is known about the limit after the reset
+	assert.Equal(t, time.Duration(0), l.reserve(now.Add(3*time.Second)))
+}
+
+func TestRateLimiterUnixReset(t *testing.T) {
+	l := newRateLimiter("example.com", 10)
+	now := time.Unix(1500000000, 0)
+	l.Update(rateLimitHeader(0, 1500000030), now)
+
+	assert.Equal(t, 30*time.Second, l.reserve(now))
+}
+
+func TestRateLimitedServerIsNotExhausted(t *testing.T) {
+	const budget = 3
+
+	var mutex sync.Mutex
+	var windowEnd time.Time
+	used, refused := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		now := time.Now()
+		if !now.Before(windowEnd) {
+			windowEnd = now.Add(time.Second)
+			used = 0
+		}
+
+		if used >= budget {
+			refused++
+			w.WriteHeader(429)
+			return
+		}
+		used++
+
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(budget-used))
+		w.Header().Set("X-RateLimit-Reset", "1")
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.ratelimit.threshold", "2")
+
+	start := time.Now()
+	for i := 0; i < 2*budget; i++ {
+		req, err := http.NewRequest("GET", srv.URL+"/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := NewHttpClient(config.Config, req.Host).Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		assert.Equal(t, 200, res.StatusCode, "request %d", i)
+	}
+
+	assert.Equal(t, 0, refused)
+	assert.True(t, time.Since(start) >= time.Second, "waited for the limit to reset")
+}
@@ -34,6 +34,13 @@ func (e *ClientError) Error() string {
 
 // Internal http request management
 func doHttpRequest(req *http.Request, creds auth.Creds) (*http.Response, error) {
+	return doHttpRequestWithRetries(req, func(req *http.Request) (*http.Response, error) {
+		return sendHttpRequest(req, creds)
+	})
+}
+
+// sendHttpRequest makes a single attempt at req
+func sendHttpRequest(req *http.Request, creds auth.Creds) (*http.Response, error) {
 	var (
 		res *http.Response
 		err error
@@ -76,7 +83,8 @@ func doHttpRequest(req *http.Request, creds auth.Creds) (*http.Response, error)
 	return res, err
 }
 
-// DoHttpRequest performs a single HTTP request
+// DoHttpRequest performs a single HTTP request, retrying it if it fails and the
+// retry policy allows
 func DoHttpRequest(req *http.Request, useCreds bool) (*http.Response, error) {
 	var creds auth.Creds
 	if useCreds {
@@ -155,6 +163,12 @@ func NewHttpRequest(method, rawurl string, header map[string]string) (*http.Requ
 		return nil, err
 	}
 
+	// Go parses "https://::1:8080/" as host "::1:8080", which can't be
+	// dialled since there's no telling the address from the port
+	if host := req.URL.Host; strings.Count(host, ":") > 1 && !strings.HasPrefix(host, "[") {
+		return nil, errutil.Errorf(nil, "Invalid host %q: IPv6 addresses must be in brackets, like \"[::1]:8080\"", host)
+	}
+
 	for key, value := range header {
 		req.Header.Set(key, value)
 	}
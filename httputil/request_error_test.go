@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/github/git-lfs/errutil"
@@ -142,3 +143,66 @@ func TestErrorStatusWithDefaultMessage(t *testing.T) {
 		}
 	}
 }
+
+func TestErrorStatusWithRequestId(t *testing.T) {
+	u, err := url.Parse("https://lfs-server.com/objects/oid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		`{"message":"quota exceeded","request_id":"req-1234"}`: "quota exceeded\nRequest ID: req-1234",
+		`{"request_id":"req-1234"}`:                            fmt.Sprintf(defaultErrors[400], u) + " from HTTP 422\nRequest ID: req-1234",
+	}
+
+	for body, expected := range tests {
+		res := &http.Response{
+			StatusCode: 422,
+			Header:     make(http.Header),
+			Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+			Request:    &http.Request{URL: u},
+		}
+		res.Header.Set("Content-Type", "application/json")
+
+		err := handleResponse(res, nil)
+		if err == nil {
+			t.Errorf("No error for %s", body)
+			continue
+		}
+
+		if actual := err.Error(); actual != expected {
+			t.Errorf("Expected for %s:\n%s\nACTUAL:\n%s", body, expected, actual)
+		}
+	}
+}
+
+func TestErrorStatusWithUnparsableBody(t *testing.T) {
+	rawurl := "https://lfs-server.com/objects/oid"
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an HTML error page, and a message beyond the 8KB that's read
+	long := `{"padding":"` + strings.Repeat("x", maxErrorBodySize) + `","message":"too far"}`
+	for _, body := range []string{"<html>Bad Gateway</html>", long} {
+		res := &http.Response{
+			StatusCode: 400,
+			Header:     make(http.Header),
+			Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+			Request:    &http.Request{URL: u},
+		}
+		res.Header.Set("Content-Type", "application/json")
+
+		err := handleResponse(res, nil)
+		if err == nil {
+			t.Errorf("No error for %.20s", body)
+			continue
+		}
+
+		expected := fmt.Sprintf(defaultErrors[400], rawurl)
+		if actual := err.Error(); actual != expected {
+			t.Errorf("Expected for %.20s:\n%s\nACTUAL:\n%s", body, expected, actual)
+		}
+	}
+}
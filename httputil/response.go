@@ -11,6 +11,7 @@ import (
 	"github.com/github/git-lfs/auth"
 	"github.com/github/git-lfs/config"
 	"github.com/github/git-lfs/errutil"
+	"github.com/rubyist/tracerx"
 )
 
 var (
@@ -29,6 +30,10 @@ var (
 	}
 )
 
+// maxErrorBodySize is the most of an error response's body which is read for
+// its message, so that a huge body isn't read into memory.
+const maxErrorBodySize = 8 * 1024
+
 // DecodeResponse attempts to decode the contents of the response as a JSON object
 func DecodeResponse(res *http.Response, obj interface{}) error {
 	ctype := res.Header.Get("Content-Type")
@@ -36,6 +41,14 @@ func DecodeResponse(res *http.Response, obj interface{}) error {
 		return nil
 	}
 
+	// A response which wasn't made by HttpClient is still compressed if its
+	// Content-Encoding says so, as Go's transport leaves the body alone when
+	// the request asks for compression itself
+	decompressResponse(res)
+	if isDecompressed(res) {
+		return decodeDecompressedResponse(res, obj)
+	}
+
 	err := json.NewDecoder(res.Body).Decode(obj)
 	io.Copy(ioutil.Discard, res.Body)
 	res.Body.Close()
@@ -47,6 +60,27 @@ func DecodeResponse(res *http.Response, obj interface{}) error {
 	return nil
 }
 
+// decodeDecompressedResponse decodes a JSON response which was compressed.
+// The whole body is read before parsing, so that a truncated or corrupt body
+// is an error even if the JSON in it is complete.
+func decodeDecompressedResponse(res *http.Response, obj interface{}) error {
+	defer func() {
+		io.Copy(ioutil.Discard, res.Body)
+		res.Body.Close()
+	}()
+
+	by, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return errutil.Errorf(err, "Unable to decompress HTTP response for %s", TraceHttpReq(res.Request))
+	}
+
+	if err := json.Unmarshal(by, obj); err != nil {
+		return errutil.Errorf(err, "Unable to parse HTTP response for %s", TraceHttpReq(res.Request))
+	}
+
+	return nil
+}
+
 // GetDefaultError returns the default text for standard error codes (blank if none)
 func GetDefaultError(code int) string {
 	if s, ok := defaultErrors[code]; ok {
@@ -68,14 +102,14 @@ func handleResponse(res *http.Response, creds auth.Creds) error {
 		res.Body.Close()
 	}()
 
-	cliErr := &ClientError{}
-	err := DecodeResponse(res, cliErr)
-	if err == nil {
-		if len(cliErr.Message) == 0 {
-			err = defaultError(res)
-		} else {
-			err = errutil.Error(cliErr)
-		}
+	var err error
+	if cliErr := DecodeErrorResponse(res); cliErr != nil && len(cliErr.Message) > 0 {
+		err = errutil.Error(cliErr)
+	} else if cliErr != nil && len(cliErr.RequestId) > 0 {
+		cliErr.Message = defaultError(res).Error()
+		err = errutil.Error(cliErr)
+	} else {
+		err = defaultError(res)
 	}
 
 	if res.StatusCode == 401 {
@@ -89,6 +123,31 @@ func handleResponse(res *http.Response, creds auth.Creds) error {
 	return err
 }
 
+// DecodeErrorResponse returns the message and request ID in the JSON body of
+// an error response, or nil if it doesn't have a JSON body which can be
+// parsed. The message of an api.ObjectError is read the same way. Only the
+// first 8KB of the body are read, so a longer body isn't parsed.
+func DecodeErrorResponse(res *http.Response) *ClientError {
+	ctype := res.Header.Get("Content-Type")
+	if !(lfsMediaTypeRE.MatchString(ctype) || jsonMediaTypeRE.MatchString(ctype)) {
+		return nil
+	}
+
+	by, err := ioutil.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+	if err != nil {
+		tracerx.Printf("http: unable to read HTTP %d response body: %s", res.StatusCode, err)
+		return nil
+	}
+
+	cliErr := &ClientError{}
+	if err := json.Unmarshal(by, cliErr); err != nil {
+		tracerx.Printf("http: unable to parse HTTP %d response body: %s", res.StatusCode, err)
+		return nil
+	}
+
+	return cliErr
+}
+
 func defaultError(res *http.Response) error {
 	var msgFmt string
 
@@ -118,7 +177,7 @@ func setErrorRequestContext(err error, req *http.Request) {
 func setErrorHeaderContext(err error, prefix string, head http.Header) {
 	for key, _ := range head {
 		contextKey := fmt.Sprintf("%s:%s", prefix, key)
-		if _, skip := hiddenHeaders[key]; skip {
+		if isRedactedHeader(key) {
 			errutil.ErrorSetContext(err, contextKey, "--")
 		} else {
 			errutil.ErrorSetContext(err, contextKey, head.Get(key))
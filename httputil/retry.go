@@ -0,0 +1,171 @@
+package httputil
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/github/git-lfs/config"
+	"github.com/rubyist/tracerx"
+)
+
+var (
+	// requestRetryDelay is how long to wait before the first retry of a
+	// failed request. The wait doubles for each retry after that, up to
+	// requestRetryMaxDelay, before jitter is applied.
+	requestRetryDelay    = 250 * time.Millisecond
+	requestRetryMaxDelay = 8 * time.Second
+
+	retryPolicy      RetryPolicy = DefaultRetryPolicy
+	retryPolicyMutex sync.Mutex
+)
+
+// RetryPolicy decides whether a failed request is sent again. res is the
+// response to it, which has a StatusCode of 0 if none was received, and err is
+// the error it failed with. Code that wishes to retry other requests should
+// pass an implementation of this function to SetRetryPolicy.
+type RetryPolicy func(req *http.Request, res *http.Response, err error) bool
+
+// SetRetryPolicy sets the policy which DoHttpRequest and
+// DoHttpRequestWithRedirects use to decide which failed requests to retry, up
+// to lfs.transfer.maxretries times, and returns the previous policy.
+func SetRetryPolicy(p RetryPolicy) RetryPolicy {
+	retryPolicyMutex.Lock()
+	defer retryPolicyMutex.Unlock()
+
+	prev := retryPolicy
+	retryPolicy = p
+	return prev
+}
+
+func currentRetryPolicy() RetryPolicy {
+	retryPolicyMutex.Lock()
+	defer retryPolicyMutex.Unlock()
+
+	return retryPolicy
+}
+
+// DefaultRetryPolicy retries requests which are safe to send again: GET and
+// HEAD requests, and PUT requests whose body can be rewound. They're retried
+// if no response was received, such as when the connection was reset, or if
+// the server responded 429, 500, 502, 503 or 504.
+func DefaultRetryPolicy(req *http.Request, res *http.Response, err error) bool {
+	switch req.Method {
+	case "GET", "HEAD":
+	case "PUT":
+		if req.Body != nil && bodySeeker(req) == nil {
+			return false
+		}
+	default:
+		return false
+	}
+
+	if res == nil {
+		return true
+	}
+
+	switch res.StatusCode {
+	case 0, 429, 500, 502, 503, 504:
+		return true
+	}
+	return false
+}
+
+// doHttpRequestWithRetries sends req, and retries it up to
+// lfs.transfer.maxretries times if it fails and the retry policy allows,
+// waiting with exponential backoff, or for as long as a 429 or 503 response's
+// Retry-After header asks, in between. The last attempt's response and error
+// are returned.
+func doHttpRequestWithRetries(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	retries := config.Config.TransferMaxRetries()
+
+	var b *backoff
+	for i := 1; ; i++ {
+		res, err := send(req)
+		if err == nil || i > retries || !currentRetryPolicy()(req, res, err) {
+			return res, err
+		}
+
+		if !rewindBody(req) {
+			return res, err
+		}
+
+		if b == nil {
+			b = newBackoff(config.Config.TransferJitter(), requestRetryDelay, requestRetryMaxDelay)
+		}
+
+		delay := b.Next()
+		if after := retryAfter(res, time.Now()); after > 0 {
+			delay = after
+		}
+
+		tracerx.Printf("http: %s failed, retry %d of %d in %s: %s", TraceHttpReq(req), i, retries, delay, err)
+		retrySleep(delay)
+	}
+}
+
+// retrySleep waits between retries. Tests replace it to avoid waiting.
+var retrySleep = time.Sleep
+
+// bodySeeker returns the seeker of req's body, unwrapping the
+// CountingReadCloser that HttpClient wraps it in, or nil if it can't seek.
+func bodySeeker(req *http.Request) io.Seeker {
+	body := req.Body
+	if counting, ok := body.(*CountingReadCloser); ok {
+		body = counting.ReadCloser
+	}
+
+	seeker, _ := body.(io.Seeker)
+	return seeker
+}
+
+// rewindBody seeks req's body back to its start, so that it can be sent
+// again, and returns whether it could.
+func rewindBody(req *http.Request) bool {
+	if req.Body == nil {
+		return true
+	}
+
+	seeker := bodySeeker(req)
+	if seeker == nil {
+		return false
+	}
+
+	if _, err := seeker.Seek(0, 0); err != nil {
+		tracerx.Printf("http: can't rewind the body of %s to retry it: %s", TraceHttpReq(req), err)
+		return false
+	}
+
+	if counting, ok := req.Body.(*CountingReadCloser); ok {
+		req.Body = counting.ReadCloser
+	}
+	return true
+}
+
+// retryAfter returns how long a 429 or 503 response's Retry-After header, in
+// seconds or as an HTTP date, asks the client to wait at now, or 0 if it
+// doesn't ask.
+func retryAfter(res *http.Response, now time.Time) time.Duration {
+	if res == nil || (res.StatusCode != 429 && res.StatusCode != 503) {
+		return 0
+	}
+
+	value := res.Header.Get("Retry-After")
+	if len(value) == 0 {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
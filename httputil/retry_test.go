@@ -0,0 +1,210 @@
+package httputil
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/github/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// seekableBody is a request body which can be rewound.
+type seekableBody struct {
+	*bytes.Reader
+}
+
+func (b *seekableBody) Close() error {
+	return nil
+}
+
+// retryTestServer serves requests with the given status codes in turn, the
+// last one from then on, and records the bodies it's sent.
+func retryTestServer(statuses ...int) (*httptest.Server, *int32, *[]string) {
+	var calls int32
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		by, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(by))
+
+		n := int(atomic.AddInt32(&calls, 1))
+		if n > len(statuses) {
+			n = len(statuses)
+		}
+
+		status := statuses[n-1]
+		if status == 503 {
+			w.Header().Set("Retry-After", "7")
+		}
+		w.WriteHeader(status)
+	}))
+	return srv, &calls, &bodies
+}
+
+func recordRetrySleeps() (*[]time.Duration, func()) {
+	var sleeps []time.Duration
+	orig := retrySleep
+	retrySleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+	}
+	return &sleeps, func() {
+		retrySleep = orig
+	}
+}
+
+func TestDoHttpRequestRetriesGet(t *testing.T) {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.transfer.maxretries", "2")
+	sleeps, restore := recordRetrySleeps()
+	defer restore()
+
+	srv, calls, _ := retryTestServer(500, 502, 200)
+	defer srv.Close()
+
+	req, _ := NewHttpRequest("GET", srv.URL+"/obj", nil)
+	res, err := DoHttpRequest(req, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, int32(3), *calls)
+	assert.Equal(t, []time.Duration{250 * time.Millisecond, 500 * time.Millisecond}, *sleeps)
+}
+
+func TestDoHttpRequestReturnsLastErrorAfterRetries(t *testing.T) {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.transfer.maxretries", "2")
+	_, restore := recordRetrySleeps()
+	defer restore()
+
+	srv, calls, _ := retryTestServer(500)
+	defer srv.Close()
+
+	req, _ := NewHttpRequest("GET", srv.URL+"/obj", nil)
+	res, err := DoHttpRequest(req, false)
+	assert.NotNil(t, err)
+	assert.Equal(t, 500, res.StatusCode)
+	assert.Equal(t, int32(3), *calls)
+}
+
+func TestDoHttpRequestDoesNotRetryByDefault(t *testing.T) {
+	srv, calls, _ := retryTestServer(500, 200)
+	defer srv.Close()
+
+	req, _ := NewHttpRequest("GET", srv.URL+"/obj", nil)
+	_, err := DoHttpRequest(req, false)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), *calls)
+}
+
+func TestDoHttpRequestDoesNotRetryClientErrors(t *testing.T) {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.transfer.maxretries", "2")
+
+	srv, calls, _ := retryTestServer(404, 200)
+	defer srv.Close()
+
+	req, _ := NewHttpRequest("GET", srv.URL+"/obj", nil)
+	_, err := DoHttpRequest(req, false)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), *calls)
+}
+
+func TestDoHttpRequestRetriesPutWithSeekableBody(t *testing.T) {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.transfer.maxretries", "2")
+	_, restore := recordRetrySleeps()
+	defer restore()
+
+	srv, calls, bodies := retryTestServer(500, 200)
+	defer srv.Close()
+
+	req, _ := NewHttpRequest("PUT", srv.URL+"/obj", nil)
+	req.Body = &seekableBody{bytes.NewReader([]byte("content"))}
+	req.ContentLength = 7
+
+	res, err := DoHttpRequest(req, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, int32(2), *calls)
+	assert.Equal(t, []string{"content", "content"}, *bodies)
+}
+
+func TestDoHttpRequestDoesNotRetryUnseekableBodies(t *testing.T) {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.transfer.maxretries", "2")
+
+	srv, calls, _ := retryTestServer(500, 500, 200)
+	defer srv.Close()
+
+	req, _ := NewHttpRequest("PUT", srv.URL+"/obj", nil)
+	req.Body = ioutil.NopCloser(strings.NewReader("content"))
+	req.ContentLength = 7
+
+	_, err := DoHttpRequest(req, false)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), *calls)
+
+	req, _ = NewHttpRequest("POST", srv.URL+"/objects/batch", nil)
+	_, err = DoHttpRequest(req, false)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(2), *calls)
+}
+
+func TestDoHttpRequestHonorsRetryAfter(t *testing.T) {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.transfer.maxretries", "1")
+	sleeps, restore := recordRetrySleeps()
+	defer restore()
+
+	srv, calls, _ := retryTestServer(503, 200)
+	defer srv.Close()
+
+	req, _ := NewHttpRequest("GET", srv.URL+"/obj", nil)
+	_, err := DoHttpRequest(req, false)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), *calls)
+	assert.Equal(t, []time.Duration{7 * time.Second}, *sleeps)
+}
+
+func TestSetRetryPolicy(t *testing.T) {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.transfer.maxretries", "1")
+	_, restore := recordRetrySleeps()
+	defer restore()
+
+	prev := SetRetryPolicy(func(req *http.Request, res *http.Response, err error) bool {
+		return req.Method == "POST"
+	})
+	defer SetRetryPolicy(prev)
+
+	srv, calls, _ := retryTestServer(500, 200)
+	defer srv.Close()
+
+	req, _ := NewHttpRequest("POST", srv.URL+"/objects/batch", nil)
+	_, err := DoHttpRequest(req, false)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), *calls)
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2016, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(status int, value string) time.Duration {
+		res := &http.Response{StatusCode: status, Header: make(http.Header)}
+		res.Header.Set("Retry-After", value)
+		return retryAfter(res, now)
+	}
+
+	assert.Equal(t, 30*time.Second, at(429, "30"))
+	assert.Equal(t, 30*time.Second, at(503, "30"))
+	assert.Equal(t, 90*time.Second, at(503, "Wed, 01 Jun 2016 12:01:30 GMT"))
+	assert.Equal(t, time.Duration(0), at(503, "Wed, 01 Jun 2016 11:59:00 GMT"))
+	assert.Equal(t, time.Duration(0), at(503, "soon"))
+	assert.Equal(t, time.Duration(0), at(503, "-5"))
+	assert.Equal(t, time.Duration(0), at(500, "30"))
+	assert.Equal(t, time.Duration(0), at(429, ""))
+	assert.Equal(t, time.Duration(0), retryAfter(nil, now))
+}
@@ -0,0 +1,42 @@
+package httputil
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// activityReader wraps a response body, and cancels its request by closing
+// cancel, the request's Cancel channel, if a read doesn't return within the
+// timeout. Waiting for the response headers is covered by the transport's
+// ResponseHeaderTimeout.
+type activityReader struct {
+	io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+func newActivityReader(body io.ReadCloser, timeout time.Duration, cancel chan struct{}) *activityReader {
+	var once sync.Once
+	timer := time.AfterFunc(timeout, func() {
+		once.Do(func() { close(cancel) })
+	})
+	timer.Stop()
+
+	return &activityReader{ReadCloser: body, timeout: timeout, timer: timer}
+}
+
+func (r *activityReader) Read(p []byte) (int, error) {
+	r.timer.Reset(r.timeout)
+	n, err := r.ReadCloser.Read(p)
+	if expired := !r.timer.Stop(); expired && err != nil && err != io.EOF {
+		err = fmt.Errorf("No data received for %s, see lfs.activitytimeout: %v", r.timeout, err)
+	}
+	return n, err
+}
+
+func (r *activityReader) Close() error {
+	r.timer.Stop()
+	return r.ReadCloser.Close()
+}
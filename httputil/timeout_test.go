@@ -0,0 +1,102 @@
+package httputil
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// stallingServer starts a server which sends body, then stalls until the
+// returned func is called.
+func stallingServer(body string) (*httptest.Server, func()) {
+	stall := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.Write([]byte(body))
+		w.(http.Flusher).Flush()
+		<-stall
+	}))
+	return srv, func() {
+		close(stall)
+		srv.Close()
+	}
+}
+
+func TestActivityTimeoutCancelsStalledBody(t *testing.T) {
+	srv, stop := stallingServer("partial")
+	defer stop()
+
+	client := &HttpClient{Client: &http.Client{}, activityTimeout: 100 * time.Millisecond}
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := client.Do(req)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer res.Body.Close()
+
+	start := time.Now()
+	by, err := ioutil.ReadAll(res.Body)
+	assert.Equal(t, "partial", string(by))
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "lfs.activitytimeout")
+	}
+	assert.True(t, time.Since(start) < 5*time.Second, "read took %s", time.Since(start))
+}
+
+func TestActivityTimeoutAllowsSteadyBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 4; i++ {
+			w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+
+	client := &HttpClient{Client: &http.Client{}, activityTimeout: 150 * time.Millisecond}
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := client.Do(req)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer res.Body.Close()
+
+	by, err := ioutil.ReadAll(res.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "chunkchunkchunkchunk", string(by))
+}
+
+func TestActivityTimeoutCancelsStalledResponse(t *testing.T) {
+	stall := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stall
+	}))
+	defer srv.Close()
+	defer close(stall)
+
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.activitytimeout", "1")
+
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	_, err = NewHttpClient(config.Config, req.Host).Do(req)
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(start) < 5*time.Second, "request took %s", time.Since(start))
+}
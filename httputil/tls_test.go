@@ -0,0 +1,175 @@
+package httputil
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/github/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTLS10Server() *httptest.Server {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	srv.TLS = &tls.Config{
+		MinVersion: tls.VersionTLS10,
+		MaxVersion: tls.VersionTLS10,
+	}
+	srv.StartTLS()
+	return srv
+}
+
+func getWithTLSConfig(t *testing.T, srv *httptest.Server, settings map[string]string) error {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("http.sslverify", "false")
+	for key, value := range settings {
+		config.Config.SetConfig(key, value)
+	}
+
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := NewHttpClient(config.Config, req.Host).Do(req)
+	if err == nil {
+		res.Body.Close()
+	}
+	return err
+}
+
+func TestTLSMinVersionRejectsOlderServer(t *testing.T) {
+	srv := newTLS10Server()
+	defer srv.Close()
+
+	err := getWithTLSConfig(t, srv, map[string]string{"lfs.tls.minversion": "1.2"})
+	assert.NotNil(t, err)
+}
+
+func TestTLSMinVersionAllowsServer(t *testing.T) {
+	srv := newTLS10Server()
+	defer srv.Close()
+
+	err := getWithTLSConfig(t, srv, map[string]string{"lfs.tls.minversion": "1.0"})
+	assert.Nil(t, err)
+}
+
+func TestTLSCipherSuitesRejectsServer(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	srv.TLS = &tls.Config{
+		MaxVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA},
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	err := getWithTLSConfig(t, srv, map[string]string{"lfs.tls.ciphers": "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"})
+	assert.NotNil(t, err)
+}
+
+func TestInvalidTLSConfigFailsRequests(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	err := getWithTLSConfig(t, srv, map[string]string{"lfs.tls.minversion": "ssl3"})
+	if assert.NotNil(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "lfs.tls.minversion"), err.Error())
+	}
+}
+
+// resumedRequests makes count requests to srv, each on a new connection, and
+// returns whether each one resumed a TLS session.
+func resumedRequests(t *testing.T, srv *httptest.Server, settings map[string]string, count int) []bool {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("http.sslverify", "false")
+	for key, value := range settings {
+		config.Config.SetConfig(key, value)
+	}
+
+	tlsSessionCache = nil
+	tlsSessionCacheOnce = sync.Once{}
+	defer func() { tlsSessionCacheOnce = sync.Once{} }()
+
+	resumed := make([]bool, 0, count)
+	for i := 0; i < count; i++ {
+		req, err := http.NewRequest("GET", srv.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Close = true
+
+		res, err := NewHttpClient(config.Config, req.Host).Do(req)
+		if !assert.Nil(t, err) {
+			break
+		}
+		res.Body.Close()
+		resumed = append(resumed, res.TLS.DidResume)
+	}
+	return resumed
+}
+
+func TestTLSSessionResumption(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	resumed := resumedRequests(t, srv, nil, 3)
+	assert.Equal(t, []bool{false, true, true}, resumed)
+}
+
+func TestTLSSessionResumptionDisabled(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	resumed := resumedRequests(t, srv, map[string]string{"lfs.tls.sessioncache": "0"}, 2)
+	assert.Equal(t, []bool{false, false}, resumed)
+}
+
+func TestWarmConnection(t *testing.T) {
+	var conns, heads int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			atomic.AddInt32(&heads, 1)
+			assert.Equal(t, "", r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(200)
+	}))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("http.sslverify", "false")
+
+	WarmConnection(srv.URL + "/repo.git/info/lfs")
+
+	req, err := http.NewRequest("POST", srv.URL+"/repo.git/info/lfs/objects/batch", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := NewHttpClient(config.Config, req.Host).Do(req)
+	if assert.Nil(t, err) {
+		res.Body.Close()
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&heads))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&conns), "the request reuses the warm connection")
+}
@@ -0,0 +1,146 @@
+package lfs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/github/git-lfs/tools"
+)
+
+// AnnexKey is a git-annex key, such as "SHA256E-s1024--<sha256>.bin", which
+// names an object by its backend, its size and, for hashing backends, the hash
+// of its content.
+type AnnexKey struct {
+	Name    string
+	Backend string
+	// Size is -1 if the key doesn't give one.
+	Size int64
+	// Hash is the key's name without any extension, which is the hash of
+	// the content for hashing backends such as SHA256 and SHA256E, and
+	// something else, such as a file name, for backends like WORM.
+	Hash string
+}
+
+// ParseAnnexKey parses a git-annex key, which has the form
+// BACKEND[-sSIZE][-mMTIME][-SCHUNKSIZE-CCHUNK]--NAME.
+func ParseAnnexKey(name string) (*AnnexKey, error) {
+	sep := strings.Index(name, "--")
+	if sep < 1 || sep+2 == len(name) {
+		return nil, fmt.Errorf("Invalid git-annex key: %q", name)
+	}
+
+	fields := strings.Split(name[:sep], "-")
+	key := &AnnexKey{Name: name, Backend: fields[0], Size: -1, Hash: name[sep+2:]}
+	for _, field := range fields[1:] {
+		if len(field) < 2 || field[0] != 's' {
+			continue
+		}
+
+		size, err := strconv.ParseInt(field[1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid size in git-annex key %q: %s", name, err)
+		}
+		key.Size = size
+	}
+
+	if strings.HasSuffix(key.Backend, "E") {
+		if dot := strings.Index(key.Hash, "."); dot > -1 {
+			key.Hash = key.Hash[:dot]
+		}
+	}
+	return key, nil
+}
+
+// Oid returns the Git LFS oid of the key's content if the key includes it, as
+// keys from the SHA256 and SHA256E backends do, or "" if it doesn't.
+func (k *AnnexKey) Oid() string {
+	switch k.Backend {
+	case "SHA256", "SHA256E":
+		return strings.ToLower(k.Hash)
+	}
+	return ""
+}
+
+// AnnexObject is an object file in a git-annex object directory.
+type AnnexObject struct {
+	Key  *AnnexKey
+	Path string
+}
+
+// ScanAnnexObjects returns the objects in a git-annex object directory, such
+// as .git/annex/objects, where each object is stored as <key>/<key> below one
+// or two levels of hash directories.
+func ScanAnnexObjects(dir string) ([]*AnnexObject, error) {
+	var objects []*AnnexObject
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() || filepath.Base(filepath.Dir(path)) != info.Name() {
+			return nil
+		}
+
+		key, err := ParseAnnexKey(info.Name())
+		if err != nil {
+			return nil
+		}
+
+		objects = append(objects, &AnnexObject{Key: key, Path: path})
+		return nil
+	})
+
+	return objects, err
+}
+
+// ImportAnnexObject copies the content of an annex object into the local
+// object store, and returns its pointer. The object isn't imported if its size
+// or content doesn't match its key.
+func ImportAnnexObject(obj *AnnexObject) (*Pointer, error) {
+	src, err := os.Open(obj.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	tmp, err := TempFile("annex")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	hasher := tools.NewHashingReader(src)
+	size, err := io.Copy(tmp, hasher)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if obj.Key.Size > -1 && size != obj.Key.Size {
+		return nil, fmt.Errorf("%s is %d bytes, but its key says %d bytes", obj.Path, size, obj.Key.Size)
+	}
+
+	oid := hasher.Hash()
+	if expected := obj.Key.Oid(); len(expected) > 0 && oid != expected {
+		return nil, fmt.Errorf("%s has oid %s, but its key says %s", obj.Path, oid, expected)
+	}
+
+	mediafile, err := LocalMediaPath(oid)
+	if err != nil {
+		return nil, err
+	}
+
+	if !tools.FileExistsOfSize(mediafile, size) {
+		if err := tools.RenameFileCopyPermissions(tmp.Name(), mediafile); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewPointer(oid, size, nil), nil
+}
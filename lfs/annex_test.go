@@ -0,0 +1,93 @@
+package lfs_test // avoid import cycle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/github/git-lfs/lfs"
+	"github.com/github/git-lfs/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAnnexKey(t *testing.T) {
+	oid := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	for name, expected := range map[string]lfs.AnnexKey{
+		"SHA256-s5--" + oid:                          {Backend: "SHA256", Size: 5, Hash: oid},
+		"SHA256E-s5--" + oid + ".txt":                {Backend: "SHA256E", Size: 5, Hash: oid},
+		"SHA256E-s5-m1461106800--" + oid + ".tar.gz": {Backend: "SHA256E", Size: 5, Hash: oid},
+		"WORM-s5-m1461106800--hello.txt":             {Backend: "WORM", Size: 5, Hash: "hello.txt"},
+		"URL--http&c%%example.com%a":                 {Backend: "URL", Size: -1, Hash: "http&c%%example.com%a"},
+	} {
+		key, err := lfs.ParseAnnexKey(name)
+		if assert.Nil(t, err, name) {
+			expected.Name = name
+			assert.Equal(t, expected, *key, name)
+		}
+	}
+
+	for _, name := range []string{"", "SHA256", "--" + oid, "SHA256--", "SHA256-sfive--" + oid} {
+		_, err := lfs.ParseAnnexKey(name)
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestImportAnnexObjects(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	objects := filepath.Join(repo.Path, ".git", "annex", "objects")
+	good := writeAnnexObject(t, objects, "SHA256E-s5--"+sha256hex("hello")+".txt", "hello")
+	worm := writeAnnexObject(t, objects, "WORM-s5-m1461106800--world.txt", "world")
+	bad := writeAnnexObject(t, objects, "SHA256-s7--"+sha256hex("corrupt"), "CORRUPT")
+	short := writeAnnexObject(t, objects, "SHA256-s9--"+sha256hex("truncated"), "trunc")
+
+	// files that aren't in a directory named after them are ignored
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(objects, "uuid.log"), []byte("annex"), 0644))
+
+	found, err := lfs.ScanAnnexObjects(objects)
+	assert.Nil(t, err)
+
+	imported := make(map[string]string)
+	failed := make(map[string]bool)
+	for _, obj := range found {
+		p, err := lfs.ImportAnnexObject(obj)
+		if err != nil {
+			failed[obj.Path] = true
+			continue
+		}
+
+		imported[obj.Path] = p.Oid
+		assert.Equal(t, int64(5), p.Size)
+		by, err := ioutil.ReadFile(lfs.LocalMediaPathReadOnly(p.Oid))
+		assert.Nil(t, err)
+		assert.Equal(t, p.Oid, sha256hex(string(by)))
+	}
+
+	assert.Equal(t, map[string]string{good: sha256hex("hello"), worm: sha256hex("world")}, imported)
+	assert.Equal(t, map[string]bool{bad: true, short: true}, failed)
+}
+
+// writeAnnexObject writes content to the object for key below objects, laid
+// out like a git-annex object directory.
+func writeAnnexObject(t *testing.T, objects, key, content string) string {
+	dir := filepath.Join(objects, "Xx", "Yy", key)
+	assert.Nil(t, os.MkdirAll(dir, 0755))
+
+	path := filepath.Join(dir, key)
+	assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0444))
+	return path
+}
+
+func sha256hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
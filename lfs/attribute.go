@@ -3,6 +3,7 @@ package lfs
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/github/git-lfs/git"
@@ -88,6 +89,84 @@ func (a *Attribute) set(key, value string, opt InstallOptions) error {
 	return nil
 }
 
+// Repair sets each property of this Attribute which is missing, repeated, or
+// set to a value that is neither its own nor the same property's in any of
+// alternatives, such as the filters which skip smudging. Values which run
+// git-lfs from a full path are accepted. If opt.Local is true, it changes the
+// local config instead, and only the properties set there. It returns a
+// description of each change it made.
+func (a *Attribute) Repair(opt InstallOptions, alternatives ...*Attribute) []string {
+	where := "global"
+	if opt.Local {
+		where = "local"
+	}
+
+	var changes []string
+	for _, k := range a.sortedKeys() {
+		key := a.normalizeKey(k)
+		want := a.Properties[k]
+
+		var values []string
+		if opt.Local {
+			values = git.Config.FindAllLocal(key)
+		} else {
+			values = git.Config.FindAllGlobal(key)
+		}
+
+		if opt.Local && len(values) == 0 {
+			continue
+		}
+
+		if len(values) == 1 && acceptsValue(values[0], a, alternatives, k) {
+			continue
+		}
+
+		if opt.Local {
+			git.Config.UnsetAllLocal(key)
+			git.Config.SetLocal("", key, want)
+		} else {
+			git.Config.UnsetAllGlobal(key)
+			git.Config.SetGlobal(key, want)
+		}
+
+		switch len(values) {
+		case 0:
+			changes = append(changes, fmt.Sprintf("Set %s to %q in the %s config.", key, want, where))
+		case 1:
+			changes = append(changes, fmt.Sprintf("Changed %s from %q to %q in the %s config.", key, values[0], want, where))
+		default:
+			changes = append(changes, fmt.Sprintf("Replaced %d values of %s with %q in the %s config.", len(values), key, want, where))
+		}
+	}
+
+	return changes
+}
+
+func (a *Attribute) sortedKeys() []string {
+	keys := make([]string, 0, len(a.Properties))
+	for k := range a.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// acceptsValue returns whether value is what a, or any of alternatives, sets
+// the property k to, possibly with a full path to git-lfs.
+func acceptsValue(value string, a *Attribute, alternatives []*Attribute, k string) bool {
+	for _, attr := range append([]*Attribute{a}, alternatives...) {
+		want, ok := attr.Properties[k]
+		if !ok {
+			continue
+		}
+
+		if value == want || strings.HasSuffix(value, "/"+want) || strings.HasSuffix(value, "\\"+want) {
+			return true
+		}
+	}
+	return false
+}
+
 // Uninstall removes all properties in the path of this property.
 func (a *Attribute) Uninstall() {
 	git.Config.UnsetGlobalSection(a.Section)
@@ -0,0 +1,124 @@
+package lfs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/github/git-lfs/config"
+)
+
+// CachePolicy is the caching policy an object's download was served with, from
+// the Cache-Control and Expires response headers. Objects are immutable by oid,
+// so the policy can't change their content, but proxies in front of a server
+// may use it to say how long they vouch for an object. Policies are recorded
+// when lfs.cachecontrol is "honor".
+type CachePolicy struct {
+	CacheControl string    `json:"cache_control,omitempty"`
+	Expires      string    `json:"expires,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// NewCachePolicy returns the caching policy from header, a download response's
+// headers, for a download at fetchedAt. It returns nil if header has no
+// caching headers.
+func NewCachePolicy(header http.Header, fetchedAt time.Time) *CachePolicy {
+	cc := header.Get("Cache-Control")
+	expires := header.Get("Expires")
+	if len(cc) == 0 && len(expires) == 0 {
+		return nil
+	}
+
+	return &CachePolicy{CacheControl: cc, Expires: expires, FetchedAt: fetchedAt}
+}
+
+// NeedsRevalidation returns whether the local copy of an object downloaded
+// with this policy should be checked with the server again at now, rather
+// than trusted. Following HTTP caching rules, "immutable" is always trusted,
+// "no-cache" and "no-store" never are, and otherwise the copy is trusted for
+// max-age seconds, or until the Expires time. An invalid Expires time has
+// already passed. Without either, the copy is trusted.
+func (p *CachePolicy) NeedsRevalidation(now time.Time) bool {
+	maxAge := -1
+	for _, directive := range strings.Split(strings.ToLower(p.CacheControl), ",") {
+		name, value := directive, ""
+		if eq := strings.Index(directive, "="); eq > -1 {
+			name, value = directive[:eq], strings.Trim(strings.TrimSpace(directive[eq+1:]), `"`)
+		}
+
+		switch strings.TrimSpace(name) {
+		case "immutable":
+			return false
+		case "no-cache", "no-store":
+			return true
+		case "max-age":
+			if n, err := strconv.Atoi(value); err == nil {
+				maxAge = n
+			} else {
+				maxAge = 0
+			}
+		}
+	}
+
+	if maxAge > -1 {
+		return now.After(p.FetchedAt.Add(time.Duration(maxAge) * time.Second))
+	}
+
+	if len(p.Expires) > 0 {
+		expires, err := http.ParseTime(p.Expires)
+		return err != nil || !now.Before(expires)
+	}
+
+	return false
+}
+
+// CachePolicyPath returns the path of the file recording the caching policy of
+// the object for oid.
+func CachePolicyPath(oid string) string {
+	return filepath.Join(config.LocalGitStorageDir, "lfs", "cachecontrol", oid[0:2], oid[2:4], oid)
+}
+
+// RecordCachePolicy records the caching policy of the object for oid, or
+// removes any earlier record if p is nil.
+func RecordCachePolicy(oid string, p *CachePolicy) error {
+	path := CachePolicyPath(oid)
+	if p == nil {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	by, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, by, 0644)
+}
+
+// LoadCachePolicy returns the recorded caching policy of the object for oid, or
+// nil if none was recorded.
+func LoadCachePolicy(oid string) (*CachePolicy, error) {
+	by, err := ioutil.ReadFile(CachePolicyPath(oid))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := &CachePolicy{}
+	if err := json.Unmarshal(by, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
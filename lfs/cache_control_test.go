@@ -0,0 +1,98 @@
+package lfs_test // avoid import cycle
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/github/git-lfs/lfs"
+	"github.com/github/git-lfs/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCachePolicy(t *testing.T) {
+	fetchedAt := time.Date(2016, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Nil(t, lfs.NewCachePolicy(http.Header{}, fetchedAt))
+	assert.Nil(t, lfs.NewCachePolicy(http.Header{"Content-Type": {"application/octet-stream"}}, fetchedAt))
+
+	p := lfs.NewCachePolicy(http.Header{
+		"Cache-Control": {"max-age=60"},
+		"Expires":       {"Sun, 01 May 2016 13:00:00 GMT"},
+	}, fetchedAt)
+	if assert.NotNil(t, p) {
+		assert.Equal(t, "max-age=60", p.CacheControl)
+		assert.Equal(t, "Sun, 01 May 2016 13:00:00 GMT", p.Expires)
+		assert.Equal(t, fetchedAt, p.FetchedAt)
+	}
+}
+
+func TestCachePolicyNeedsRevalidation(t *testing.T) {
+	fetchedAt := time.Date(2016, 5, 1, 12, 0, 0, 0, time.UTC)
+	soon := fetchedAt.Add(30 * time.Second)
+	later := fetchedAt.Add(2 * time.Hour)
+
+	tests := []struct {
+		CacheControl string
+		Expires      string
+		At           time.Time
+		Expected     bool
+	}{
+		{"", "", later, false},
+		{"max-age=60", "", soon, false},
+		{"max-age=60", "", later, true},
+		{"public, max-age=\"60\"", "", soon, false},
+		{"max-age=0", "", soon, true},
+		{"max-age=soon", "", soon, true},
+		{"MAX-AGE=3600, must-revalidate", "", soon, false},
+		{"no-cache", "", soon, true},
+		{"private, no-store", "", soon, true},
+		{"immutable, max-age=60", "", later, false},
+		{"public, immutable", "", later, false},
+		{"", "Sun, 01 May 2016 13:00:00 GMT", soon, false},
+		{"", "Sun, 01 May 2016 13:00:00 GMT", later, true},
+		{"", "0", soon, true},
+		{"max-age=86400", "Sun, 01 May 2016 12:00:10 GMT", later, false},
+		{"public", "", later, false},
+	}
+
+	for _, tt := range tests {
+		p := &lfs.CachePolicy{CacheControl: tt.CacheControl, Expires: tt.Expires, FetchedAt: fetchedAt}
+		assert.Equal(t, tt.Expected, p.NeedsRevalidation(tt.At), "Cache-Control %q, Expires %q at %s", tt.CacheControl, tt.Expires, tt.At)
+	}
+}
+
+func TestRecordCachePolicy(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	oid := "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+
+	p, err := lfs.LoadCachePolicy(oid)
+	assert.Nil(t, err)
+	assert.Nil(t, p)
+
+	fetchedAt := time.Date(2016, 5, 1, 12, 0, 0, 0, time.UTC)
+	recorded := &lfs.CachePolicy{CacheControl: "max-age=60", FetchedAt: fetchedAt}
+	assert.Nil(t, lfs.RecordCachePolicy(oid, recorded))
+
+	p, err = lfs.LoadCachePolicy(oid)
+	assert.Nil(t, err)
+	if assert.NotNil(t, p) {
+		assert.Equal(t, "max-age=60", p.CacheControl)
+		assert.Equal(t, "", p.Expires)
+		assert.True(t, fetchedAt.Equal(p.FetchedAt))
+	}
+
+	assert.Nil(t, lfs.RecordCachePolicy(oid, nil))
+	p, err = lfs.LoadCachePolicy(oid)
+	assert.Nil(t, err)
+	assert.Nil(t, p)
+
+	// removing a missing record isn't an error
+	assert.Nil(t, lfs.RecordCachePolicy(oid, nil))
+}
@@ -0,0 +1,52 @@
+package lfs
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/httputil"
+	"github.com/rubyist/tracerx"
+)
+
+// ObjectOnCDN returns whether the CDN set by lfs.cdn.url has the object for
+// oid, by sending a HEAD request for <url>/<oid>. The object only counts as
+// being there if the CDN responds 200, with a Content-Length of size if it
+// sends one. It returns false if there's no CDN.
+func ObjectOnCDN(oid string, size int64) (bool, error) {
+	base := config.Config.CDNUrl()
+	if base == "" {
+		return false, nil
+	}
+
+	req, err := httputil.NewHttpRequest("HEAD", fmt.Sprintf("%s/%s", base, oid), nil)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := httputil.DoHttpRequest(req, false)
+	if res != nil && res.Body != nil {
+		io.Copy(ioutil.Discard, res.Body)
+		res.Body.Close()
+	}
+	if res != nil && res.StatusCode == 404 {
+		tracerx.Printf("cdn: %s not found", oid)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if res.StatusCode != 200 {
+		tracerx.Printf("cdn: %s responded %d", oid, res.StatusCode)
+		return false, nil
+	}
+	if res.ContentLength >= 0 && res.ContentLength != size {
+		tracerx.Printf("cdn: %s is %d bytes, expected %d", oid, res.ContentLength, size)
+		return false, nil
+	}
+
+	tracerx.Printf("cdn: found %s", oid)
+	return true, nil
+}
@@ -0,0 +1,69 @@
+package lfs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/github/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCDNServer(sizes map[string]int64) (*httptest.Server, *[]string) {
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		size, ok := sizes[r.URL.Path[len("/lfs/"):]]
+		if !ok {
+			w.WriteHeader(404)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		w.WriteHeader(200)
+	}))
+	return srv, &methods
+}
+
+func TestObjectOnCDN(t *testing.T) {
+	srv, methods := newCDNServer(map[string]int64{"aaaa": 4, "bbbb": 10})
+	defer srv.Close()
+
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.cdn.url", srv.URL+"/lfs/")
+
+	ok, err := ObjectOnCDN("aaaa", 4)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = ObjectOnCDN("bbbb", 4)
+	assert.Nil(t, err)
+	assert.False(t, ok, "size doesn't match")
+
+	ok, err = ObjectOnCDN("cccc", 4)
+	assert.Nil(t, err)
+	assert.False(t, ok, "not on the CDN")
+
+	assert.Equal(t, []string{"HEAD", "HEAD", "HEAD"}, *methods)
+}
+
+func TestObjectOnCDNWithoutCDN(t *testing.T) {
+	defer config.Config.ResetConfig()
+
+	ok, err := ObjectOnCDN("aaaa", 4)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestObjectOnCDNServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+	}))
+	defer srv.Close()
+
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.cdn.url", srv.URL)
+
+	ok, _ := ObjectOnCDN("aaaa", 4)
+	assert.False(t, ok)
+}
@@ -18,8 +18,10 @@ func (d *Downloadable) Oid() string {
 	return d.pointer.Oid
 }
 
+// Size returns the size of the object, or 0 if its pointer has an
+// UnknownSize, which leaves the server to fill it in.
 func (d *Downloadable) Size() int64 {
-	return d.pointer.Size
+	return KnownSize(d.pointer.Size)
 }
 
 func (d *Downloadable) Name() string {
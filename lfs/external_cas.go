@@ -0,0 +1,90 @@
+package lfs
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/subprocess"
+	"github.com/github/git-lfs/tools"
+	"github.com/rubyist/tracerx"
+)
+
+// StoreInExternalCAS hands the downloaded object for oid at path to the
+// external content-addressed store set by lfs.storage.externalcas, by running
+// `<command> store <oid> <path>`. The command must copy the file rather than
+// move or change it. It does nothing if there's no external store.
+func StoreInExternalCAS(oid, path string) error {
+	if _, err := runExternalCAS("store", oid, path); err != nil {
+		return err
+	}
+
+	tracerx.Printf("cas: stored %s", oid)
+	return nil
+}
+
+// copyFromExternalCAS copies the object for oid into mediafile from the
+// external content-addressed store, by running `<command> retrieve <oid>
+// <path>`, which writes the object to path or exits non-zero if the store
+// doesn't have it. The object is only used if its content matches oid, so a
+// bad copy in the store is downloaded instead.
+func copyFromExternalCAS(oid string, size int64, mediafile string) error {
+	if len(config.Config.ExternalCAS()) == 0 {
+		return nil
+	}
+
+	tmp, err := TempFile("cas")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if ran, err := runExternalCAS("retrieve", oid, tmp.Name()); !ran || err != nil {
+		return err
+	}
+
+	f, err := os.Open(tmp.Name())
+	if err != nil {
+		return err
+	}
+
+	verifier := tools.NewVerifyingReader(f, oid, nil)
+	n, err := io.Copy(ioutil.Discard, verifier)
+	f.Close()
+	if _, ok := err.(*tools.OidMismatchError); ok || (err == nil && n != size) {
+		tracerx.Printf("cas: retrieved %d bytes with oid %s for %s, ignoring them", n, verifier.Hash(), oid)
+		return fmt.Errorf("Object %s from the external store is corrupt", oid)
+	}
+	if err != nil {
+		return err
+	}
+
+	tracerx.Printf("cas: retrieved %s", oid)
+	return tools.RenameFileCopyPermissions(tmp.Name(), mediafile)
+}
+
+// runExternalCAS runs the external store's command for action, and returns
+// whether it ran. A retrieval that exits non-zero means the store doesn't have
+// the object, and isn't an error.
+func runExternalCAS(action, oid, path string) (bool, error) {
+	pieces := strings.Fields(config.Config.ExternalCAS())
+	if len(pieces) == 0 {
+		return false, nil
+	}
+
+	args := append(pieces[1:], action, oid, path)
+	tracerx.Printf("run_command: '%s' %s", pieces[0], strings.Join(args, " "))
+	output, err := subprocess.ExecCommand(pieces[0], args...).CombinedOutput()
+	if err != nil {
+		if action == "retrieve" {
+			tracerx.Printf("cas: %s isn't in the external store: %s", oid, strings.TrimSpace(string(output)))
+			return false, nil
+		}
+		return true, fmt.Errorf("Error running the external store to %s %s: %s %s", action, oid, err, strings.TrimSpace(string(output)))
+	}
+	return true, nil
+}
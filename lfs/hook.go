@@ -72,6 +72,58 @@ func (h *Hook) Upgrade() error {
 	return h.write()
 }
 
+// Repair installs this hook if it's missing, upgrades it if it's a past
+// version, and makes it executable if it isn't, returning a description of
+// what it fixed, or "" if nothing needed fixing. A hook with other contents is
+// left alone unless force is true, and returns an error if it doesn't run
+// `git lfs <type>`.
+func (h *Hook) Repair(force bool) (string, error) {
+	stat, err := os.Stat(h.Path())
+	if os.IsNotExist(err) {
+		if err := h.Install(false); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Installed the %s hook.", h.Type), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	by, err := ioutil.ReadFile(h.Path())
+	if err != nil {
+		return "", err
+	}
+
+	contents := strings.TrimSpace(string(by))
+	if contents != h.Contents {
+		if upgradeable, _ := h.matchesCurrent(); upgradeable || force {
+			if err := h.write(); err != nil {
+				return "", err
+			}
+			if err := os.Chmod(h.Path(), 0755); err != nil {
+				return "", err
+			}
+			if upgradeable {
+				return fmt.Sprintf("Upgraded the %s hook.", h.Type), nil
+			}
+			return fmt.Sprintf("Overwrote the %s hook.", h.Type), nil
+		}
+
+		if !strings.Contains(contents, "git lfs "+h.Type) {
+			return "", fmt.Errorf("The %s hook doesn't run `git lfs %s`.", h.Type, h.Type)
+		}
+	}
+
+	if stat.Mode()&0111 == 0 {
+		if err := os.Chmod(h.Path(), stat.Mode()|0755); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Made the %s hook executable.", h.Type), nil
+	}
+
+	return "", nil
+}
+
 // Uninstall removes the hook on disk so long as it matches the current version,
 // or any of the past versions of this hook.
 func (h *Hook) Uninstall() error {
@@ -60,8 +60,15 @@ func LocalReferencePath(sha string) string {
 	return filepath.Join(config.LocalReferenceDir, sha[0:2], sha[2:4], sha)
 }
 
+// ObjectExistsOfSize returns whether the local store has the object for oid
+// with the given size. Any non-empty object will do for an UnknownSize, since
+// objects are only stored once their content has been verified.
 func ObjectExistsOfSize(oid string, size int64) bool {
 	path := localstorage.Objects().ObjectPath(oid)
+	if size == UnknownSize {
+		fi, err := os.Stat(path)
+		return err == nil && !fi.IsDir() && fi.Size() > 0
+	}
 	return tools.FileExistsOfSize(path, size)
 }
 
@@ -141,8 +148,14 @@ func AllObjects() []localstorage.Object {
 	return localstorage.Objects().AllObjects()
 }
 
+// LinkOrCopyFromReference puts the object for oid in the local store from the
+// clone reference repository, or failing that from the read-only mirror or the
+// external content-addressed store, if any of them has it, so that it doesn't
+// need to be downloaded.
 func LinkOrCopyFromReference(oid string, size int64) error {
-	if ObjectExistsOfSize(oid, size) {
+	// the copies can't be checked without the size, so the object is
+	// downloaded instead
+	if ObjectExistsOfSize(oid, size) || size == UnknownSize || localstorage.Objects().ReadOnly() {
 		return nil
 	}
 	altMediafile := LocalReferencePath(oid)
@@ -153,5 +166,8 @@ func LinkOrCopyFromReference(oid string, size int64) error {
 	if altMediafile != "" && tools.FileExistsOfSize(altMediafile, size) {
 		return LinkOrCopy(altMediafile, mediafile)
 	}
-	return nil
+	if err := copyFromMirror(oid, size, mediafile); err != nil || ObjectExistsOfSize(oid, size) {
+		return err
+	}
+	return copyFromExternalCAS(oid, size, mediafile)
 }
@@ -0,0 +1,60 @@
+package lfs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/tools"
+	"github.com/rubyist/tracerx"
+)
+
+// LocalMirrorPath returns the path that the object for oid would have in the
+// read-only mirror set by lfs.storage.readonlymirror, or "" if there isn't
+// one. The mirror has the same layout as the local object store.
+func LocalMirrorPath(oid string) string {
+	dir := config.Config.ReadOnlyMirror()
+	if dir == "" || len(oid) < 5 {
+		return ""
+	}
+	return filepath.Join(dir, oid[0:2], oid[2:4], oid)
+}
+
+// copyFromMirror copies the object for oid into mediafile from the read-only
+// mirror, if the mirror has it. The object is only used if its content
+// matches oid, so a bad copy in the mirror is downloaded instead.
+func copyFromMirror(oid string, size int64, mediafile string) error {
+	mirrorfile := LocalMirrorPath(oid)
+	if mirrorfile == "" || !tools.FileExistsOfSize(mirrorfile, size) {
+		return nil
+	}
+
+	src, err := os.Open(mirrorfile)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp, err := TempFile("mirror")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, tools.NewVerifyingReader(src, oid, nil))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if mismatch, ok := err.(*tools.OidMismatchError); ok {
+		tracerx.Printf("mirror: %s has oid %s, ignoring it", mirrorfile, mismatch.Actual)
+		return fmt.Errorf("Object %s in %s is corrupt", oid, mirrorfile)
+	}
+	if err != nil {
+		return err
+	}
+
+	tracerx.Printf("mirror: copied %s from %s", oid, mirrorfile)
+	return tools.RenameFileCopyPermissions(tmp.Name(), mediafile)
+}
@@ -0,0 +1,288 @@
+package lfs
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/rubyist/tracerx"
+)
+
+// OidSet is a set of object ids.
+type OidSet interface {
+	// Add adds oid to the set, and returns whether it wasn't in the set
+	// already.
+	Add(oid string) bool
+}
+
+const (
+	// oidLen is the length of an oid, and of each of a DiskOidSet's records.
+	oidLen = 64
+
+	// maxOidRuns is how many files a DiskOidSet writes before merging them
+	// into one, which bounds how many files each lookup reads.
+	maxOidRuns = 8
+)
+
+type oidKey [oidLen]byte
+
+// DiskOidSet is an OidSet which keeps at most a fixed number of oids in
+// memory, and writes the rest to sorted files in a temp directory, which are
+// binary searched for each oid that's added. It's much slower than a
+// StringSet, but its memory doesn't grow with the number of oids, for scanning
+// histories with millions of objects.
+//
+// An oid which isn't 64 characters long, which isn't a valid pointer oid, is
+// kept in memory.
+type DiskOidSet struct {
+	dir   string
+	limit int
+	files int
+
+	pending map[oidKey]struct{}
+	other   StringSet
+	runs    []*oidRun
+	err     error
+}
+
+// oidRun is a file of sorted oids, each oidLen bytes with no separators.
+type oidRun struct {
+	f *os.File
+	n int64
+}
+
+// remove closes and deletes the run's file.
+func (r *oidRun) remove() {
+	r.f.Close()
+	os.Remove(r.f.Name())
+}
+
+// NewDiskOidSet returns an empty DiskOidSet which keeps up to limit oids in
+// memory, and writes the rest to a new directory in dir.
+func NewDiskOidSet(dir string, limit int) (*DiskOidSet, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	d, err := ioutil.TempDir(dir, "oids")
+	if err != nil {
+		return nil, err
+	}
+
+	return &DiskOidSet{
+		dir:     d,
+		limit:   limit,
+		pending: make(map[oidKey]struct{}),
+		other:   NewStringSet(),
+	}, nil
+}
+
+// Add adds oid to the set, and returns whether it wasn't in the set already.
+// Once the set's files can't be read or written, oids are only kept in memory,
+// and only found again if they were added since, so Err should be checked
+// once all the oids have been added.
+func (s *DiskOidSet) Add(oid string) bool {
+	if len(oid) != oidLen {
+		return s.other.Add(oid)
+	}
+
+	var key oidKey
+	copy(key[:], oid)
+	if _, ok := s.pending[key]; ok {
+		return false
+	}
+	if s.err != nil {
+		s.pending[key] = struct{}{}
+		return true
+	}
+
+	for _, r := range s.runs {
+		found, err := r.contains(key)
+		if err != nil {
+			s.fail(err)
+			break
+		}
+		if found {
+			return false
+		}
+	}
+
+	s.pending[key] = struct{}{}
+	if s.err == nil && len(s.pending) >= s.limit {
+		if err := s.spill(); err != nil {
+			s.fail(err)
+		}
+	}
+	return true
+}
+
+// Err returns the first error reading or writing the set's files, after which
+// they're no longer used, or nil.
+func (s *DiskOidSet) Err() error {
+	return s.err
+}
+
+// Close removes the set's files.
+func (s *DiskOidSet) Close() error {
+	s.removeRuns()
+	return os.RemoveAll(s.dir)
+}
+
+func (s *DiskOidSet) fail(err error) {
+	tracerx.Printf("oid set: unable to use %s: %s", s.dir, err)
+	s.err = err
+	s.removeRuns()
+}
+
+func (s *DiskOidSet) removeRuns() {
+	for _, r := range s.runs {
+		r.remove()
+	}
+	s.runs = nil
+}
+
+// spill writes the oids in memory to a new run, and merges the runs into one
+// if there are more than maxOidRuns of them.
+func (s *DiskOidSet) spill() error {
+	keys := make([]oidKey, 0, len(s.pending))
+	for key := range s.pending {
+		keys = append(keys, key)
+	}
+	sort.Sort(oidKeys(keys))
+
+	i := 0
+	r, err := s.writeRun(func() (oidKey, bool) {
+		if i >= len(keys) {
+			return oidKey{}, false
+		}
+		i++
+		return keys[i-1], true
+	})
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, r)
+	s.pending = make(map[oidKey]struct{})
+
+	if len(s.runs) > maxOidRuns {
+		return s.merge()
+	}
+	return nil
+}
+
+// merge replaces the runs with one run of all their oids.
+func (s *DiskOidSet) merge() error {
+	readers := make([]*bufio.Reader, len(s.runs))
+	heads := make([]*oidKey, len(s.runs))
+	next := func(i int) error {
+		var key oidKey
+		if _, err := io.ReadFull(readers[i], key[:]); err != nil {
+			heads[i] = nil
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		heads[i] = &key
+		return nil
+	}
+
+	for i, r := range s.runs {
+		if _, err := r.f.Seek(0, 0); err != nil {
+			return err
+		}
+		readers[i] = bufio.NewReader(r.f)
+		if err := next(i); err != nil {
+			return err
+		}
+	}
+
+	var readErr error
+	merged, err := s.writeRun(func() (oidKey, bool) {
+		min := -1
+		for i, head := range heads {
+			if head != nil && (min < 0 || bytes.Compare(head[:], heads[min][:]) < 0) {
+				min = i
+			}
+		}
+		if min < 0 {
+			return oidKey{}, false
+		}
+
+		key := *heads[min]
+		if err := next(min); err != nil && readErr == nil {
+			readErr = err
+		}
+		return key, true
+	})
+	if err != nil {
+		return err
+	}
+	if readErr != nil {
+		merged.remove()
+		return readErr
+	}
+
+	tracerx.Printf("oid set: merged %d files of %d oids", len(s.runs), merged.n)
+	s.removeRuns()
+	s.runs = []*oidRun{merged}
+	return nil
+}
+
+// writeRun writes the sorted oids given by next, until it returns false, to
+// a new file in the set's directory.
+func (s *DiskOidSet) writeRun(next func() (oidKey, bool)) (*oidRun, error) {
+	f, err := os.Create(filepath.Join(s.dir, strconv.Itoa(s.files)))
+	if err != nil {
+		return nil, err
+	}
+	s.files++
+
+	r := &oidRun{f: f}
+	w := bufio.NewWriter(f)
+	for key, ok := next(); ok; key, ok = next() {
+		if _, err := w.Write(key[:]); err != nil {
+			r.remove()
+			return nil, err
+		}
+		r.n++
+	}
+	if err := w.Flush(); err != nil {
+		r.remove()
+		return nil, err
+	}
+	return r, nil
+}
+
+// contains returns whether key is in the run, with a binary search of its
+// file.
+func (r *oidRun) contains(key oidKey) (bool, error) {
+	var rec oidKey
+	lo, hi := int64(0), r.n
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if _, err := r.f.ReadAt(rec[:], mid*oidLen); err != nil {
+			return false, err
+		}
+
+		switch bytes.Compare(rec[:], key[:]) {
+		case 0:
+			return true, nil
+		case -1:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return false, nil
+}
+
+type oidKeys []oidKey
+
+func (k oidKeys) Len() int           { return len(k) }
+func (k oidKeys) Swap(i, j int)      { k[i], k[j] = k[j], k[i] }
+func (k oidKeys) Less(i, j int) bool { return bytes.Compare(k[i][:], k[j][:]) < 0 }
@@ -0,0 +1,80 @@
+package lfs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testOids(n int) []string {
+	oids := make([]string, n)
+	for i := range oids {
+		sum := sha256.Sum256([]byte(strconv.Itoa(i)))
+		oids[i] = hex.EncodeToString(sum[:])
+	}
+	return oids
+}
+
+func TestDiskOidSetBoundsMemory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oid-set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	set, err := NewDiskOidSet(dir, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oids := testOids(5000)
+	for _, oid := range oids {
+		assert.True(t, set.Add(oid), "first add of %s", oid)
+		assert.True(t, len(set.pending) < 100, "%d oids in memory", len(set.pending))
+		assert.True(t, len(set.runs) <= maxOidRuns, "%d files", len(set.runs))
+	}
+
+	// found again, whether they're in memory, in a file, or in a merged file
+	for _, oid := range oids {
+		assert.False(t, set.Add(oid), "second add of %s", oid)
+	}
+	assert.True(t, set.Add("short"))
+	assert.False(t, set.Add("short"))
+	assert.Nil(t, set.Err())
+
+	assert.Nil(t, set.Close())
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Empty(t, files)
+}
+
+func TestDiskOidSetKeepsOidsInMemoryAfterError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oid-set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	set, err := NewDiskOidSet(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer set.Close()
+
+	// the set can't write its files once its directory is gone
+	assert.Nil(t, os.RemoveAll(set.dir))
+
+	oids := testOids(50)
+	for _, oid := range oids {
+		assert.True(t, set.Add(oid))
+	}
+	assert.NotNil(t, set.Err())
+	for _, oid := range oids {
+		assert.False(t, set.Add(oid))
+	}
+}
@@ -0,0 +1,153 @@
+package lfs
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/github/git-lfs/config"
+)
+
+//go:generate go run unicode_gen.go
+
+// NormalizePath returns path in Unicode normalization form D, so that paths
+// which only differ in whether accented characters are composed compare as
+// equal. macOS filesystems return decomposed (NFD) names, while Git with
+// core.precomposeunicode and most other systems use composed (NFC) ones.
+func NormalizePath(path string) string {
+	ascii := true
+	for i := 0; i < len(path) && ascii; i++ {
+		ascii = path[i] < utf8.RuneSelf
+	}
+	if ascii {
+		return path
+	}
+
+	runes := make([]rune, 0, len(path))
+	for _, r := range path {
+		runes = appendDecomposed(runes, r)
+	}
+
+	// Put each run of combining characters in canonical order
+	for i := 0; i < len(runes); {
+		if combiningClass(runes[i]) == 0 {
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(runes) && combiningClass(runes[j]) != 0 {
+			j++
+		}
+		sort.Stable(byCombiningClass(runes[i:j]))
+		i = j
+	}
+
+	return string(runes)
+}
+
+// Hangul syllables decompose algorithmically, rather than with a table.
+const (
+	hangulBase   = 0xAC00
+	hangulEnd    = 0xD7A3
+	hangulLBase  = 0x1100
+	hangulVBase  = 0x1161
+	hangulTBase  = 0x11A7
+	hangulTCount = 28
+	hangulNCount = 21 * hangulTCount
+)
+
+func appendDecomposed(runes []rune, r rune) []rune {
+	if r >= hangulBase && r <= hangulEnd {
+		s := r - hangulBase
+		runes = append(runes, hangulLBase+s/hangulNCount, hangulVBase+(s%hangulNCount)/hangulTCount)
+		if t := s % hangulTCount; t != 0 {
+			runes = append(runes, hangulTBase+t)
+		}
+		return runes
+	}
+
+	if d, ok := canonicalDecompositions[r]; ok {
+		return append(runes, []rune(d)...)
+	}
+	return append(runes, r)
+}
+
+type combiningClassRange struct {
+	lo, hi rune
+	class  uint8
+}
+
+func combiningClass(r rune) uint8 {
+	if r < combiningClasses[0].lo {
+		return 0
+	}
+
+	i := sort.Search(len(combiningClasses), func(i int) bool {
+		return combiningClasses[i].hi >= r
+	})
+	if i < len(combiningClasses) && combiningClasses[i].lo <= r {
+		return combiningClasses[i].class
+	}
+	return 0
+}
+
+type byCombiningClass []rune
+
+func (b byCombiningClass) Len() int           { return len(b) }
+func (b byCombiningClass) Less(i, j int) bool { return combiningClass(b[i]) < combiningClass(b[j]) }
+func (b byCombiningClass) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// PathCollisions finds paths which name the same file as a path seen before,
+// on a filesystem which ignores case, Unicode normalization, or both.
+type PathCollisions struct {
+	ignoreCase          bool
+	ignoreNormalization bool
+	seen                map[string]string
+}
+
+// NewPathCollisions returns a PathCollisions for a filesystem which ignores
+// case if ignoreCase is true, and Unicode normalization if
+// ignoreNormalization is true.
+func NewPathCollisions(ignoreCase, ignoreNormalization bool) *PathCollisions {
+	return &PathCollisions{
+		ignoreCase:          ignoreCase,
+		ignoreNormalization: ignoreNormalization,
+		seen:                make(map[string]string),
+	}
+}
+
+// NewPathCollisionsFromConfig returns a PathCollisions for this repository's
+// filesystem, as described by Git's core.ignorecase and core.precomposeunicode
+// settings, or nil if lfs.pathcollisions is "ignore" or the filesystem tells
+// all paths apart.
+func NewPathCollisionsFromConfig() *PathCollisions {
+	if config.Config.PathCollisions() == "ignore" {
+		return nil
+	}
+
+	ignoreCase := config.Config.GitConfigBool("core.ignorecase")
+	ignoreNormalization := config.Config.GitConfigBool("core.precomposeunicode")
+	if !ignoreCase && !ignoreNormalization {
+		return nil
+	}
+	return NewPathCollisions(ignoreCase, ignoreNormalization)
+}
+
+// Add records path, and returns the different path seen before which names
+// the same file, if there is one.
+func (c *PathCollisions) Add(path string) (string, bool) {
+	key := path
+	if c.ignoreNormalization {
+		key = NormalizePath(key)
+	}
+	if c.ignoreCase {
+		key = strings.ToLower(key)
+	}
+
+	if other, ok := c.seen[key]; ok {
+		return other, other != path
+	}
+
+	c.seen[key] = path
+	return "", false
+}
@@ -0,0 +1,61 @@
+package lfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	// "café.dat", with the é composed (NFC) and decomposed (NFD)
+	composedPath   = "caf\u00e9.dat"
+	decomposedPath = "cafe\u0301.dat"
+)
+
+func TestNormalizePath(t *testing.T) {
+	assert.Equal(t, decomposedPath, NormalizePath(decomposedPath))
+	assert.Equal(t, decomposedPath, NormalizePath(composedPath))
+	assert.Equal(t, "plain/ascii.dat", NormalizePath("plain/ascii.dat"))
+
+	// combining characters are put in canonical order
+	assert.Equal(t, NormalizePath("a\u0323\u0307.dat"), NormalizePath("a\u0307\u0323.dat"))
+	assert.Equal(t, NormalizePath("\u1e0b\u0323.dat"), NormalizePath("\u1e0d\u0307.dat"))
+
+	// Hangul syllables
+	assert.Equal(t, "\u1100\u1161/\u1112\u1161\u11ab.dat", NormalizePath("\uac00/\ud55c.dat"))
+}
+
+func TestPathCollisions(t *testing.T) {
+	tests := []struct {
+		ignoreCase, ignoreNormalization bool
+		// collisions maps each colliding path to the earlier one
+		collisions map[string]string
+	}{
+		{true, false, map[string]string{"A.dat": "a.dat", "DIR/b.dat": "dir/b.dat", "CAF\u00c9.dat": composedPath}},
+		{false, true, map[string]string{decomposedPath: composedPath}},
+		{true, true, map[string]string{"A.dat": "a.dat", "DIR/b.dat": "dir/b.dat", "CAF\u00c9.dat": composedPath, decomposedPath: composedPath}},
+		{false, false, map[string]string{}},
+	}
+
+	paths := []string{"a.dat", "A.dat", "a.dat", "dir/b.dat", "DIR/b.dat", "b.dat", composedPath, "CAF\u00c9.dat", decomposedPath}
+
+	for _, tt := range tests {
+		c := NewPathCollisions(tt.ignoreCase, tt.ignoreNormalization)
+
+		found := make(map[string]string)
+		for _, path := range paths {
+			if other, ok := c.Add(path); ok {
+				found[path] = other
+			}
+		}
+
+		assert.Equal(t, tt.collisions, found, "ignore case: %v, ignore normalization: %v", tt.ignoreCase, tt.ignoreNormalization)
+	}
+}
+
+func TestFilenamePassesIncludeExcludeFilterNormalizesUnicode(t *testing.T) {
+	assert.True(t, FilenamePassesIncludeExcludeFilter(composedPath, []string{decomposedPath}, nil))
+	assert.True(t, FilenamePassesIncludeExcludeFilter(decomposedPath, []string{"caf\u00e9*"}, nil))
+	assert.False(t, FilenamePassesIncludeExcludeFilter("re\u0301sume\u0301s/cv.dat", nil, []string{"r\u00e9sum\u00e9s"}))
+	assert.True(t, FilenamePassesIncludeExcludeFilter("resumes/cv.dat", nil, []string{"r\u00e9sum\u00e9s"}))
+}
@@ -0,0 +1,95 @@
+package lfs
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/github/git-lfs/config"
+)
+
+// PinnedObjectsPath returns the path of the file listing the oids of objects
+// that are always kept locally, one per line.
+func PinnedObjectsPath() string {
+	return filepath.Join(config.LocalGitStorageDir, "lfs", "pinned")
+}
+
+// PinnedObjects returns the oids of all pinned objects. These are never
+// deleted by prune.
+func PinnedObjects() (StringSet, error) {
+	pinned := NewStringSet()
+
+	f, err := os.Open(PinnedObjectsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return pinned, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if oid := strings.TrimSpace(scanner.Text()); len(oid) > 0 {
+			pinned.Add(oid)
+		}
+	}
+
+	return pinned, scanner.Err()
+}
+
+// PinObject adds oid to the pinned objects. Returns false if it was already
+// pinned.
+func PinObject(oid string) (bool, error) {
+	pinned, err := PinnedObjects()
+	if err != nil {
+		return false, err
+	}
+
+	if !pinned.Add(oid) {
+		return false, nil
+	}
+
+	return true, writePinnedObjects(pinned)
+}
+
+// UnpinObject removes oid from the pinned objects. Returns false if it was not
+// pinned.
+func UnpinObject(oid string) (bool, error) {
+	pinned, err := PinnedObjects()
+	if err != nil {
+		return false, err
+	}
+
+	if !pinned.Contains(oid) {
+		return false, nil
+	}
+
+	pinned.Remove(oid)
+	return true, writePinnedObjects(pinned)
+}
+
+func writePinnedObjects(pinned StringSet) error {
+	oids := make([]string, 0, pinned.Cardinality())
+	for oid := range pinned.Iter() {
+		oids = append(oids, oid)
+	}
+	sort.Strings(oids)
+
+	var buf bytes.Buffer
+	for _, oid := range oids {
+		buf.WriteString(oid)
+		buf.WriteString("\n")
+	}
+
+	path := PinnedObjectsPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
@@ -0,0 +1,55 @@
+package lfs_test // avoid import cycle
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/github/git-lfs/lfs"
+	"github.com/github/git-lfs/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinAndUnpinObjects(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	oid1 := strings.Repeat("a", 64)
+	oid2 := strings.Repeat("b", 64)
+
+	pinned, err := lfs.PinnedObjects()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, pinned.Cardinality())
+
+	added, err := lfs.PinObject(oid2)
+	assert.Nil(t, err)
+	assert.True(t, added)
+
+	added, err = lfs.PinObject(oid1)
+	assert.Nil(t, err)
+	assert.True(t, added)
+
+	added, err = lfs.PinObject(oid1)
+	assert.Nil(t, err)
+	assert.False(t, added)
+
+	by, err := ioutil.ReadFile(lfs.PinnedObjectsPath())
+	assert.Nil(t, err)
+	assert.Equal(t, oid1+"\n"+oid2+"\n", string(by))
+
+	removed, err := lfs.UnpinObject(oid1)
+	assert.Nil(t, err)
+	assert.True(t, removed)
+
+	removed, err = lfs.UnpinObject(oid1)
+	assert.Nil(t, err)
+	assert.False(t, removed)
+
+	pinned, err = lfs.PinnedObjects()
+	assert.Nil(t, err)
+	assert.True(t, pinned.Equal(lfs.NewStringSetFromSlice([]string{oid2})))
+}
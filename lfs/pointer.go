@@ -12,10 +12,26 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/github/git-lfs/config"
 	"github.com/github/git-lfs/errutil"
 	"github.com/github/git-lfs/progress"
+	"github.com/rubyist/tracerx"
 )
 
+// UnknownSize is the Size of a pointer whose size line is missing or invalid,
+// when lfs.pointer.unknownsize allows them. The size is found by counting the
+// bytes of the object when it's downloaded.
+const UnknownSize int64 = -1
+
+// KnownSize returns size, or 0 if it's UnknownSize, so that objects whose size
+// isn't known yet are left out of totals.
+func KnownSize(size int64) int64 {
+	if size == UnknownSize {
+		return 0
+	}
+	return size
+}
+
 var (
 	v1Aliases = []string{
 		"http://git-media.io/v/2",            // alpha
@@ -79,7 +95,9 @@ func (p *Pointer) Encoded() string {
 		buffer.WriteString(fmt.Sprintf("ext-%d-%s %s:%s\n", ext.Priority, ext.Name, ext.OidType, ext.Oid))
 	}
 	buffer.WriteString(fmt.Sprintf("oid %s:%s\n", p.OidType, p.Oid))
-	buffer.WriteString(fmt.Sprintf("size %d\n", p.Size))
+	if p.Size != UnknownSize {
+		buffer.WriteString(fmt.Sprintf("size %d\n", p.Size))
+	}
 	return buffer.String()
 }
 
@@ -87,6 +105,14 @@ func EncodePointer(writer io.Writer, pointer *Pointer) (int, error) {
 	return writer.Write([]byte(pointer.Encoded()))
 }
 
+// EncodePlaceholder writes the pointer with a comment explaining that the
+// file's content is missing. Placeholders decode as the pointer they contain,
+// so they are cleaned back to the pointer rather than committed as content, and
+// are replaced by checkout and pull once the object is downloaded.
+func EncodePlaceholder(writer io.Writer, pointer *Pointer) (int, error) {
+	return writer.Write([]byte(placeholderHeader + pointer.Encoded()))
+}
+
 func DecodePointerFromFile(file string) (*Pointer, error) {
 	// Check size before reading
 	stat, err := os.Stat(file)
@@ -117,10 +143,33 @@ func DecodeFrom(reader io.Reader) ([]byte, *Pointer, error) {
 		return output, nil, err
 	}
 
-	p, err := decodeKV(bytes.TrimSpace(output))
+	p, err := decodeKV(bytes.TrimSpace(normalizePointerData(output)))
 	return output, p, err
 }
 
+const placeholderHeader = "# Git LFS placeholder: this file's content could not be downloaded.\n" +
+	"# Run 'git lfs pull' to download it.\n"
+
+var (
+	utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+	crlf    = []byte("\r\n")
+	cr      = []byte("\r")
+	lf      = []byte("\n")
+)
+
+// normalizePointerData strips a leading UTF-8 byte order mark and converts CRLF
+// and CR line endings to LF, which some editors add to pointer files. It also
+// strips the comment from placeholders written by EncodePlaceholder.
+func normalizePointerData(data []byte) []byte {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	if bytes.IndexByte(data, '\r') >= 0 {
+		data = bytes.Replace(data, crlf, lf, -1)
+		data = bytes.Replace(data, cr, lf, -1)
+	}
+
+	return bytes.TrimPrefix(data, []byte(placeholderHeader))
+}
+
 func verifyVersion(version string) error {
 	if len(version) == 0 {
 		return errutil.NewNotAPointerError(errors.New("Missing version"))
@@ -159,9 +208,13 @@ func decodeKV(data []byte) (*Pointer, error) {
 	}
 
 	value, ok = kvps["size"]
-	size, err := strconv.ParseInt(value, 10, 0)
+	size, err := strconv.ParseInt(value, 10, 64)
 	if err != nil || size < 0 {
-		return nil, fmt.Errorf("Invalid size: %q", value)
+		if config.Config.PointerUnknownSize() != "allow" {
+			return nil, fmt.Errorf("Invalid size: %q", value)
+		}
+		tracerx.Printf("pointer: %s has an invalid size %q, which will be found on download", oid, value)
+		size = UnknownSize
 	}
 
 	var extensions []*PointerExtension
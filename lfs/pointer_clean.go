@@ -78,6 +78,11 @@ func copyToTemp(reader io.Reader, fileSize int64, cb progress.CopyCallback) (oid
 
 	by, ptr, err := DecodeFrom(reader)
 	if err == nil && len(by) < 512 {
+		if !bytes.Equal(by, normalizePointerData(by)) {
+			// Always write the pointer without a BOM, CRLF line endings or
+			// placeholder comment
+			by = []byte(ptr.Encoded())
+		}
 		err = errutil.NewCleanPointerError(err, ptr, by)
 		return
 	}
@@ -89,6 +94,12 @@ func copyToTemp(reader io.Reader, fileSize int64, cb progress.CopyCallback) (oid
 		return
 	}
 
+	// Make sure the content is on disk before it's moved into the object
+	// store, so an interrupted clean can't leave an incomplete object there
+	if err = tmp.Sync(); err != nil {
+		return
+	}
+
 	oid = hex.EncodeToString(oidHash.Sum(nil))
 	return
 }
@@ -3,10 +3,12 @@ package lfs
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 
 	"github.com/cheggaaa/pb"
+	"github.com/github/git-lfs/localstorage"
 	"github.com/github/git-lfs/tools"
 	"github.com/github/git-lfs/transfer"
 
@@ -38,10 +40,8 @@ func PointerSmudgeToFile(filename string, ptr *Pointer, download bool, cb progre
 }
 
 func PointerSmudge(writer io.Writer, ptr *Pointer, workingfile string, download bool, cb progress.CopyCallback) error {
-	mediafile, err := LocalMediaPath(ptr.Oid)
-	if err != nil {
-		return err
-	}
+	var err error
+	mediafile := LocalMediaPathReadOnly(ptr.Oid)
 
 	LinkOrCopyFromReference(ptr.Oid, ptr.Size)
 
@@ -49,19 +49,31 @@ func PointerSmudge(writer io.Writer, ptr *Pointer, workingfile string, download
 
 	if statErr == nil && stat != nil {
 		fileSize := stat.Size()
-		if fileSize == 0 || fileSize != ptr.Size {
-			tracerx.Printf("Removing %s, size %d is invalid", mediafile, fileSize)
-			os.RemoveAll(mediafile)
+		if fileSize == 0 || (fileSize != ptr.Size && ptr.Size != UnknownSize) {
+			if !localstorage.Objects().ReadOnly() {
+				tracerx.Printf("Removing %s, size %d is invalid", mediafile, fileSize)
+				os.RemoveAll(mediafile)
+			}
 			stat = nil
 		}
 	}
 
 	if statErr != nil || stat == nil {
-		if download {
-			err = downloadFile(writer, ptr, workingfile, mediafile, cb)
-		} else {
+		if !download {
 			return errutil.NewDownloadDeclinedError(nil)
 		}
+
+		if !localstorage.Objects().ReadOnly() {
+			if mediafile, err = LocalMediaPath(ptr.Oid); err == nil {
+				err = downloadFile(writer, ptr, workingfile, mediafile, cb)
+			}
+		}
+
+		// also if storing the object just found the store is read-only,
+		// as nothing has been written to writer yet
+		if localstorage.Objects().ReadOnly() {
+			err = downloadFileWithoutStoring(writer, ptr, workingfile, cb)
+		}
 	} else {
 		err = readLocalFile(writer, ptr, mediafile, workingfile, cb)
 	}
@@ -74,15 +86,21 @@ func PointerSmudge(writer io.Writer, ptr *Pointer, workingfile string, download
 }
 
 func downloadFile(writer io.Writer, ptr *Pointer, workingfile, mediafile string, cb progress.CopyCallback) error {
-	fmt.Fprintf(os.Stderr, "Downloading %s (%s)\n", workingfile, pb.FormatBytes(ptr.Size))
+	size := ptr.Size
+	if size == UnknownSize {
+		fmt.Fprintf(os.Stderr, "Downloading %s (unknown size)\n", workingfile)
+		size = 0
+	} else {
+		fmt.Fprintf(os.Stderr, "Downloading %s (%s)\n", workingfile, pb.FormatBytes(size))
+	}
 
 	xfers := transfer.GetDownloadAdapterNames()
-	obj, adapterName, err := api.BatchOrLegacySingle(&api.ObjectResource{Oid: ptr.Oid, Size: ptr.Size}, "download", xfers)
+	obj, adapterName, err := api.BatchOrLegacySingle(&api.ObjectResource{Oid: ptr.Oid, Size: size}, "download", xfers)
 	if err != nil {
 		return errutil.Errorf(err, "Error downloading %s: %s", filepath.Base(mediafile), err)
 	}
 
-	if ptr.Size == 0 {
+	if size == 0 {
 		ptr.Size = obj.Size
 	}
 
@@ -104,17 +122,37 @@ func downloadFile(writer io.Writer, ptr *Pointer, workingfile, mediafile string,
 		return errutil.Errorf(err, "Error buffering media file: %s", res.Error)
 	}
 
+	if err := StoreInExternalCAS(ptr.Oid, mediafile); err != nil {
+		tracerx.Printf("smudge: %s", err)
+	}
+
 	return readLocalFile(writer, ptr, mediafile, workingfile, nil)
 }
 
+// downloadFileWithoutStoring downloads the object to a temp file rather than
+// the local object store, which is read-only, and writes it to writer.
+func downloadFileWithoutStoring(writer io.Writer, ptr *Pointer, workingfile string, cb progress.CopyCallback) error {
+	tracerx.Printf("smudge: %s is read-only, not storing %s", LocalMediaDir(), ptr.Oid)
+
+	tmp, err := ioutil.TempFile(LocalObjectTempDir(), ptr.Oid+"-")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	return downloadFile(writer, ptr, workingfile, tmp.Name(), cb)
+}
+
 func readLocalFile(writer io.Writer, ptr *Pointer, mediafile string, workingfile string, cb progress.CopyCallback) error {
-	reader, err := os.Open(mediafile)
+	files := config.Config.FileLimiter()
+	reader, err := files.Open(mediafile)
 	if err != nil {
 		return errutil.Errorf(err, "Error opening media file.")
 	}
 	defer reader.Close()
 
-	if ptr.Size == 0 {
+	if ptr.Size == 0 || ptr.Size == UnknownSize {
 		if stat, _ := os.Stat(mediafile); stat != nil {
 			ptr.Size = stat.Size()
 		}
@@ -151,6 +189,10 @@ func readLocalFile(writer io.Writer, ptr *Pointer, mediafile string, workingfile
 			return errutil.Error(err)
 		}
 
+		// The media file has been read by the extensions, release it before
+		// opening the smudged file.
+		reader.Close()
+
 		actualExts := make(map[string]*pipeExtResult)
 		for _, result := range response.results {
 			actualExts[result.name] = result
@@ -176,14 +218,15 @@ func readLocalFile(writer io.Writer, ptr *Pointer, mediafile string, workingfile
 		}
 
 		// setup reader
-		reader, err = os.Open(response.file.Name())
+		reader, err = files.Open(response.file.Name())
 		if err != nil {
 			return errutil.Errorf(err, "Error opening smudged file: %s", err)
 		}
 		defer reader.Close()
 	}
 
-	_, err = tools.CopyWithCallback(writer, reader, ptr.Size, cb)
+	// Pass the *os.File through so that CopyWithCallback can clone it
+	_, err = tools.CopyWithCallback(writer, reader.File, ptr.Size, cb)
 	if err != nil {
 		return errutil.Errorf(err, "Error reading from media file: %s", err)
 	}
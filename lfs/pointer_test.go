@@ -8,6 +8,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/github/git-lfs/config"
 	"github.com/github/git-lfs/errutil"
 	"github.com/stretchr/testify/assert"
 )
@@ -99,6 +100,43 @@ size 12345`
 	assertEqualWithExample(t, ex, int64(12345), p.Size)
 }
 
+func TestDecodeLargeSize(t *testing.T) {
+	// larger than both int32 and uint32, to catch sizes parsed as ints on
+	// 32-bit platforms
+	ex := `version https://git-lfs.github.com/spec/v1
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 5368709120`
+
+	p, err := DecodePointer(bytes.NewBufferString(ex))
+	assertEqualWithExample(t, ex, nil, err)
+	assertEqualWithExample(t, ex, int64(5368709120), p.Size)
+	assertEqualWithExample(t, ex, ex+"\n", p.Encoded())
+}
+
+func TestDecodeUnknownSize(t *testing.T) {
+	defer config.Config.ResetConfig()
+
+	missing := `version https://git-lfs.github.com/spec/v1
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+`
+	invalid := missing + "size fif\n"
+
+	for _, ex := range []string{missing, invalid} {
+		config.Config.SetConfig("lfs.pointer.unknownsize", "")
+		_, err := DecodePointer(bytes.NewBufferString(ex))
+		assertEqualWithExample(t, ex, false, err == nil)
+
+		config.Config.SetConfig("lfs.pointer.unknownsize", "allow")
+		p, err := DecodePointer(bytes.NewBufferString(ex))
+		assertEqualWithExample(t, ex, nil, err)
+		assertEqualWithExample(t, ex, "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", p.Oid)
+		assertEqualWithExample(t, ex, UnknownSize, p.Size)
+
+		// encoded without a size, so that it cleans back to itself
+		assertEqualWithExample(t, ex, missing, p.Encoded())
+	}
+}
+
 func TestDecodeExtensions(t *testing.T) {
 	ex := `version https://git-lfs.github.com/spec/v1
 ext-0-foo sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff
@@ -292,3 +330,49 @@ size 12345`,
 func assertEqualWithExample(t *testing.T, example string, expected, actual interface{}) {
 	assert.Equal(t, expected, actual, "Example:\n%s", strings.TrimSpace(example))
 }
+
+func TestDecodeWithBOMAndLineEndings(t *testing.T) {
+	lines := []string{
+		"version https://git-lfs.github.com/spec/v1",
+		"oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393",
+		"size 12345",
+	}
+
+	examples := map[string]string{
+		"bom":      "\xef\xbb\xbf" + strings.Join(lines, "\n") + "\n",
+		"crlf":     strings.Join(lines, "\r\n") + "\r\n",
+		"cr":       strings.Join(lines, "\r") + "\r",
+		"bom+crlf": "\xef\xbb\xbf" + strings.Join(lines, "\r\n") + "\r\n",
+	}
+
+	for name, ex := range examples {
+		p, err := DecodePointer(bytes.NewBufferString(ex))
+		if assert.Nil(t, err, name) {
+			assert.Equal(t, latest, p.Version, name)
+			assert.Equal(t, "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", p.Oid, name)
+			assert.Equal(t, int64(12345), p.Size, name)
+			assert.Equal(t, strings.Join(lines, "\n")+"\n", p.Encoded(), name)
+		}
+	}
+}
+
+func TestNormalizePointerData(t *testing.T) {
+	assert.Equal(t, "a\nb\nc\n", string(normalizePointerData([]byte("\xef\xbb\xbfa\r\nb\rc\n"))))
+	assert.Equal(t, "a\nb\n", string(normalizePointerData([]byte("a\nb\n"))))
+}
+
+func TestDecodePlaceholder(t *testing.T) {
+	ptr := NewPointer("4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", 12345, nil)
+
+	var buf bytes.Buffer
+	_, err := EncodePlaceholder(&buf, ptr)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(buf.String(), "# Git LFS placeholder"), buf.String())
+	assert.True(t, strings.HasSuffix(buf.String(), ptr.Encoded()), buf.String())
+
+	p, err := DecodePointer(&buf)
+	if assert.Nil(t, err) {
+		assert.Equal(t, ptr.Oid, p.Oid)
+		assert.Equal(t, ptr.Size, p.Size)
+	}
+}
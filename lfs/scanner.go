@@ -36,10 +36,14 @@ var (
 	// lfs changes and format the output suitable for parseLogOutput.. method(s)
 	logLfsSearchArgs = []string{
 		"-G", "oid sha256:", // only diffs which include an lfs file SHA change
-		"-p",   // include diff so we can read the SHA
-		"-U12", // Make sure diff context is always big enough to support 10 extension lines to get whole pointer
+		"-p",                             // include diff so we can read the SHA
+		"-U12",                           // Make sure diff context is always big enough to support 10 extension lines to get whole pointer
 		`--format=lfs-commit-sha: %H %P`, // just a predictable commit header we can detect
 	}
+
+	// Format to give after logLfsSearchArgs, so that it replaces its --format,
+	// to include the author and author date of each commit
+	logLfsAuthorFormat = "--format=lfs-commit-sha: %H %P%nlfs-commit-author: %at %aN <%aE>"
 )
 
 // WrappedPointer wraps a pointer.Pointer and provides the git sha1
@@ -529,6 +533,8 @@ func catFileBatch(revs *StringChannelWrapper) (*PointerChannelWrapper, error) {
 	pointers := make(chan *WrappedPointer, chanBufSize)
 	errchan := make(chan error, 5) // shared by 2 goroutines & may add more detail errors?
 
+	index := CurrentSizeIndex()
+
 	go func() {
 		for {
 			l, err := cmd.Stdout.ReadBytes('\n')
@@ -549,6 +555,7 @@ func catFileBatch(revs *StringChannelWrapper) (*PointerChannelWrapper, error) {
 
 			p, err := DecodePointer(bytes.NewBuffer(nbuf))
 			if err == nil {
+				index.Add(p)
 				pointers <- &WrappedPointer{
 					Sha1:    string(fields[0]),
 					Size:    p.Size,
@@ -572,9 +579,24 @@ func catFileBatch(revs *StringChannelWrapper) (*PointerChannelWrapper, error) {
 	}()
 
 	go func() {
+		var indexed int
 		for r := range revs.Results {
+			// pointers are sent before stdin is closed, so the
+			// other goroutine hasn't closed the channel yet
+			if p, ok := index.Pointer(r); ok {
+				indexed++
+				pointers <- &WrappedPointer{
+					Sha1:    r,
+					Size:    p.Size,
+					Pointer: p,
+				}
+				continue
+			}
 			cmd.Stdin.Write([]byte(r + "\n"))
 		}
+		if index != nil {
+			tracerx.Printf("size index: %d pointer(s) found in the index", indexed)
+		}
 		err := revs.Wait()
 		if err != nil {
 			// We can share errchan with other goroutine since that won't close it
@@ -661,6 +683,97 @@ func ScanTree(ref string) ([]*WrappedPointer, error) {
 	return pointers, err
 }
 
+// ScanIndexTree returns a slice of WrappedPointer objects for every file in the
+// index, like ScanTree does for a commit. Unlike ScanIndex it includes files
+// that are unchanged from HEAD, and reports each file that uses the same content.
+func ScanIndexTree() ([]*WrappedPointer, error) {
+	start := time.Now()
+	defer func() {
+		tracerx.PerformanceSince("scan-index-tree", start)
+	}()
+
+	cmd, err := startCommand("git", "ls-files", "--stage", "-z", "--full-name", "--", ":/")
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdin.Close()
+
+	blobs := make(chan TreeBlob, chanBufSize)
+	go parseLsFilesStage(cmd.Stdout, blobs)
+
+	names := make(map[string][]string)
+	for blob := range blobs {
+		names[blob.Sha1] = append(names[blob.Sha1], blob.Filename)
+	}
+
+	stderr, _ := ioutil.ReadAll(cmd.Stderr)
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("Error in git ls-files: %v %v", err, string(stderr))
+	}
+
+	revsChan := make(chan string, chanBufSize)
+	revsErr := make(chan error)
+	go func() {
+		for sha1 := range names {
+			revsChan <- sha1
+		}
+		close(revsChan)
+		close(revsErr)
+	}()
+
+	smallShas, err := catFileBatchCheck(NewStringChannelWrapper(revsChan, revsErr))
+	if err != nil {
+		return nil, err
+	}
+
+	pointerc, err := catFileBatch(smallShas)
+	if err != nil {
+		return nil, err
+	}
+
+	pointers := make([]*WrappedPointer, 0)
+	for p := range pointerc.Results {
+		for _, name := range names[p.Sha1] {
+			pointers = append(pointers, &WrappedPointer{
+				Sha1:    p.Sha1,
+				Name:    name,
+				Size:    p.Size,
+				Pointer: p.Pointer,
+			})
+		}
+	}
+	err = pointerc.Wait()
+
+	return pointers, err
+}
+
+// parseLsFilesStage sends the regular files from git ls-files --stage -z output
+// to output, then closes it. Files in conflict (stage > 0) are left out.
+func parseLsFilesStage(reader io.Reader, output chan TreeBlob) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Split(scanNullLines)
+	for scanner.Scan() {
+		// <mode> SP <sha1> SP <stage> TAB <file>
+		line := scanner.Text()
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) < 2 {
+			continue
+		}
+
+		attrs := strings.Fields(parts[0])
+		if len(attrs) < 3 || attrs[2] != "0" {
+			continue
+		}
+
+		if attrs[0] != "100644" && attrs[0] != "100755" {
+			continue // symlinks & submodules can't be pointers
+		}
+
+		output <- TreeBlob{attrs[1], parts[1]}
+	}
+	close(output)
+}
+
 // catFileBatchTree uses git cat-file --batch to get the object contents
 // of a git object, given its sha1. The contents will be decoded into
 // a Git LFS pointer. treeblobs is a channel over which blob entries
@@ -674,8 +787,22 @@ func catFileBatchTree(treeblobs *TreeBlobChannelWrapper) (*PointerChannelWrapper
 	pointers := make(chan *WrappedPointer, chanBufSize)
 	errchan := make(chan error, 10) // Multiple errors possible
 
+	index := CurrentSizeIndex()
+
 	go func() {
+		var indexed int
 		for t := range treeblobs.Results {
+			if p, ok := index.Pointer(t.Sha1); ok {
+				indexed++
+				pointers <- &WrappedPointer{
+					Sha1:    t.Sha1,
+					Size:    p.Size,
+					Pointer: p,
+					Name:    t.Filename,
+				}
+				continue
+			}
+
 			cmd.Stdin.Write([]byte(t.Sha1 + "\n"))
 			l, err := cmd.Stdout.ReadBytes('\n')
 			if err != nil {
@@ -695,6 +822,7 @@ func catFileBatchTree(treeblobs *TreeBlobChannelWrapper) (*PointerChannelWrapper
 
 			p, err := DecodePointer(bytes.NewBuffer(nbuf))
 			if err == nil {
+				index.Add(p)
 				pointers <- &WrappedPointer{
 					Sha1:    string(fields[0]),
 					Size:    p.Size,
@@ -708,6 +836,10 @@ func catFileBatchTree(treeblobs *TreeBlobChannelWrapper) (*PointerChannelWrapper
 				break
 			}
 		}
+		if index != nil {
+			tracerx.Printf("size index: %d pointer(s) found in the index", indexed)
+		}
+
 		// Deal with nested error from incoming treeblobs
 		err := treeblobs.Wait()
 		if err != nil {
@@ -946,6 +1078,67 @@ func logPreviousSHAs(ref string, since time.Time) (*PointerChannelWrapper, error
 
 }
 
+// AddedPointer is an LFS pointer with the commit which first added its object.
+type AddedPointer struct {
+	*WrappedPointer
+	Commit *LogCommit
+}
+
+// ScanAddedPointers scans the history of refs, or of all refs if none are
+// given, for the commit which first added each LFS object, oldest first.
+// Objects added again by later commits, for example when a file is copied or
+// a change is reverted, are only returned once.
+func ScanAddedPointers(refs []string) ([]*AddedPointer, error) {
+	pointers := make([]*AddedPointer, 0, 10)
+	err := ScanAddedPointersFunc(refs, NewStringSet(), func(p *AddedPointer) {
+		pointers = append(pointers, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pointers, nil
+}
+
+// ScanAddedPointersFunc is like ScanAddedPointers, but calls found with each
+// pointer as it's scanned instead of returning them all, and adds their oids
+// to seen, which may be a DiskOidSet for histories too big to keep every oid
+// in memory.
+func ScanAddedPointersFunc(refs []string, seen OidSet, found func(*AddedPointer)) error {
+	start := time.Now()
+	defer func() {
+		tracerx.PerformanceSince("scan", start)
+	}()
+
+	logArgs := []string{"log", "--reverse"}
+	logArgs = append(logArgs, logLfsSearchArgs...)
+	logArgs = append(logArgs, logLfsAuthorFormat)
+	if len(refs) == 0 {
+		logArgs = append(logArgs, "--all")
+	} else {
+		logArgs = append(logArgs, refs...)
+	}
+
+	cmd, err := startCommand("git", logArgs...)
+	if err != nil {
+		return err
+	}
+
+	cmd.Stdin.Close()
+
+	parseLogOutput(cmd.Stdout, LogDiffAdditions, nil, nil, func(c *LogCommit, p *WrappedPointer) {
+		if seen.Add(p.Oid) {
+			found(&AddedPointer{WrappedPointer: p, Commit: c})
+		}
+	})
+
+	stderr, _ := ioutil.ReadAll(cmd.Stderr)
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("Error in git log: %v %v", err, string(stderr))
+	}
+
+	return nil
+}
+
 // When scanning diffs e.g. parseLogOutputToPointers, which direction of diff to include
 // data from, i.e. '+' or '-'. Depending on what you're scanning for either might be useful
 type LogDiffDirection byte
@@ -962,6 +1155,24 @@ const (
 // results: a channel which will receive the pointers (caller must close)
 func parseLogOutputToPointers(log io.Reader, dir LogDiffDirection,
 	includePaths, excludePaths []string, results chan *WrappedPointer) {
+	parseLogOutput(log, dir, includePaths, excludePaths, func(c *LogCommit, p *WrappedPointer) {
+		results <- p
+	})
+}
+
+// LogCommit is the commit a pointer was found in when scanning git log output.
+// Author and Date are only set if the log output has an lfs-commit-author
+// header, as with logLfsAuthorFormat.
+type LogCommit struct {
+	Sha    string
+	Author string
+	Date   time.Time
+}
+
+// parseLogOutput parses log output like parseLogOutputToPointers, calling found
+// with each pointer and the commit it was found in.
+func parseLogOutput(log io.Reader, dir LogDiffDirection,
+	includePaths, excludePaths []string, found func(*LogCommit, *WrappedPointer)) {
 
 	// For each commit we'll get something like this:
 	/*
@@ -982,12 +1193,14 @@ func parseLogOutputToPointers(log io.Reader, dir LogDiffDirection,
 
 	// Define regexes to capture commit & diff headers
 	commitHeaderRegex := regexp.MustCompile(`^lfs-commit-sha: ([A-Fa-f0-9]{40})(?: ([A-Fa-f0-9]{40}))*`)
+	authorHeaderRegex := regexp.MustCompile(`^lfs-commit-author: (\d+) (.*)$`)
 	fileHeaderRegex := regexp.MustCompile(`diff --git a\/(.+?)\s+b\/(.+)`)
 	fileMergeHeaderRegex := regexp.MustCompile(`diff --cc (.+)`)
 	pointerDataRegex := regexp.MustCompile(`^([\+\- ])(version https://git-lfs|oid sha256|size|ext-).*$`)
 	var pointerData bytes.Buffer
 	var currentFilename string
 	currentFileIncluded := true
+	currentCommit := &LogCommit{}
 
 	// Utility func used at several points below (keep in narrow scope)
 	finishLastPointer := func() {
@@ -995,7 +1208,7 @@ func parseLogOutputToPointers(log io.Reader, dir LogDiffDirection,
 			if currentFileIncluded {
 				p, err := DecodePointer(&pointerData)
 				if err == nil {
-					results <- &WrappedPointer{Name: currentFilename, Size: p.Size, Pointer: p}
+					found(currentCommit, &WrappedPointer{Name: currentFilename, Size: p.Size, Pointer: p})
 				} else {
 					tracerx.Printf("Unable to parse pointer from log: %v", err)
 				}
@@ -1008,10 +1221,15 @@ func parseLogOutputToPointers(log io.Reader, dir LogDiffDirection,
 	for scanner.Scan() {
 		line := scanner.Text()
 		if match := commitHeaderRegex.FindStringSubmatch(line); match != nil {
-			// Currently we're not pulling out commit groupings, but could if we wanted
-			// This just acts as a delimiter for finishing a multiline pointer
+			// This also acts as a delimiter for finishing a multiline pointer
 			finishLastPointer()
+			currentCommit = &LogCommit{Sha: match[1]}
 
+		} else if match := authorHeaderRegex.FindStringSubmatch(line); match != nil {
+			if unix, err := strconv.ParseInt(match[1], 10, 64); err == nil {
+				currentCommit.Date = time.Unix(unix, 0).UTC()
+			}
+			currentCommit.Author = match[2]
 		} else if match := fileHeaderRegex.FindStringSubmatch(line); match != nil {
 			// Finding a regular file header
 			finishLastPointer()
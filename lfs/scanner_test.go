@@ -247,6 +247,51 @@ func TestParseLogOutputToPointersDeletion(t *testing.T) {
 
 }
 
+func TestParseLogOutputWithAuthors(t *testing.T) {
+	log := `lfs-commit-sha: 637908bf28b38ab238e1b5e6a5bfbfb2e513a0df
+lfs-commit-author: 1420070400 A U Thor <author@example.com>
+
+diff --git a/a.bin b/a.bin
+new file mode 100644
+index 0000000..2622b4a
+--- /dev/null
++++ b/a.bin
+@@ -0,0 +1,3 @@
++version https://git-lfs.github.com/spec/v1
++oid sha256:f5d84da40ab1f6aa28df2b2bf1ade2cdcd4397133f903c12b4106641b10e1ed6
++size 1289
+lfs-commit-sha: 07d571b413957508679042e45508af5945b3f1e5 637908bf28b38ab238e1b5e6a5bfbfb2e513a0df
+lfs-commit-author: 1422748800 Other Person <other@example.com>
+
+diff --git a/b.bin b/b.bin
+new file mode 100644
+index 0000000..2622b4a
+--- /dev/null
++++ b/b.bin
+@@ -0,0 +1,3 @@
++version https://git-lfs.github.com/spec/v1
++oid sha256:fe2c2f236b97bba4585d9909a227a8fa64897d9bbe297fa272f714302d86c908
++size 125873
+`
+
+	var commits []*LogCommit
+	var names []string
+	parseLogOutput(strings.NewReader(log), LogDiffAdditions, nil, nil, func(c *LogCommit, p *WrappedPointer) {
+		commits = append(commits, c)
+		names = append(names, p.Name)
+	})
+
+	assert.Equal(t, []string{"a.bin", "b.bin"}, names)
+	if assert.Len(t, commits, 2) {
+		assert.Equal(t, "637908bf28b38ab238e1b5e6a5bfbfb2e513a0df", commits[0].Sha)
+		assert.Equal(t, "A U Thor <author@example.com>", commits[0].Author)
+		assert.Equal(t, "2015-01-01", commits[0].Date.Format("2006-01-02"))
+		assert.Equal(t, "07d571b413957508679042e45508af5945b3f1e5", commits[1].Sha)
+		assert.Equal(t, "Other Person <other@example.com>", commits[1].Author)
+		assert.Equal(t, "2015-02-01", commits[1].Date.Format("2006-01-02"))
+	}
+}
+
 func TestLsTreeParser(t *testing.T) {
 	stdout := "100644 blob d899f6551a51cf19763c5955c7a06a2726f018e9      42	.gitattributes\000100644 blob 4d343e022e11a8618db494dc3c501e80c7e18197     126	PB SCN 16 Odhrán.wav"
 
@@ -274,3 +319,25 @@ func BenchmarkLsTreeParser(b *testing.B) {
 	}
 	close(blobs)
 }
+
+func TestLsFilesStageParser(t *testing.T) {
+	stdout := "100644 d899f6551a51cf19763c5955c7a06a2726f018e9 0\t.gitattributes\000" +
+		"100755 4d343e022e11a8618db494dc3c501e80c7e18197 0\tbin/PB SCN 16 Odhrán.wav\000" +
+		"120000 a0a01d5a4a0c4e23b7bb5d0c1b9a8bb2ff4e8cc1 0\tlink\000" +
+		"160000 7e0bfe2b1e0b3de1c1b0b1b1f2fc0c2a1cfdb1fa 0\tsubmodule\000" +
+		"100644 e69de29bb2d1d6434b8b29ae775ad8c2e48c5391 1\tconflict.dat\000" +
+		"100644 e69de29bb2d1d6434b8b29ae775ad8c2e48c5391 2\tconflict.dat\000"
+
+	blobs := make(chan TreeBlob, 10)
+	parseLsFilesStage(strings.NewReader(stdout), blobs)
+
+	var actual []TreeBlob
+	for blob := range blobs {
+		actual = append(actual, blob)
+	}
+
+	assert.Equal(t, []TreeBlob{
+		{"d899f6551a51cf19763c5955c7a06a2726f018e9", ".gitattributes"},
+		{"4d343e022e11a8618db494dc3c501e80c7e18197", "bin/PB SCN 16 Odhrán.wav"},
+	}, actual)
+}
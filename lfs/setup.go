@@ -3,6 +3,10 @@ package lfs
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"strings"
+
+	"github.com/github/git-lfs/config"
 )
 
 var (
@@ -23,6 +27,14 @@ var (
 		prePushHook,
 	}
 
+	// preCommitHook invokes `git lfs pre-commit` to warn about large files
+	// which aren't tracked. It's only installed on request, and doesn't
+	// stop commits if git-lfs isn't installed.
+	preCommitHook = &Hook{
+		Type:     "pre-commit",
+		Contents: "#!/bin/sh\ncommand -v git-lfs >/dev/null 2>&1 || exit 0\ngit lfs pre-commit \"$@\"",
+	}
+
 	filters = &Attribute{
 		Section: "filter.lfs",
 		Properties: map[string]string{
@@ -40,13 +52,32 @@ var (
 			"required": "true",
 		},
 	}
+
+	// mergeDriver reports conflicting objects instead of merging pointers
+	// as text, for paths with the merge=lfs attribute.
+	mergeDriver = &Attribute{
+		Section: "merge.lfs",
+		Properties: map[string]string{
+			"name":   "Git LFS merge driver",
+			"driver": "git-lfs merge-driver --ancestor %O --current %A --other %B --marker-size %L --path %P",
+		},
+	}
 )
 
 // Get user-readable manual install steps for hooks
 func GetHookInstallSteps() string {
+	return hookInstallSteps(hooks)
+}
 
+// GetPreCommitHookInstallSteps returns user-readable manual install steps for
+// the pre-commit hook.
+func GetPreCommitHookInstallSteps() string {
+	return hookInstallSteps([]*Hook{preCommitHook})
+}
+
+func hookInstallSteps(hs []*Hook) string {
 	var buf bytes.Buffer
-	for _, h := range hooks {
+	for _, h := range hs {
 		buf.WriteString(fmt.Sprintf("Add the following to .git/hooks/%s :\n\n", h.Type))
 		buf.WriteString(h.Contents)
 		buf.WriteString("\n")
@@ -65,7 +96,14 @@ func InstallHooks(force bool) error {
 	return nil
 }
 
-// UninstallHooks removes all hooks in range of the `hooks` var.
+// InstallPreCommitHook installs the pre-commit hook, which isn't one of the
+// `hooks` installed by default.
+func InstallPreCommitHook(force bool) error {
+	return preCommitHook.Install(force)
+}
+
+// UninstallHooks removes all hooks in range of the `hooks` var, and the
+// pre-commit hook if it was installed and hasn't been changed.
 func UninstallHooks() error {
 	for _, h := range hooks {
 		if err := h.Uninstall(); err != nil {
@@ -73,6 +111,12 @@ func UninstallHooks() error {
 		}
 	}
 
+	if preCommitHook.Exists() {
+		if match, _ := preCommitHook.matchesCurrent(); match {
+			return os.RemoveAll(preCommitHook.Path())
+		}
+	}
+
 	return nil
 }
 
@@ -90,9 +134,61 @@ func InstallFilters(opt InstallOptions, passThrough bool) error {
 	return filters.Install(opt)
 }
 
+// InstallMergeDriver installs the merge driver for paths with the merge=lfs
+// attribute. It's left out of InstallFilters, since Git doesn't pass merge
+// options such as -Xtheirs to merge drivers.
+func InstallMergeDriver(opt InstallOptions) error {
+	return mergeDriver.Install(opt)
+}
+
+// RepairFilters fixes the filter config in the global config, and whatever of
+// it the local config sets, leaving filters which skip smudging alone. It
+// returns a description of each change.
+func RepairFilters() []string {
+	changes := filters.Repair(InstallOptions{}, passFilters)
+	if InRepo() {
+		changes = append(changes, filters.Repair(InstallOptions{Local: true}, passFilters)...)
+	}
+	return changes
+}
+
+// RepairHooks fixes the hooks in the `hooks` var, and the pre-commit hook if
+// it's installed, returning a description of each change and any problems
+// which were left alone.
+func RepairHooks(force bool) ([]string, []error) {
+	hs := hooks
+	if preCommitHook.Exists() {
+		hs = append(hs[:len(hs):len(hs)], preCommitHook)
+	}
+
+	var changes []string
+	var problems []error
+	for _, h := range hs {
+		change, err := h.Repair(force)
+		if err != nil {
+			problems = append(problems, err)
+		} else if len(change) > 0 {
+			changes = append(changes, change)
+		}
+	}
+	return changes, problems
+}
+
+// FiltersInstalled returns whether the lfs clean and smudge filters are
+// configured, without which Git leaves files tracked by Git LFS as pointer text.
+func FiltersInstalled() bool {
+	for _, key := range []string{"clean", "smudge"} {
+		if value, _ := config.Config.GitConfig("filter.lfs." + key); len(strings.TrimSpace(value)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // UninstallFilters proxies into the Uninstall method on the Filters type to
 // remove all installed filters.
 func UninstallFilters() error {
 	filters.Uninstall()
+	mergeDriver.Uninstall()
 	return nil
 }
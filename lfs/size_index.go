@@ -0,0 +1,217 @@
+package lfs
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/github/git-lfs/config"
+	"github.com/rubyist/tracerx"
+)
+
+var (
+	sizeIndex     *SizeIndex
+	sizeIndexOnce sync.Once
+)
+
+// SizeIndexPath returns the path of the sidecar index of pointer sizes.
+func SizeIndexPath() string {
+	return filepath.Join(config.LocalGitStorageDir, "lfs", "size-index")
+}
+
+// CurrentSizeIndex returns the repository's size index if lfs.sizeindex is
+// on, or nil otherwise; a nil index has no entries and records nothing.
+func CurrentSizeIndex() *SizeIndex {
+	sizeIndexOnce.Do(func() {
+		if config.Config.SizeIndex() {
+			sizeIndex = LoadSizeIndex(SizeIndexPath())
+		}
+	})
+	return sizeIndex
+}
+
+// SizeIndex maps the git blob sha1 of pointer files to the oid and size of
+// their objects, so that scans can report pointers without reading their
+// blobs from git. Entries are appended as pointers are cleaned and scanned, a
+// line of "<sha1> <oid> <size>" each.
+//
+// The index is only a cache. An entry is only used if encoding its pointer
+// gives a blob with the same sha1, so an entry which is stale or corrupt is
+// ignored and the pointer is read from git instead, which replaces the entry.
+// Deleting the file is always safe, and it's rebuilt as pointers are scanned.
+type SizeIndex struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]*Pointer
+	f       *os.File
+}
+
+// LoadSizeIndex reads the size index at path. Lines which can't be parsed, and
+// entries which are replaced by later lines, are dropped by rewriting the file.
+func LoadSizeIndex(path string) *SizeIndex {
+	s := &SizeIndex{
+		path:    path,
+		entries: make(map[string]*Pointer),
+	}
+
+	dropped, err := s.load()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			tracerx.Printf("size index: unable to read %s: %s", path, err)
+		}
+		return s
+	}
+
+	if dropped > 0 {
+		tracerx.Printf("size index: rebuilding %s without %d stale line(s)", path, dropped)
+		if err := s.Rebuild(); err != nil {
+			tracerx.Printf("size index: unable to rebuild %s: %s", path, err)
+		}
+	}
+
+	return s
+}
+
+func (s *SizeIndex) load() (int, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	dropped := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		sha1, p, ok := parseSizeIndexLine(scanner.Text())
+		if !ok {
+			dropped++
+			continue
+		}
+
+		if _, ok := s.entries[sha1]; ok {
+			dropped++
+		}
+		s.entries[sha1] = p
+	}
+
+	return dropped, scanner.Err()
+}
+
+func parseSizeIndexLine(line string) (string, *Pointer, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 || len(fields[0]) != 40 || len(fields[1]) != 64 {
+		return "", nil, false
+	}
+
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil || size < 1 {
+		return "", nil, false
+	}
+
+	return fields[0], NewPointer(fields[1], size, nil), true
+}
+
+// Pointer returns the pointer whose blob has the given sha1, if the index has
+// a valid entry for it.
+func (s *SizeIndex) Pointer(sha1 string) (*Pointer, bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.entries[sha1]
+	if ok && PointerBlobSha1(p) != sha1 {
+		tracerx.Printf("size index: ignoring stale entry for %s", sha1)
+		delete(s.entries, sha1)
+		ok = false
+	}
+	return p, ok
+}
+
+// Add records p in the index, under the sha1 of its encoded blob. Pointers
+// with extensions aren't recorded.
+func (s *SizeIndex) Add(p *Pointer) {
+	if s == nil || p == nil || p.Size < 1 || len(p.Extensions) > 0 {
+		return
+	}
+
+	sha1 := PointerBlobSha1(p)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.entries[sha1]; ok && existing.Oid == p.Oid && existing.Size == p.Size {
+		return
+	}
+	s.entries[sha1] = NewPointer(p.Oid, p.Size, nil)
+
+	if s.f == nil {
+		if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+			return
+		}
+
+		f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			tracerx.Printf("size index: unable to write %s: %s", s.path, err)
+			return
+		}
+		s.f = f
+	}
+
+	fmt.Fprintf(s.f, "%s %s %d\n", sha1, p.Oid, p.Size)
+}
+
+// Rebuild rewrites the index with one line for each of its entries.
+func (s *SizeIndex) Rebuild() error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), "size-index")
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(tmp)
+	for sha1, p := range s.entries {
+		fmt.Fprintf(w, "%s %s %d\n", sha1, p.Oid, p.Size)
+	}
+
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	tmp.Close()
+
+	if s.f != nil {
+		s.f.Close()
+		s.f = nil
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// PointerBlobSha1 returns the sha1 git gives the blob of p's encoded pointer.
+func PointerBlobSha1(p *Pointer) string {
+	encoded := p.Encoded()
+	hash := sha1.New()
+	fmt.Fprintf(hash, "blob %d\x00%s", len(encoded), encoded)
+	return hex.EncodeToString(hash.Sum(nil))
+}
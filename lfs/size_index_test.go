@@ -0,0 +1,95 @@
+package lfs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPointerBlobSha1(t *testing.T) {
+	p := NewPointer(strings.Repeat("a", 64), 12345, nil)
+
+	// git hash-object of the encoded pointer
+	assert.Equal(t, "3f20b51a0a25d87c30cb636d2875b09456ec3b12", PointerBlobSha1(p))
+}
+
+func TestSizeIndexFindsAddedPointers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "size-index")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "lfs", "size-index")
+	p := NewPointer(strings.Repeat("a", 64), 12345, nil)
+	sha1 := PointerBlobSha1(p)
+
+	index := LoadSizeIndex(path)
+	_, ok := index.Pointer(sha1)
+	assert.False(t, ok)
+
+	index.Add(p)
+	index.Add(NewPointer(strings.Repeat("b", 64), 10, []*PointerExtension{NewPointerExtension("foo", 0, strings.Repeat("c", 64))}))
+	index.Add(NewPointer(strings.Repeat("d", 64), 0, nil))
+
+	// a later scan reads it from the file
+	index = LoadSizeIndex(path)
+	found, ok := index.Pointer(sha1)
+	if assert.True(t, ok) {
+		assert.Equal(t, p.Oid, found.Oid)
+		assert.Equal(t, int64(12345), found.Size)
+	}
+
+	by, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, sha1+" "+p.Oid+" 12345\n", string(by))
+}
+
+func TestSizeIndexIgnoresStaleEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "size-index")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "size-index")
+	p := NewPointer(strings.Repeat("a", 64), 12345, nil)
+	sha1 := PointerBlobSha1(p)
+
+	stale := strings.Join([]string{
+		sha1 + " " + p.Oid + " 999",
+		"not an entry",
+		strings.Repeat("e", 40) + " " + strings.Repeat("f", 64) + " -1",
+	}, "\n") + "\n"
+	assert.Nil(t, ioutil.WriteFile(path, []byte(stale), 0644))
+
+	// unparsable lines are dropped when it's loaded
+	index := LoadSizeIndex(path)
+	by, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, sha1+" "+p.Oid+" 999\n", string(by))
+
+	// the wrong size doesn't match the blob, so it's read from git instead
+	_, ok := index.Pointer(sha1)
+	assert.False(t, ok)
+	index.Add(p)
+
+	index = LoadSizeIndex(path)
+	found, ok := index.Pointer(sha1)
+	if assert.True(t, ok) {
+		assert.Equal(t, int64(12345), found.Size)
+	}
+
+	by, err = ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, sha1+" "+p.Oid+" 12345\n", string(by))
+}
+
+func TestNilSizeIndex(t *testing.T) {
+	var index *SizeIndex
+	index.Add(NewPointer(strings.Repeat("a", 64), 1, nil))
+
+	_, ok := index.Pointer(strings.Repeat("a", 40))
+	assert.False(t, ok)
+	assert.Nil(t, index.Rebuild())
+}
@@ -0,0 +1,204 @@
+package lfs
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/github/git-lfs/config"
+)
+
+// TrackedPattern is a gitattributes pattern whose files are tracked by Git LFS,
+// along with the other LFS related attributes set for it.
+//
+// Attribute values are given the same way as by `git check-attr`: "set" or
+// "unset" for "attr" and "-attr", the value for "attr=value", and "" if the
+// attribute isn't specified.
+type TrackedPattern struct {
+	// Pattern is relative to the root of the working directory, so a
+	// pattern from a .gitattributes file in a subdirectory is prefixed with
+	// that directory.
+	Pattern string
+	// Source is the attributes file which sets filter=lfs for Pattern,
+	// relative to the root of the working directory if it's inside it.
+	Source   string
+	Filter   string
+	Diff     string
+	Merge    string
+	Lockable bool
+}
+
+// TrackedPatterns returns the patterns which are tracked by Git LFS, in the
+// order they're first found, by reading the attributes files the way git
+// layers them: the core.attributesfile, then .gitattributes files from the
+// root of the working directory down, then $GIT_DIR/info/attributes. An
+// attribute set for a pattern in a later file overrides the same pattern's
+// attribute in an earlier one, so patterns whose filter is later changed or
+// unset aren't returned.
+func TrackedPatterns() ([]*TrackedPattern, error) {
+	var order []string
+	patterns := make(map[string]*TrackedPattern)
+
+	for _, file := range attributeFiles() {
+		if err := readAttributeFile(file, patterns, &order); err != nil {
+			return nil, err
+		}
+	}
+
+	tracked := make([]*TrackedPattern, 0, len(order))
+	for _, pattern := range order {
+		if p := patterns[pattern]; p.Filter == "lfs" {
+			tracked = append(tracked, p)
+		}
+	}
+	return tracked, nil
+}
+
+// attributeFiles returns the paths of the attributes files which apply to the
+// current repository, from lowest to highest precedence.
+func attributeFiles() []string {
+	var files []string
+
+	if global, ok := config.Config.GitConfig("core.attributesfile"); ok && len(global) > 0 {
+		if strings.HasPrefix(global, "~/") {
+			if home := os.Getenv("HOME"); len(home) > 0 {
+				global = filepath.Join(home, global[2:])
+			}
+		}
+		files = append(files, global)
+	}
+
+	var nested []string
+	filepath.Walk(config.LocalWorkingDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		if !info.IsDir() && info.Name() == ".gitattributes" {
+			nested = append(nested, path)
+		}
+		return nil
+	})
+
+	// files in deeper directories take precedence
+	sort.Stable(pathsByDepth(nested))
+	files = append(files, nested...)
+
+	return append(files, filepath.Join(config.LocalGitDir, "info", "attributes"))
+}
+
+func readAttributeFile(file string, patterns map[string]*TrackedPattern, order *[]string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	source := file
+	dir := ""
+	if rel, err := filepath.Rel(config.LocalWorkingDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+		source = filepath.ToSlash(rel)
+		if filepath.Base(file) == ".gitattributes" {
+			if reldir := path.Dir(source); reldir != "." {
+				dir = reldir
+			}
+		}
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[attr]") {
+			continue
+		}
+
+		pattern := fields[0]
+		if len(dir) > 0 {
+			pattern = path.Join(dir, pattern)
+		}
+
+		p, ok := patterns[pattern]
+		if !ok {
+			p = &TrackedPattern{Pattern: pattern}
+			patterns[pattern] = p
+			*order = append(*order, pattern)
+		}
+
+		for _, field := range fields[1:] {
+			name, value := parseAttribute(field)
+			switch name {
+			case "filter":
+				p.Filter = value
+				p.Source = source
+			case "diff":
+				p.Diff = value
+			case "merge":
+				p.Merge = value
+			case "lockable":
+				p.Lockable = value == "set"
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// parseAttribute parses an attribute from a gitattributes line, returning its
+// name and value as `git check-attr` would show it.
+func parseAttribute(field string) (name, value string) {
+	switch {
+	case strings.HasPrefix(field, "-"):
+		return field[1:], "unset"
+	case strings.HasPrefix(field, "!"):
+		return field[1:], ""
+	}
+
+	if i := strings.Index(field, "="); i >= 0 {
+		return field[:i], field[i+1:]
+	}
+	return field, "set"
+}
+
+type pathsByDepth []string
+
+func (p pathsByDepth) Len() int      { return len(p) }
+func (p pathsByDepth) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p pathsByDepth) Less(i, j int) bool {
+	return strings.Count(p[i], string(filepath.Separator)) < strings.Count(p[j], string(filepath.Separator))
+}
+
+// NonFileKind returns what the path is if it isn't a regular file, such as
+// "submodule" for a directory with a .git entry or "symbolic link", so that
+// paths which happen to match a tracked pattern can be skipped. It returns ""
+// for regular files and paths that don't exist.
+func NonFileKind(path string) string {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return ""
+	}
+
+	mode := info.Mode()
+	switch {
+	case mode.IsRegular():
+		return ""
+	case mode.IsDir():
+		if _, err := os.Lstat(filepath.Join(path, ".git")); err == nil {
+			return "submodule"
+		}
+		return "directory"
+	case mode&os.ModeSymlink != 0:
+		return "symbolic link"
+	default:
+		return "special file"
+	}
+}
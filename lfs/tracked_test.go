@@ -0,0 +1,89 @@
+package lfs_test // to avoid import cycles
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/github/git-lfs/lfs"
+	"github.com/github/git-lfs/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrackedPatternsNested(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	writeAttributes(t, ".gitattributes",
+		"# comment\n"+
+			"*.dat filter=lfs diff=lfs merge=lfs -text\n"+
+			"*.psd filter=lfs diff=lfs merge=lfs lockable\n"+
+			"*.txt text\n")
+	writeAttributes(t, "sub/.gitattributes",
+		"*.bin filter=lfs -diff\n"+
+			"*.dat !filter\n")
+	writeAttributes(t, "sub/deeper/.gitattributes", "/*.iso filter=lfs\n")
+	writeAttributes(t, ".git/info/attributes", "*.psd -filter\n")
+
+	patterns, err := TrackedPatterns()
+	assert.Nil(t, err)
+
+	assert.Equal(t, []*TrackedPattern{
+		{Pattern: "*.dat", Source: ".gitattributes", Filter: "lfs", Diff: "lfs", Merge: "lfs"},
+		{Pattern: "sub/*.bin", Source: "sub/.gitattributes", Filter: "lfs", Diff: "unset"},
+		{Pattern: "sub/deeper/*.iso", Source: "sub/deeper/.gitattributes", Filter: "lfs"},
+	}, patterns)
+}
+
+func TestTrackedPatternsLaterFileOverrides(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	writeAttributes(t, ".gitattributes", "*.psd filter=lfs diff=lfs merge=lfs\n")
+	writeAttributes(t, ".git/info/attributes", "*.psd filter=lfs lockable\n")
+
+	patterns, err := TrackedPatterns()
+	assert.Nil(t, err)
+
+	assert.Equal(t, []*TrackedPattern{
+		{Pattern: "*.psd", Source: ".git/info/attributes", Filter: "lfs", Diff: "lfs", Merge: "lfs", Lockable: true},
+	}, patterns)
+}
+
+func writeAttributes(t *testing.T, name, content string) {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNonFileKind(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	assert.Nil(t, ioutil.WriteFile("a.dat", []byte("a"), 0644))
+	assert.Nil(t, os.MkdirAll(filepath.Join("dir.dat", "nested"), 0755))
+	assert.Nil(t, os.MkdirAll(filepath.Join("sub.dat", ".git"), 0755))
+	assert.Nil(t, os.Symlink("a.dat", "link.dat"))
+
+	assert.Equal(t, "", NonFileKind("a.dat"))
+	assert.Equal(t, "", NonFileKind("missing.dat"))
+	assert.Equal(t, "directory", NonFileKind("dir.dat"))
+	assert.Equal(t, "submodule", NonFileKind("sub.dat"))
+	assert.Equal(t, "symbolic link", NonFileKind("link.dat"))
+}
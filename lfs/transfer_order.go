@@ -0,0 +1,47 @@
+package lfs
+
+import (
+	"sort"
+
+	"github.com/github/git-lfs/api"
+)
+
+// sortObjectsForTransfer reorders objs in place by size according to order,
+// as returned by config.Configuration.TransferOrder(). Objects of the same size
+// keep their relative order, as do all objects when order is "natural".
+func sortObjectsForTransfer(objs []*api.ObjectResource, order string) {
+	switch order {
+	case "largest":
+		sort.Stable(sort.Reverse(objectsBySize(objs)))
+	case "smallest":
+		sort.Stable(objectsBySize(objs))
+	}
+}
+
+// sortPriorityObjectsFirst moves the objects whose oids are high priority ahead
+// of the rest of objs, in place. Both keep their relative order, so that the
+// order from sortObjectsForTransfer still applies within each.
+func sortPriorityObjectsFirst(objs []*api.ObjectResource, isPriority func(oid string) bool) {
+	sorted := make([]*api.ObjectResource, 0, len(objs))
+	for _, o := range objs {
+		if isPriority(o.Oid) {
+			sorted = append(sorted, o)
+		}
+	}
+	if len(sorted) == 0 {
+		return
+	}
+
+	for _, o := range objs {
+		if !isPriority(o.Oid) {
+			sorted = append(sorted, o)
+		}
+	}
+	copy(objs, sorted)
+}
+
+type objectsBySize []*api.ObjectResource
+
+func (o objectsBySize) Len() int           { return len(o) }
+func (o objectsBySize) Less(i, j int) bool { return o[i].Size < o[j].Size }
+func (o objectsBySize) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
@@ -0,0 +1,164 @@
+package lfs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/github/git-lfs/api"
+	"github.com/github/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortObjectsForTransfer(t *testing.T) {
+	tests := map[string][]string{
+		"natural":  {"b", "a", "c", "d"},
+		"largest":  {"c", "a", "d", "b"},
+		"smallest": {"b", "a", "d", "c"},
+	}
+
+	for order, expected := range tests {
+		objs := []*api.ObjectResource{
+			{Oid: "b", Size: 1},
+			{Oid: "a", Size: 5},
+			{Oid: "c", Size: 10},
+			{Oid: "d", Size: 5},
+		}
+
+		sortObjectsForTransfer(objs, order)
+
+		actual := make([]string, 0, len(objs))
+		for _, o := range objs {
+			actual = append(actual, o.Oid)
+		}
+		assert.Equal(t, expected, actual, "lfs.transfer.order=%s", order)
+	}
+}
+
+func TestSortPriorityObjectsFirst(t *testing.T) {
+	objs := []*api.ObjectResource{
+		{Oid: "a"},
+		{Oid: "b"},
+		{Oid: "c"},
+		{Oid: "d"},
+	}
+
+	sortPriorityObjectsFirst(objs, func(oid string) bool {
+		return oid == "c" || oid == "b"
+	})
+
+	actual := make([]string, 0, len(objs))
+	for _, o := range objs {
+		actual = append(actual, o.Oid)
+	}
+	assert.Equal(t, []string{"b", "c", "a", "d"}, actual)
+}
+
+func TestTransferQueueDispatchOrder(t *testing.T) {
+	srv := newBatchTestServer()
+	defer srv.Close()
+
+	defer config.Config.ResetConfig()
+
+	oidA := strings.Repeat("a", 64)
+	oidB := strings.Repeat("b", 64)
+	oidC := strings.Repeat("c", 64)
+
+	tests := map[string][]string{
+		"":         {oidB, oidA, oidC},
+		"natural":  {oidB, oidA, oidC},
+		"largest":  {oidC, oidA, oidB},
+		"smallest": {oidB, oidA, oidC},
+	}
+
+	for order, expected := range tests {
+		config.Config.ResetConfig()
+		config.Config.SetConfig("lfs.url", srv.URL+"/repo.git/info/lfs")
+		config.Config.SetConfig("lfs.transfer.order", order)
+
+		q := NewDownloadCheckQueue(3, 16)
+		watchc := q.Watch()
+		addTestObjects(q, oidB, oidA, oidC)
+		actual := waitForTestObjects(q, watchc)
+
+		assert.Empty(t, q.Errors(), "lfs.transfer.order=%q", order)
+		assert.Equal(t, expected, actual, "lfs.transfer.order=%q", order)
+	}
+}
+
+func TestTransferQueueDispatchesPriorityObjectsFirst(t *testing.T) {
+	srv := newBatchTestServer()
+	defer srv.Close()
+
+	defer config.Config.ResetConfig()
+	config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.url", srv.URL+"/repo.git/info/lfs")
+	config.Config.SetConfig("lfs.transfer.order", "largest")
+
+	oidA := strings.Repeat("a", 64)
+	oidB := strings.Repeat("b", 64)
+	oidC := strings.Repeat("c", 64)
+
+	q := NewDownloadCheckQueue(3, 16)
+	watchc := q.Watch()
+	q.Prioritize(oidB)
+	addTestObjects(q, oidB, oidA, oidC)
+	// objects can be prioritized after they're queued too
+	q.Prioritize(oidA)
+	actual := waitForTestObjects(q, watchc)
+
+	assert.Empty(t, q.Errors())
+	assert.Equal(t, []string{oidA, oidB, oidC}, actual)
+}
+
+// addTestObjects adds objects with the given oids to q, sized 1, 5 and 10 in
+// turn.
+func addTestObjects(q *TransferQueue, oids ...string) {
+	sizes := []int64{1, 5, 10}
+	for i, oid := range oids {
+		p := NewPointer(oid, sizes[i], nil)
+		q.Add(NewDownloadable(&WrappedPointer{Name: oid[:1] + ".dat", Size: p.Size, Pointer: p}))
+	}
+}
+
+// waitForTestObjects waits for q to finish, and returns the oids written to
+// watchc, in the order q transferred them.
+func waitForTestObjects(q *TransferQueue, watchc chan string) []string {
+	var actual []string
+	done := make(chan struct{})
+	go func() {
+		for oid := range watchc {
+			actual = append(actual, oid)
+		}
+		close(done)
+	}()
+
+	q.Wait()
+	<-done
+	return actual
+}
+
+// newBatchTestServer returns a server whose batch API has a download action
+// for every object requested.
+func newBatchTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Objects []*api.ObjectResource `json:"objects"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(400)
+			return
+		}
+
+		for _, o := range req.Objects {
+			o.Actions = map[string]*api.LinkRelation{
+				"download": &api.LinkRelation{Href: "http://example.com/" + o.Oid},
+			}
+		}
+
+		w.Header().Set("Content-Type", api.MediaType)
+		json.NewEncoder(w).Encode(map[string]interface{}{"objects": req.Objects})
+	}))
+}
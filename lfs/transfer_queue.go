@@ -1,13 +1,17 @@
 package lfs
 
 import (
+	"fmt"
+	"os"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/github/git-lfs/api"
 	"github.com/github/git-lfs/config"
 	"github.com/github/git-lfs/errutil"
 	"github.com/github/git-lfs/git"
+	"github.com/github/git-lfs/httputil"
 	"github.com/github/git-lfs/progress"
 	"github.com/github/git-lfs/transfer"
 	"github.com/rubyist/tracerx"
@@ -40,6 +44,9 @@ type TransferQueue struct {
 	dryRun            bool
 	retrying          uint32
 	meter             *progress.ProgressMeter
+	events            *progress.EventWriter
+	oidsByName        map[string]string // Transfer names to oids, for events
+	priority          map[string]bool   // High priority oids, transferred first
 	errors            []error
 	transferables     map[string]Transferable
 	retries           []Transferable
@@ -60,7 +67,10 @@ func newTransferQueue(files int, size int64, dryRun bool, dir transfer.Direction
 	q := &TransferQueue{
 		direction:     dir,
 		dryRun:        dryRun,
-		meter:         progress.NewProgressMeter(files, size, dryRun, config.Config.Getenv("GIT_LFS_PROGRESS")),
+		meter:         NewProgressMeter(files, size, dryRun),
+		events:        progressEvents(),
+		oidsByName:    make(map[string]string),
+		priority:      make(map[string]bool),
 		apic:          make(chan Transferable, batchSize),
 		retriesc:      make(chan Transferable, batchSize),
 		errorc:        make(chan error),
@@ -92,6 +102,24 @@ func (q *TransferQueue) Add(t Transferable) {
 	q.apic <- t
 }
 
+// Prioritize marks the objects with the given oids as high priority, so that
+// they're transferred ahead of the other objects in their batch, even if they
+// were added after them. It can be called before or after the objects are
+// added, but has no effect on objects whose batch has already been sent.
+func (q *TransferQueue) Prioritize(oids ...string) {
+	q.trMutex.Lock()
+	for _, oid := range oids {
+		q.priority[oid] = true
+	}
+	q.trMutex.Unlock()
+}
+
+func (q *TransferQueue) isPriority(oid string) bool {
+	q.trMutex.Lock()
+	defer q.trMutex.Unlock()
+	return q.priority[oid]
+}
+
 func (q *TransferQueue) useAdapter(name string) {
 	q.adapterInitMutex.Lock()
 	defer q.adapterInitMutex.Unlock()
@@ -122,6 +150,11 @@ func (q *TransferQueue) addToAdapter(t Transferable) {
 
 	tr := transfer.NewTransfer(t.Name(), t.Object(), t.Path())
 
+	q.trMutex.Lock()
+	q.oidsByName[t.Name()] = t.Oid()
+	q.trMutex.Unlock()
+	q.events.Started(q.transferKind(), t.Oid(), t.Size())
+
 	if q.dryRun {
 		// Don't actually transfer
 		res := transfer.TransferResult{tr, nil}
@@ -136,6 +169,45 @@ func (q *TransferQueue) Skip(size int64) {
 	q.meter.Skip(size)
 }
 
+// NewProgressMeter builds a ProgressMeter for files objects of size bytes,
+// styled by the progress config. Without a style, it's a bar if stdout is a
+// terminal, and lfs.progress.noninteractive's style if not.
+func NewProgressMeter(files int, size int64, dryRun bool) *progress.ProgressMeter {
+	style := config.Config.ProgressStyle()
+	if len(style) == 0 && !progress.IsTerminal(os.Stdout) {
+		style = config.Config.ProgressNonInteractive()
+	}
+
+	meter := progress.NewProgressMeter(files, size, dryRun, config.Config.ProgressLogPath(), style)
+	meter.SetInterval(config.Config.ProgressInterval())
+	return meter
+}
+
+var (
+	progressEventWriter *progress.EventWriter
+	progressEventsOnce  sync.Once
+)
+
+// progressEvents returns the writer of JSON progress events to the file
+// descriptor given by GIT_LFS_PROGRESS_FD, or nil if there isn't one. The file
+// descriptor is shared by every queue, and is left open for the next one.
+// Stdin, stdout and stderr can't be used, so that events never get mixed up
+// with the progress meter or other output.
+func progressEvents() *progress.EventWriter {
+	progressEventsOnce.Do(func() {
+		fd := config.Config.ProgressFd()
+		if fd < 0 {
+			return
+		}
+		if fd < 3 {
+			fmt.Fprintf(os.Stderr, "GIT_LFS_PROGRESS_FD must be 3 or more, not %d\n", fd)
+			return
+		}
+		progressEventWriter = progress.NewEventWriter(os.NewFile(uintptr(fd), "GIT_LFS_PROGRESS_FD"))
+	})
+	return progressEventWriter
+}
+
 func (q *TransferQueue) transferKind() string {
 	if q.direction == transfer.Download {
 		return "download"
@@ -156,7 +228,13 @@ func (q *TransferQueue) ensureAdapterBegun() {
 
 	// Progress callback - receives byte updates
 	cb := func(name string, total, read int64, current int) error {
-		q.meter.TransferBytes(q.transferKind(), name, read, total, current)
+		q.meter.TransferBytes(q.transferKind(), name, read, total, int64(current))
+		if q.events != nil {
+			q.trMutex.Lock()
+			oid := q.oidsByName[name]
+			q.trMutex.Unlock()
+			q.events.Progress(q.transferKind(), oid, read, int64(current))
+		}
 		return nil
 	}
 
@@ -184,18 +262,41 @@ func (q *TransferQueue) handleTransferResult(res transfer.TransferResult) {
 			if ok {
 				q.retry(t)
 			} else {
+				q.events.Errored(q.transferKind(), res.Transfer.Object.Oid, res.Error)
 				q.errorc <- res.Error
 			}
 		} else {
+			q.events.Errored(q.transferKind(), res.Transfer.Object.Oid, res.Error)
 			q.errorc <- res.Error
 		}
 	} else {
 		oid := res.Transfer.Object.Oid
+		if res.Transfer.Header != nil && config.Config.CacheControl() == "honor" {
+			if err := RecordCachePolicy(oid, NewCachePolicy(res.Transfer.Header, time.Now())); err != nil {
+				tracerx.Printf("tq: unable to record cache policy of %s: %s", oid, err)
+			}
+		}
+
+		if q.direction == transfer.Download && !q.dryRun {
+			if err := StoreInExternalCAS(oid, res.Transfer.Path); err != nil {
+				tracerx.Printf("tq: %s", err)
+			}
+		}
+
+		q.trMutex.Lock()
+		t, ok := q.transferables[oid]
+		q.trMutex.Unlock()
+		if ok && t.Size() == 0 && res.Transfer.Object.Size > 0 {
+			// the object's size wasn't known until it was transferred
+			q.meter.AddEstimatedBytes(res.Transfer.Object.Size)
+		}
+
 		for _, c := range q.watchers {
 			c <- oid
 		}
 
 		q.meter.FinishTransfer(res.Transfer.Name)
+		q.events.Completed(q.transferKind(), oid)
 	}
 
 	q.wait.Done()
@@ -321,6 +422,7 @@ func (q *TransferQueue) batchApiRoutine() {
 	var startProgress sync.Once
 
 	transferAdapterNames := transfer.GetAdapterNames(q.direction)
+	order := config.Config.TransferOrder()
 
 	for {
 		batch := q.batcher.Next()
@@ -364,6 +466,8 @@ func (q *TransferQueue) batchApiRoutine() {
 		q.useAdapter(adapterName)
 		startProgress.Do(q.meter.Start)
 
+		sortObjectsForTransfer(objs, order)
+		sortPriorityObjectsFirst(objs, q.isPriority)
 		for _, o := range objs {
 			if o.Error != nil {
 				q.errorc <- errutil.Errorf(o.Error, "[%v] %v", o.Oid, o.Error.Message)
@@ -436,6 +540,9 @@ func (q *TransferQueue) run() {
 	if config.Config.BatchTransfer() {
 		tracerx.Printf("tq: running as batched queue, batch size of %d", batchSize)
 		q.batcher = NewBatcher(batchSize)
+		if config.Config.TLSWarmup() {
+			go httputil.WarmConnection(config.Config.Endpoint(q.transferKind()).Url)
+		}
 		go q.batchApiRoutine()
 	} else {
 		tracerx.Printf("tq: running as individual queue")
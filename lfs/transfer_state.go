@@ -0,0 +1,183 @@
+package lfs
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/git-lfs/config"
+	"github.com/rubyist/tracerx"
+)
+
+// transferStateHeader starts the first line of a transfer state file, which
+// is followed by the URL the objects were pushed to.
+const transferStateHeader = "url "
+
+// TransferStatePath returns the path of the file recording the objects pushed
+// to remote by a push which hasn't finished yet.
+func TransferStatePath(remote string) string {
+	return filepath.Join(config.LocalGitStorageDir, "lfs", "transfer-state", url.QueryEscape(remote))
+}
+
+// TransferState records the objects which a push to a remote has uploaded, so
+// that if the push is interrupted, the next one can skip them. The file holds
+// the URL of the remote's LFS endpoint, then an oid per line as each upload
+// completes.
+type TransferState struct {
+	path      string
+	url       string
+	completed StringSet
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// LoadTransferState returns the state of pushes to remote, whose LFS endpoint
+// is url. The state of an earlier push is discarded if it was last written to
+// more than maxAge ago, or if it was to a different url. Returns nil if maxAge
+// isn't positive, which turns the state off; a nil state records nothing.
+func LoadTransferState(remote, url string, maxAge time.Duration) *TransferState {
+	if maxAge <= 0 {
+		return nil
+	}
+
+	s := &TransferState{
+		path:      TransferStatePath(remote),
+		url:       url,
+		completed: NewStringSet(),
+	}
+
+	if err := s.load(maxAge); err != nil {
+		if !os.IsNotExist(err) {
+			tracerx.Printf("transfer state: discarding %s: %s", s.path, err)
+			os.Remove(s.path)
+		}
+		s.completed = NewStringSet()
+	}
+
+	return s
+}
+
+func (s *TransferState) load(maxAge time.Duration) error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if age := time.Since(stat.ModTime()); age > maxAge {
+		return fmt.Errorf("last written %s ago", age)
+	}
+
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		return fmt.Errorf("no url")
+	}
+	if u := strings.TrimPrefix(scanner.Text(), transferStateHeader); u != s.url {
+		return fmt.Errorf("for %q, not %q", u, s.url)
+	}
+
+	for scanner.Scan() {
+		if oid := strings.TrimSpace(scanner.Text()); len(oid) > 0 {
+			s.completed.Add(oid)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	tracerx.Printf("transfer state: %d objects already pushed to %s", s.completed.Cardinality(), s.url)
+	return nil
+}
+
+// Completed returns whether an earlier push uploaded oid.
+func (s *TransferState) Completed(oid string) bool {
+	if s == nil {
+		return false
+	}
+	return s.completed.Contains(oid)
+}
+
+// Add records that oid has been uploaded.
+func (s *TransferState) Add(oid string) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.f == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	s.completed.Add(oid)
+	_, err := fmt.Fprintln(s.f, oid)
+	return err
+}
+
+// open opens the state file for appending to, writing the url first if it's
+// new.
+func (s *TransferState) open() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	if stat, err := f.Stat(); err == nil && stat.Size() == 0 {
+		_, err = fmt.Fprintf(f, "%s%s\n", transferStateHeader, s.url)
+		if err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	s.f = f
+	return nil
+}
+
+// Close closes the state file, keeping it for the next push.
+func (s *TransferState) Close() error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
+
+// Clear removes the state file, once a push has finished and there's nothing
+// to resume.
+func (s *TransferState) Clear() error {
+	if s == nil {
+		return nil
+	}
+
+	s.Close()
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
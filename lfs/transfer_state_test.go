@@ -0,0 +1,92 @@
+package lfs_test // avoid import cycle
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/git-lfs/lfs"
+	"github.com/github/git-lfs/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferStateRecordsCompletedObjects(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	oid1 := strings.Repeat("a", 64)
+	oid2 := strings.Repeat("b", 64)
+
+	state := lfs.LoadTransferState("origin", "https://example.com/lfs", time.Hour)
+	assert.False(t, state.Completed(oid1))
+	assert.Nil(t, state.Add(oid1))
+	assert.Nil(t, state.Add(oid2))
+	assert.True(t, state.Completed(oid1))
+	assert.Nil(t, state.Close())
+
+	by, err := ioutil.ReadFile(lfs.TransferStatePath("origin"))
+	assert.Nil(t, err)
+	assert.Equal(t, "url https://example.com/lfs\n"+oid1+"\n"+oid2+"\n", string(by))
+
+	// a later push resumes from it
+	state = lfs.LoadTransferState("origin", "https://example.com/lfs", time.Hour)
+	assert.True(t, state.Completed(oid1))
+	assert.True(t, state.Completed(oid2))
+
+	// other remotes have their own
+	other := lfs.LoadTransferState("other/remote", "https://example.com/lfs", time.Hour)
+	assert.False(t, other.Completed(oid1))
+
+	// once the push finishes, there's nothing to resume
+	assert.Nil(t, state.Clear())
+	_, err = os.Stat(lfs.TransferStatePath("origin"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestTransferStateDiscardsStaleState(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	oid := strings.Repeat("a", 64)
+
+	state := lfs.LoadTransferState("origin", "https://example.com/lfs", time.Hour)
+	assert.Nil(t, state.Add(oid))
+	assert.Nil(t, state.Close())
+
+	// the remote's URL changed
+	state = lfs.LoadTransferState("origin", "https://example.com/other", time.Hour)
+	assert.False(t, state.Completed(oid))
+	_, err := os.Stat(lfs.TransferStatePath("origin"))
+	assert.True(t, os.IsNotExist(err))
+
+	state = lfs.LoadTransferState("origin", "https://example.com/lfs", time.Hour)
+	assert.Nil(t, state.Add(oid))
+	assert.Nil(t, state.Close())
+
+	// it's too old
+	old := time.Now().Add(-2 * time.Hour)
+	assert.Nil(t, os.Chtimes(lfs.TransferStatePath("origin"), old, old))
+	state = lfs.LoadTransferState("origin", "https://example.com/lfs", time.Hour)
+	assert.False(t, state.Completed(oid))
+}
+
+func TestTransferStateDisabled(t *testing.T) {
+	state := lfs.LoadTransferState("origin", "https://example.com/lfs", 0)
+	assert.Nil(t, state)
+
+	// a nil state records nothing
+	assert.False(t, state.Completed(strings.Repeat("a", 64)))
+	assert.Nil(t, state.Add(strings.Repeat("a", 64)))
+	assert.Nil(t, state.Close())
+	assert.Nil(t, state.Clear())
+}
@@ -0,0 +1,79 @@
+//go:build ignore
+// +build ignore
+
+// Generates unicode_tables.go, the tables NormalizePath uses to decompose
+// paths, from golang.org/x/text/unicode/norm, which must be in the GOPATH.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	hangulBase = 0xAC00
+	hangulEnd  = 0xD7A3
+)
+
+func main() {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// generated by go generate from Unicode %s; DO NOT EDIT\n\n", norm.Version)
+	fmt.Fprintln(&buf, "package lfs")
+	fmt.Fprintln(&buf)
+
+	fmt.Fprintln(&buf, "// canonicalDecompositions maps each character with a canonical")
+	fmt.Fprintln(&buf, "// decomposition, other than Hangul syllables, to its full decomposition.")
+	fmt.Fprintln(&buf, "var canonicalDecompositions = map[rune]string{")
+	for r := rune(0); r <= utf8.MaxRune; r++ {
+		if r >= hangulBase && r <= hangulEnd || !utf8.ValidRune(r) {
+			continue
+		}
+		s := string(r)
+		if d := norm.NFD.String(s); d != s {
+			fmt.Fprintf(&buf, "\t0x%04X: %+q,\n", r, d)
+		}
+	}
+	fmt.Fprintln(&buf, "}")
+	fmt.Fprintln(&buf)
+
+	fmt.Fprintln(&buf, "// combiningClasses are the ranges of characters with the same non-zero")
+	fmt.Fprintln(&buf, "// canonical combining class, in order.")
+	fmt.Fprintln(&buf, "var combiningClasses = []combiningClassRange{")
+	var lo, hi rune
+	var class uint8
+	flush := func() {
+		if class != 0 {
+			fmt.Fprintf(&buf, "\t{0x%04X, 0x%04X, %d},\n", lo, hi, class)
+		}
+	}
+	for r := rune(0); r <= utf8.MaxRune; r++ {
+		c := uint8(0)
+		if utf8.ValidRune(r) {
+			c = norm.NFD.PropertiesString(string(r)).CCC()
+		}
+		if c == class && r == hi+1 {
+			hi = r
+			continue
+		}
+		flush()
+		lo, hi, class = r, r, c
+	}
+	flush()
+	fmt.Fprintln(&buf, "}")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile("unicode_tables.go", src, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -71,6 +71,13 @@ func NewUploadable(oid, filename string) (*Uploadable, error) {
 	return &Uploadable{oid: oid, OidPath: localMediaPath, Filename: filename, size: fi.Size()}, nil
 }
 
+// NewDryRunUploadable builds an Uploadable for the object of p without reading
+// or cleaning its content, for dry runs which only ask the server whether it
+// needs the object.
+func NewDryRunUploadable(p *WrappedPointer) *Uploadable {
+	return &Uploadable{oid: p.Oid, Filename: p.Name, size: p.Size}
+}
+
 // NewUploadQueue builds an UploadQueue, allowing `workers` concurrent uploads.
 func NewUploadQueue(files int, size int64, dryRun bool) *TransferQueue {
 	return newTransferQueue(files, size, dryRun, transfer.Upload)
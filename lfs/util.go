@@ -27,7 +27,7 @@ const (
 var currentPlatform = PlatformUndetermined
 
 func CopyCallbackFile(event, filename string, index, totalFiles int) (progress.CopyCallback, *os.File, error) {
-	logPath := config.Config.Getenv("GIT_LFS_PROGRESS")
+	logPath := config.Config.ProgressLogPath()
 	if len(logPath) == 0 || len(filename) == 0 || len(event) == 0 {
 		return nil, nil, nil
 	}
@@ -81,11 +81,15 @@ func FilenamePassesIncludeExcludeFilter(filename string, includePaths, excludePa
 		return true
 	}
 
+	// Paths given on the command line on macOS may be decomposed
+	filename = NormalizePath(filename)
+
 	// For Win32, because git reports files with / separators
 	cleanfilename := filepath.Clean(filename)
 	if len(includePaths) > 0 {
 		matched := false
 		for _, inc := range includePaths {
+			inc = NormalizePath(inc)
 			// Special case local dir, matches all (inc subpaths)
 			if _, local := localDirSet[inc]; local {
 				matched = true
@@ -114,6 +118,7 @@ func FilenamePassesIncludeExcludeFilter(filename string, includePaths, excludePa
 
 	if len(excludePaths) > 0 {
 		for _, ex := range excludePaths {
+			ex = NormalizePath(ex)
 			// Special case local dir, matches all (inc subpaths)
 			if _, local := localDirSet[ex]; local {
 				return false
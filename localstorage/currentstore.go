@@ -42,7 +42,15 @@ func ResolveDirs() {
 	objects = objs
 	config.LocalLogDir = filepath.Join(objs.RootDir, "logs")
 	if err := os.MkdirAll(config.LocalLogDir, localLogDirPerms); err != nil {
-		panic(fmt.Errorf("Error trying to create log directory in '%s': %s", config.LocalLogDir, err))
+		if !objs.FailedWrite(err) {
+			panic(fmt.Errorf("Error trying to create log directory in '%s': %s", config.LocalLogDir, err))
+		}
+
+		// keep logs with the temp files instead
+		config.LocalLogDir = filepath.Join(TempDir, "logs")
+		if err := os.MkdirAll(config.LocalLogDir, localLogDirPerms); err != nil {
+			panic(fmt.Errorf("Error trying to create log directory in '%s': %s", config.LocalLogDir, err))
+		}
 	}
 }
 
@@ -7,6 +7,11 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sync"
+	"syscall"
+
+	"github.com/github/git-lfs/config"
+	"github.com/rubyist/tracerx"
 )
 
 const (
@@ -22,6 +27,9 @@ var (
 type LocalStorage struct {
 	RootDir string
 	TempDir string
+
+	mu       sync.Mutex
+	readOnly bool
 }
 
 // Object represents a locally stored LFS object.
@@ -39,7 +47,53 @@ func NewStorage(storageDir, tempDir string) (*LocalStorage, error) {
 		return nil, err
 	}
 
-	return &LocalStorage{storageDir, tempDir}, nil
+	return &LocalStorage{RootDir: storageDir, TempDir: tempDir}, nil
+}
+
+// ReadOnly returns whether objects can't be added to the store, as set by
+// lfs.storage.readonly, or if that's "auto", whether writing to the store has
+// failed with a permission or read-only file system error.
+func (s *LocalStorage) ReadOnly() bool {
+	switch config.Config.StorageReadOnly() {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readOnly
+}
+
+// FailedWrite records that writing to the store failed with err, returning
+// whether the store is read-only. With lfs.storage.readonly "auto", a
+// permission or read-only file system error makes it read-only from then on,
+// so that callers can fall back to not storing objects.
+func (s *LocalStorage) FailedWrite(err error) bool {
+	if err == nil || config.Config.StorageReadOnly() != "auto" || !isReadOnlyError(err) {
+		return s.ReadOnly()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.readOnly {
+		tracerx.Printf("localstorage: %s is read-only: %s", s.RootDir, err)
+		s.readOnly = true
+	}
+	return true
+}
+
+func isReadOnlyError(err error) bool {
+	switch e := err.(type) {
+	case *os.PathError:
+		err = e.Err
+	case *os.LinkError:
+		err = e.Err
+	case *os.SyscallError:
+		err = e.Err
+	}
+	return os.IsPermission(err) || err == syscall.EROFS
 }
 
 func (s *LocalStorage) ObjectPath(oid string) string {
@@ -49,6 +103,7 @@ func (s *LocalStorage) ObjectPath(oid string) string {
 func (s *LocalStorage) BuildObjectPath(oid string) (string, error) {
 	dir := localObjectDir(s, oid)
 	if err := os.MkdirAll(dir, dirPerms); err != nil {
+		s.FailedWrite(err)
 		return "", fmt.Errorf("Error trying to create local storage directory in %q: %s", dir, err)
 	}
 
@@ -0,0 +1,42 @@
+package localstorage
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/github/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailedWriteMakesStoreReadOnly(t *testing.T) {
+	s := &LocalStorage{RootDir: "/lfs/objects"}
+	assert.False(t, s.ReadOnly())
+
+	assert.False(t, s.FailedWrite(nil))
+	assert.False(t, s.FailedWrite(errors.New("disk full")))
+	assert.False(t, s.ReadOnly())
+
+	assert.True(t, s.FailedWrite(&os.PathError{Op: "mkdir", Path: "/lfs/objects/ab", Err: syscall.EROFS}))
+	assert.True(t, s.ReadOnly())
+}
+
+func TestFailedWriteWithPermissionError(t *testing.T) {
+	s := &LocalStorage{RootDir: "/lfs/objects"}
+	assert.True(t, s.FailedWrite(&os.PathError{Op: "open", Path: "/lfs/objects/ab", Err: syscall.EACCES}))
+	assert.True(t, s.ReadOnly())
+}
+
+func TestFailedWriteWithStorageReadOnlySet(t *testing.T) {
+	defer config.Config.ResetConfig()
+	err := &os.PathError{Op: "mkdir", Path: "/lfs/objects/ab", Err: syscall.EROFS}
+
+	config.Config.SetConfig("lfs.storage.readonly", "false")
+	s := &LocalStorage{RootDir: "/lfs/objects"}
+	assert.False(t, s.FailedWrite(err))
+	assert.False(t, s.ReadOnly())
+
+	config.Config.SetConfig("lfs.storage.readonly", "true")
+	assert.True(t, s.ReadOnly())
+}
@@ -9,13 +9,31 @@ type CallbackReader struct {
 	TotalSize int64
 	ReadSize  int64
 	io.Reader
+
+	// Throttle limits the rate of reads, if it isn't nil. It may be shared
+	// with other readers to limit them together.
+	Throttle *Throttle
+
+	rate *TransferRate
 }
 
 func (w *CallbackReader) Read(p []byte) (int, error) {
+	if w.rate == nil {
+		w.rate = NewTransferRate()
+	}
+
+	if w.Throttle != nil {
+		if max := w.Throttle.chunkSize(); len(p) > max {
+			p = p[:max]
+		}
+	}
+
 	n, err := w.Reader.Read(p)
 
 	if n > 0 {
 		w.ReadSize += int64(n)
+		w.rate.Add(int64(n))
+		w.Throttle.Wait(n)
 	}
 
 	if err == nil && w.C != nil {
@@ -24,3 +42,12 @@ func (w *CallbackReader) Read(p []byte) (int, error) {
 
 	return n, err
 }
+
+// BytesPerSecond returns the smoothed rate at which the reader is being read,
+// so that callbacks can report it.
+func (w *CallbackReader) BytesPerSecond() float64 {
+	if w.rate == nil {
+		return 0
+	}
+	return w.rate.BytesPerSecond()
+}
@@ -0,0 +1,112 @@
+package progress
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// Event is a line of machine readable progress, written by EventWriter. It has
+// the same shape as the progress messages of the custom transfer protocol, so
+// that GUI clients can read both the same way.
+type Event struct {
+	Event          string      `json:"event"`
+	Direction      string      `json:"direction"`
+	Oid            string      `json:"oid"`
+	Size           int64       `json:"size,omitempty"`
+	BytesSoFar     int64       `json:"bytesSoFar,omitempty"`
+	BytesSinceLast int64       `json:"bytesSinceLast,omitempty"`
+	BytesPerSecond float64     `json:"bytesPerSecond,omitempty"`
+	Error          *EventError `json:"error,omitempty"`
+}
+
+// EventError describes why a transfer failed.
+type EventError struct {
+	Message string `json:"message"`
+}
+
+// EventWriter writes transfer progress as newline delimited JSON Events, for
+// GUI clients which would otherwise parse the progress meter's output. Each
+// event is written whole, so the writer can be shared by many transfers. A nil
+// EventWriter writes nothing.
+type EventWriter struct {
+	w     io.Writer
+	rates map[string]*TransferRate
+	mu    sync.Mutex
+}
+
+// NewEventWriter returns an EventWriter which writes events to w.
+func NewEventWriter(w io.Writer) *EventWriter {
+	return &EventWriter{w: w, rates: make(map[string]*TransferRate)}
+}
+
+// Started writes a "started" event for the transfer of oid.
+func (e *EventWriter) Started(direction, oid string, size int64) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rates[oid] = NewTransferRate()
+	e.write(&Event{Event: "started", Direction: direction, Oid: oid, Size: size})
+}
+
+// Progress writes a "progress" event for the transfer of oid, with the
+// smoothed rate of that transfer.
+func (e *EventWriter) Progress(direction, oid string, bytesSoFar, bytesSinceLast int64) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	rate, ok := e.rates[oid]
+	if !ok {
+		rate = NewTransferRate()
+		e.rates[oid] = rate
+	}
+	rate.Add(bytesSinceLast)
+	e.write(&Event{Event: "progress", Direction: direction, Oid: oid,
+		BytesSoFar: bytesSoFar, BytesSinceLast: bytesSinceLast, BytesPerSecond: rate.BytesPerSecond()})
+}
+
+// Completed writes a "completed" event for the transfer of oid.
+func (e *EventWriter) Completed(direction, oid string) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.rates, oid)
+	e.write(&Event{Event: "completed", Direction: direction, Oid: oid})
+}
+
+// Errored writes an "errored" event for the transfer of oid.
+func (e *EventWriter) Errored(direction, oid string, err error) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.rates, oid)
+	e.write(&Event{Event: "errored", Direction: direction, Oid: oid, Error: &EventError{Message: err.Error()}})
+}
+
+// write must be called with mu held. Once a write fails, for example because
+// the client closed its end of a pipe, no more events are written.
+func (e *EventWriter) write(ev *Event) {
+	if e.w == nil {
+		return
+	}
+
+	by, err := json.Marshal(ev)
+	if err == nil {
+		_, err = e.w.Write(append(by, '\n'))
+	}
+	if err != nil {
+		e.w = nil
+	}
+}
@@ -0,0 +1,97 @@
+package progress
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readEvents(t *testing.T, buf *bytes.Buffer) []*Event {
+	var events []*Event
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var ev Event
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("bad event %q: %v", scanner.Text(), err)
+		}
+		events = append(events, &ev)
+	}
+	return events
+}
+
+func TestEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEventWriter(&buf)
+
+	e.Started("download", "aaaa", 10)
+	e.Progress("download", "aaaa", 4, 4)
+	e.Progress("download", "aaaa", 10, 6)
+	e.Completed("download", "aaaa")
+	e.Started("upload", "bbbb", 5)
+	e.Errored("upload", "bbbb", errors.New("no space"))
+
+	events := readEvents(t, &buf)
+	if !assert.Equal(t, 6, len(events)) {
+		return
+	}
+
+	assert.Equal(t, &Event{Event: "started", Direction: "download", Oid: "aaaa", Size: 10}, events[0])
+	assert.Equal(t, "progress", events[1].Event)
+	assert.Equal(t, int64(4), events[1].BytesSoFar)
+	assert.Equal(t, int64(4), events[1].BytesSinceLast)
+	assert.Equal(t, int64(10), events[2].BytesSoFar)
+	assert.Equal(t, int64(6), events[2].BytesSinceLast)
+	assert.Equal(t, &Event{Event: "completed", Direction: "download", Oid: "aaaa"}, events[3])
+	assert.Equal(t, &Event{Event: "errored", Direction: "upload", Oid: "bbbb", Error: &EventError{Message: "no space"}}, events[5])
+	assert.Empty(t, e.rates)
+}
+
+func TestEventWriterWritesWholeLines(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEventWriter(&buf)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				e.Progress("download", "aaaa", int64(j), 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1000, len(readEvents(t, &buf)))
+}
+
+type failingEventWriter struct {
+	writes int
+}
+
+func (w *failingEventWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("broken pipe")
+}
+
+func TestEventWriterStopsAfterWriteError(t *testing.T) {
+	w := &failingEventWriter{}
+	e := NewEventWriter(w)
+
+	e.Started("download", "aaaa", 10)
+	e.Completed("download", "aaaa")
+	assert.Equal(t, 1, w.writes)
+}
+
+func TestNilEventWriter(t *testing.T) {
+	var e *EventWriter
+	e.Started("download", "aaaa", 10)
+	e.Progress("download", "aaaa", 4, 4)
+	e.Completed("download", "aaaa")
+	e.Errored("download", "aaaa", errors.New("failed"))
+}
@@ -2,6 +2,7 @@ package progress
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"sync"
@@ -15,6 +16,10 @@ import (
 // is given an estimated file count and size up front and tracks the number of
 // files and bytes transferred as well as the number of files and bytes that
 // get skipped because the transfer is unnecessary.
+//
+// The style is "bar" for a line which is updated in place, "plain" for a new
+// line each time the progress changes, which suits logs, or "none" for no
+// output.
 type ProgressMeter struct {
 	finishedFiles     int64 // int64s must come first for struct alignment
 	skippedFiles      int64
@@ -30,16 +35,31 @@ type ProgressMeter struct {
 	fileIndex         map[string]int64 // Maps a file name to its transfer number
 	fileIndexMutex    *sync.Mutex
 	dryRun            bool
+	style             string
+	out               io.Writer
+	lastStatus        string
+	lastPrinted       time.Time
+	interval          time.Duration
+	rate              *TransferRate
+	updateMutex       sync.Mutex
 }
 
 // NewProgressMeter creates a new ProgressMeter for the number and size of
-// files given.
-func NewProgressMeter(estFiles int, estBytes int64, dryRun bool, logPath string) *ProgressMeter {
+// files given. An empty style shows a bar if stdout is a terminal, and plain
+// lines if it isn't.
+func NewProgressMeter(estFiles int, estBytes int64, dryRun bool, logPath, style string) *ProgressMeter {
 	logger, err := newProgressLogger(logPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating progress logger: %s\n", err)
 	}
 
+	if len(style) == 0 {
+		style = "plain"
+		if IsTerminal(os.Stdout) {
+			style = "bar"
+		}
+	}
+
 	return &ProgressMeter{
 		logger:         logger,
 		startTime:      time.Now(),
@@ -49,9 +69,21 @@ func NewProgressMeter(estFiles int, estBytes int64, dryRun bool, logPath string)
 		estimatedFiles: int32(estFiles),
 		estimatedBytes: estBytes,
 		dryRun:         dryRun,
+		style:          style,
+		out:            os.Stdout,
+		rate:           NewTransferRate(),
 	}
 }
 
+// SetInterval makes the plain style write a line at most once per interval,
+// rather than each time the progress changes. The first line is written
+// straight away, and the last when the meter finishes.
+func (p *ProgressMeter) SetInterval(interval time.Duration) {
+	p.updateMutex.Lock()
+	p.interval = interval
+	p.updateMutex.Unlock()
+}
+
 func (p *ProgressMeter) Start() {
 	if atomic.SwapInt32(&p.started, 1) == 0 {
 		go p.writer()
@@ -78,12 +110,25 @@ func (p *ProgressMeter) Skip(size int64) {
 
 }
 
+// AddEstimatedBytes adds size to the estimated bytes, for a file whose size
+// wasn't known when it was added, once its transfer has found it.
+func (p *ProgressMeter) AddEstimatedBytes(size int64) {
+	atomic.AddInt64(&p.estimatedBytes, size)
+}
+
 // TransferBytes increments the number of bytes transferred
-func (p *ProgressMeter) TransferBytes(direction, name string, read, total int64, current int) {
-	atomic.AddInt64(&p.currentBytes, int64(current))
+func (p *ProgressMeter) TransferBytes(direction, name string, read, total, current int64) {
+	atomic.AddInt64(&p.currentBytes, current)
+	p.rate.Add(current)
 	p.logBytes(direction, name, read, total)
 }
 
+// BytesPerSecond returns the smoothed rate of all the transfers, over the last
+// few seconds.
+func (p *ProgressMeter) BytesPerSecond() float64 {
+	return p.rate.BytesPerSecond()
+}
+
 // FinishTransfer increments the finished transfer count
 func (p *ProgressMeter) FinishTransfer(name string) {
 	atomic.AddInt64(&p.finishedFiles, 1)
@@ -95,10 +140,11 @@ func (p *ProgressMeter) FinishTransfer(name string) {
 // Finish shuts down the ProgressMeter
 func (p *ProgressMeter) Finish() {
 	close(p.finished)
+	p.SetInterval(0)
 	p.update()
 	p.logger.Close()
-	if !p.dryRun && p.estimatedBytes > 0 {
-		fmt.Fprintf(os.Stdout, "\n")
+	if !p.dryRun && p.style == "bar" && p.estimatedBytes > 0 {
+		fmt.Fprintf(p.out, "\n")
 	}
 }
 
@@ -125,7 +171,31 @@ func (p *ProgressMeter) writer() {
 }
 
 func (p *ProgressMeter) update() {
-	if p.dryRun || (p.estimatedFiles == 0 && p.skippedFiles == 0) {
+	if p.dryRun || p.style == "none" || (p.estimatedFiles == 0 && p.skippedFiles == 0) {
+		return
+	}
+
+	p.updateMutex.Lock()
+	defer p.updateMutex.Unlock()
+
+	// (%d of %d files, %d skipped) %f B / %f B, %f B skipped
+	// skipped counts only show when > 0
+
+	status := fmt.Sprintf("Git LFS: (%d of %d files", atomic.LoadInt64(&p.finishedFiles), atomic.LoadInt32(&p.estimatedFiles))
+	if skipped := atomic.LoadInt64(&p.skippedFiles); skipped > 0 {
+		status += fmt.Sprintf(", %d skipped", skipped)
+	}
+	status += fmt.Sprintf(") %s / %s", formatBytes(atomic.LoadInt64(&p.currentBytes)), formatBytes(atomic.LoadInt64(&p.estimatedBytes)))
+	if skipped := atomic.LoadInt64(&p.skippedBytes); skipped > 0 {
+		status += fmt.Sprintf(", %s skipped", formatBytes(skipped))
+	}
+
+	if p.style == "plain" {
+		if status != p.lastStatus && time.Since(p.lastPrinted) >= p.interval {
+			fmt.Fprintln(p.out, status)
+			p.lastStatus = status
+			p.lastPrinted = time.Now()
+		}
 		return
 	}
 
@@ -135,24 +205,17 @@ func (p *ProgressMeter) update() {
 		width = size.Col()
 	}
 
-	// (%d of %d files, %d skipped) %f B / %f B, %f B skipped
-	// skipped counts only show when > 0
-
-	out := fmt.Sprintf("\rGit LFS: (%d of %d files", p.finishedFiles, p.estimatedFiles)
-	if p.skippedFiles > 0 {
-		out += fmt.Sprintf(", %d skipped", p.skippedFiles)
-	}
-	out += fmt.Sprintf(") %s / %s", formatBytes(p.currentBytes), formatBytes(p.estimatedBytes))
-	if p.skippedBytes > 0 {
-		out += fmt.Sprintf(", %s skipped", formatBytes(p.skippedBytes))
+	if rate := p.BytesPerSecond(); rate >= 1 {
+		status += " | " + FormatRate(rate)
 	}
 
+	out := "\r" + status
 	padlen := width - len(out)
 	if 0 < padlen {
 		out += strings.Repeat(" ", padlen)
 	}
 
-	fmt.Fprintf(os.Stdout, out)
+	fmt.Fprint(p.out, out)
 }
 
 func formatBytes(i int64) string {
@@ -0,0 +1,123 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressMeterLargeTransfers(t *testing.T) {
+	const gb = int64(1024 * 1024 * 1024)
+
+	m := NewProgressMeter(2, 11*gb, true, "", "bar")
+	m.Add("a.dat")
+	m.Add("b.dat")
+
+	// a single update of more than 4GB, as checkout gives
+	m.TransferBytes("checkout", "a.dat", 5*gb, 11*gb, 5*gb)
+	m.FinishTransfer("a.dat")
+
+	// and many smaller ones adding up to more than 4GB
+	for read := int64(0); read < 6*gb; read += gb / 2 {
+		m.TransferBytes("download", "b.dat", read+gb/2, 6*gb, gb/2)
+	}
+	m.FinishTransfer("b.dat")
+
+	assert.Equal(t, 11*gb, m.currentBytes)
+	assert.Equal(t, 11*gb, m.estimatedBytes)
+	assert.Equal(t, int64(2), m.finishedFiles)
+}
+
+func TestProgressMeterAddEstimatedBytes(t *testing.T) {
+	// b.dat's size isn't known, so only a.dat's is estimated
+	m := NewProgressMeter(2, 10, true, "", "none")
+	m.Add("a.dat")
+	m.Add("b.dat")
+	m.TransferBytes("download", "a.dat", 10, 10, 10)
+	m.FinishTransfer("a.dat")
+	m.TransferBytes("download", "b.dat", 15, 0, 15)
+	m.AddEstimatedBytes(15)
+	m.FinishTransfer("b.dat")
+
+	assert.Equal(t, int64(25), m.currentBytes)
+	assert.Equal(t, int64(25), m.estimatedBytes)
+}
+
+func TestProgressMeterBarStyle(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewProgressMeter(1, 10, false, "", "bar")
+	m.out = &buf
+
+	m.Add("a.dat")
+	m.update()
+	m.TransferBytes("download", "a.dat", 10, 10, 10)
+	m.FinishTransfer("a.dat")
+	m.update()
+	m.Finish()
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "\rGit LFS: (0 of 1 files) 0 B / 10 B"))
+	assert.Contains(t, out, "\rGit LFS: (1 of 1 files) 10 B / 10 B")
+	assert.Equal(t, 1, strings.Count(out, "\n"), "only ends the line when finished")
+	assert.True(t, strings.HasSuffix(out, "\n"))
+}
+
+func TestProgressMeterPlainStyle(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewProgressMeter(1, 10, false, "", "plain")
+	m.out = &buf
+
+	m.Add("a.dat")
+	m.update()
+	m.update()
+	m.TransferBytes("download", "a.dat", 10, 10, 10)
+	m.FinishTransfer("a.dat")
+	m.update()
+	m.Finish()
+
+	assert.Equal(t, "Git LFS: (0 of 1 files) 0 B / 10 B\n"+
+		"Git LFS: (1 of 1 files) 10 B / 10 B\n", buf.String(), "one line per change")
+}
+
+func TestProgressMeterPlainStyleInterval(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewProgressMeter(2, 20, false, "", "plain")
+	m.out = &buf
+	m.SetInterval(time.Hour)
+
+	m.Add("a.dat")
+	m.Add("b.dat")
+	m.update()
+	m.TransferBytes("download", "a.dat", 10, 10, 10)
+	m.FinishTransfer("a.dat")
+	m.update()
+	m.TransferBytes("download", "b.dat", 10, 10, 10)
+	m.FinishTransfer("b.dat")
+	m.Finish()
+
+	assert.Equal(t, "Git LFS: (0 of 2 files) 0 B / 20 B\n"+
+		"Git LFS: (2 of 2 files) 20 B / 20 B\n", buf.String(), "skips lines within the interval, but not the last")
+}
+
+func TestProgressMeterNoneStyle(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewProgressMeter(1, 10, false, "", "none")
+	m.out = &buf
+
+	m.Add("a.dat")
+	m.update()
+	m.TransferBytes("download", "a.dat", 10, 10, 10)
+	m.FinishTransfer("a.dat")
+	m.Finish()
+
+	assert.Equal(t, "", buf.String())
+}
+
+func TestProgressMeterDefaultStyle(t *testing.T) {
+	// test output isn't a terminal
+	m := NewProgressMeter(1, 10, false, "", "")
+	assert.Equal(t, "plain", m.style)
+}
@@ -0,0 +1,98 @@
+package progress
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// rateSampleInterval is the shortest time over which bytes are
+	// averaged into a sample of the rate, so that bursts of small reads
+	// don't make it jump about.
+	rateSampleInterval = 200 * time.Millisecond
+
+	// rateWindow is the time constant of the rate's exponential moving
+	// average: older samples carry less weight the longer ago they were,
+	// so the rate follows roughly the last few seconds.
+	rateWindow = 3 * time.Second
+)
+
+// TransferRate works out a smoothed rate of transfer, in bytes per second,
+// from the bytes added to it, as an exponential moving average over the last
+// few seconds. A transfer which stalls decays towards 0.
+type TransferRate struct {
+	rate    float64
+	sampled bool
+	pending int64
+	last    time.Time
+	mutex   sync.Mutex
+}
+
+// NewTransferRate creates a TransferRate for a transfer starting now.
+func NewTransferRate() *TransferRate {
+	return &TransferRate{last: time.Now()}
+}
+
+// Add records that n more bytes have been transferred.
+func (r *TransferRate) Add(n int64) {
+	r.add(n, time.Now())
+}
+
+// BytesPerSecond returns the smoothed rate of transfer.
+func (r *TransferRate) BytesPerSecond() float64 {
+	return r.at(time.Now())
+}
+
+func (r *TransferRate) add(n int64, now time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.last.IsZero() {
+		// added to before it was started, so these bytes come at the
+		// start of the first sample
+		r.last = now
+	}
+
+	r.pending += n
+	r.sample(now)
+}
+
+func (r *TransferRate) at(now time.Time) float64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.last.IsZero() {
+		return 0
+	}
+
+	r.sample(now)
+	return r.rate
+}
+
+// sample folds the bytes added since the last sample into the rate, once at
+// least rateSampleInterval has passed. If none were added, the rate decays.
+func (r *TransferRate) sample(now time.Time) {
+	elapsed := now.Sub(r.last)
+	if elapsed < rateSampleInterval {
+		return
+	}
+
+	current := float64(r.pending) / elapsed.Seconds()
+	if r.sampled {
+		weight := 1 - math.Exp(-elapsed.Seconds()/rateWindow.Seconds())
+		r.rate += weight * (current - r.rate)
+	} else {
+		r.rate = current
+		r.sampled = true
+	}
+
+	r.pending = 0
+	r.last = now
+}
+
+// FormatRate formats a rate of transfer in bytes per second, like "1.50 MB/s".
+func FormatRate(bytesPerSecond float64) string {
+	return fmt.Sprintf("%s/s", formatBytes(int64(bytesPerSecond)))
+}
@@ -0,0 +1,95 @@
+package progress
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferRateBeforeFirstSample(t *testing.T) {
+	start := time.Now()
+
+	var unstarted TransferRate
+	assert.Equal(t, float64(0), unstarted.at(start))
+
+	unstarted.add(1000, start)
+	assert.Equal(t, float64(0), unstarted.at(start))
+	assert.Equal(t, float64(0), unstarted.at(start.Add(time.Millisecond)))
+
+	r := &TransferRate{last: start}
+	r.add(1000, start)
+	assert.Equal(t, float64(0), r.at(start))
+}
+
+func TestTransferRateFirstSample(t *testing.T) {
+	start := time.Now()
+	r := &TransferRate{last: start}
+
+	r.add(500, start.Add(100*time.Millisecond))
+	r.add(500, start.Add(500*time.Millisecond))
+
+	assert.Equal(t, float64(2000), r.at(start.Add(500*time.Millisecond)))
+}
+
+func TestTransferRateSteady(t *testing.T) {
+	start := time.Now()
+	r := &TransferRate{last: start}
+
+	now := start
+	for i := 0; i < 50; i++ {
+		now = now.Add(500 * time.Millisecond)
+		r.add(5000, now)
+	}
+
+	assert.InDelta(t, 10000, r.at(now), 0.01)
+}
+
+func TestTransferRateSmoothsChanges(t *testing.T) {
+	start := time.Now()
+	r := &TransferRate{last: start}
+
+	now := start.Add(time.Second)
+	r.add(10000, now)
+
+	now = now.Add(time.Second)
+	r.add(20000, now)
+
+	rate := r.at(now)
+	assert.True(t, rate > 10000 && rate < 20000, "rate %f", rate)
+}
+
+func TestTransferRateDecaysWhenStalled(t *testing.T) {
+	start := time.Now()
+	r := &TransferRate{last: start}
+
+	now := start.Add(time.Second)
+	r.add(10000, now)
+
+	stalled := r.at(now.Add(3 * time.Second))
+	assert.True(t, stalled > 0 && stalled < 10000, "rate %f", stalled)
+
+	longStalled := r.at(now.Add(time.Minute))
+	assert.True(t, longStalled < stalled, "rate %f", longStalled)
+	assert.False(t, math.IsNaN(longStalled) || math.IsInf(longStalled, 0))
+}
+
+func TestCallbackReaderBytesPerSecond(t *testing.T) {
+	reader := &CallbackReader{
+		TotalSize: 5,
+		Reader:    bytes.NewBufferString("BOOYA"),
+	}
+	assert.Equal(t, float64(0), reader.BytesPerSecond())
+
+	_, err := reader.Read(make([]byte, 5))
+	assert.Nil(t, err)
+	assert.False(t, math.IsNaN(reader.BytesPerSecond()))
+}
+
+func TestFormatRate(t *testing.T) {
+	assert.Equal(t, "0 B/s", FormatRate(0))
+	assert.Equal(t, "2.00 KB/s", FormatRate(2048))
+	assert.Equal(t, "1.50 MB/s", FormatRate(1.5*1048576))
+}
@@ -3,16 +3,20 @@ package progress
 import (
 	"fmt"
 	"io"
+	"os"
 	"runtime"
 	"strings"
 
 	"github.com/olekukonko/ts"
 )
 
-// Indeterminate progress indicator 'spinner'
+// Indeterminate progress indicator 'spinner'. Like ProgressMeter, it only
+// spins when stdout is a terminal; otherwise just the finishing message is
+// written, on its own line.
 type Spinner struct {
 	stage int
 	msg   string
+	tty   bool
 }
 
 var spinnerChars = []byte{'|', '/', '-', '\\'}
@@ -25,6 +29,10 @@ func (s *Spinner) Print(out io.Writer, msg string) {
 
 // Just spin the spinner one more notch & use the last message
 func (s *Spinner) Spin(out io.Writer) {
+	if !s.tty {
+		return
+	}
+
 	s.stage = (s.stage + 1) % len(spinnerChars)
 	s.update(out, string(spinnerChars[s.stage]), s.msg)
 }
@@ -33,6 +41,11 @@ func (s *Spinner) Spin(out io.Writer) {
 func (s *Spinner) Finish(out io.Writer, finishMsg string) {
 	s.msg = finishMsg
 	s.stage = 0
+	if !s.tty {
+		fmt.Fprintln(out, finishMsg)
+		return
+	}
+
 	var sym string
 	if runtime.GOOS == "windows" {
 		// Windows console sucks, can't do nice check mark except in ConEmu (not cmd or git bash)
@@ -61,5 +74,5 @@ func (s *Spinner) update(out io.Writer, prefix, msg string) {
 }
 
 func NewSpinner() *Spinner {
-	return &Spinner{}
+	return &Spinner{tty: IsTerminal(os.Stdout)}
 }
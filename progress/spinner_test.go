@@ -0,0 +1,36 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpinnerSpinsOnTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewSpinner()
+	s.tty = true
+
+	s.Print(&buf, "1 objects found")
+	s.Print(&buf, "2 objects found")
+	s.Finish(&buf, "2 objects found")
+
+	out := buf.String()
+	assert.Equal(t, 3, strings.Count(out, "\r"))
+	assert.Contains(t, out, "1 objects found")
+	assert.True(t, strings.HasSuffix(out, "\n"))
+}
+
+func TestSpinnerOnlyFinishesWhenNotTerminal(t *testing.T) {
+	// test output isn't a terminal
+	var buf bytes.Buffer
+	s := NewSpinner()
+
+	s.Print(&buf, "1 objects found")
+	s.Spin(&buf)
+	s.Finish(&buf, "1 objects found")
+
+	assert.Equal(t, "1 objects found\n", buf.String())
+}
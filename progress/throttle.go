@@ -0,0 +1,121 @@
+package progress
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Throttle limits the combined rate at which the readers and writers it wraps
+// transfer data, so that concurrent transfers sharing a Throttle stay under a
+// single limit between them. Each read or write is limited to a tenth of a
+// second's worth of data, so that waits are short and the transfers sharing
+// the limit take turns. A nil *Throttle doesn't limit anything.
+type Throttle struct {
+	rate int64 // bytes per second
+
+	mutex sync.Mutex
+	next  time.Time // when the data transferred so far is paid for
+}
+
+// NewThrottle returns a Throttle limiting data to bytesPerSecond, or nil if
+// bytesPerSecond isn't positive, meaning no limit.
+func NewThrottle(bytesPerSecond int64) *Throttle {
+	if bytesPerSecond < 1 {
+		return nil
+	}
+	return &Throttle{rate: bytesPerSecond}
+}
+
+// Wait blocks until the n bytes just transferred are within the limit.
+func (t *Throttle) Wait(n int) {
+	if t == nil || n < 1 {
+		return
+	}
+
+	if delay := t.reserve(time.Now(), n); delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// reserve adds n bytes to those transferred, and returns how long to wait
+// until they're within the limit. Time when nothing was transferred isn't
+// saved up, so that data can't burst over the limit after a pause.
+func (t *Throttle) reserve(now time.Time, n int) time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.next.Before(now) {
+		t.next = now
+	}
+	t.next = t.next.Add(time.Duration(int64(n) * int64(time.Second) / t.rate))
+	return t.next.Sub(now)
+}
+
+// chunkSize returns the most that a single read or write may transfer.
+func (t *Throttle) chunkSize() int {
+	const maxChunk = 1 << 20
+	n := t.rate / 10
+	if n < 1 {
+		return 1
+	}
+	if n > maxChunk {
+		return maxChunk
+	}
+	return int(n)
+}
+
+// Reader returns r, with its reads limited by t.
+func (t *Throttle) Reader(r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+	return &throttledReader{r: r, t: t}
+}
+
+// Writer returns w, with its writes limited by t.
+func (t *Throttle) Writer(w io.Writer) io.Writer {
+	if t == nil {
+		return w
+	}
+	return &throttledWriter{w: w, t: t}
+}
+
+type throttledReader struct {
+	r io.Reader
+	t *Throttle
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if max := r.t.chunkSize(); len(p) > max {
+		p = p[:max]
+	}
+
+	n, err := r.r.Read(p)
+	r.t.Wait(n)
+	return n, err
+}
+
+type throttledWriter struct {
+	w io.Writer
+	t *Throttle
+}
+
+func (w *throttledWriter) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		chunk := p
+		if max := w.t.chunkSize(); len(chunk) > max {
+			chunk = chunk[:max]
+		}
+
+		n, err := w.w.Write(chunk)
+		written += n
+		w.t.Wait(n)
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
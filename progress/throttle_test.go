@@ -0,0 +1,82 @@
+package progress
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottleReserve(t *testing.T) {
+	start := time.Now()
+	throttle := NewThrottle(100)
+
+	assert.Equal(t, 500*time.Millisecond, throttle.reserve(start, 50))
+	assert.Equal(t, time.Second, throttle.reserve(start, 50))
+	assert.Equal(t, 500*time.Millisecond, throttle.reserve(start.Add(time.Second), 50))
+
+	// time spent idle isn't saved up
+	assert.Equal(t, 100*time.Millisecond, throttle.reserve(start.Add(10*time.Second), 10))
+}
+
+func TestThrottleLimitsReaderChunks(t *testing.T) {
+	throttle := NewThrottle(1000)
+	r := throttle.Reader(bytes.NewReader(make([]byte, 500)))
+
+	buf := make([]byte, 500)
+	n, err := r.Read(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, 100, n)
+}
+
+func TestThrottleWriterWritesEverything(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewThrottle(20000).Writer(&buf)
+
+	n, err := w.Write(make([]byte, 5000))
+	assert.Nil(t, err)
+	assert.Equal(t, 5000, n)
+	assert.Equal(t, 5000, buf.Len())
+}
+
+func TestThrottleSharedByReaders(t *testing.T) {
+	throttle := NewThrottle(20000)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cbr := &CallbackReader{
+				TotalSize: 4000,
+				Reader:    bytes.NewReader(make([]byte, 4000)),
+				Throttle:  throttle,
+			}
+			by, err := ioutil.ReadAll(cbr)
+			assert.Nil(t, err)
+			assert.Equal(t, 4000, len(by))
+		}()
+	}
+	wg.Wait()
+
+	// 8000 bytes between them at 20000 a second
+	elapsed := time.Since(start)
+	assert.True(t, elapsed >= 350*time.Millisecond, "took %s", elapsed)
+}
+
+func TestNilThrottle(t *testing.T) {
+	assert.Nil(t, NewThrottle(0))
+
+	var throttle *Throttle
+	throttle.Wait(100)
+
+	r := bytes.NewReader(nil)
+	assert.Equal(t, r, throttle.Reader(r))
+
+	var buf bytes.Buffer
+	assert.Equal(t, &buf, throttle.Writer(&buf))
+}
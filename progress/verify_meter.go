@@ -0,0 +1,91 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/olekukonko/ts"
+)
+
+// VerifyMeter shows how many of a known number of objects have been verified,
+// e.g. checked to be present on the remote. Output is only written when out is
+// a terminal, so logs and piped output are left alone.
+type VerifyMeter struct {
+	out      io.Writer
+	tty      bool
+	total    int
+	verified int
+	mutex    sync.Mutex
+}
+
+// NewVerifyMeter creates a VerifyMeter for total objects, writing to out.
+func NewVerifyMeter(total int, out io.Writer) *VerifyMeter {
+	return &VerifyMeter{
+		out:   out,
+		tty:   IsTerminal(out),
+		total: total,
+	}
+}
+
+// Start writes the initial, empty, progress line.
+func (m *VerifyMeter) Start() {
+	m.mutex.Lock()
+	m.update()
+	m.mutex.Unlock()
+}
+
+// Verify tells the meter that another object has been verified.
+func (m *VerifyMeter) Verify() {
+	m.mutex.Lock()
+	m.verified++
+	m.update()
+	m.mutex.Unlock()
+}
+
+// Finish ends the progress line.
+func (m *VerifyMeter) Finish() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.tty && m.total > 0 {
+		m.update()
+		fmt.Fprintf(m.out, "\n")
+	}
+}
+
+func (m *VerifyMeter) update() {
+	if !m.tty || m.total == 0 {
+		return
+	}
+
+	width := 80 // default to 80 chars wide if ts.GetSize() fails
+	size, err := ts.GetSize()
+	if err == nil {
+		width = size.Col()
+	}
+
+	out := fmt.Sprintf("\rGit LFS: verifying (%d of %d objects)", m.verified, m.total)
+	if padlen := width - len(out); padlen > 0 {
+		out += strings.Repeat(" ", padlen)
+	}
+
+	fmt.Fprint(m.out, out)
+}
+
+// IsTerminal returns whether out is a terminal, rather than a file or pipe.
+func IsTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return stat.Mode()&os.ModeCharDevice != 0
+}
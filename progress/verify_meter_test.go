@@ -0,0 +1,59 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyMeterReportsProgress(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewVerifyMeter(100, &buf)
+	m.tty = true
+
+	m.Start()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			m.Verify()
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+	m.Finish()
+
+	out := buf.String()
+	assert.Contains(t, out, "Git LFS: verifying (0 of 100 objects)")
+	assert.Contains(t, out, "Git LFS: verifying (50 of 100 objects)")
+	assert.Contains(t, out, "Git LFS: verifying (100 of 100 objects)")
+	assert.Equal(t, 102, strings.Count(out, "\r"), "one update for start, each object and finish")
+	assert.True(t, strings.HasSuffix(out, "\n"))
+}
+
+func TestVerifyMeterSilentWhenNotTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewVerifyMeter(10, &buf)
+
+	m.Start()
+	for i := 0; i < 10; i++ {
+		m.Verify()
+	}
+	m.Finish()
+
+	assert.Equal(t, "", buf.String())
+}
+
+func TestVerifyMeterSilentWithNoObjects(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewVerifyMeter(0, &buf)
+	m.tty = true
+
+	m.Start()
+	m.Finish()
+
+	assert.Equal(t, "", buf.String())
+}
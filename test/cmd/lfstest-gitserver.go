@@ -1,3 +1,4 @@
+//go:build testtools
 // +build testtools
 
 package main
@@ -50,6 +51,9 @@ var (
 		"status-storage-403", "status-storage-404", "status-storage-410", "status-storage-422", "status-storage-500",
 		"status-legacy-404", "status-legacy-410", "status-legacy-422", "status-legacy-403", "status-legacy-500",
 		"status-batch-resume-206", "batch-resume-fail-fallback", "return-expired-action",
+		"batch-download-unavailable", "status-storage-409", "status-storage-409-present",
+		"status-storage-503-once", "status-storage-500-present",
+		"storage-download-stall",
 	}
 )
 
@@ -68,6 +72,7 @@ func main() {
 
 	mux.HandleFunc("/storage/", storageHandler)
 	mux.HandleFunc("/redirect307/", redirect307Handler)
+	mux.HandleFunc("/verify", verifyHandler)
 	mux.HandleFunc("/locks", locksHandler)
 	mux.HandleFunc("/locks/", locksHandler)
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -354,6 +359,12 @@ func lfsBatchHandler(w http.ResponseWriter, r *http.Request, repo string) {
 
 		handler := oidHandlers[obj.Oid]
 
+		if handler == "batch-download-unavailable" && action == "download" {
+			// accepted on upload, but not yet served for downloads
+			o.Err = &lfsError{Code: 404, Message: fmt.Sprintf("Object %v is not available yet", obj.Oid)}
+			addAction = false
+		}
+
 		switch handler {
 		case "status-batch-403":
 			o.Err = &lfsError{Code: 403, Message: "welp"}
@@ -378,6 +389,10 @@ func lfsBatchHandler(w http.ResponseWriter, r *http.Request, repo string) {
 				}
 
 				o.Actions = map[string]lfsLink{action: a}
+
+				if action == "upload" && strings.HasPrefix(repo, "verify") {
+					o.Actions["verify"] = lfsLink{Href: server.URL + "/verify?r=" + repo}
+				}
 			}
 		}
 
@@ -405,6 +420,27 @@ func lfsBatchHandler(w http.ResponseWriter, r *http.Request, repo string) {
 	w.Write(by)
 }
 
+// verifyHandler answers verify actions, which are only sent for repositories
+// whose names start with "verify", with a 404 for objects that haven't been
+// stored.
+func verifyHandler(w http.ResponseWriter, r *http.Request) {
+	repo := r.URL.Query().Get("r")
+
+	var obj lfsObject
+	if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
+		w.WriteHeader(422)
+		return
+	}
+
+	log.Printf("verify %s repo: %s\n", obj.Oid, repo)
+	if !largeObjects.Has(repo, obj.Oid) {
+		w.WriteHeader(404)
+		return
+	}
+
+	w.WriteHeader(200)
+}
+
 // emu guards expiredRepos
 var emu sync.Mutex
 
@@ -434,6 +470,11 @@ func serveExpired(repo string) {
 // Persistent state across requests
 var batchResumeFailFallbackStorageAttempts = 0
 var tusStorageAttempts = 0
+var storage503Attempts = make(map[string]int)
+var storage503Mutex sync.Mutex
+
+var storageStallAttempts = make(map[string]int)
+var storageStallMutex sync.Mutex
 
 // handles any /storage/{oid} requests
 func storageHandler(w http.ResponseWriter, r *http.Request) {
@@ -463,6 +504,34 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 		case "status-storage-500":
 			w.WriteHeader(500)
 			return
+		case "status-storage-409":
+			w.WriteHeader(409)
+			return
+		case "status-storage-409-present":
+			// as if another client uploaded the object at the same time
+			by, _ := ioutil.ReadAll(r.Body)
+			largeObjects.Set(repo, oid, by)
+			w.WriteHeader(409)
+			return
+		case "status-storage-500-present":
+			// as if the object store committed the object, but the
+			// response was lost
+			by, _ := ioutil.ReadAll(r.Body)
+			largeObjects.Set(repo, oid, by)
+			w.WriteHeader(500)
+			return
+		case "status-storage-503-once":
+			storage503Mutex.Lock()
+			storage503Attempts[repo+"/"+oid]++
+			attempts := storage503Attempts[repo+"/"+oid]
+			storage503Mutex.Unlock()
+
+			if attempts == 1 {
+				io.Copy(ioutil.Discard, r.Body)
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(503)
+				return
+			}
 		}
 
 		if testingChunkedTransferEncoding(r) {
@@ -524,6 +593,39 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 					byteLimit = 8
 					batchResumeFailFallbackStorageAttempts++
 				}
+			} else if string(by) == "storage-download-stall" {
+				if rangeHdr := r.Header.Get("Range"); rangeHdr != "" {
+					match := regexp.MustCompile(`bytes=(\d+)\-.*`).FindStringSubmatch(rangeHdr)
+					if match != nil {
+						statusCode = 206
+						resumeAt, _ = strconv.ParseInt(match[1], 10, 32)
+						w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", resumeAt, len(by)-1, len(by)))
+					}
+				} else {
+					storageStallMutex.Lock()
+					storageStallAttempts[repo+"/"+oid]++
+					attempts := storageStallAttempts[repo+"/"+oid]
+					storageStallMutex.Unlock()
+
+					if attempts == 1 {
+						// send half of the object, then stall, so that
+						// the client can be killed midway
+						w.Header().Set("Content-Length", strconv.Itoa(len(by)))
+						w.WriteHeader(200)
+						w.Write(by[:len(by)/2])
+						w.(http.Flusher).Flush()
+						select {
+						case <-w.(http.CloseNotifier).CloseNotify():
+						case <-time.After(30 * time.Second):
+						}
+						return
+					}
+				}
+			} else if bytes.HasPrefix(by, []byte("cache-control: ")) {
+				// Serve the rest of the content as the Cache-Control header
+				w.Header().Set("Cache-Control", strings.TrimSpace(string(by[len("cache-control: "):])))
+			} else if bytes.HasPrefix(by, []byte("expires: ")) {
+				w.Header().Set("Expires", strings.TrimSpace(string(by[len("expires: "):])))
 			}
 			w.WriteHeader(statusCode)
 			if byteLimit > 0 {
@@ -538,6 +640,17 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 
 		w.WriteHeader(404)
 	case "HEAD":
+		if len(r.Header.Get("Tus-Resumable")) == 0 {
+			// whether the object is stored, for lfs.transfer.uploadretry
+			if by, ok := largeObjects.Get(repo, oid); ok {
+				w.Header().Set("Content-Length", strconv.Itoa(len(by)))
+				w.WriteHeader(200)
+				return
+			}
+			w.WriteHeader(404)
+			return
+		}
+
 		// tus.io
 		if !validateTusHeaders(r) {
 			w.WriteHeader(400)
@@ -760,6 +873,26 @@ func addLocks(l ...Lock) {
 	sort.Sort(LocksByCreatedAt(locks))
 }
 
+// delLock removes the lock with the given id, returning the lock and whether it
+// was found.
+func delLock(id string) (Lock, bool) {
+	lmu.Lock()
+	defer lmu.Unlock()
+
+	for i, l := range locks {
+		if l.Id == id {
+			// copy, rather than append in place, since callers of
+			// getLocks() may still be reading the old slice
+			remaining := make([]Lock, 0, len(locks)-1)
+			remaining = append(remaining, locks[:i]...)
+			locks = append(remaining, locks[i+1:]...)
+			return l, true
+		}
+	}
+
+	return Lock{}, false
+}
+
 func getLocks() []Lock {
 	lmu.RLock()
 	defer lmu.RUnlock()
@@ -854,20 +987,10 @@ func locksHandler(w http.ResponseWriter, r *http.Request) {
 				})
 			}
 
-			lockIndex := -1
-			for i, l := range locks {
-				if l.Id == unlockRequest.Id {
-					lockIndex = i
-					break
-				}
-			}
-
-			if lockIndex > -1 {
+			if l, ok := delLock(unlockRequest.Id); ok {
 				enc.Encode(&UnlockResponse{
-					Lock: &locks[lockIndex],
+					Lock: &l,
 				})
-
-				locks = append(locks[:lockIndex], locks[lockIndex+1:]...)
 			} else {
 				enc.Encode(&UnlockResponse{
 					Err: "unable to find lock",
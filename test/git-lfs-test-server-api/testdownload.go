@@ -51,10 +51,10 @@ func downloadAllMissing(oidsExist, oidsMissing []TestObject) error {
 	for _, o := range retobjs {
 		link, ok := o.Rel("download")
 		if ok {
-			errbuf.WriteString(fmt.Sprintf("Download link should not exist for %s, was %s\n", o.Oid, link))
+			errbuf.WriteString(fmt.Sprintf("Download link should not exist for %s, was %s\n", o.Oid, link.Href))
 		}
 		if o.Error == nil {
-			errbuf.WriteString(fmt.Sprintf("Download should include an error for missing object %s, was %s\n", o.Oid))
+			errbuf.WriteString(fmt.Sprintf("Download should include an error for missing object %s\n", o.Oid))
 		} else if o.Error.Code != 404 {
 			errbuf.WriteString(fmt.Sprintf("Download error code for missing object %s should be 404, got %d\n", o.Oid, o.Error.Code))
 		}
@@ -96,7 +96,7 @@ func downloadMixed(oidsExist, oidsMissing []TestObject) error {
 		link, ok := o.Rel("download")
 		if missingSet.Contains(o.Oid) {
 			if ok {
-				errbuf.WriteString(fmt.Sprintf("Download link should not exist for %s, was %s\n", o.Oid, link))
+				errbuf.WriteString(fmt.Sprintf("Download link should not exist for %s, was %s\n", o.Oid, link.Href))
 			}
 			if o.Error == nil {
 				errbuf.WriteString(fmt.Sprintf("Download should include an error for missing object %s", o.Oid))
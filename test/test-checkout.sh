@@ -104,6 +104,106 @@ begin_test "checkout"
 )
 end_test
 
+begin_test "checkout --stage"
+(
+  set -e
+
+  reponame="checkout-stage"
+  setup_remote_repo "$reponame"
+
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+
+  printf "a head" > a.dat
+  printf "b head" > b.dat
+  git add a.dat b.dat
+  git commit -m "add files"
+  git push origin master
+
+  # a.dat: staged from another branch without its content being local
+  a_staged="a staged"
+  a_staged_oid="$(calc_oid "$a_staged")"
+  git checkout -b other
+  printf "$a_staged" > a.dat
+  git add a.dat
+  git commit -m "change a.dat"
+  git push origin other
+  git checkout master
+  GIT_LFS_SKIP_SMUDGE=1 git checkout other -- a.dat
+  delete_local_object "$a_staged_oid"
+  rm a.dat
+
+  # b.dat: staged locally, then removed from the working copy
+  b_staged="b staged"
+  printf "$b_staged" > b.dat
+  git add b.dat
+  rm b.dat
+
+  git ls-files --stage > index.before
+
+  git lfs checkout --stage
+
+  [ "$a_staged" = "$(cat a.dat)" ]
+  [ "$b_staged" = "$(cat b.dat)" ]
+  assert_local_object "$a_staged_oid" "${#a_staged}"
+
+  git ls-files --stage > index.after
+  diff -u index.before index.after
+  [ "a.dat b.dat" = "$(git diff --cached --name-only | tr '\n' ' ' | sed 's/ $//')" ]
+  [ -z "$(git diff --name-only)" ]
+
+  echo "with read-ahead"
+  git config lfs.checkoutreadahead 1
+  delete_local_object "$a_staged_oid"
+  rm a.dat b.dat
+  git lfs checkout --stage b.dat
+  [ "$b_staged" = "$(cat b.dat)" ]
+  [ ! -f a.dat ]
+  refute_local_object "$a_staged_oid"
+
+  git lfs checkout --stage
+  [ "$a_staged" = "$(cat a.dat)" ]
+  assert_local_object "$a_staged_oid" "${#a_staged}"
+  git config --unset lfs.checkoutreadahead
+
+  echo "without --stage, HEAD's content is used"
+  rm a.dat b.dat
+  git lfs checkout b.dat
+  [ "b head" = "$(cat b.dat)" ]
+  [ ! -f a.dat ]
+)
+end_test
+
+begin_test "checkout: keeps local changes"
+(
+  set -e
+
+  reponame="checkout-local-changes"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "original" > a.dat
+  printf "unchanged" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add files"
+
+  printf "local changes" > a.dat
+
+  git lfs checkout 2> checkout.log
+  [ "local changes" = "$(cat a.dat)" ]
+  [ "unchanged" = "$(cat b.dat)" ]
+  grep "Not checking out a.dat: it has local changes" checkout.log
+  [ "$(grep -c "Not checking out" checkout.log)" -eq 1 ]
+
+  git -c lfs.checkoutoverwrite=true lfs checkout 2> checkout.log
+  [ "original" = "$(cat a.dat)" ]
+  [ ! -s checkout.log ]
+)
+end_test
+
 begin_test "checkout: outside git repository"
 (
   set +e
@@ -119,3 +219,79 @@ begin_test "checkout: outside git repository"
   grep "Not in a git repository" checkout.log
 )
 end_test
+
+begin_test "checkout: paths which collide on case-insensitive filesystems"
+(
+  set -e
+
+  reponame="checkout-path-collisions"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "upper" > UPPER.dat
+  printf "lower" > upper.dat
+  printf "other" > other.dat
+  git add .gitattributes UPPER.dat upper.dat other.dat
+  git commit -m "add case-colliding files"
+
+  # pretend to be on a case-insensitive filesystem, as git detects at init
+  git config core.ignorecase true
+
+  git lfs status 2>&1 | tee status.log
+  grep "Git LFS files whose paths collide on this filesystem:" status.log
+  grep "UPPER.dat and upper.dat" status.log
+
+  rm UPPER.dat upper.dat other.dat
+  git lfs checkout 2>&1 | tee checkout.log
+  grep "Not checking out upper.dat: it would overwrite UPPER.dat, as this filesystem doesn't tell their paths apart." checkout.log
+  [ "upper" = "$(cat UPPER.dat)" ]
+  [ ! -e upper.dat ]
+  [ "other" = "$(cat other.dat)" ]
+
+  # only paths being checked out can collide
+  rm UPPER.dat
+  git lfs checkout upper.dat 2>&1 | tee checkout.log
+  [ "0" = "$(grep -c "Not checking out" checkout.log)" ]
+  [ "lower" = "$(cat upper.dat)" ]
+
+  git config lfs.pathcollisions ignore
+  git lfs checkout 2>&1 | tee checkout.log
+  [ "0" = "$(grep -c "Not checking out" checkout.log)" ]
+  [ "upper" = "$(cat UPPER.dat)" ]
+  [ "lower" = "$(cat upper.dat)" ]
+)
+end_test
+
+begin_test "checkout: paths which differ in unicode normalization"
+(
+  set -e
+
+  reponame="checkout-unicode-normalization"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  composed="$(printf "caf\303\251.dat")"
+  decomposed="$(printf "cafe\314\201.dat")"
+
+  git lfs track "*.dat"
+  printf "composed" > "$composed"
+  printf "decomposed" > "$decomposed"
+  git add .gitattributes "$composed" "$decomposed"
+  git commit -m "add files with both normalizations"
+
+  # as git sets on macOS, whose filesystems don't tell these paths apart
+  git config core.precomposeunicode true
+
+  rm "$composed" "$decomposed"
+  git lfs checkout 2>&1 | tee checkout.log
+  grep "Not checking out" checkout.log
+  [ "1" = "$(ls caf*.dat | wc -l | tr -d ' ')" ]
+
+  # a decomposed path given on the command line matches a composed one
+  git config --unset core.precomposeunicode
+  rm caf*.dat
+  git lfs checkout "$decomposed"
+  [ "composed" = "$(cat "$composed")" ]
+)
+end_test
@@ -56,3 +56,181 @@ This is my test pointer.  There are many like it, but this one is mine.\n" | git
   [ "$(pointer c2f909f6961bf85a92e2942ef3ed80c938a3d0ebaee6e72940692581052333be 586)" = "$(cat clean.log)" ]
 )
 end_test
+
+begin_test "clean a pointer with a BOM and CRLF line endings"
+(
+  set -e
+  clean_setup "pointer-bom-crlf"
+
+  oid="cd293be6cea034bd45a0352775a219ef5dc7825ce55d1f7dae9762d80ce64411"
+  pointer "$oid" 9 > expected.txt
+
+  printf '\357\273\277' > bom.txt
+  pointer "$oid" 9 | sed 's/$/\r/' >> bom.txt
+  git lfs clean < bom.txt > clean.log
+  cmp expected.txt clean.log
+
+  pointer "$oid" 9 | tr '\n' '\r' > cr.txt
+  git lfs clean < cr.txt > clean.log
+  cmp expected.txt clean.log
+)
+end_test
+
+begin_test "clean skips submodules and directories"
+(
+  set -e
+  clean_setup "non-files"
+
+  mkdir -p dir.dat sub.dat/.git
+  echo "dir" | git lfs clean dir.dat > clean.log 2> clean.err
+  [ "dir" = "$(cat clean.log)" ]
+  grep "Git LFS: skipping dir.dat: it is a directory, not a file" clean.err
+
+  echo "Subproject commit" | git lfs clean sub.dat > clean.log 2> clean.err
+  [ "Subproject commit" = "$(cat clean.log)" ]
+  grep "Git LFS: skipping sub.dat: it is a submodule, not a file" clean.err
+
+  git config lfs.nonfilepaths error
+  set +e
+  echo "dir" | git lfs clean dir.dat > clean.log 2>&1
+  status=$?
+  set -e
+  [ "$status" -ne 0 ]
+  grep "Unable to clean dir.dat: it is a directory, not a file." clean.log
+)
+end_test
+
+begin_test "clean doesn't warn during a merge"
+(
+  set -e
+
+  mkdir repo-merge
+  cd repo-merge
+  git init
+  git lfs track "*.dat"
+  echo "base" > a.dat
+  echo "base" > b.dat
+  echo "base" > c.dat
+  git add .gitattributes a.dat b.dat c.dat
+  git commit -m "base"
+
+  git checkout -b other
+  echo "other" > a.dat
+  git mv c.dat d.dat
+  git commit -am "change a.dat, rename c.dat"
+
+  git checkout master
+  echo "master" > a.dat
+  echo "master" > b.dat
+  git commit -am "change a.dat and b.dat"
+
+  set +e
+  git merge --no-edit other 2> merge.err
+  res=$?
+  set -e
+  [ "$res" != "0" ]
+  git checkout --theirs -- a.dat 2>> merge.err
+  git add a.dat 2>> merge.err
+  git commit --no-edit 2>> merge.err
+  [ "other" = "$(cat a.dat)" ]
+  [ "master" = "$(cat b.dat)" ]
+  [ "base" = "$(cat d.dat)" ]
+  [ "0" = "$(grep -c "pointer text" merge.err)" ]
+
+  git status 2> status.err
+  [ ! -s status.err ]
+)
+end_test
+
+begin_test "clean after an interrupted add"
+(
+  set -e
+  clean_setup "interrupted"
+
+  git lfs track "*.dat"
+  printf "first" > a.dat
+  printf "second" > b.dat
+  printf "third" > c.dat
+  oida="$(calc_oid "first")"
+  oidb="$(calc_oid "second")"
+  oidc="$(calc_oid "third")"
+
+  # an add which was interrupted after cleaning a.dat, while b.dat's object
+  # was being written, leaving it incomplete
+  git add .gitattributes a.dat
+  mkdir -p ".git/lfs/objects/${oidb:0:2}/${oidb:2:2}"
+  printf "sec" > ".git/lfs/objects/${oidb:0:2}/${oidb:2:2}/$oidb"
+
+  git add a.dat b.dat c.dat 2>&1 | tee add.log
+  grep "replacing" add.log
+
+  assert_local_object "$oida" 5
+  assert_local_object "$oidb" 6
+  assert_local_object "$oidc" 5
+  [ "second" = "$(cat ".git/lfs/objects/${oidb:0:2}/${oidb:2:2}/$oidb")" ]
+  [ "0" = "$(find .git/lfs/tmp -type f | wc -l | tr -d '[:space:]')" ]
+
+  git commit -m "add files"
+  assert_pointer "master" "a.dat" "$oida" 5
+  assert_pointer "master" "b.dat" "$oidb" 6
+  assert_pointer "master" "c.dat" "$oidc" 5
+  [ "Git LFS fsck OK" = "$(git lfs fsck)" ]
+)
+end_test
+
+begin_test "clean after an interrupted add with lfs.clean.mismatch=error"
+(
+  set -e
+  clean_setup "interrupted-error"
+
+  git lfs track "*.dat"
+  git config lfs.clean.mismatch error
+  printf "second" > b.dat
+  oidb="$(calc_oid "second")"
+
+  mkdir -p ".git/lfs/objects/${oidb:0:2}/${oidb:2:2}"
+  printf "sec" > ".git/lfs/objects/${oidb:0:2}/${oidb:2:2}/$oidb"
+
+  set +e
+  git add b.dat > add.log 2>&1
+  add_exit=$?
+  set -e
+  cat add.log
+  [ "$add_exit" != "0" ]
+  grep "Files don't match" add.log
+  assert_local_object "$oidb" 3
+)
+end_test
+
+begin_test "clean with a corrupt object in local storage"
+(
+  set -e
+  clean_setup "mismatch-corrupt"
+
+  git lfs track "*.dat"
+  printf "second" > b.dat
+  printf "third" > c.dat
+  oidb="$(calc_oid "second")"
+  oidc="$(calc_oid "third")"
+
+  # shorter, but not the start of the content
+  mkdir -p ".git/lfs/objects/${oidb:0:2}/${oidb:2:2}"
+  printf "xyz" > ".git/lfs/objects/${oidb:0:2}/${oidb:2:2}/$oidb"
+  # larger than the content
+  mkdir -p ".git/lfs/objects/${oidc:0:2}/${oidc:2:2}"
+  printf "third and more" > ".git/lfs/objects/${oidc:0:2}/${oidc:2:2}/$oidc"
+
+  for file in b.dat c.dat; do
+    set +e
+    git add $file > add.log 2>&1
+    add_exit=$?
+    set -e
+    cat add.log
+    [ "$add_exit" != "0" ]
+    grep "Files don't match" add.log
+  done
+
+  assert_local_object "$oidb" 3
+  assert_local_object "$oidc" 14
+)
+end_test
@@ -149,3 +149,41 @@ begin_test "extension config (with gitconfig)"
   [ "$expected2" = "$(git lfs ext)" ]
 )
 end_test
+
+begin_test "config: invalid lfs.tls settings"
+(
+  set -e
+
+  reponame="invalid-tls"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  echo "invalid tls" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  # only requests to the server check the settings
+  git config lfs.tls.minversion "1.9"
+  git lfs env
+
+  set +e
+  git lfs push origin master > push.log 2>&1
+  res=$?
+  set -e
+  [ "$res" != "0" ]
+  grep "Invalid lfs.tls.minversion \"1.9\"" push.log
+
+  git config lfs.tls.minversion "1.2"
+  git config lfs.tls.ciphers "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,NOT_A_CIPHER"
+  set +e
+  git lfs push origin master > push.log 2>&1
+  res=$?
+  set -e
+  [ "$res" != "0" ]
+  grep "unknown cipher suite \"NOT_A_CIPHER\"" push.log
+
+  git config --unset lfs.tls.ciphers
+  git lfs push origin master
+)
+end_test
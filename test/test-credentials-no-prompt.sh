@@ -44,3 +44,43 @@ begin_test "attempt private access without credential helper"
     grep "Git credentials for $GITSERVER/$reponame not found" push.log
 )
 end_test
+
+begin_test "attempt private access with lfs.credential.prompt false"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-prompt-false"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" prompt-false
+
+  git lfs track "*.dat"
+  echo "hi" > hi.dat
+  git add hi.dat
+  git add .gitattributes
+  git commit -m "initial commit"
+
+  git config credential.usehttppath true
+  git config --global credential.helper lfsnoop
+  git config credential.helper lfsnoop
+
+  # a prompt which would answer, but records that it was asked
+  askpass="$TRASHDIR/$reponame-askpass.sh"
+  printf '#!/bin/sh\ntouch "%s"\necho wrong\n' "$TRASHDIR/$reponame-asked" > "$askpass"
+  chmod +x "$askpass"
+
+  git config lfs.credential.prompt false
+  GIT_ASKPASS="$askpass" git push origin master 2>&1 | tee push.log
+  grep "prompting for them is disabled by lfs.credential.prompt or GIT_LFS_SKIP_CREDENTIAL_PROMPT" push.log
+  [ ! -e "$TRASHDIR/$reponame-asked" ]
+  refute_server_object "$reponame" "$(calc_oid "hi\n")"
+
+  git config --unset lfs.credential.prompt
+  GIT_LFS_SKIP_CREDENTIAL_PROMPT=1 GIT_ASKPASS="$askpass" git push origin master 2>&1 | tee push.log
+  grep "prompting for them is disabled by lfs.credential.prompt or GIT_LFS_SKIP_CREDENTIAL_PROMPT" push.log
+  [ ! -e "$TRASHDIR/$reponame-asked" ]
+
+  # without it, the prompt is asked
+  GIT_ASKPASS="$askpass" git push origin master 2>&1 | tee push.log
+  [ -e "$TRASHDIR/$reponame-asked" ]
+)
+end_test
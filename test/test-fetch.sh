@@ -633,3 +633,543 @@ begin_test "fetch with invalid remote"
   grep "Invalid remote name" fetch.log
 )
 end_test
+
+begin_test "fetch with lfs.storage.readonlymirror"
+(
+  set -e
+
+  reponame="fetch-readonly-mirror"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "b" > b.dat
+  printf "c" > c.dat
+  git add .gitattributes *.dat
+  git commit -m "add files"
+  git push origin master
+
+  a_oid="$(calc_oid "a")"
+  b_oid="$(calc_oid "b")"
+  c_oid="$(calc_oid "c")"
+
+  # the mirror has a.dat's object, and a corrupt copy of b.dat's
+  mirror="$TRASHDIR/$reponame-mirror"
+  mkdir -p "$mirror/${a_oid:0:2}/${a_oid:2:2}" "$mirror/${b_oid:0:2}/${b_oid:2:2}"
+  printf "a" > "$mirror/${a_oid:0:2}/${a_oid:2:2}/$a_oid"
+  printf "x" > "$mirror/${b_oid:0:2}/${b_oid:2:2}/$b_oid"
+  chmod -R a-w "$mirror"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 clone_repo "$reponame" "$reponame-clone"
+  git config lfs.storage.readonlymirror "$mirror"
+
+  refute_local_object "$a_oid"
+  refute_local_object "$b_oid"
+  refute_local_object "$c_oid"
+
+  GIT_TRACE=1 git lfs fetch 2>&1 | tee fetch.log
+  grep "mirror: copied $a_oid" fetch.log
+  grep "mirror: .*$b_oid has oid .*, ignoring it" fetch.log
+  grep "Git LFS: (2 of 2 files, 1 skipped)" fetch.log
+
+  assert_local_object "$a_oid" 1
+  assert_local_object "$b_oid" 1
+  assert_local_object "$c_oid" 1
+
+  # smudge uses the mirror too
+  delete_local_object "$a_oid"
+  [ "a" = "$(git cat-file -p :a.dat | GIT_TRACE=1 git lfs smudge a.dat 2> smudge.log)" ]
+  grep "mirror: copied $a_oid" smudge.log
+  assert_local_object "$a_oid" 1
+
+  # the mirror is left alone
+  [ "x" = "$(cat "$mirror/${b_oid:0:2}/${b_oid:2:2}/$b_oid")" ]
+  chmod -R u+w "$mirror"
+)
+end_test
+
+begin_test "fetch with lfs.storage.externalcas"
+(
+  set -e
+
+  reponame="fetch-external-cas"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "b" > b.dat
+  printf "c" > c.dat
+  git add .gitattributes *.dat
+  git commit -m "add files"
+  git push origin master
+
+  a_oid="$(calc_oid "a")"
+  b_oid="$(calc_oid "b")"
+  c_oid="$(calc_oid "c")"
+
+  # a mock external store, keeping objects in a flat directory by oid
+  store="$TRASHDIR/$reponame-store"
+  mkdir -p "$store"
+  cas="$TRASHDIR/$reponame-cas.sh"
+  cat > "$cas" <<CAS
+#!/bin/sh
+case "\$1" in
+  store) cp "\$3" "$store/\$2" ;;
+  retrieve) [ -f "$store/\$2" ] && cp "$store/\$2" "\$3" ;;
+  *) exit 1 ;;
+esac
+CAS
+  chmod +x "$cas"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 clone_repo "$reponame" "$reponame-clone"
+  git config lfs.storage.externalcas "$cas"
+
+  # downloaded objects are handed to the store
+  GIT_TRACE=1 git lfs fetch 2>&1 | tee fetch.log
+  grep "cas: stored $a_oid" fetch.log
+  [ "a" = "$(cat "$store/$a_oid")" ]
+  [ "b" = "$(cat "$store/$b_oid")" ]
+  [ "c" = "$(cat "$store/$c_oid")" ]
+
+  # objects missing from the local store are retrieved from it, except for a
+  # corrupt one, which is downloaded
+  printf "x" > "$store/$b_oid"
+  rm "$store/$c_oid"
+  delete_local_object "$a_oid"
+  delete_local_object "$b_oid"
+  delete_local_object "$c_oid"
+
+  GIT_TRACE=1 git lfs fetch 2>&1 | tee fetch.log
+  grep "cas: retrieved $a_oid" fetch.log
+  grep "cas: retrieved 1 bytes with oid .* for $b_oid, ignoring them" fetch.log
+  grep "cas: $c_oid isn't in the external store" fetch.log
+  grep "Git LFS: (2 of 2 files, 1 skipped)" fetch.log
+
+  assert_local_object "$a_oid" 1
+  assert_local_object "$b_oid" 1
+  assert_local_object "$c_oid" 1
+  [ "c" = "$(cat "$store/$c_oid")" ]
+
+  # smudge retrieves from it, and hands it objects it downloads
+  delete_local_object "$a_oid"
+  [ "a" = "$(git cat-file -p :a.dat | GIT_TRACE=1 git lfs smudge a.dat 2> smudge.log)" ]
+  grep "cas: retrieved $a_oid" smudge.log
+  assert_local_object "$a_oid" 1
+
+  rm "$store/$c_oid"
+  delete_local_object "$c_oid"
+  [ "c" = "$(git cat-file -p :c.dat | GIT_TRACE=1 git lfs smudge c.dat 2> smudge.log)" ]
+  grep "cas: stored $c_oid" smudge.log
+  [ "c" = "$(cat "$store/$c_oid")" ]
+)
+end_test
+
+begin_test "fetch with lfs.transfer.downloadhook"
+(
+  set -e
+
+  reponame="fetch-download-hook"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "clean" > clean.dat
+  printf "EICAR infected" > infected.dat
+  git add .gitattributes *.dat
+  git commit -m "add files"
+  git push origin master
+
+  clean_oid="$(calc_oid "clean")"
+  infected_oid="$(calc_oid "EICAR infected")"
+
+  # a mock virus scanner, which logs the objects it checks
+  hook="$TRASHDIR/$reponame-scan.sh"
+  cat > "$hook" <<HOOK
+#!/bin/sh
+echo "scanned \$1" >> "$TRASHDIR/$reponame-scan.log"
+if grep -q EICAR "\$2"; then
+  echo "virus found in \$1" >&2
+  exit 1
+fi
+HOOK
+  chmod +x "$hook"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 clone_repo "$reponame" "$reponame-clone"
+  git config lfs.transfer.downloadhook "$hook"
+
+  set +e
+  git lfs fetch 2>&1 | tee fetch.log
+  res="${PIPESTATUS[0]}"
+  set -e
+  [ "$res" != "0" ]
+  grep "Download of $infected_oid rejected by lfs.transfer.downloadhook: virus found in $infected_oid" fetch.log
+
+  grep "scanned $clean_oid" "$TRASHDIR/$reponame-scan.log"
+  grep "scanned $infected_oid" "$TRASHDIR/$reponame-scan.log"
+  assert_local_object "$clean_oid" 5
+  refute_local_object "$infected_oid"
+
+  # smudge checks the objects it downloads too
+  delete_local_object "$clean_oid"
+  [ "clean" = "$(git cat-file -p :clean.dat | git lfs smudge clean.dat)" ]
+  [ "2" = "$(grep -c "scanned $clean_oid" "$TRASHDIR/$reponame-scan.log")" ]
+  assert_local_object "$clean_oid" 5
+)
+end_test
+
+begin_test "fetch with lfs.cachecontrol"
+(
+  set -e
+
+  reponame="fetch-cachecontrol"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+
+  # the test server sends the rest of "cache-control: " contents as the header
+  stale="cache-control: no-cache"
+  stale_oid="$(calc_oid "$stale")"
+  fresh="cache-control: public, immutable"
+  fresh_oid="$(calc_oid "$fresh")"
+  maxage="cache-control: max-age=3600"
+  maxage_oid="$(calc_oid "$maxage")"
+  plain="no caching headers"
+  plain_oid="$(calc_oid "$plain")"
+
+  printf "$stale" > stale.dat
+  printf "$fresh" > fresh.dat
+  printf "$maxage" > maxage.dat
+  printf "$plain" > plain.dat
+  git add .gitattributes *.dat
+  git commit -m "add files"
+  git push origin master
+
+  # policies aren't recorded unless configured
+  rm -rf .git/lfs/objects
+  git lfs fetch
+  assert_local_object "$stale_oid" 23
+  [ ! -d .git/lfs/cachecontrol ]
+
+  git config lfs.cachecontrol honor
+  rm -rf .git/lfs/objects
+  git lfs fetch
+  assert_local_object "$stale_oid" 23
+
+  record=".git/lfs/cachecontrol/${stale_oid:0:2}/${stale_oid:2:2}/$stale_oid"
+  grep '"cache_control":"no-cache"' "$record"
+  [ -f ".git/lfs/cachecontrol/${fresh_oid:0:2}/${fresh_oid:2:2}/$fresh_oid" ]
+  [ -f ".git/lfs/cachecontrol/${maxage_oid:0:2}/${maxage_oid:2:2}/$maxage_oid" ]
+  [ ! -f ".git/lfs/cachecontrol/${plain_oid:0:2}/${plain_oid:2:2}/$plain_oid" ]
+
+  # only the stale object is checked with the server again
+  GIT_TRACE=1 git lfs fetch 2>&1 | tee fetch.log
+  grep "Revalidating stale.dat \[$stale_oid\]" fetch.log
+  [ "0" = "$(grep -c "Revalidating fresh.dat" fetch.log)" ]
+  [ "0" = "$(grep -c "Revalidating maxage.dat" fetch.log)" ]
+  [ "0" = "$(grep -c "Revalidating plain.dat" fetch.log)" ]
+  assert_local_object "$stale_oid" 23
+)
+end_test
+
+begin_test "fetch --max-bytes"
+(
+  set -e
+
+  reponame="fetch-max-bytes"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  small="small object"
+  small_oid="$(calc_oid "$small")"
+  large="a rather larger object than the other"
+  large_oid="$(calc_oid "$large")"
+
+  printf "$small" > small.dat
+  git add .gitattributes small.dat
+  git commit -m "add small.dat"
+
+  git checkout -b large
+  printf "$large" > large.dat
+  git add large.dat
+  git commit -m "add large.dat"
+  git push origin master large
+
+  rm -rf .git/lfs/objects
+
+  # the whole set is refused before anything is downloaded
+  set +e
+  git lfs fetch --max-bytes=40 origin large 2>&1 | tee fetch.log
+  res="${PIPESTATUS[0]}"
+  set -e
+  [ "$res" = "2" ]
+  grep "Would have fetched:" fetch.log
+  grep "  small.dat (12 B)" fetch.log
+  grep "  large.dat (37 B)" fetch.log
+  grep "Not fetching 2 object(s) (49 B): that's over --max-bytes (40 B)." fetch.log
+  refute_local_object "$small_oid"
+  refute_local_object "$large_oid"
+
+  # refs are fetched until the next would go over
+  set +e
+  git lfs fetch --max-bytes=40 origin master large 2>&1 | tee fetch.log
+  res="${PIPESTATUS[0]}"
+  set -e
+  [ "$res" = "2" ]
+  grep "Not fetching 1 object(s) (37 B): that's over --max-bytes (40 B), with 12 B already fetched." fetch.log
+  assert_local_object "$small_oid" 12
+  refute_local_object "$large_oid"
+
+  # objects that are already local don't count
+  git lfs fetch --max-bytes=40 origin large
+  assert_local_object "$large_oid" 37
+
+  git lfs fetch --max-bytes=1KB origin large
+
+  set +e
+  git lfs fetch --max-bytes=lots origin large 2>&1 | tee fetch.log
+  res="${PIPESTATUS[0]}"
+  set -e
+  [ "$res" = "2" ]
+  grep "Invalid --max-bytes \"lots\"" fetch.log
+)
+end_test
+
+begin_test "fetch --max-bandwidth"
+(
+  set -e
+
+  reponame="fetch-max-bandwidth"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="$(printf 'x%.0s' $(seq 1 1500))"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  rm -rf .git/lfs/objects
+
+  # 1500 bytes at 1 KB/s take over a second
+  start=$SECONDS
+  GIT_TRACE=1 git lfs fetch --max-bandwidth=1k origin master 2>&1 | tee fetch.log
+  [ $((SECONDS - start)) -ge 1 ]
+  grep "xfer: limiting transfers to 1024 bytes per second" fetch.log
+  assert_local_object "$contents_oid" 1500
+
+  set +e
+  git lfs fetch --max-bandwidth=fast origin master 2>&1 | tee fetch.log
+  res="${PIPESTATUS[0]}"
+  set -e
+  [ "$res" = "2" ]
+  grep "Invalid transfer bandwidth limit \"fast\"" fetch.log
+
+  git config lfs.transfer.maxbandwidth "2 per sec"
+  set +e
+  git lfs fetch origin master 2>&1 | tee fetch.log
+  res="${PIPESTATUS[0]}"
+  set -e
+  [ "$res" = "2" ]
+  grep "Invalid transfer bandwidth limit \"2 per sec\"" fetch.log
+
+  # the flag takes precedence
+  git lfs fetch --max-bandwidth=2MB/s origin master
+)
+end_test
+
+begin_test "fetch --priority"
+(
+  set -e
+
+  reponame="fetch-priority"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  for name in a b c; do
+    printf "$name" > "$name.dat"
+  done
+  git add .gitattributes a.dat b.dat c.dat
+  git commit -m "add files"
+  git push origin master
+
+  rm -rf .git/lfs/objects
+
+  GIT_TRACE=1 git lfs fetch --priority="c.dat" origin master 2>&1 | tee fetch.log
+  grep "xfer: adapter \"basic\" Add() for" fetch.log | head -n 1 | grep "$(calc_oid "c")"
+  assert_local_object "$(calc_oid "a")" 1
+  assert_local_object "$(calc_oid "b")" 1
+  assert_local_object "$(calc_oid "c")" 1
+)
+end_test
+
+begin_test "fetch with lfs.tls.warmup"
+(
+  set -e
+
+  reponame="fetch-tls-warmup"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+  git config lfs.url "$SSLGITSERVER/$reponame.git/info/lfs"
+  git config http.sslverify false
+
+  git lfs track "*.dat"
+  contents="warm"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  rm -rf .git/lfs/objects
+
+  GIT_TRACE=1 git lfs fetch origin master 2>&1 | tee fetch.log
+  [ "0" -eq "$(grep -c "tls: warmed up" fetch.log)" ]
+
+  rm -rf .git/lfs/objects
+
+  git config lfs.tls.warmup true
+  GIT_TRACE=1 git lfs fetch origin master 2>&1 | tee fetch.log
+  grep "tls: warmed up a connection to 127.0.0.1" fetch.log
+  assert_local_object "$contents_oid" 4
+)
+end_test
+
+begin_test "fetch with GIT_LFS_HAR"
+(
+  set -e
+
+  reponame="fetch-har"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="har"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  rm -rf .git/lfs/objects
+
+  GIT_LFS_HAR="$TRASHDIR/fetch.har" git lfs fetch origin master
+  assert_local_object "$contents_oid" 3
+
+  cat "$TRASHDIR/fetch.har"
+  grep '^{"log":{"version":"1.2","creator":{"name":"git-lfs"' "$TRASHDIR/fetch.har"
+  grep '"method":"POST","url":"http://127.0.0.1:[0-9]*/fetch-har.git/info/lfs/objects/batch"' "$TRASHDIR/fetch.har"
+  grep '"method":"GET","url":"http://127.0.0.1:[0-9]*/storage/'"$contents_oid"'?r=%2A%2A%2A%2A%2A"' "$TRASHDIR/fetch.har"
+  grep '"name":"Authorization","value":"Basic \* \* \* \* \*"' "$TRASHDIR/fetch.har"
+  [ "2" -eq "$(grep -c '"status":200' "$TRASHDIR/fetch.har")" ]
+  tail -n 1 "$TRASHDIR/fetch.har" | grep '^]}}$'
+
+  # a directory gets a file for each process
+  mkdir "$TRASHDIR/har"
+  rm -rf .git/lfs/objects
+  GIT_LFS_HAR="$TRASHDIR/har" git lfs fetch origin master
+  [ "1" -eq "$(ls "$TRASHDIR/har" | grep -c '^git-lfs-.*\.har$')" ]
+)
+end_test
+
+begin_test "fetch --repos"
+(
+  set -e
+
+  shared="shared"
+  shared_oid="$(calc_oid "$shared")"
+
+  for name in a b; do
+    reponame="fetch-repos-$name"
+    setup_remote_repo "$reponame"
+    clone_repo "$reponame" "$reponame"
+
+    git lfs track "*.dat"
+    printf "$shared" > shared.dat
+    printf "only $name" > only.dat
+    git add .gitattributes *.dat
+    git commit -m "add files"
+    git push origin master
+    cd ..
+
+    GIT_LFS_SKIP_SMUDGE=1 clone_repo "$reponame" "$reponame-clone"
+    cd ..
+  done
+
+  GIT_TRACE=1 git lfs fetch --repos=fetch-repos-a-clone,fetch-repos-b-clone 2>&1 | tee fetch.log
+  grep "Fetching in fetch-repos-a-clone" fetch.log
+  grep "Fetching in fetch-repos-b-clone" fetch.log
+  grep "Fetching 2 object(s) for fetch-repos-a-clone" fetch.log
+  grep "Fetching 1 object(s) for fetch-repos-b-clone" fetch.log
+
+  # the shared object is only downloaded once
+  [ "1" = "$(grep -c "HTTP: GET .*/$shared_oid" fetch.log)" ]
+  [ "1" = "$(grep -c "HTTP: GET .*/$(calc_oid "only a")" fetch.log)" ]
+  [ "1" = "$(grep -c "HTTP: GET .*/$(calc_oid "only b")" fetch.log)" ]
+
+  cd fetch-repos-a-clone
+  assert_local_object "$shared_oid" 6
+  assert_local_object "$(calc_oid "only a")" 6
+  refute_local_object "$(calc_oid "only b")"
+  cd ../fetch-repos-b-clone
+  assert_local_object "$shared_oid" 6
+  assert_local_object "$(calc_oid "only b")" 6
+  refute_local_object "$(calc_oid "only a")"
+  cd ..
+
+  set +e
+  git lfs fetch --repos=fetch-repos-a-clone,missing-repo > fetch.log 2>&1
+  res=$?
+  set -e
+  [ "$res" != "0" ]
+  grep "Unable to fetch in missing-repo" fetch.log
+  grep "Fetching failed in 1 of 2 repositories" fetch.log
+)
+end_test
+
+begin_test "fetch --repos with a repository which has no remote"
+(
+  set -e
+
+  reponame="fetch-repos-noremote"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "good" > good.dat
+  git add .gitattributes good.dat
+  git commit -m "add good.dat"
+  git push origin master
+  cd ..
+
+  GIT_LFS_SKIP_SMUDGE=1 clone_repo "$reponame" "$reponame-good"
+  cd ..
+
+  git init "$reponame-bad"
+  cd "$reponame-bad"
+  git commit --allow-empty -m "initial commit"
+  cd ..
+
+  set +e
+  git lfs fetch --repos="$reponame-bad,$reponame-good" > fetch.log 2>&1
+  res=$?
+  set -e
+  cat fetch.log
+  [ "$res" != "0" ]
+  grep "Unable to fetch in $reponame-bad: No default remote" fetch.log
+  grep "Fetching failed in 1 of 2 repositories" fetch.log
+
+  # the repository after the one which failed is still fetched
+  cd "$reponame-good"
+  assert_local_object "$(calc_oid "good")" 4
+)
+end_test
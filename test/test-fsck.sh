@@ -40,6 +40,7 @@ begin_test "fsck default"
 
   moved=$(native_path "$TRASHDIR/$reponame/.git/lfs/bad/$aOid")
   expected="$(printf 'Object a.dat (%s) is corrupt
+  its size is 21 bytes, but the pointer says 10 bytes
   moved to %s' "$aOid" "$moved")"
   [ "$expected" = "$(git lfs fsck)" ]
 
@@ -90,7 +91,9 @@ begin_test "fsck dry run"
 
   echo "CORRUPTION" >> .git/lfs/objects/$aOid12/$aOid34/$aOid
 
-  [ "Object a.dat ($aOid) is corrupt" = "$(git lfs fsck --dry-run)" ]
+  expected="Object a.dat ($aOid) is corrupt
+  its size is 21 bytes, but the pointer says 10 bytes"
+  [ "$expected" = "$(git lfs fsck --dry-run)" ]
 
   if [ "$aOid" = "$(shasum -a 256 .git/lfs/objects/$aOid12/$aOid34/$aOid | cut -d " " -f 1)" ]; then
     echo "oid for a.dat still matches match"
@@ -104,6 +107,41 @@ begin_test "fsck dry run"
 )
 end_test
 
+begin_test "fsck size mismatch"
+(
+  set -e
+
+  reponame="fsck-size-mismatch"
+  git init $reponame
+  cd $reponame
+
+  git lfs track "*.dat"
+  printf "test data" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "first commit"
+
+  aOid="$(calc_oid "test data")"
+
+  # commit a pointer to a.dat's object with the wrong size, without going
+  # through the clean filter
+  pointer="$(printf "version https://git-lfs.github.com/spec/v1
+oid sha256:%s
+size 5" "$aOid")"
+  blob="$(printf "%s\n" "$pointer" | git hash-object -w --stdin)"
+  git update-index --add --cacheinfo 100644 "$blob" b.dat
+  git commit -m "bad pointer"
+  rm a.dat
+  git checkout a.dat
+
+  git lfs fsck --dry-run > fsck.log
+  [ "Pointer for b.dat ($aOid) has size 5, but its object is 9 bytes" = "$(cat fsck.log)" ]
+
+  # the object itself is fine, so it isn't moved
+  git lfs fsck
+  assert_local_object "$aOid" 9
+)
+end_test
+
 begin_test "fsck: outside git repository"
 (
   set +e
@@ -119,3 +157,43 @@ begin_test "fsck: outside git repository"
   grep "Not in a git repository" fsck.log
 )
 end_test
+
+begin_test "fsck --remote"
+(
+  set -e
+
+  reponame="fsck-remote"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "pushed" > a.dat
+  cp a.dat copy-of-a.dat
+  git add .gitattributes a.dat copy-of-a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  [ "Git LFS fsck OK" = "$(git lfs fsck --remote)" ]
+
+  printf "not pushed" > b.dat
+  printf "not pushed either" > c.dat
+  git add b.dat c.dat
+  git commit -m "add b.dat and c.dat"
+
+  set +e
+  git lfs fsck --remote > fsck.log 2>&1
+  res=$?
+  set -e
+
+  cat fsck.log
+  [ "$res" = "2" ]
+  grep "Object b.dat ($(calc_oid "not pushed")) is missing from the remote" fsck.log
+  grep "Object c.dat ($(calc_oid "not pushed either")) is missing from the remote" fsck.log
+  [ "0" -eq "$(grep -c "a.dat" fsck.log)" ]
+  grep "2 of 3 objects are missing from origin: run \`git lfs push --all origin\`" fsck.log
+  [ "0" -eq "$(grep -c "Git LFS fsck OK" fsck.log)" ]
+
+  git lfs push --all origin
+  [ "Git LFS fsck OK" = "$(git lfs fsck --remote)" ]
+)
+end_test
@@ -59,3 +59,102 @@ begin_test "locking a directory"
   grep "cannot lock directory" lock.log
 )
 end_test
+
+begin_test "locking multiple files"
+(
+  set -e
+
+  reponame="lock_multiple"
+  setup_remote_repo "remote_$reponame"
+  clone_repo "remote_$reponame" "clone_$reponame"
+
+  git lfs track "*.dat"
+  # locks aren't scoped to a repo by the test server, so use names that other
+  # tests don't lock
+  for f in a b c d e f; do
+    echo "$f" > "lock_multi_$f.dat"
+  done
+  git add .gitattributes *.dat
+  git commit -m "add files"
+  git push origin master
+
+  git lfs lock "lock_multi_c.dat" | tee lock.log
+  c_id=$(grep -oh "\((.*)\)" lock.log | tr -d "()")
+
+  set +e
+  git -c lfs.concurrentlocks=2 lfs lock lock_multi_a.dat lock_multi_b.dat lock_multi_c.dat missing.dat lock_multi_d.dat lock_multi_e.dat lock_multi_f.dat > lock.log 2> lock-err.log
+  res=$?
+  set -e
+  cat lock.log lock-err.log
+
+  [ "$res" = "2" ]
+
+  # every other path is locked, despite the failures
+  for f in a b d e f; do
+    grep "'lock_multi_$f.dat' was locked" lock.log
+    assert_server_lock "$(grep "'lock_multi_$f.dat' was locked" lock.log | grep -oh "\((.*)\)" | tr -d "()")"
+  done
+  [ "$(grep -c "was locked" lock.log)" -eq 5 ]
+
+  grep "'lock_multi_c.dat' could not be locked: lock already created" lock-err.log
+  grep "'missing.dat' could not be locked" lock-err.log
+  grep "Server unable to create 2 of 7 lock(s)." lock-err.log
+  assert_server_lock "$c_id"
+)
+end_test
+
+begin_test "locking lockable files on clean"
+(
+  set -e
+
+  reponame="lock_on_clean"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  echo "*.psd filter=lfs diff=lfs merge=lfs -text lockable" > .gitattributes
+  echo "*.dat filter=lfs diff=lfs merge=lfs -text" >> .gitattributes
+  echo "a" > lock_on_clean_a.psd
+  echo "b" > lock_on_clean_b.dat
+  echo "c" > lock_on_clean_c.psd
+  git add .gitattributes *.psd *.dat
+  git commit -m "add files"
+  git push origin master
+
+  git config lfs.lockonclean true
+
+  echo "changed" > lock_on_clean_a.psd
+  git add lock_on_clean_a.psd 2>&1 | tee add.log
+  grep "Git LFS: locked lock_on_clean_a.psd" add.log
+  id=$(grep -oh "\((.*)\)" add.log | tr -d "()")
+  assert_server_lock $id
+
+  # the lock is already held, so no new one is requested
+  echo "changed again" > lock_on_clean_a.psd
+  git add lock_on_clean_a.psd 2>&1 | tee add.log
+  [ "0" -eq "$(grep -c "locked" add.log)" ]
+
+  # files which aren't lockable aren't locked
+  echo "changed" > lock_on_clean_b.dat
+  git add lock_on_clean_b.dat 2>&1 | tee add.log
+  [ "0" -eq "$(grep -c "locked" add.log)" ]
+
+  # someone else's lock only warns
+  git -c user.name="Other" -c user.email="other@example.com" lfs lock lock_on_clean_c.psd
+  echo "changed" > lock_on_clean_c.psd
+  git add lock_on_clean_c.psd 2>&1 | tee add.log
+  grep "Git LFS: unable to lock lock_on_clean_c.psd: it is locked by Other <other@example.com>" add.log
+  git diff --cached --name-only | grep "lock_on_clean_c.psd"
+
+  # unless lfs.lockonclean is strict
+  git reset lock_on_clean_c.psd
+  git config lfs.lockonclean strict
+  echo "changed again" > lock_on_clean_c.psd
+  set +e
+  git add lock_on_clean_c.psd 2>&1 | tee add.log
+  status="${PIPESTATUS[0]}"
+  set -e
+  [ "$status" -ne 0 ]
+  grep "Unable to lock lock_on_clean_c.psd: it is locked by Other <other@example.com>" add.log
+  [ "0" -eq "$(git diff --cached --name-only | grep -c "lock_on_clean_c.psd")" ]
+)
+end_test
@@ -109,3 +109,63 @@ a1fff0ffefb9eace7230c24e50731f0a91c62f9cefdfe77121c2f607125dffae * two.tgz")"
   [ "$expected" = "$(git lfs ls-files --long)" ]
 )
 end_test
+
+begin_test "ls-files: with --size"
+(
+  set -e
+
+  mkdir sizeRepo
+  cd sizeRepo
+  git init
+
+  git lfs track "*.tgz" | grep "Tracking \*.tgz"
+  echo "test content" > one.tgz
+  git add one.tgz
+  git commit -m "add a file"
+
+  [ "a1fff0ffef * one.tgz (13 B)" = "$(git lfs ls-files --size)" ]
+)
+end_test
+
+begin_test "ls-files: with lfs.sizeindex"
+(
+  set -e
+
+  mkdir sizeIndexRepo
+  cd sizeIndexRepo
+  git init
+  git config lfs.sizeindex true
+
+  git lfs track "*.tgz" | grep "Tracking \*.tgz"
+  echo "test content" > one.tgz
+  echo "test content" > two.tgz
+  git add one.tgz two.tgz
+  git commit -m "add duplicate files"
+
+  # the clean filter indexed the pointer
+  blob="$(git rev-parse HEAD:one.tgz)"
+  oid="a1fff0ffefb9eace7230c24e50731f0a91c62f9cefdfe77121c2f607125dffae"
+  [ "$blob $oid 13" = "$(cat .git/lfs/size-index)" ]
+
+  expected="$(echo "a1fff0ffef * one.tgz (13 B)
+a1fff0ffef * two.tgz (13 B)")"
+
+  GIT_TRACE=1 git lfs ls-files --size > ls-files.log 2> trace.log
+  [ "$expected" = "$(cat ls-files.log)" ]
+  grep "size index: 2 pointer(s) found in the index" trace.log
+
+  # a stale entry is ignored, and replaced with the pointer read from git
+  echo "$blob $oid 999" > .git/lfs/size-index
+  GIT_TRACE=1 git lfs ls-files --size > ls-files.log 2> trace.log
+  [ "$expected" = "$(cat ls-files.log)" ]
+  grep "size index: ignoring stale entry for $blob" trace.log
+
+  git lfs ls-files --size
+  [ "$blob $oid 13" = "$(cat .git/lfs/size-index)" ]
+
+  # and it's rebuilt if it's deleted
+  rm .git/lfs/size-index
+  [ "$expected" = "$(git lfs ls-files --size)" ]
+  [ "$blob $oid 13" = "$(cat .git/lfs/size-index)" ]
+)
+end_test
@@ -0,0 +1,134 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "merge driver: conflicting objects"
+(
+  set -e
+
+  mkdir merge-conflict
+  cd merge-conflict
+  git init
+
+  [ "" = "$(git config merge.lfs.driver)" ]
+  git lfs install --local --merge-driver
+  [ "git-lfs merge-driver --ancestor %O --current %A --other %B --marker-size %L --path %P" = "$(git config merge.lfs.driver)" ]
+
+  git lfs track "*.dat"
+  printf "base" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "base"
+
+  git checkout -b theirs
+  printf "their change" > a.dat
+  git add a.dat
+  git commit -m "their change"
+
+  git checkout master
+  printf "our change" > a.dat
+  git add a.dat
+  git commit -m "our change"
+
+  set +e
+  git merge theirs > merge.log 2>&1
+  res=$?
+  set -e
+  cat merge.log
+
+  [ "$res" != "0" ]
+  grep "a.dat has conflicting changes to a Git LFS object:" merge.log
+  grep "ours:   $(calc_oid "our change") (10 B)" merge.log
+  grep "theirs: $(calc_oid "their change") (12 B)" merge.log
+  grep "base:   $(calc_oid "base") (4 B)" merge.log
+  grep "git checkout --theirs -- a.dat" merge.log
+
+  # ours is left in the working copy, not pointer text with conflict markers
+  [ "our change" = "$(cat a.dat)" ]
+  git ls-files -u a.dat | grep " 3	a.dat"
+
+  git checkout --theirs -- a.dat
+  [ "their change" = "$(cat a.dat)" ]
+  git add a.dat
+  git commit -m "merge theirs"
+  assert_pointer "master" "a.dat" "$(calc_oid "their change")" 12
+)
+end_test
+
+begin_test "merge driver: text files"
+(
+  set -e
+
+  mkdir merge-text
+  cd merge-text
+  git init
+  git lfs install --local --merge-driver
+
+  echo "*.txt merge=lfs" > .gitattributes
+  printf "one\ntwo\nthree\n" > a.txt
+  git add .gitattributes a.txt
+  git commit -m "base"
+
+  git checkout -b theirs
+  printf "one\ntwo\nthree changed\n" > a.txt
+  git commit -am "their change"
+
+  git checkout master
+  printf "one changed\ntwo\nthree\n" > a.txt
+  git commit -am "our change"
+
+  git merge --no-edit theirs 2>&1 | tee merge.log
+  [ "$(printf "one changed\ntwo\nthree changed")" = "$(cat a.txt)" ]
+
+  git checkout -b conflict HEAD~1
+  printf "one\ntwo\nthree mine\n" > a.txt
+  git commit -am "conflicting change"
+
+  set +e
+  git merge --no-edit theirs > merge.log 2>&1
+  res=$?
+  set -e
+  cat merge.log
+
+  [ "$res" != "0" ]
+  grep "<<<<<<< a.txt" a.txt
+  grep ">>>>>>> a.txt" a.txt
+)
+end_test
+
+begin_test "merge driver: not installed by default, so -Xours and -Xtheirs resolve"
+(
+  set -e
+
+  mkdir merge-favor
+  cd merge-favor
+  git init
+
+  git lfs install
+  [ "" = "$(git config merge.lfs.driver)" ]
+
+  git lfs track "*.dat"
+  printf "base" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "base"
+
+  git checkout -b theirs
+  printf "their change" > a.dat
+  git add a.dat
+  git commit -m "their change"
+
+  git checkout master
+  printf "our change" > a.dat
+  git add a.dat
+  git commit -m "our change"
+
+  git checkout -b favor-theirs
+  git merge --no-edit -Xtheirs theirs
+  assert_pointer "favor-theirs" "a.dat" "$(calc_oid "their change")" 12
+  [ "their change" = "$(cat a.dat)" ]
+
+  git checkout -b favor-ours master
+  git merge --no-edit -Xours theirs
+  assert_pointer "favor-ours" "a.dat" "$(calc_oid "our change")" 10
+  [ "our change" = "$(cat a.dat)" ]
+)
+end_test
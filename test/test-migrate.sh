@@ -0,0 +1,95 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+# setup_footprint_repo makes a repository with objects added by two authors on
+# two dates: Alice adds 10 and 20 bytes on 2015-01-01, Bob adds 100 bytes on
+# 2015-02-01 and copies one of Alice's files, which adds no new object.
+setup_footprint_repo() {
+  mkdir "$1"
+  cd "$1"
+  git init
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "track dat files"
+
+  printf "0123456789" > a.dat
+  printf "01234567890123456789" > b.dat
+  git add a.dat b.dat
+  GIT_AUTHOR_NAME="Alice" GIT_AUTHOR_EMAIL="alice@example.com" \
+    GIT_AUTHOR_DATE="2015-01-01T12:00:00Z" git commit -m "alice's files"
+
+  head -c 100 /dev/zero > c.dat
+  cp a.dat copy.dat
+  git add c.dat copy.dat
+  GIT_AUTHOR_NAME="Bob" GIT_AUTHOR_EMAIL="bob@example.com" \
+    GIT_AUTHOR_DATE="2015-02-01T12:00:00Z" git commit -m "bob's files"
+}
+
+begin_test "migrate info: group by author"
+(
+  set -e
+
+  setup_footprint_repo migrate-info-author
+
+  git lfs migrate info 2>&1 | tee info.log
+  [ "3" = "$(wc -l < info.log | tr -d ' ')" ]
+  [ "Bob <bob@example.com>" = "$(head -n 1 info.log | cut -c 1-21)" ]
+  grep "Bob <bob@example.com>  *100 B  1 object, 1 commit" info.log
+  grep "Alice <alice@example.com>  *30 B  2 objects, 1 commit" info.log
+  grep "Total  *130 B  3 objects" info.log
+)
+end_test
+
+begin_test "migrate info: group by date"
+(
+  set -e
+
+  setup_footprint_repo migrate-info-date
+
+  git lfs migrate info --group-by=date 2>&1 | tee info.log
+  [ "2015-01-01" = "$(head -n 1 info.log | cut -c 1-10)" ]
+  grep "2015-01-01  *30 B  2 objects, 1 commit" info.log
+  grep "2015-02-01  *100 B  1 object, 1 commit" info.log
+
+  git lfs migrate info --group-by=size 2>&1 | tee invalid.log
+  grep "Invalid --group-by \"size\"" invalid.log
+)
+end_test
+
+begin_test "migrate info: json"
+(
+  set -e
+
+  setup_footprint_repo migrate-info-json
+
+  git lfs migrate info --json > info.json
+  cat info.json
+  grep '"group_by": "author"' info.json
+  grep '"total_size": 130' info.json
+  grep '"total_objects": 3' info.json
+  grep -A 3 '"name": "Alice <alice@example.com>"' info.json | grep '"size": 30'
+
+  git lfs migrate info --json --group-by=date HEAD~1 > date.json
+  cat date.json
+  grep '"name": "2015-01-01"' date.json
+  [ "0" = "$(grep -c '"name": "2015-02-01"' date.json)" ]
+)
+end_test
+
+begin_test "migrate info: low memory"
+(
+  set -e
+
+  setup_footprint_repo migrate-info-low-memory
+
+  git lfs migrate info > info.log
+  git lfs migrate info --low-memory > low-memory.log
+  cat low-memory.log
+  diff -u info.log low-memory.log
+  grep "Alice <alice@example.com>  *30 B  2 objects, 1 commit" low-memory.log
+
+  # the objects found are only kept on disk while it runs
+  [ ! -d .git/lfs/tmp ] || [ "0" = "$(find .git/lfs/tmp -name "oids*" | wc -l | tr -d ' ')" ]
+)
+end_test
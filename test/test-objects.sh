@@ -0,0 +1,139 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+# write_annex_object writes $content to the git-annex object for $key, and
+# links $file to it, as `git annex add` would.
+write_annex_object() {
+  local key="$1"
+  local content="$2"
+  local file="$3"
+  local dir=".git/annex/objects/Ab/Cd/$key"
+
+  mkdir -p "$dir"
+  printf "$content" > "$dir/$key"
+  chmod a-w "$dir/$key"
+  ln -s "$dir/$key" "$file"
+}
+
+begin_test "objects import-annex"
+(
+  set -e
+
+  reponame="objects-import-annex"
+  git init $reponame
+  cd $reponame
+
+  contents_a="annexed a"
+  oid_a=$(calc_oid "$contents_a")
+  key_a="SHA256E-s${#contents_a}--$oid_a.dat"
+  write_annex_object "$key_a" "$contents_a" a.dat
+
+  contents_b="annexed b"
+  oid_b=$(calc_oid "$contents_b")
+  key_b="WORM-s${#contents_b}-m1461106800--b.dat"
+  write_annex_object "$key_b" "$contents_b" b.dat
+
+  contents_c="annexed c"
+  key_c="SHA256E-s${#contents_c}--$(calc_oid "not c").dat"
+  write_annex_object "$key_c" "$contents_c" c.dat
+
+  set +e
+  git lfs objects import-annex --rewrite-links > import.log 2>&1
+  res=$?
+  set -e
+
+  cat import.log
+  [ "$res" = "2" ]
+  grep "$key_a $oid_a" import.log
+  grep "$key_b $oid_b" import.log
+  grep "Unable to import $key_c: .* has oid $(calc_oid "$contents_c"), but its key says" import.log
+  grep "Imported 2 of 3 annex object(s)" import.log
+
+  assert_local_object "$oid_a" "${#contents_a}"
+  assert_local_object "$oid_b" "${#contents_b}"
+  refute_local_object "$(calc_oid "$contents_c")"
+
+  grep "Rewrote a.dat" import.log
+  grep "Rewrote b.dat" import.log
+  [ ! -L a.dat ]
+  [ ! -L b.dat ]
+  [ -L c.dat ]
+  [ "$(pointer "$oid_a" "${#contents_a}")" = "$(cat a.dat)" ]
+
+  git lfs track "*.dat"
+  git add .gitattributes a.dat b.dat
+  git commit -m "import from annex"
+  assert_pointer "master" "a.dat" "$oid_a" "${#contents_a}"
+  assert_pointer "master" "b.dat" "$oid_b" "${#contents_b}"
+
+  rm a.dat
+  git checkout -- a.dat
+  [ "$contents_a" = "$(cat a.dat)" ]
+)
+end_test
+
+begin_test "objects import-annex keeps links without --rewrite-links"
+(
+  set -e
+
+  reponame="objects-import-annex-links"
+  git init $reponame
+  cd $reponame
+
+  contents="annexed"
+  oid=$(calc_oid "$contents")
+  key="SHA256-s${#contents}--$oid"
+  write_annex_object "$key" "$contents" a.dat
+
+  git lfs objects import-annex .git/annex/objects 2>&1 | tee import.log
+  grep "$key $oid" import.log
+  grep "Imported 1 of 1 annex object(s)" import.log
+  assert_local_object "$oid" "${#contents}"
+  [ -L a.dat ]
+)
+end_test
+
+begin_test "objects path"
+(
+  set -e
+
+  reponame="objects-path"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  a_oid="$(calc_oid "a")"
+  a_path="$(pwd)/.git/lfs/objects/${a_oid:0:2}/${a_oid:2:2}/$a_oid"
+  [ "$a_path" = "$(git lfs objects path "$a_oid")" ]
+  [ -f "$a_path" ]
+
+  # by file, whether it's the object's content or its pointer
+  [ "$a_path" = "$(git lfs objects path a.dat)" ]
+  git cat-file -p :a.dat > a.pointer
+  [ "$a_path" = "$(git lfs objects path a.pointer)" ]
+
+  # objects which aren't in the store have a path too, which isn't created
+  b_oid="$(calc_oid "b")"
+  b_path="$(pwd)/.git/lfs/objects/${b_oid:0:2}/${b_oid:2:2}/$b_oid"
+  printf "b" > b.dat
+  [ "$(printf "$a_path\n$b_path")" = "$(git lfs objects path a.dat b.dat)" ]
+  [ ! -e "$(dirname "$b_path")" ]
+
+  mkdir dir
+  cd dir
+  [ "$a_path" = "$(git lfs objects path ../a.dat)" ]
+  cd ..
+
+  set +e
+  git lfs objects path missing.dat 2> path.log
+  res=$?
+  set -e
+  [ "$res" = "2" ]
+  grep "Unable to find the object for \"missing.dat\"" path.log
+)
+end_test
@@ -0,0 +1,106 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "pre-commit warns about large untracked files"
+(
+  set -e
+
+  reponame="pre-commit-warnsize"
+  git init $reponame
+  cd $reponame
+
+  git lfs update --pre-commit | tee update.log
+  grep "Updated pre-commit hook." update.log
+  [ "#!/bin/sh
+command -v git-lfs >/dev/null 2>&1 || exit 0
+git lfs pre-commit \"\$@\"" = "$(cat .git/hooks/pre-commit)" ]
+
+  git lfs track "*.dat"
+  head -c 2048 /dev/zero > big.dat
+  head -c 2048 /dev/zero > big.bin
+  printf "small" > small.txt
+
+  # no warnings until lfs.warnsize is set
+  git add .gitattributes big.dat small.txt
+  git commit -m "no warnsize" 2>&1 | tee commit.log
+  [ "0" -eq "$(grep -c "Git LFS:" commit.log)" ]
+
+  git config lfs.warnsize 1k
+  git add big.bin
+  printf "still small" > small.txt
+  git add small.txt
+  git commit -m "add big.bin" 2>&1 | tee commit.log
+  grep "Git LFS: big.bin is 2.0 KB, which is over lfs.warnsize (1.0 KB), but isn't tracked by Git LFS." commit.log
+  grep "git lfs track <pattern>" commit.log
+  [ "0" -eq "$(grep -c "dat\|small" commit.log)" ]
+  [ "add big.bin" = "$(git log -1 --format=%s)" ]
+)
+end_test
+
+begin_test "pre-commit with lfs.warnsizestrict"
+(
+  set -e
+
+  reponame="pre-commit-warnsizestrict"
+  git init $reponame
+  cd $reponame
+
+  git lfs update --pre-commit
+  git config lfs.warnsize 1000
+  git config lfs.warnsizestrict true
+
+  printf "first" > a.txt
+  git add a.txt
+  git commit -m "first"
+
+  head -c 1001 /dev/zero > big.bin
+  git add big.bin
+
+  set +e
+  git commit -m "add big.bin" > commit.log 2>&1
+  res=$?
+  set -e
+
+  cat commit.log
+  [ "$res" != "0" ]
+  grep "Git LFS: big.bin is 1001 B, which is over lfs.warnsize (1000 B)" commit.log
+  grep "Commit aborted: 1 file(s) over lfs.warnsize aren't tracked by Git LFS." commit.log
+  [ "first" = "$(git log -1 --format=%s)" ]
+
+  git commit --no-verify -m "add big.bin anyway"
+  [ "add big.bin anyway" = "$(git log -1 --format=%s)" ]
+)
+end_test
+
+begin_test "pre-commit hook isn't installed over an existing hook"
+(
+  set -e
+
+  reponame="pre-commit-existing-hook"
+  git init $reponame
+  cd $reponame
+
+  git lfs update
+  [ ! -e .git/hooks/pre-commit ]
+
+  printf "#!/bin/sh\necho custom" > .git/hooks/pre-commit
+
+  set +e
+  git lfs update --pre-commit > update.log 2>&1
+  res=$?
+  set -e
+
+  cat update.log
+  [ "$res" = "2" ]
+  grep "Hook already exists: pre-commit" update.log
+  grep "git lfs update --manual --pre-commit" update.log
+
+  git lfs update --manual --pre-commit | tee manual.log
+  grep "Add the following to .git/hooks/pre-commit" manual.log
+  grep "git lfs pre-commit" manual.log
+
+  git lfs update --force --pre-commit
+  grep "git lfs pre-commit" .git/hooks/pre-commit
+)
+end_test
@@ -72,6 +72,8 @@ begin_test "prune unreferenced and old"
   git lfs prune --dry-run --verbose 2>&1 | tee prune.log
 
   grep "5 local objects, 3 retained" prune.log
+  # not a terminal, so the spinner only prints its last line
+  [ "0" -eq "$(grep -c $'\r' prune.log)" ]
   grep "2 files would be pruned" prune.log
   grep "$oid_oldandpushed" prune.log
   grep "$oid_unreferenced" prune.log
@@ -99,6 +101,159 @@ begin_test "prune unreferenced and old"
 end_test
 
 
+begin_test "prune keeps pinned objects"
+(
+  set -e
+
+  reponame="prune_pinned"
+  setup_remote_repo "remote_$reponame"
+
+  clone_repo "remote_$reponame" "clone_$reponame"
+
+  git lfs track "*.dat" 2>&1 | tee track.log
+  grep "Tracking \*.dat" track.log
+
+  content_pinned="Keep: pinned but unreferenced"
+  content_unreferenced="To delete: unreferenced"
+  content_head="Keep: at HEAD"
+  oid_pinned=$(calc_oid "$content_pinned")
+  oid_unreferenced=$(calc_oid "$content_unreferenced")
+  oid_head=$(calc_oid "$content_head")
+
+  echo "[
+  {
+    \"CommitDate\":\"$(get_date -20d)\",
+    \"Files\":[
+      {\"Filename\":\"pinned.dat\",\"Size\":${#content_pinned}, \"Data\":\"$content_pinned\"},
+      {\"Filename\":\"unreferenced.dat\",\"Size\":${#content_unreferenced}, \"Data\":\"$content_unreferenced\"}]
+  },
+  {
+    \"CommitDate\":\"$(get_date -10d)\",
+    \"Files\":[
+      {\"Filename\":\"pinned.dat\",\"Size\":${#content_head}, \"Data\":\"$content_head\"},
+      {\"Filename\":\"unreferenced.dat\",\"Size\":${#content_head}, \"Data\":\"$content_head\"}]
+  }
+  ]" | lfstest-testutils addcommits
+
+  git push origin master
+
+  git config lfs.fetchrecentrefsdays 0
+  git config lfs.fetchrecentcommitsdays 0
+  git config lfs.pruneoffsetdays 0
+
+  git lfs objects pin "$oid_pinned" 2>&1 | tee pin.log
+  grep "Pinned $oid_pinned" pin.log
+
+  git lfs objects list --pinned 2>&1 | tee list.log
+  [ "$oid_pinned" = "$(cat list.log)" ]
+
+  git lfs objects list 2>&1 | tee list.log
+  grep "$oid_pinned 29 B (pinned)" list.log
+  grep "$oid_unreferenced 23 B" list.log
+
+  git lfs prune --verbose 2>&1 | tee prune.log
+  grep "Pruning 1 files" prune.log
+  grep "$oid_unreferenced" prune.log
+  [ "0" -eq "$(grep -c "$oid_pinned" prune.log)" ]
+  assert_local_object "$oid_pinned" "${#content_pinned}"
+  refute_local_object "$oid_unreferenced"
+  assert_local_object "$oid_head" "${#content_head}"
+
+  # once unpinned, it can be pruned
+  git lfs objects unpin "$oid_pinned" 2>&1 | tee unpin.log
+  grep "Unpinned $oid_pinned" unpin.log
+  [ "" = "$(git lfs objects list --pinned)" ]
+
+  git lfs prune 2>&1 | tee prune.log
+  grep "Pruning 1 files" prune.log
+  refute_local_object "$oid_pinned"
+)
+end_test
+
+begin_test "prune keeps objects at lfs.prune.keep paths"
+(
+  set -e
+
+  reponame="prune_keep_paths"
+  setup_remote_repo "remote_$reponame"
+
+  clone_repo "remote_$reponame" "clone_$reponame"
+
+  git lfs track "*.dat" 2>&1 | tee track.log
+  grep "Tracking \*.dat" track.log
+
+  content_release="Keep: old release asset"
+  content_art="Keep: old art asset"
+  content_other="To delete: old and elsewhere"
+  content_head="Keep: at HEAD"
+  oid_release=$(calc_oid "$content_release")
+  oid_art=$(calc_oid "$content_art")
+  oid_other=$(calc_oid "$content_other")
+  oid_head=$(calc_oid "$content_head")
+
+  echo "[
+  {
+    \"CommitDate\":\"$(get_date -20d)\",
+    \"Files\":[
+      {\"Filename\":\"release/1.0/app.dat\",\"Size\":${#content_release}, \"Data\":\"$content_release\"},
+      {\"Filename\":\"art/logo.dat\",\"Size\":${#content_art}, \"Data\":\"$content_art\"},
+      {\"Filename\":\"other.dat\",\"Size\":${#content_other}, \"Data\":\"$content_other\"}]
+  },
+  {
+    \"CommitDate\":\"$(get_date -10d)\",
+    \"Files\":[
+      {\"Filename\":\"release/1.0/app.dat\",\"Size\":${#content_head}, \"Data\":\"$content_head\"},
+      {\"Filename\":\"art/logo.dat\",\"Size\":${#content_head}, \"Data\":\"$content_head\"},
+      {\"Filename\":\"other.dat\",\"Size\":${#content_head}, \"Data\":\"$content_head\"}]
+  }
+  ]" | lfstest-testutils addcommits
+
+  git push origin master
+
+  git config lfs.fetchrecentrefsdays 0
+  git config lfs.fetchrecentcommitsdays 0
+  git config lfs.pruneoffsetdays 0
+  git config --add lfs.prune.keep "release/**"
+  git config --add lfs.prune.keep "art/*.dat"
+
+  git lfs prune --verbose 2>&1 | tee prune.log
+  [ "0" -eq "$(grep -c "clash" prune.log)" ]
+  grep "Pruning 1 files" prune.log
+  grep "$oid_other" prune.log
+  assert_local_object "$oid_release" "${#content_release}"
+  assert_local_object "$oid_art" "${#content_art}"
+  assert_local_object "$oid_head" "${#content_head}"
+  refute_local_object "$oid_other"
+
+  # without the setting, they can be pruned
+  git config --unset-all lfs.prune.keep
+  git lfs prune 2>&1 | tee prune.log
+  grep "Pruning 2 files" prune.log
+  refute_local_object "$oid_release"
+  refute_local_object "$oid_art"
+)
+end_test
+
+begin_test "objects pin (invalid oid)"
+(
+  set -e
+
+  reponame="objects_pin_invalid"
+  git init "$reponame"
+  cd "$reponame"
+
+  set +e
+  git lfs objects pin "not-an-oid" 2>&1 | tee pin.log
+  res=${PIPESTATUS[0]}
+  set -e
+
+  [ "$res" != "0" ]
+  grep "Invalid object ID: \"not-an-oid\"" pin.log
+  [ ! -e .git/lfs/pinned ]
+)
+end_test
+
+
 begin_test "prune keep unpushed"
 (
   set -e
@@ -497,7 +652,7 @@ begin_test "prune verify"
 
   # confirm that it would prune with verify when no issues
   git lfs prune --dry-run --verify-remote --verbose 2>&1 | tee prune.log
-  grep "4 local objects, 1 retained, 3 verified with remote" prune.log
+  grep "4 local objects, 1 retained, 3 of 3 verified with remote" prune.log
   grep "3 files would be pruned" prune.log
   grep "$oid_commit3" prune.log
   grep "$oid_commit2_failverify" prune.log
@@ -507,7 +662,7 @@ begin_test "prune verify"
   delete_server_object "remote_$reponame" "$oid_commit2_failverify"
   # this should now fail
   git lfs prune --verify-remote 2>&1 | tee prune.log
-  grep "4 local objects, 1 retained, 2 verified with remote" prune.log
+  grep "4 local objects, 1 retained, 2 of 3 verified with remote" prune.log
   grep "missing on remote:" prune.log
   grep "$oid_commit2_failverify" prune.log
   # Nothing should have been deleted
@@ -519,7 +674,7 @@ begin_test "prune verify"
   git config lfs.pruneverifyremotealways true
   # no verify arg but should be pulled from global
   git lfs prune 2>&1 | tee prune.log
-  grep "4 local objects, 1 retained, 2 verified with remote" prune.log
+  grep "4 local objects, 1 retained, 2 of 3 verified with remote" prune.log
   grep "missing on remote:" prune.log
   grep "$oid_commit2_failverify" prune.log
   # Nothing should have been deleted
@@ -126,3 +126,172 @@ begin_test "pull: outside git repository"
   grep "Not in a git repository" pull.log
 )
 end_test
+
+begin_test "pull after checking out a commit that deletes a file"
+(
+  set -e
+
+  reponame="pull-deleted-file"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  kept="kept"
+  kept_oid="$(calc_oid "$kept")"
+  deleted="deleted"
+
+  printf "$kept" > kept.dat
+  printf "$deleted" > deleted.dat
+  git add .gitattributes kept.dat deleted.dat
+  git commit -m "add files"
+  git rm deleted.dat
+  git commit -m "delete deleted.dat"
+  git push origin master
+
+  git checkout -q HEAD^
+  rm -rf .git/lfs/objects
+
+  # git only smudges files in the target commit, and pull only fetches them
+  GIT_TRACE=1 git checkout master 2>&1 | tee checkout.log
+  [ ! -e deleted.dat ]
+  [ "0" = "$(grep -c "smudge.*deleted.dat" checkout.log)" ]
+
+  GIT_TRACE=1 git lfs pull 2>&1 | tee pull.log
+  [ "0" = "$(grep -c "deleted.dat" pull.log)" ]
+  [ ! -e deleted.dat ]
+  [ "$kept" = "$(cat kept.dat)" ]
+  assert_local_object "$kept_oid" 4
+)
+end_test
+
+begin_test "pull with lfs.checkoutdeleted"
+(
+  set -e
+
+  reponame="pull-checkoutdeleted"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="removed from the working copy"
+  contents_oid="$(calc_oid "$contents")"
+
+  printf "$contents" > removed.dat
+  printf "kept" > kept.dat
+  git add .gitattributes removed.dat kept.dat
+  git commit -m "add files"
+  git push origin master
+
+  rm removed.dat
+  rm -rf .git/lfs/objects
+
+  git config lfs.checkoutdeleted skip
+  GIT_TRACE=1 git lfs pull 2>&1 | tee pull.log
+  grep "Skipping removed.dat \[$contents_oid\], deleted from the working copy" pull.log
+  [ ! -e removed.dat ]
+  refute_local_object "$contents_oid"
+  [ "kept" = "$(cat kept.dat)" ]
+
+  git lfs checkout
+  [ ! -e removed.dat ]
+
+  git config --unset lfs.checkoutdeleted
+  git lfs pull
+  [ "$contents" = "$(cat removed.dat)" ]
+  assert_local_object "$contents_oid" 29
+)
+end_test
+
+begin_test "pull with lfs.pointer.unknownsize"
+(
+  set -e
+
+  reponame="pull-unknownsize"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="a pointer without its size"
+  contents_oid="$(calc_oid "$contents")"
+
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  # replace the pointer with one that has lost its size line
+  pointer="version https://git-lfs.github.com/spec/v1
+oid sha256:$contents_oid"
+  blob="$(printf "$pointer\n" | git hash-object -w --stdin)"
+  git update-index --cacheinfo 100644 "$blob" a.dat
+  git commit -m "drop the size of a.dat"
+  git push origin master
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 clone_repo "$reponame" "$reponame-clone"
+
+  # by default, it's not a valid pointer
+  git lfs pull
+  refute_local_object "$contents_oid"
+  [ "$pointer" = "$(cat a.dat)" ]
+
+  git config lfs.pointer.unknownsize allow
+  GIT_TRACE=1 git lfs pull 2>&1 | tee pull.log
+  grep "xfer: $contents_oid was of unknown size, and is 26 bytes" pull.log
+  assert_local_object "$contents_oid" 26
+  [ "$contents" = "$(cat a.dat)" ]
+
+  # smudge downloads it too
+  delete_local_object "$contents_oid"
+  [ "$contents" = "$(printf "$pointer\n" | git lfs smudge a.dat 2> smudge.log)" ]
+  grep "Downloading a.dat (unknown size)" smudge.log
+  assert_local_object "$contents_oid" 26
+
+  # checking it out stages the whole pointer, which can be committed to fix it
+  git diff --cached a.dat | tee diff.log
+  grep "^+size 26" diff.log
+)
+end_test
+
+begin_test "fetch and status with lfs.pointer.unknownsize"
+(
+  set -e
+
+  reponame="fetch-unknownsize"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+  git config lfs.pointer.unknownsize allow
+
+  git lfs track "*.dat"
+  contents="a pointer without its size"
+  contents_oid="$(calc_oid "$contents")"
+
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  pointer="version https://git-lfs.github.com/spec/v1
+oid sha256:$contents_oid"
+  blob="$(printf "$pointer\n" | git hash-object -w --stdin)"
+  git update-index --cacheinfo 100644 "$blob" a.dat
+  git commit -m "drop the size of a.dat"
+
+  # unknown sizes are left out of totals
+  git lfs status | tee status.log
+  grep "a.dat (unknown size)" status.log
+  git lfs status --summary | tee summary.log
+  grep "Git LFS objects to be pushed to origin/master: 1 (0 B)" summary.log
+  [ "0" -eq "$(grep -c -- "-1" summary.log)" ]
+
+  git push origin master
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 clone_repo "$reponame" "$reponame-clone"
+  git config lfs.pointer.unknownsize allow
+
+  git lfs fetch --max-bytes=1KB 2>&1 | tee fetch.log
+  [ "0" -eq "$(grep -c -- "-1 B" fetch.log)" ]
+  assert_local_object "$contents_oid" 26
+)
+end_test
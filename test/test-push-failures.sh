@@ -69,6 +69,110 @@ begin_test "push: upload file with storage 500"
 )
 end_test
 
+begin_test "push: upload file with storage 409"
+(
+  set -e
+
+  push_fail_test "status-storage-409"
+)
+end_test
+
+begin_test "push: upload file with storage 409 for an object the server has"
+(
+  set -e
+
+  contents="status-storage-409-present"
+  reponame="$(basename "$0" ".sh")-$contents"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "$contents" > conflict.dat
+  git add .gitattributes conflict.dat
+  git commit -m "add conflict.dat"
+
+  GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  grep "master -> master" push.log
+  grep "upload of $(calc_oid "$contents") conflicted" push.log
+  assert_server_object "$reponame" "$(calc_oid "$contents")"
+
+)
+end_test
+
+begin_test "push: upload file with storage 409 and lfs.transfer.uploadconflict retry"
+(
+  set -e
+
+  contents="status-storage-409-present"
+  reponame="$(basename "$0" ".sh")-$contents-retry"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.transfer.uploadconflict retry
+  git lfs track "*.dat"
+  printf "$contents" > conflict.dat
+  git add .gitattributes conflict.dat
+  git commit -m "add conflict.dat"
+
+  # the conflict is retried like other failures, and the retried batch request
+  # finds the object on the server
+  GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  grep "tq: retrying object $(calc_oid "$contents")" push.log
+  [ "0" = "$(grep -c "conflicted" push.log)" ]
+  assert_server_object "$reponame" "$(calc_oid "$contents")"
+)
+end_test
+
+begin_test "push: upload file with storage 500 for an object the server stored and lfs.transfer.uploadretry verify"
+(
+  set -e
+
+  contents="status-storage-500-present"
+  reponame="$(basename "$0" ".sh")-$contents"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.transfer.uploadretry verify
+  git lfs track "*.dat"
+  printf "$contents" > stored.dat
+  git add .gitattributes stored.dat
+  git commit -m "add stored.dat"
+
+  # the object store has the object despite the 500, so it isn't uploaded again
+  GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  grep "master -> master" push.log
+  grep "upload of $(calc_oid "$contents") failed, checking the object store has it" push.log
+  grep "$(calc_oid "$contents") is already in the object store" push.log
+  [ "0" = "$(grep -c "tq: retrying object" push.log)" ]
+  assert_server_object "$reponame" "$(calc_oid "$contents")"
+)
+end_test
+
+begin_test "push: upload file with storage 503 and lfs.transfer.maxretries"
+(
+  set -e
+
+  contents="status-storage-503-once"
+  reponame="$(basename "$0" ".sh")-$contents"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.transfer.maxretries 2
+  git lfs track "*.dat"
+  printf "$contents" > flaky.dat
+  git add .gitattributes flaky.dat
+  git commit -m "add flaky.dat"
+
+  # the upload is retried after the wait the server's Retry-After header asks
+  # for, within the transfer
+  GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  grep "master -> master" push.log
+  grep "http: PUT .*/storage/$(calc_oid "$contents").* failed, retry 1 of 2 in 1s" push.log
+  [ "0" = "$(grep -c "tq: retrying object" push.log)" ]
+  assert_server_object "$reponame" "$(calc_oid "$contents")"
+)
+end_test
+
 begin_test "push: upload file with api 403"
 (
   set -e
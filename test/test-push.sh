@@ -46,6 +46,36 @@ begin_test "push"
 )
 end_test
 
+begin_test "push --dry-run --ask-server"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-ask-server"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" repo-ask-server
+
+  git lfs track "*.dat"
+  echo "push a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git lfs push origin master 2>&1 | tee push.log
+  grep "(1 of 1 files)" push.log
+
+  git checkout -b ask-server
+  printf "push b" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+
+  git lfs push --dry-run --ask-server origin ask-server 2>&1 | tee push.log
+  grep "push 4c48d2a6991c9895bcddcf027e1e4907280bcf21975492b1afbade396d6a3340 => a.dat" push.log && exit 1
+  grep "push 0249e45f653b0b7eda919bd957cba64bdd748c3a4de5cdf74b6a6c4f2ba272e4 => b.dat (6 B)" push.log
+  grep "1 objects would be pushed (6 B)" push.log
+
+  refute_server_object "$reponame" 0249e45f653b0b7eda919bd957cba64bdd748c3a4de5cdf74b6a6c4f2ba272e4
+)
+end_test
+
 # sets up the tests for the next few push --all tests
 push_all_setup() {
   suffix="$1"
@@ -161,7 +191,8 @@ begin_test "push --all (no ref args)"
   grep "push $extraoid => file2.dat" push.log
   [ $(grep -c "push" push.log) -eq 6 ]
 
-  git push --all origin 2>&1 | tee push.log
+  # a bar, so that all progress for the push is on one line
+  GIT_LFS_PROGRESS=bar git push --all origin 2>&1 | tee push.log
   grep "(2 of 2 files, 1 skipped)" push.log
   grep "(3 of 3 files)" push.log
   [ $(grep -c "files)" push.log) -eq 1 ]
@@ -511,3 +542,194 @@ begin_test "push (retry with expired actions)"
   grep "(1 of 1 files)" push.log
 )
 end_test
+
+begin_test "push --verify"
+(
+  set -e
+
+  reponame="push-verify"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "verify a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git lfs push --verify origin master 2>&1 | tee push.log
+  grep "(1 of 1 files)" push.log
+  grep "Verified 1 pushed objects" push.log
+  # progress is only shown on a terminal
+  [ "0" -eq "$(grep -c "Git LFS: verifying" push.log)" ]
+
+  printf "batch-download-unavailable" > b.dat
+  printf "verify c" > c.dat
+  git add b.dat c.dat
+  git commit -m "add b.dat, c.dat"
+
+  boid="$(calc_oid "batch-download-unavailable")"
+  coid="$(calc_oid "verify c")"
+
+  set +e
+  git lfs push --verify origin master 2>&1 | tee push.log
+  res=${PIPESTATUS[0]}
+  set -e
+
+  if [ "$res" = "0" ]; then
+    echo "push --verify should fail when objects are unavailable"
+    exit 1
+  fi
+
+  grep "Object $boid (b.dat) is not available from the server" push.log
+  [ "0" -eq "$(grep -c "$coid" push.log)" ]
+  grep "Verification failed: 1 of 3 pushed objects are not available" push.log
+)
+end_test
+
+begin_test "push --progress"
+(
+  set -e
+
+  reponame="push-progress"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  for name in a b c d; do
+    printf "progress $name" > "$name.dat"
+  done
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  # not a terminal, so lines are plain by default
+  git lfs push origin master 2>&1 | tee push.log
+  grep "^Git LFS: (1 of 1 files) 10 B / 10 B$" push.log
+  [ "0" -eq "$(grep -c $'\r' push.log)" ]
+
+  git add b.dat
+  git commit -m "add b.dat"
+  git lfs push --progress=bar origin master 2>&1 | tee push.log
+  grep $'\rGit LFS: (1 of 1 files, 1 skipped) 10 B / 10 B, 10 B skipped' push.log
+
+  git add c.dat
+  git commit -m "add c.dat"
+  git lfs push --progress=none origin master 2>&1 | tee push.log
+  [ "0" -eq "$(grep -c "Git LFS:" push.log)" ]
+
+  git add d.dat
+  git commit -m "add d.dat"
+  GIT_LFS_PROGRESS=none git lfs push origin master 2>&1 | tee push.log
+  [ "0" -eq "$(grep -c "Git LFS:" push.log)" ]
+  assert_server_object "$reponame" "$(calc_oid "progress d")"
+
+  set +e
+  git lfs push --progress=spinner origin master 2>&1 | tee push.log
+  status="${PIPESTATUS[0]}"
+  set -e
+  [ "$status" -ne 0 ]
+  grep 'Invalid --progress style "spinner": use bar, plain or none.' push.log
+)
+end_test
+
+begin_test "push with lfs.progress.noninteractive"
+(
+  set -e
+
+  reponame="push-progress-noninteractive"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  for name in a b c; do
+    printf "progress $name" > "$name.dat"
+  done
+
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git config lfs.progress.noninteractive none
+  git lfs push origin master 2>&1 | tee push.log
+  [ "0" -eq "$(grep -c "Git LFS:" push.log)" ]
+  assert_server_object "$reponame" "$(calc_oid "progress a")"
+
+  # an explicit style still applies
+  git add b.dat
+  git commit -m "add b.dat"
+  git lfs push --progress=plain origin master 2>&1 | tee push.log
+  grep "^Git LFS: (1 of 1 files, 1 skipped) 10 B / 10 B, 10 B skipped$" push.log
+
+  # with an interval, a quick push only prints its first and last lines
+  git add c.dat
+  git commit -m "add c.dat"
+  git config lfs.progress.noninteractive plain
+  git config lfs.progress.interval 3600
+  git lfs push origin master 2>&1 | tee push.log
+  [ "2" -eq "$(grep -c "Git LFS:" push.log)" ]
+  grep "^Git LFS: (0 of 1 files, 2 skipped) 0 B / 10 B, 20 B skipped$" push.log
+  grep "^Git LFS: (1 of 1 files, 2 skipped) 10 B / 10 B, 20 B skipped$" push.log
+  [ "0" -eq "$(grep -c $'\r' push.log)" ]
+)
+end_test
+
+begin_test "push with transfer ramp-up"
+(
+  set -e
+
+  reponame="push-transfer-rampup"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  for name in a b c d e f; do
+    printf "ramp up $name" > "$name.dat"
+  done
+  git add .gitattributes *.dat
+  git commit -m "add files"
+
+  git config lfs.concurrenttransfers 3
+  git config lfs.transfer.rampupwindow 1
+  GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  grep "(6 of 6 files)" push.log
+  grep "ramp-up admitted worker 1" push.log
+  grep "ramp-up admitted worker 2" push.log
+
+  for name in a b c d e f; do
+    assert_server_object "$reponame" "$(calc_oid "ramp up $name")"
+  done
+)
+end_test
+
+begin_test "push resumes from an interrupted push"
+(
+  set -e
+
+  reponame="push-transfer-state"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "state a" > a.dat
+  printf "state b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add files"
+
+  # record a.dat as pushed by an earlier push that didn't finish
+  endpoint="$(git lfs env | grep "^Endpoint=" | head -n 1 | cut -d= -f2 | cut -d" " -f1)"
+  mkdir -p .git/lfs/transfer-state
+  printf "url $endpoint\n$(calc_oid "state a")\n" > .git/lfs/transfer-state/origin
+
+  GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  grep "already pushed" push.log
+  refute_server_object "$reponame" "$(calc_oid "state a")"
+  assert_server_object "$reponame" "$(calc_oid "state b")"
+  [ ! -e .git/lfs/transfer-state/origin ]
+
+  # state for another endpoint is ignored
+  printf "state c" > c.dat
+  git add c.dat
+  git commit -m "add c.dat"
+  printf "url https://example.com/lfs\n$(calc_oid "state c")\n" > .git/lfs/transfer-state/origin
+  git push origin master
+  assert_server_object "$reponame" "$(calc_oid "state c")"
+  [ ! -e .git/lfs/transfer-state/origin ]
+)
+end_test
@@ -85,3 +85,89 @@ begin_test "resume-http-range-fallback"
 )
 end_test
 
+
+begin_test "resume-http-range after the process is killed"
+(
+  set -e
+
+  reponame="resume-http-range-killed"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" $reponame
+
+  git lfs track "*.dat"
+
+  # this string makes the server send half of the object, then stall, the
+  # first time it's downloaded from the start
+  contents="storage-download-stall"
+  contents_oid=$(calc_oid "$contents")
+  partial=".git/lfs/objects/incomplete/$contents_oid.tmp"
+
+  printf "$contents" > a.dat
+  git add a.dat .gitattributes
+  git commit -m "add a.dat"
+  git push origin master
+  assert_server_object "$reponame" "$contents_oid"
+
+  rm -rf .git/lfs/objects
+  git-lfs fetch > fetchkilled.log 2>&1 &
+  pid=$!
+  for i in $(seq 1 100); do
+    [ "11" = "$(wc -c < "$partial" 2> /dev/null | tr -d ' ')" ] && break
+    sleep 0.1
+  done
+  kill -9 "$pid"
+  wait "$pid" || true
+
+  [ "11" = "$(wc -c < "$partial" | tr -d ' ')" ]
+  refute_local_object "$contents_oid"
+
+  # the next run resumes from the partial file
+  GIT_TRACE=1 git lfs fetch 2>&1 | tee fetchresume.log
+  grep "xfer: Attempting to resume download of \"$contents_oid\" from byte 11" fetchresume.log
+  grep "xfer: server accepted resume download request: \"$contents_oid\" from byte 11" fetchresume.log
+  assert_local_object "$contents_oid" "${#contents}"
+  [ ! -e "$partial" ]
+)
+end_test
+
+begin_test "resume-http-range from a corrupt partial file"
+(
+  set -e
+
+  reponame="resume-http-range-corrupt"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" $reponame
+
+  git lfs track "*.dat"
+
+  contents="storage-download-stall"
+  contents_oid=$(calc_oid "$contents")
+
+  printf "$contents" > a.dat
+  git add a.dat .gitattributes
+  git commit -m "add a.dat"
+  git push origin master
+  assert_server_object "$reponame" "$contents_oid"
+
+  # the server stalls on the first download from the start, so make sure the
+  # retried download below isn't the first
+  rm -rf .git/lfs/objects
+  git-lfs fetch > fetchkilled.log 2>&1 &
+  pid=$!
+  sleep 1
+  kill -9 "$pid"
+  wait "$pid" || true
+
+  # a partial file that the oid can't match
+  rm -rf .git/lfs/objects
+  mkdir -p .git/lfs/objects/incomplete
+  printf "xxxxxxxxxxx" > ".git/lfs/objects/incomplete/$contents_oid.tmp"
+
+  # resuming finds the corrupt data once the download completes, so the
+  # partial file is removed and the download retried from the start
+  GIT_TRACE=1 git lfs fetch 2>&1 | tee fetchresume.log
+  grep "xfer: server accepted resume download request: \"$contents_oid\" from byte 11" fetchresume.log
+  grep "tq: retrying object $contents_oid" fetchresume.log
+  assert_local_object "$contents_oid" "${#contents}"
+)
+end_test
@@ -233,3 +233,110 @@ begin_test "smudge skip download failure"
 
 )
 end_test
+
+begin_test "smudge download failure with placeholder fallback"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-placeholder"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" placeholder
+
+  git lfs track "*.dat"
+  contents="smudge a"
+  oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  pointer="$(pointer "$oid" ${#contents})"
+
+  # make the download fail
+  rm -rf .git/lfs/objects
+  git remote set-url origin httpnope://nope.com/nope
+
+  git config lfs.smudgefallback placeholder
+  echo "$pointer" | git lfs smudge a.dat > placeholder.txt 2> smudge.log
+  grep "wrote a placeholder instead" smudge.log
+  grep "# Git LFS placeholder" placeholder.txt
+  [ "$pointer" = "$(tail -n 3 placeholder.txt)" ]
+
+  # a checkout with the object missing proceeds, leaving the placeholder
+  rm a.dat
+  git checkout -- a.dat
+  grep "# Git LFS placeholder" a.dat
+
+  # the placeholder can't be committed as content
+  [ "$pointer" = "$(git lfs clean < a.dat)" ]
+  [ -z "$(git status --porcelain a.dat)" ]
+
+  # and is replaced once the object can be fetched
+  git remote set-url origin "$GITSERVER/$reponame"
+  git lfs pull
+  [ "$contents" = "$(cat a.dat)" ]
+)
+end_test
+
+begin_test "smudge with read-only object store"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-readonly"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  echo "read-only a" > a.dat
+  echo "read-only b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add files"
+  git push origin master
+
+  oid_a="$(calc_oid "read-only a
+")"
+  oid_b="$(calc_oid "read-only b
+")"
+
+  # a pre-populated cache with only a.dat's object
+  rm -rf .git/lfs/objects/${oid_b:0:2}
+  rm -rf a.dat b.dat
+  chmod -R a-w .git/lfs/objects
+  if [ "$(id -u)" = "0" ]; then
+    # root can write to it anyway
+    git config lfs.storage.readonly true
+  fi
+
+  git checkout -- a.dat b.dat 2>&1 | tee checkout.log
+  chmod -R u+w .git/lfs/objects
+
+  [ "read-only a" = "$(cat a.dat)" ]
+  [ "read-only b" = "$(cat b.dat)" ]
+  assert_local_object "$oid_a" 12
+  refute_local_object "$oid_b"
+  [ -z "$(find .git/lfs/tmp -type f -name "$oid_b*")" ]
+)
+end_test
+
+begin_test "smudge with lfs.storage.readonly"
+(
+  set -e
+
+  cd "$(basename "$0" ".sh")-readonly"
+  oid_b="$(calc_oid "read-only b
+")"
+
+  # the store isn't written to, even though it could be
+  git config lfs.storage.readonly true
+  rm -f b.dat
+  git checkout -- b.dat
+  [ "read-only b" = "$(cat b.dat)" ]
+  refute_local_object "$oid_b"
+
+  git config lfs.storage.readonly false
+  rm -f b.dat
+  git checkout -- b.dat
+  [ "read-only b" = "$(cat b.dat)" ]
+  assert_local_object "$oid_b" 12
+)
+end_test
@@ -38,6 +38,56 @@ Git LFS objects not staged for commit:
 )
 end_test
 
+begin_test "status without the lfs filters"
+(
+  set -e
+
+  mkdir repo-no-filters
+  cd repo-no-filters
+  git init
+  git commit --allow-empty -m "initial commit"
+
+  git lfs status 2> status.err
+  [ ! -s status.err ]
+
+  git -c filter.lfs.smudge= -c filter.lfs.clean= lfs status 2> status.err
+  grep "Git LFS isn't installed for this repository, so Git checks out files tracked by Git LFS as pointer text." status.err
+
+  git -c filter.lfs.smudge= -c filter.lfs.clean= -c lfs.setupcheck=off lfs status 2> status.err
+  [ ! -s status.err ]
+)
+end_test
+
+begin_test "status with pointer text in the working copy"
+(
+  set -e
+
+  mkdir repo-pointer-text
+  cd repo-pointer-text
+  git init
+  git lfs track "*.dat"
+  echo "pointer text" > a.dat
+  echo "content" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add a.dat and b.dat"
+
+  # as if a.dat was checked out while the filters weren't configured
+  git show HEAD:a.dat > a.dat
+  git lfs status 2>&1 | tee status.log
+  grep "Git LFS files checked out as pointer text" status.log
+  grep "	a.dat" status.log
+  [ "0" = "$(grep -c "b.dat" status.log)" ]
+
+  git -c lfs.setupcheck=off lfs status 2>&1 | tee status.log
+  [ "0" = "$(grep -c "pointer text" status.log)" ]
+
+  git lfs checkout a.dat
+  [ "pointer text" = "$(cat a.dat)" ]
+  git lfs status 2>&1 | tee status.log
+  [ "0" = "$(grep -c "pointer text" status.log)" ]
+)
+end_test
+
 begin_test "status --porcelain"
 (
   set -e
@@ -83,3 +133,47 @@ begin_test "status: outside git repository"
   grep "Not in a git repository" status.log
 )
 end_test
+
+begin_test "status --summary"
+(
+  set -e
+
+  reponame="status-summary"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "track"
+  git push origin master
+
+  printf "pushed" > pushed.dat
+  git add pushed.dat
+  git commit -m "pushed.dat"
+  git push origin master
+
+  printf "unpushed 1" > unpushed1.dat
+  printf "unpushed 22" > unpushed2.dat
+  git add unpushed1.dat unpushed2.dat
+  git commit -m "unpushed files"
+
+  printf "staged" > staged.dat
+  git add staged.dat
+
+  printf "changed" > pushed.dat
+
+  # backdate the files and refresh the index, so that status doesn't re-clean
+  # unpushed2.dat and restore its object
+  touch -d "1 hour ago" unpushed1.dat unpushed2.dat staged.dat
+  git update-index --refresh > /dev/null || true
+  delete_local_object "$(calc_oid "unpushed 22")"
+
+  expected="On branch master
+Git LFS objects to be pushed to origin/master: 2 (21 B)
+Git LFS objects to be committed: 1 (6 B)
+Git LFS objects not staged for commit: 1
+Git LFS objects missing locally: 1 (11 B)"
+
+  [ "$expected" = "$(git lfs status --summary)" ]
+)
+end_test
@@ -0,0 +1,66 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "test-server"
+(
+  set -e
+
+  reponame="verify-test-server"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs test-server 2>&1 | tee test-server.log
+  grep "Testing $GITSERVER/$reponame.git/info/lfs" test-server.log
+  grep "batch upload    ok" test-server.log
+  grep "upload          ok" test-server.log
+  grep "verify          ok" test-server.log
+  grep "batch download  ok" test-server.log
+  grep "download        ok" test-server.log
+  grep "checksum        ok" test-server.log
+  grep "lock            ok" test-server.log
+  grep "unlock          ok" test-server.log
+  grep "8 passed, 0 failed, 0 skipped" test-server.log
+
+  oid=$(grep -o "Left test object [0-9a-f]* (1024 bytes)" test-server.log | cut -d " " -f 4)
+  assert_server_object "$reponame" "$oid"
+  [ "0" -eq "$(find "$TMPDIR" -maxdepth 1 -name "git-lfs-test-server*" | wc -l)" ]
+)
+end_test
+
+begin_test "test-server with an endpoint"
+(
+  set -e
+
+  reponame="test-server-endpoint"
+  setup_remote_repo "$reponame"
+
+  mkdir not-a-repo
+  cd not-a-repo
+
+  git lfs test-server "$GITSERVER/$reponame.git/info/lfs" 2>&1 | tee test-server.log
+  grep "Testing $GITSERVER/$reponame.git/info/lfs" test-server.log
+  grep "verify          skipped: the server sent no verify action" test-server.log
+  grep "checksum        ok" test-server.log
+  grep "7 passed, 0 failed, 1 skipped" test-server.log
+)
+end_test
+
+begin_test "test-server without batch support"
+(
+  set -e
+
+  set +e
+  git lfs test-server "$GITSERVER/batchunsupported.git/info/lfs" > test-server.log 2>&1
+  res=$?
+  set -e
+
+  cat test-server.log
+  [ "$res" = "2" ]
+  grep "batch upload    FAILED: the server doesn't support the batch API" test-server.log
+  grep "upload          skipped: batch upload didn't pass" test-server.log
+  grep "checksum        skipped: download didn't pass" test-server.log
+  grep "lock            ok" test-server.log
+  grep "2 passed, 1 failed, 5 skipped" test-server.log
+)
+end_test
@@ -293,3 +293,85 @@ begin_test "track blocklisted files with glob"
 )
 end_test
 
+
+begin_test "track with lfs.track.location=root"
+(
+  set -e
+
+  git init track-location-root
+  cd track-location-root
+  git config lfs.track.location root
+
+  mkdir -p a/b
+  cd a/b
+  git lfs track "*.psd" | grep "Tracking \*.psd"
+  git lfs track "images/*.png" | grep "Tracking images/\*.png"
+
+  [ ! -f .gitattributes ]
+  grep "^a/b/\*\*/\*.psd filter=lfs diff=lfs merge=lfs -text$" ../../.gitattributes
+  grep "^a/b/images/\*.png filter=lfs diff=lfs merge=lfs -text$" ../../.gitattributes
+
+  git lfs track "*.psd" | grep "\*.psd already supported"
+  [ "$(grep -c "psd" ../../.gitattributes)" -eq 1 ]
+
+  # the patterns match the same files as they would in a/b/.gitattributes
+  [ "lfs" = "$(git check-attr filter c/d.psd | cut -d ' ' -f 3)" ]
+  [ "lfs" = "$(git check-attr filter images/e.png | cut -d ' ' -f 3)" ]
+  [ "unspecified" = "$(git check-attr filter ../f.psd | cut -d ' ' -f 3)" ]
+)
+end_test
+
+begin_test "track with lfs.track.textauto"
+(
+  set -e
+
+  git init track-textauto
+  cd track-textauto
+  git config lfs.track.textauto true
+
+  git lfs track "*.dat"
+  [ "* text=auto" = "$(head -n 1 .gitattributes)" ]
+  grep "^\*.dat filter=lfs diff=lfs merge=lfs -text$" .gitattributes
+
+  # only added when the file is created
+  git lfs track "*.bin"
+  [ "$(grep -c "text=auto" .gitattributes)" -eq 1 ]
+
+  mkdir sub
+  cd sub
+  echo "*.txt eol=lf" > .gitattributes
+  git lfs track "*.iso"
+  [ "$(grep -c "text=auto" .gitattributes)" -eq 0 ]
+)
+end_test
+
+begin_test "track skips submodules matching the pattern"
+(
+  set -e
+
+  git init track-submodule-lib
+  cd track-submodule-lib
+  echo "lib" > lib.txt
+  git add lib.txt
+  git commit -m "lib"
+  cd ..
+
+  git init track-submodule
+  cd track-submodule
+  echo "a" > a.dat
+  git add a.dat
+  git -c protocol.file.allow=always submodule add ../track-submodule-lib lib.dat
+  git commit -m "add submodule"
+
+  git lfs track "*.dat" > track.log
+  grep "Git LFS: skipping lib.dat: it is a submodule, not a file" track.log
+  [ "0" -eq "$(grep -c "touching lib.dat" track.log)" ]
+
+  git add .gitattributes a.dat
+  git commit -m "track dat files"
+  git lfs ls-files | tee ls.log
+  grep "a.dat" ls.log
+  [ "0" -eq "$(grep -c "lib.dat" ls.log)" ]
+  [ "$(cd lib.dat && git rev-parse HEAD)" = "$(git ls-tree HEAD lib.dat | cut -d ' ' -f 3 | cut -f 1)" ]
+)
+end_test
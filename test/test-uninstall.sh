@@ -14,15 +14,17 @@ begin_test "uninstall outside repository"
 
   # uninstall multiple times to trigger https://github.com/github/git-lfs/issues/529
   git lfs uninstall
-  git lfs install
+  git lfs install --merge-driver
   git lfs uninstall | tee uninstall.log
   grep "configuration has been removed" uninstall.log
 
   [ "" = "$(git config --global filter.lfs.smudge)" ]
   [ "" = "$(git config --global filter.lfs.clean)" ]
+  [ "" = "$(git config --global merge.lfs.driver)" ]
 
   cat $HOME/.gitconfig
   [ "$(grep 'filter "lfs"' $HOME/.gitconfig -c)" = "0" ]
+  [ "$(grep 'merge "lfs"' $HOME/.gitconfig -c)" = "0" ]
 )
 end_test
 
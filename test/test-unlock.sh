@@ -50,3 +50,95 @@ begin_test "unlocking a lock without sufficient info"
   assert_server_lock $id
 )
 end_test
+
+begin_test "unlocking multiple files"
+(
+  set -e
+
+  reponame="unlock_multiple"
+  setup_remote_repo "remote_$reponame"
+  clone_repo "remote_$reponame" "clone_$reponame"
+
+  git lfs track "*.dat"
+  # locks aren't scoped to a repo by the test server, so use names that other
+  # tests don't lock
+  for f in a b c d; do
+    echo "$f" > "unlock_multi_$f.dat"
+  done
+  git add .gitattributes *.dat
+  git commit -m "add files"
+  git push origin master
+
+  git lfs lock unlock_multi_a.dat unlock_multi_b.dat unlock_multi_d.dat | tee lock.log
+  a_id=$(grep "'unlock_multi_a.dat' was locked" lock.log | grep -oh "\((.*)\)" | tr -d "()")
+  b_id=$(grep "'unlock_multi_b.dat' was locked" lock.log | grep -oh "\((.*)\)" | tr -d "()")
+  d_id=$(grep "'unlock_multi_d.dat' was locked" lock.log | grep -oh "\((.*)\)" | tr -d "()")
+
+  set +e
+  git lfs unlock unlock_multi_a.dat unlock_multi_b.dat unlock_multi_c.dat unlock_multi_d.dat > unlock.log 2> unlock-err.log
+  res=$?
+  set -e
+  cat unlock.log unlock-err.log
+
+  [ "$res" = "2" ]
+  grep "'unlock_multi_a.dat' was unlocked ($a_id)" unlock.log
+  grep "'unlock_multi_b.dat' was unlocked ($b_id)" unlock.log
+  grep "'unlock_multi_d.dat' was unlocked ($d_id)" unlock.log
+  grep "'unlock_multi_c.dat' could not be unlocked: lfs: no matching locks found" unlock-err.log
+  grep "Server unable to unlock 1 of 4 lock(s)." unlock-err.log
+
+  refute_server_lock "$a_id"
+  refute_server_lock "$b_id"
+  refute_server_lock "$d_id"
+)
+end_test
+
+begin_test "unlocking all of my locks"
+(
+  set -e
+
+  reponame="unlock_all_mine"
+  setup_remote_repo "remote_$reponame"
+  clone_repo "remote_$reponame" "clone_$reponame"
+
+  git lfs track "*.dat"
+  # locks aren't scoped to a repo by the test server, so keep them in a
+  # directory that other tests don't lock in
+  mkdir -p unlock_all_mine/sub
+  for f in a b c d; do
+    echo "$f" > "unlock_all_mine/$f.dat"
+  done
+  echo "e" > unlock_all_mine/sub/e.dat
+  echo "outside" > unlock_all_mine_outside.dat
+  git add .gitattributes unlock_all_mine unlock_all_mine_outside.dat
+  git commit -m "add files"
+  git push origin master
+
+  git config lfs.concurrentlocks 2
+  git lfs lock unlock_all_mine/a.dat unlock_all_mine/b.dat unlock_all_mine/c.dat unlock_all_mine/sub/e.dat unlock_all_mine_outside.dat | tee lock.log
+  outside_id=$(grep "'unlock_all_mine_outside.dat' was locked" lock.log | grep -oh "\((.*)\)" | tr -d "()")
+
+  git -c user.email="someone-else@example.com" lfs lock unlock_all_mine/d.dat | tee lock.log
+  other_id=$(grep -oh "\((.*)\)" lock.log | tr -d "()")
+
+  # scoped to a directory, given relative to the working directory
+  cd unlock_all_mine/sub
+  git lfs unlock --all-mine .. 2>&1 | tee ../../unlock.log
+  cd ../..
+  [ "4" -eq "$(grep -c "was unlocked" unlock.log)" ]
+  for f in a b c sub/e; do
+    grep "'unlock_all_mine/$f.dat' was unlocked" unlock.log
+  done
+
+  assert_server_lock "$outside_id"
+  assert_server_lock "$other_id"
+
+  git lfs unlock --all-mine unlock_all_mine 2>&1 | tee unlock.log
+  grep "No locks to unlock." unlock.log
+
+  git lfs unlock --all-mine unlock_all_mine_outside.dat 2>&1 | tee unlock.log
+  grep "'unlock_all_mine_outside.dat' was unlocked ($outside_id)" unlock.log
+  refute_server_lock "$outside_id"
+  assert_server_lock "$other_id"
+)
+end_test
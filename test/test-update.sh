@@ -164,3 +164,128 @@ begin_test "update: outside git repository"
   grep "Not in a git repository" check.log
 )
 end_test
+
+begin_test "update --repair fixes filter config"
+(
+  set -e
+
+  reponame="update-repair-filters"
+  mkdir "$reponame"
+  cd "$reponame"
+  git init
+
+  git lfs update --repair
+  [ "Git LFS is set up correctly: nothing to repair." = "$(git lfs update --repair)" ]
+
+  git config --global --unset filter.lfs.clean
+  git config --global filter.lfs.smudge "git lfs smudge %f"
+  git config --global --add filter.lfs.required true
+  git config --global filter.other.clean "other-clean %f"
+  git config --local filter.lfs.clean "cat"
+
+  git lfs update --repair | tee repair.log
+  grep "Set filter.lfs.clean to \"git-lfs clean -- %f\" in the global config." repair.log
+  grep "Changed filter.lfs.smudge from \"git lfs smudge %f\" to \"git-lfs smudge -- %f\" in the global config." repair.log
+  grep "Replaced 2 values of filter.lfs.required with \"true\" in the global config." repair.log
+  grep "Changed filter.lfs.clean from \"cat\" to \"git-lfs clean -- %f\" in the local config." repair.log
+  [ "4" = "$(wc -l < repair.log | tr -d ' ')" ]
+
+  [ "git-lfs clean -- %f" = "$(git config --global filter.lfs.clean)" ]
+  [ "git-lfs smudge -- %f" = "$(git config --global filter.lfs.smudge)" ]
+  [ "true" = "$(git config --global --get-all filter.lfs.required)" ]
+  [ "git-lfs clean -- %f" = "$(git config --local filter.lfs.clean)" ]
+  [ -z "$(git config --local filter.lfs.smudge)" ]
+  [ "other-clean %f" = "$(git config --global filter.other.clean)" ]
+
+  [ "Git LFS is set up correctly: nothing to repair." = "$(git lfs update --repair)" ]
+)
+end_test
+
+begin_test "update --repair keeps acceptable filter config"
+(
+  set -e
+
+  reponame="update-repair-acceptable"
+  mkdir "$reponame"
+  cd "$reponame"
+  git init
+
+  git lfs install --skip-smudge
+  git config --global filter.lfs.clean "/usr/local/bin/git-lfs clean -- %f"
+  git lfs update
+
+  [ "Git LFS is set up correctly: nothing to repair." = "$(git lfs update --repair)" ]
+  [ "git-lfs smudge --skip -- %f" = "$(git config --global filter.lfs.smudge)" ]
+  [ "/usr/local/bin/git-lfs clean -- %f" = "$(git config --global filter.lfs.clean)" ]
+)
+end_test
+
+begin_test "update --repair fixes hooks"
+(
+  set -e
+
+  reponame="update-repair-hooks"
+  mkdir "$reponame"
+  cd "$reponame"
+  git init
+
+  git lfs update --pre-commit
+  pre_push_hook="$(cat .git/hooks/pre-push)"
+  pre_commit_hook="$(cat .git/hooks/pre-commit)"
+
+  rm .git/hooks/pre-push
+  chmod -x .git/hooks/pre-commit
+  git lfs update --repair | tee repair.log
+  grep "Installed the pre-push hook." repair.log
+  grep "Made the pre-commit hook executable." repair.log
+  [ "$pre_push_hook" = "$(cat .git/hooks/pre-push)" ]
+  [ -x .git/hooks/pre-commit ]
+
+  echo "#!/bin/sh
+git lfs push --stdin \$*" > .git/hooks/pre-push
+  git lfs update --repair | tee repair.log
+  grep "Upgraded the pre-push hook." repair.log
+  [ "$pre_push_hook" = "$(cat .git/hooks/pre-push)" ]
+  [ -x .git/hooks/pre-push ]
+
+  # custom hooks which run Git LFS are kept
+  printf "#!/bin/sh\necho custom\ngit lfs pre-push \"\$@\"\n" > .git/hooks/pre-push
+  chmod +x .git/hooks/pre-push
+  [ "Git LFS is set up correctly: nothing to repair." = "$(git lfs update --repair)" ]
+  grep "echo custom" .git/hooks/pre-push
+
+  [ "$pre_commit_hook" = "$(cat .git/hooks/pre-commit)" ]
+)
+end_test
+
+begin_test "update --repair with a custom hook"
+(
+  set -e
+
+  reponame="update-repair-custom-hook"
+  mkdir "$reponame"
+  cd "$reponame"
+  git init
+  git lfs update
+
+  echo "test" > .git/hooks/pre-push
+  set +e
+  git lfs update --repair 2> repair.log
+  res=$?
+  set -e
+
+  [ "$res" = "2" ]
+  grep "The pre-push hook doesn't run \`git lfs pre-push\`." repair.log
+  grep "git lfs update --repair --force" repair.log
+  [ "test" = "$(cat .git/hooks/pre-push)" ]
+
+  git lfs update --repair --force | tee repair.log
+  grep "Overwrote the pre-push hook." repair.log
+  grep "git lfs pre-push" .git/hooks/pre-push
+
+  [ "Git LFS is set up correctly: nothing to repair." = "$(git lfs update --repair)" ]
+
+  git lfs update --repair --manual 2>&1 | tee repair.log
+  grep "You cannot use --repair and --manual options together" repair.log
+)
+end_test
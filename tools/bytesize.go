@@ -0,0 +1,35 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var byteSizeUnits = map[string]int64{
+	"":  1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+	"t": 1 << 40,
+}
+
+// ParseByteSize parses a number of bytes with an optional unit, such as "512",
+// "10m" or "10GB". Like Git, units are powers of 1024, and are case
+// insensitive, with an optional trailing "b" or "ib".
+func ParseByteSize(s string) (int64, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+	num := strings.TrimRight(v, "kmgtib")
+	unit := strings.TrimSuffix(strings.TrimSuffix(v[len(num):], "b"), "i")
+
+	scale, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("Invalid size %q", s)
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(num), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("Invalid size %q", s)
+	}
+	return n * scale, nil
+}
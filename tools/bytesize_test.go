@@ -0,0 +1,32 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseByteSize(t *testing.T) {
+	for value, expected := range map[string]int64{
+		"0":      0,
+		"512":    512,
+		"512b":   512,
+		"10k":    10 << 10,
+		"10KB":   10 << 10,
+		"10m":    10 << 20,
+		"10 MiB": 10 << 20,
+		"10GB":   10 << 30,
+		" 2g ":   2 << 30,
+		"1T":     1 << 40,
+	} {
+		n, err := ParseByteSize(value)
+		if assert.Nil(t, err, value) {
+			assert.Equal(t, expected, n, value)
+		}
+	}
+
+	for _, value := range []string{"", "GB", "ten", "10x", "10bk", "-1", "1.5g", "10kib5"} {
+		_, err := ParseByteSize(value)
+		assert.NotNil(t, err, value)
+	}
+}
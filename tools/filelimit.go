@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"os"
+	"sync"
+)
+
+// FileLimiter bounds the number of files that are open at the same time.
+// Opening a file blocks until another file opened through the same limiter
+// has been closed.
+//
+// Callers must not hold a file open while waiting on a second one from the
+// same limiter, or they may deadlock once the limit has been reached.
+type FileLimiter struct {
+	sem chan struct{}
+}
+
+// NewFileLimiter returns a FileLimiter that allows max files to be open at
+// once. A max of 0 or less means no limit.
+func NewFileLimiter(max int) *FileLimiter {
+	l := &FileLimiter{}
+	if max > 0 {
+		l.sem = make(chan struct{}, max)
+	}
+	return l
+}
+
+// Open opens the named file for reading, like os.Open.
+func (l *FileLimiter) Open(name string) (*LimitedFile, error) {
+	return l.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// Create creates the named file, like os.Create.
+func (l *FileLimiter) Create(name string) (*LimitedFile, error) {
+	return l.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// OpenFile opens the named file, like os.OpenFile. The file counts towards
+// the limit until it is closed.
+func (l *FileLimiter) OpenFile(name string, flag int, perm os.FileMode) (*LimitedFile, error) {
+	l.acquire()
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		l.release()
+		return nil, err
+	}
+
+	return &LimitedFile{File: f, limiter: l}, nil
+}
+
+func (l *FileLimiter) acquire() {
+	if l.sem != nil {
+		l.sem <- struct{}{}
+	}
+}
+
+func (l *FileLimiter) release() {
+	if l.sem != nil {
+		<-l.sem
+	}
+}
+
+// LimitedFile is an *os.File opened through a FileLimiter. Close may be called
+// more than once, and gives the file's slot back to the limiter the first time.
+type LimitedFile struct {
+	*os.File
+	limiter *FileLimiter
+	closed  sync.Once
+}
+
+func (f *LimitedFile) Close() error {
+	err := f.File.Close()
+	f.closed.Do(f.limiter.release)
+	return err
+}
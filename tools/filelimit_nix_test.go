@@ -0,0 +1,26 @@
+// +build !windows
+
+package tools_test
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestFileLimiterUnderLowFdBudget(t *testing.T) {
+	var orig syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &orig); err != nil {
+		t.Skipf("cannot read fd limit: %v", err)
+	}
+
+	// Leave a little room for the test binary's own files, but far fewer
+	// than the 100 concurrent opens below.
+	low := orig
+	low.Cur = 32
+	if err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, &low); err != nil {
+		t.Skipf("cannot lower fd limit: %v", err)
+	}
+	defer syscall.Setrlimit(syscall.RLIMIT_NOFILE, &orig)
+
+	checkFileLimiterUnderLowFdBudget(t, 8)
+}
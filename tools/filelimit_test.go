@@ -0,0 +1,107 @@
+package tools_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/github/git-lfs/tools"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileLimiterBlocksUntilClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-filelimit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := tools.NewFileLimiter(1)
+	f1, err := l.Create(filepath.Join(dir, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opened := make(chan *tools.LimitedFile)
+	go func() {
+		f2, err := l.Open(filepath.Join(dir, "a"))
+		assert.Nil(t, err)
+		opened <- f2
+	}()
+
+	select {
+	case <-opened:
+		t.Fatal("expected second open to block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// closing twice only gives back one slot
+	assert.Nil(t, f1.Close())
+	f1.Close()
+
+	f2 := <-opened
+	assert.Nil(t, f2.Close())
+}
+
+func TestFileLimiterFailedOpenReleases(t *testing.T) {
+	l := tools.NewFileLimiter(1)
+	for i := 0; i < 3; i++ {
+		_, err := l.Open(filepath.Join(os.TempDir(), "lfs-filelimit-missing"))
+		assert.NotNil(t, err)
+	}
+}
+
+func TestFileLimiterUnlimited(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-filelimit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := tools.NewFileLimiter(0)
+	var files []*tools.LimitedFile
+	for i := 0; i < 10; i++ {
+		f, err := l.Create(filepath.Join(dir, "a"))
+		if assert.Nil(t, err) {
+			files = append(files, f)
+		}
+	}
+
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+func checkFileLimiterUnderLowFdBudget(t *testing.T, budget int) {
+	dir, err := ioutil.TempDir("", "lfs-filelimit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := tools.NewFileLimiter(budget)
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f, err := l.Create(filepath.Join(dir, string('a'+rune(i%26))))
+			if err != nil {
+				errs <- err
+				return
+			}
+			time.Sleep(time.Millisecond)
+			f.Close()
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
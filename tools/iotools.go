@@ -3,6 +3,7 @@ package tools
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"hash"
 	"io"
 
@@ -33,22 +34,42 @@ func NewReadSeekCloserWrapper(r io.ReadSeeker) io.ReadCloser {
 
 // CopyWithCallback copies reader to writer while performing a progress callback
 func CopyWithCallback(writer io.Writer, reader io.Reader, totalSize int64, cb progress.CopyCallback) (int64, error) {
+	return CopyWithCallbackBuffer(writer, reader, totalSize, cb, 0)
+}
+
+// CopyWithCallbackBuffer is like CopyWithCallback, but copies through a buffer
+// of bufSize bytes. A bufSize of 0 or less uses io.Copy's default size.
+func CopyWithCallbackBuffer(writer io.Writer, reader io.Reader, totalSize int64, cb progress.CopyCallback, bufSize int) (int64, error) {
+	return CopyWithCallbackThrottle(writer, reader, totalSize, cb, bufSize, nil)
+}
+
+// CopyWithCallbackThrottle is like CopyWithCallbackBuffer, but the rate at
+// which reader is read is limited by throttle, which may be shared with other
+// copies to limit them together. A nil throttle doesn't limit the copy.
+func CopyWithCallbackThrottle(writer io.Writer, reader io.Reader, totalSize int64, cb progress.CopyCallback, bufSize int, throttle *progress.Throttle) (int64, error) {
 	if success, _ := CloneFile(writer, reader); success {
 		if cb != nil {
 			cb(totalSize, totalSize, 0)
 		}
 		return totalSize, nil
 	}
+
+	var buf []byte
+	if bufSize > 0 {
+		buf = make([]byte, bufSize)
+	}
+
 	if cb == nil {
-		return io.Copy(writer, reader)
+		return io.CopyBuffer(writer, throttle.Reader(reader), buf)
 	}
 
 	cbReader := &progress.CallbackReader{
 		C:         cb,
 		TotalSize: totalSize,
 		Reader:    reader,
+		Throttle:  throttle,
 	}
-	return io.Copy(writer, cbReader)
+	return io.CopyBuffer(writer, cbReader, buf)
 }
 
 // Get a new Hash instance of the type used to hash LFS content
@@ -85,3 +106,82 @@ func (r *HashingReader) Read(b []byte) (int, error) {
 
 	return w, err
 }
+
+// ValidateFunc is called with content as a VerifyingReader reads it. Returning
+// an error stops the read, and is returned to the reader's caller.
+type ValidateFunc func(p []byte) error
+
+// OidMismatchError is returned by a VerifyingReader when the content it read
+// doesn't hash to the oid it expected.
+type OidMismatchError struct {
+	Expected string
+	Actual   string
+	Size     int64
+}
+
+func (e *OidMismatchError) Error() string {
+	return fmt.Sprintf("Expected OID %s, got %s after %d bytes read", e.Expected, e.Actual, e.Size)
+}
+
+// VerifyingReader wraps a reader, calculating the oid of the data as it is
+// read and passing it to an optional ValidateFunc. If an oid is expected, the
+// end of the data is only reported once it's been checked, so a mismatch is
+// returned from Read instead of io.EOF.
+type VerifyingReader struct {
+	reader   io.Reader
+	hasher   hash.Hash
+	oid      string
+	validate ValidateFunc
+	size     int64
+	err      error
+}
+
+// NewVerifyingReader returns a VerifyingReader which checks that the content of
+// r has the given oid, and passes it to validate. Either may be empty to skip
+// that check.
+func NewVerifyingReader(r io.Reader, oid string, validate ValidateFunc) *VerifyingReader {
+	return &VerifyingReader{
+		reader:   r,
+		hasher:   NewLfsContentHash(),
+		oid:      oid,
+		validate: validate,
+	}
+}
+
+// Hash returns the oid of the data read so far.
+func (r *VerifyingReader) Hash() string {
+	return hex.EncodeToString(r.hasher.Sum(nil))
+}
+
+// Size returns the number of bytes read so far.
+func (r *VerifyingReader) Size() int64 {
+	return r.size
+}
+
+func (r *VerifyingReader) Read(b []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	n, err := r.reader.Read(b)
+	if n > 0 {
+		r.size += int64(n)
+		r.hasher.Write(b[:n])
+		if r.validate != nil {
+			if verr := r.validate(b[:n]); verr != nil {
+				r.err = verr
+				return n, verr
+			}
+		}
+	}
+
+	if err == io.EOF && len(r.oid) > 0 {
+		if actual := r.Hash(); actual != r.oid {
+			err = &OidMismatchError{Expected: r.oid, Actual: actual, Size: r.size}
+		}
+	}
+	if err != nil {
+		r.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,78 @@
+package tools_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/github/git-lfs/tools"
+	"github.com/stretchr/testify/assert"
+)
+
+const helloOid = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+func TestVerifyingReaderReadsMatchingContent(t *testing.T) {
+	r := tools.NewVerifyingReader(bytes.NewBufferString("hello"), helloOid, nil)
+
+	by, err := ioutil.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(by))
+	assert.Equal(t, helloOid, r.Hash())
+	assert.EqualValues(t, 5, r.Size())
+}
+
+func TestVerifyingReaderDetectsMismatch(t *testing.T) {
+	r := tools.NewVerifyingReader(bytes.NewBufferString("hellp"), helloOid, nil)
+
+	_, err := ioutil.ReadAll(r)
+	if assert.IsType(t, &tools.OidMismatchError{}, err) {
+		mismatch := err.(*tools.OidMismatchError)
+		assert.Equal(t, helloOid, mismatch.Expected)
+		assert.Equal(t, r.Hash(), mismatch.Actual)
+		assert.EqualValues(t, 5, mismatch.Size)
+	}
+
+	// the error sticks, rather than turning into io.EOF
+	n, err := r.Read(make([]byte, 1))
+	assert.Equal(t, 0, n)
+	assert.IsType(t, &tools.OidMismatchError{}, err)
+}
+
+func TestVerifyingReaderWithoutOidOnlyHashes(t *testing.T) {
+	r := tools.NewVerifyingReader(bytes.NewBufferString("hello"), "", nil)
+
+	_, err := ioutil.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, helloOid, r.Hash())
+}
+
+func TestVerifyingReaderRunsValidator(t *testing.T) {
+	var seen bytes.Buffer
+	validate := func(p []byte) error {
+		seen.Write(p)
+		return nil
+	}
+	r := tools.NewVerifyingReader(bytes.NewBufferString("hello"), helloOid, validate)
+
+	_, err := ioutil.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", seen.String())
+}
+
+func TestVerifyingReaderStopsOnValidatorError(t *testing.T) {
+	rejected := errors.New("rejected")
+	validate := func(p []byte) error {
+		if bytes.Contains(p, []byte("ell")) {
+			return rejected
+		}
+		return nil
+	}
+	r := tools.NewVerifyingReader(bytes.NewBufferString("hello"), helloOid, validate)
+
+	_, err := ioutil.ReadAll(r)
+	assert.Equal(t, rejected, err)
+
+	_, err = r.Read(make([]byte, 1))
+	assert.Equal(t, rejected, err)
+}
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"bytes"
+	"io"
 	"io/ioutil"
 	"testing"
 
@@ -27,3 +28,43 @@ func TestCopyWithCallback(t *testing.T) {
 	assert.Len(t, calledWritten, 1)
 	assert.Equal(t, 5, int(calledWritten[0]))
 }
+
+func TestCopyWithCallbackBuffer(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 1000)
+
+	var reads []int
+	var out bytes.Buffer
+	n, err := CopyWithCallbackBuffer(writeOnly{&out}, bytes.NewReader(data), 1000, func(total int64, written int64, current int) error {
+		reads = append(reads, current)
+		return nil
+	}, 300)
+	assert.Nil(t, err)
+	assert.Equal(t, 1000, int(n))
+	assert.Equal(t, data, out.Bytes())
+	assert.Equal(t, []int{300, 300, 300, 100}, reads)
+}
+
+func BenchmarkCopyWithCallbackBuffer4KB(b *testing.B)  { benchmarkCopyWithCallbackBuffer(b, 4<<10) }
+func BenchmarkCopyWithCallbackBuffer32KB(b *testing.B) { benchmarkCopyWithCallbackBuffer(b, 32<<10) }
+func BenchmarkCopyWithCallbackBuffer1MB(b *testing.B)  { benchmarkCopyWithCallbackBuffer(b, 1<<20) }
+func BenchmarkCopyWithCallbackBuffer4MB(b *testing.B)  { benchmarkCopyWithCallbackBuffer(b, 4<<20) }
+
+func benchmarkCopyWithCallbackBuffer(b *testing.B, bufSize int) {
+	data := make([]byte, 64<<20)
+	cb := func(total int64, written int64, current int) error { return nil }
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := CopyWithCallbackBuffer(writeOnly{ioutil.Discard}, bytes.NewReader(data), int64(len(data)), cb, bufSize)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// writeOnly hides any ReadFrom method of the wrapped writer, which would
+// otherwise bypass the copy buffer.
+type writeOnly struct {
+	io.Writer
+}
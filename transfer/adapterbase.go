@@ -5,7 +5,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/github/git-lfs/config"
 	"github.com/github/git-lfs/errutil"
+	"github.com/github/git-lfs/progress"
 	"github.com/rubyist/tracerx"
 )
 
@@ -15,6 +17,30 @@ const (
 	objectExpirationGracePeriod = 5 * time.Second
 )
 
+var (
+	throttle     *progress.Throttle
+	throttleOnce sync.Once
+)
+
+// sharedThrottle returns the throttle which limits every transfer in the
+// process together to lfs.transfer.maxbandwidth, or nil if there's no limit.
+// Commands check the setting is valid before transferring anything, so an
+// invalid value is only traced here.
+func sharedThrottle() *progress.Throttle {
+	throttleOnce.Do(func() {
+		n, err := config.Config.TransferMaxBandwidth()
+		if err != nil {
+			tracerx.Printf("xfer: not limiting bandwidth: %s", err)
+			return
+		}
+		if n > 0 {
+			tracerx.Printf("xfer: limiting transfers to %d bytes per second", n)
+		}
+		throttle = progress.NewThrottle(n)
+	})
+	return throttle
+}
+
 // adapterBase implements the common functionality for core adapters which
 // process transfers with N workers handling an oid each, and which wait for
 // authentication to succeed on one worker before proceeding
@@ -29,13 +55,30 @@ type adapterBase struct {
 	workerWait sync.WaitGroup
 	// WaitGroup to serialise the first transfer response to perform login if needed
 	authWait sync.WaitGroup
+	// flights coalesces jobs for an oid which is already being transferred
+	flights flightGroup
+	// rampStart workers begin at once, and the rest over rampWindow; see
+	// rampUp
+	rampStart  int
+	rampWindow time.Duration
+	ramp       *rampUp
+	// timeout is how long each transfer may take before it's abandoned and
+	// retried; see lfs.transfer.timeout
+	timeout time.Duration
+	// hosts caps the transfers in flight to each host; see
+	// lfs.transfer.<host>.maxconnections
+	hosts *hostLimiter
+	// throttle limits the combined throughput of the transfers; see
+	// lfs.transfer.maxbandwidth
+	throttle *progress.Throttle
 }
 
 // transferImplementation must be implemented to provide the actual upload/download
 // implementation for all core transfer approaches that use adapterBase for
 // convenience. This function will be called on multiple goroutines so it
 // must be either stateless or thread safe. However it will never be called
-// for the same oid in parallel.
+// for the same oid in parallel: a job for an oid that is already being
+// transferred waits for that transfer and shares its result.
 // If authOkFunc is not nil, implementations must call it as early as possible
 // when authentication succeeded, before the whole file content is transferred
 type transferImplementation interface {
@@ -43,7 +86,16 @@ type transferImplementation interface {
 }
 
 func newAdapterBase(name string, dir Direction, ti transferImplementation) *adapterBase {
-	return &adapterBase{name: name, direction: dir, transferImpl: ti}
+	return &adapterBase{
+		name:         name,
+		direction:    dir,
+		transferImpl: ti,
+		rampStart:    config.Config.TransferRampUpStart(),
+		rampWindow:   config.Config.TransferRampUpWindow(),
+		timeout:      config.Config.TransferTimeout(),
+		hosts:        newHostLimiter(config.Config.TransferHostMaxConnections),
+		throttle:     sharedThrottle(),
+	}
 }
 
 func (a *adapterBase) Name() string {
@@ -58,6 +110,7 @@ func (a *adapterBase) Begin(maxConcurrency int, cb TransferProgressCallback, com
 	a.cb = cb
 	a.outChan = completion
 	a.jobChan = make(chan *Transfer, 100)
+	a.ramp = newRampUp(maxConcurrency, a.rampStart, a.rampWindow)
 
 	tracerx.Printf("xfer: adapter %q Begin() with %d workers", a.Name(), maxConcurrency)
 
@@ -102,6 +155,7 @@ func (a *adapterBase) worker(workerNum int) {
 		a.authWait.Wait()
 		tracerx.Printf("xfer: adapter %q worker %d auth signal received", a.Name(), workerNum)
 	}
+	a.ramp.Wait(workerNum)
 
 	for t := range a.jobChan {
 		var authCallback func()
@@ -119,7 +173,37 @@ func (a *adapterBase) worker(workerNum int) {
 			tracerx.Printf("xfer: adapter %q worker %d found job for %q expired, retrying...", a.Name(), workerNum, t.Object.Oid)
 			err = errutil.NewRetriableError(fmt.Errorf("lfs/transfer: object %q has expired", t.Object.Oid))
 		} else {
-			err = a.transferImpl.DoTransfer(t, a.cb, authCallback)
+			var shared bool
+			timeout := a.transferTimeout(t)
+			deadline := time.Now().Add(timeout)
+			shared, err = a.flights.Do(t.Object.Oid, func() error {
+				// the timeout starts once the host has a free
+				// connection
+				defer a.hosts.Acquire(transferHost(t, a.action()))()
+
+				cb := a.cb
+				deadline = time.Now().Add(timeout)
+				if timeout > 0 {
+					cb = deadlineCallback(cb, t, deadline, timeout)
+				}
+				return a.transferImpl.DoTransfer(t, cb, authCallback)
+			})
+			if err != nil && timeout > 0 && time.Now().After(deadline) {
+				// adapters may wrap the callback's error, so mark it
+				// retriable here
+				err = errutil.NewRetriableError(err)
+			}
+
+			if shared {
+				tracerx.Printf("xfer: adapter %q worker %d shared another transfer of %q", a.Name(), workerNum, t.Object.Oid)
+				if err == nil {
+					advanceCallbackProgress(a.cb, t, t.Object.Size)
+				}
+			}
+		}
+
+		if err == nil {
+			a.ramp.Release()
 		}
 
 		if a.outChan != nil {
@@ -133,10 +217,46 @@ func (a *adapterBase) worker(workerNum int) {
 	if signalAuthOnResponse {
 		a.authWait.Done()
 	}
+	a.ramp.Release()
 	tracerx.Printf("xfer: adapter %q worker %d stopping", a.Name(), workerNum)
 	a.workerWait.Done()
 }
 
+// transferTimeout returns how long the transfer of t may take, which is the
+// timeout hint of its action in the batch response if there is one, or
+// lfs.transfer.timeout otherwise.
+func (a *adapterBase) transferTimeout(t *Transfer) time.Duration {
+	if hint := t.Object.ActionTimeout(a.action()); hint > 0 {
+		return hint
+	}
+	return a.timeout
+}
+
+// action returns the name of the action in the batch response which the
+// adapter's transfers perform.
+func (a *adapterBase) action() string {
+	if a.direction == Upload {
+		return "upload"
+	}
+	return "download"
+}
+
+// deadlineCallback wraps cb so that it returns an error once the deadline has
+// passed, which aborts the copy that's reporting progress. A transfer that
+// stops making progress altogether is cancelled by lfs.activitytimeout
+// instead, as the callback isn't called.
+func deadlineCallback(cb TransferProgressCallback, t *Transfer, deadline time.Time, timeout time.Duration) TransferProgressCallback {
+	return func(name string, totalSize, readSoFar int64, readSinceLast int) error {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Transfer of %s took longer than %s, see lfs.transfer.timeout", t.Object.Oid, timeout)
+		}
+		if cb != nil {
+			return cb(name, totalSize, readSoFar, readSinceLast)
+		}
+		return nil
+	}
+}
+
 func advanceCallbackProgress(cb TransferProgressCallback, t *Transfer, numBytes int64) {
 	if cb != nil {
 		// Must split into max int sizes since read count is int
@@ -0,0 +1,72 @@
+package transfer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/github/git-lfs/api"
+	"github.com/github/git-lfs/errutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowTransfer reports progress every 20ms until it has taken duration.
+type slowTransfer struct {
+	duration time.Duration
+}
+
+func (s *slowTransfer) DoTransfer(t *Transfer, cb TransferProgressCallback, authOkFunc func()) error {
+	if authOkFunc != nil {
+		authOkFunc()
+	}
+	for start := time.Now(); time.Since(start) < s.duration; {
+		time.Sleep(20 * time.Millisecond)
+		if cb != nil {
+			if err := cb(t.Name, t.Object.Size, 0, 0); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func newTimeoutTransfer(name string, hint int) *Transfer {
+	return NewTransfer(name, &api.ObjectResource{
+		Oid:  name,
+		Size: 1,
+		Actions: map[string]*api.LinkRelation{
+			"download": &api.LinkRelation{Href: "https://example.com/" + name, Timeout: hint},
+		},
+	}, name)
+}
+
+func TestAdapterTransferTimeout(t *testing.T) {
+	a := newAdapterBase("test", Download, &slowTransfer{duration: 200 * time.Millisecond})
+	a.timeout = 50 * time.Millisecond
+
+	results := make(chan TransferResult, 2)
+	assert.Nil(t, a.Begin(2, nil, results))
+	a.Add(newTimeoutTransfer("plain", 0))
+	a.Add(newTimeoutTransfer("cold", 5))
+	a.End()
+
+	errs := make(map[string]error)
+	for res := range results {
+		errs[res.Transfer.Name] = res.Error
+	}
+
+	if assert.NotNil(t, errs["plain"]) {
+		assert.Contains(t, errs["plain"].Error(), "lfs.transfer.timeout")
+		assert.True(t, errutil.IsRetriableError(errs["plain"]))
+	}
+	assert.Nil(t, errs["cold"], "the timeout hint overrides lfs.transfer.timeout")
+}
+
+func TestAdapterTransferTimeoutFromHint(t *testing.T) {
+	download := newAdapterBase("test", Download, nil)
+	download.timeout = time.Minute
+	upload := newAdapterBase("test", Upload, nil)
+
+	assert.Equal(t, time.Minute, download.transferTimeout(newTimeoutTransfer("plain", 0)))
+	assert.Equal(t, 10*time.Minute, download.transferTimeout(newTimeoutTransfer("cold", 600)))
+	assert.Equal(t, time.Duration(0), upload.transferTimeout(newTimeoutTransfer("cold", 600)), "only the hint of the adapter's action")
+}
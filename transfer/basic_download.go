@@ -9,7 +9,9 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"syscall"
 
+	"github.com/github/git-lfs/config"
 	"github.com/github/git-lfs/errutil"
 	"github.com/github/git-lfs/httputil"
 	"github.com/github/git-lfs/localstorage"
@@ -17,6 +19,12 @@ import (
 	"github.com/rubyist/tracerx"
 )
 
+// downloadWriter returns the writer that downloaded content is written to.
+// Tests replace it to simulate disk errors.
+var downloadWriter = func(f *os.File) io.Writer {
+	return f
+}
+
 // Adapter for basic HTTP downloads, includes resuming via HTTP Range
 type basicDownloadAdapter struct {
 	*adapterBase
@@ -30,7 +38,21 @@ func (a *basicDownloadAdapter) tempDir() string {
 	// Must be dedicated to this adapter as deleted by ClearTempStorage
 	// Also make local to this repo not global, and separate to localstorage temp,
 	// which gets cleared at the end of every invocation
-	d := filepath.Join(localstorage.Objects().RootDir, "incomplete")
+	objs := localstorage.Objects()
+	if !objs.ReadOnly() {
+		d := filepath.Join(objs.RootDir, "incomplete")
+		err := os.MkdirAll(d, 0755)
+		if err == nil {
+			return d
+		}
+		if !objs.FailedWrite(err) {
+			return os.TempDir()
+		}
+	}
+
+	// downloads can't be resumed, but stay on the same device as the temp
+	// files they're moved to
+	d := filepath.Join(objs.TempDir, "incomplete")
 	if err := os.MkdirAll(d, 0755); err != nil {
 		return os.TempDir()
 	}
@@ -38,23 +60,66 @@ func (a *basicDownloadAdapter) tempDir() string {
 }
 
 func (a *basicDownloadAdapter) DoTransfer(t *Transfer, cb TransferProgressCallback, authOkFunc func()) error {
+	// A transient error writing the temp file is retried up to
+	// lfs.transfer.maxretries times, resuming from the data already written,
+	// and then left to the transfer queue to retry.
+	maxRetries := config.Config.TransferMaxRetries()
+	cb = resumedProgressCallback(cb)
+	for attempt := 0; ; attempt++ {
+		f, fromByte, hashSoFar, err := a.checkResumeDownload(t)
+		if err != nil {
+			return err
+		}
 
-	f, fromByte, hashSoFar, err := a.checkResumeDownload(t)
-	if err != nil {
-		return err
+		err = a.download(t, cb, authOkFunc, f, fromByte, hashSoFar)
+		werr, ok := err.(*writeError)
+		if !ok || werr.Persistent() {
+			return err
+		}
+		if attempt >= maxRetries {
+			return errutil.NewRetriableError(err)
+		}
+
+		// Writing only starts once the server has responded, so auth has
+		// already been signalled.
+		authOkFunc = nil
+		tracerx.Printf("xfer: retrying download of %q after write error: %v", t.Object.Oid, werr.err)
+	}
+}
+
+// resumedProgressCallback wraps cb so that bytes already reported by an
+// earlier attempt aren't reported again when a retry resumes, or restarts,
+// the download.
+func resumedProgressCallback(cb TransferProgressCallback) TransferProgressCallback {
+	if cb == nil {
+		return nil
+	}
+
+	var reported int64
+	return func(name string, totalSize, readSoFar int64, readSinceLast int) error {
+		if readSoFar <= reported {
+			return nil
+		}
+		readSinceLast = int(readSoFar - reported)
+		reported = readSoFar
+		return cb(name, totalSize, readSoFar, readSinceLast)
 	}
-	return a.download(t, cb, authOkFunc, f, fromByte, hashSoFar)
 }
 
 // Checks to see if a download can be resumed, and if so returns a non-nil locked file, byte start and hash
-func (a *basicDownloadAdapter) checkResumeDownload(t *Transfer) (outFile *os.File, fromByte int64, hashSoFar hash.Hash, e error) {
+func (a *basicDownloadAdapter) checkResumeDownload(t *Transfer) (outFile *tools.LimitedFile, fromByte int64, hashSoFar hash.Hash, e error) {
 	// lock the file by opening it for read/write, rather than checking Stat() etc
 	// which could be subject to race conditions by other processes
-	f, err := os.OpenFile(a.downloadFilename(t), os.O_RDWR, 0644)
+	files := config.Config.FileLimiter()
+	f, err := files.OpenFile(a.downloadFilename(t), os.O_RDWR, 0644)
 
 	if err != nil {
 		// Create a new file instead, must not already exist or error (permissions / race condition)
-		newfile, err := os.OpenFile(a.downloadFilename(t), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+		newfile, err := files.OpenFile(a.downloadFilename(t), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+		if err != nil && !localstorage.Objects().ReadOnly() && localstorage.Objects().FailedWrite(err) {
+			// the temp dir is out of the store now
+			return a.checkResumeDownload(t)
+		}
 		return newfile, 0, nil, err
 	}
 
@@ -66,6 +131,30 @@ func (a *basicDownloadAdapter) checkResumeDownload(t *Transfer) (outFile *os.Fil
 		f.Close()
 		return nil, 0, nil, err
 	}
+
+	discard := false
+	if n > 0 && t.Object.Size <= 0 {
+		// without the object's size, there's no knowing where to resume
+		tracerx.Printf("xfer: discarding %d bytes already downloaded for %q, whose size isn't known", n, t.Object.Oid)
+		discard = true
+	} else if n > 0 && n >= t.Object.Size {
+		// there's nothing left to resume, so the data is wrong
+		tracerx.Printf("xfer: discarding %d bytes already downloaded for %q, which is %d bytes", n, t.Object.Oid, t.Object.Size)
+		discard = true
+	}
+
+	if discard {
+		if err := f.Truncate(0); err != nil {
+			f.Close()
+			return nil, 0, nil, err
+		}
+		if _, err := f.Seek(0, 0); err != nil {
+			f.Close()
+			return nil, 0, nil, err
+		}
+		return f, 0, nil, nil
+	}
+
 	tracerx.Printf("xfer: Attempting to resume download of %q from byte %d", t.Object.Oid, n)
 	return f, n, hash, nil
 
@@ -78,7 +167,7 @@ func (a *basicDownloadAdapter) downloadFilename(t *Transfer) string {
 }
 
 // download starts or resumes and download. Always closes dlFile if non-nil
-func (a *basicDownloadAdapter) download(t *Transfer, cb TransferProgressCallback, authOkFunc func(), dlFile *os.File, fromByte int64, hash hash.Hash) error {
+func (a *basicDownloadAdapter) download(t *Transfer, cb TransferProgressCallback, authOkFunc func(), dlFile *tools.LimitedFile, fromByte int64, hash hash.Hash) error {
 
 	if dlFile != nil {
 		// ensure we always close dlFile. Note that this does not conflict with the
@@ -165,23 +254,40 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb TransferProgressCallback
 		}
 	}
 
+	if res.StatusCode > 299 {
+		return invalidStatusError(req, res)
+	}
+
 	// Signal auth OK on success response, before starting download to free up
 	// other workers immediately
 	if authOkFunc != nil {
 		authOkFunc()
 	}
 
+	// Integrity headers describe the whole object, so they're only checked
+	// when it's downloaded from the start.
+	var body io.Reader = res.Body
+	var checks []*integrityCheck
+	if fromByte == 0 {
+		if checks, err = newIntegrityChecks(res.Header); err != nil {
+			return err
+		}
+		if w := integrityWriter(checks); w != nil {
+			body = io.TeeReader(res.Body, w)
+		}
+	}
+
 	var hasher *tools.HashingReader
 	if fromByte > 0 && hash != nil {
 		// pre-load hashing reader with previous content
-		hasher = tools.NewHashingReaderPreloadHash(res.Body, hash)
+		hasher = tools.NewHashingReaderPreloadHash(body, hash)
 	} else {
-		hasher = tools.NewHashingReader(res.Body)
+		hasher = tools.NewHashingReader(body)
 	}
 
 	if dlFile == nil {
 		// New file start
-		dlFile, err = os.OpenFile(a.downloadFilename(t), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		dlFile, err = config.Config.FileLimiter().OpenFile(a.downloadFilename(t), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 		if err != nil {
 			return err
 		}
@@ -195,7 +301,11 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb TransferProgressCallback
 		}
 		return nil
 	}
-	written, err := tools.CopyWithCallback(dlFile, hasher, res.ContentLength, ccb)
+	w := &errorRecordingWriter{w: downloadWriter(dlFile.File)}
+	written, err := tools.CopyWithCallbackThrottle(w, hasher, res.ContentLength, ccb, config.Config.TransferBufferSize(), a.throttle)
+	if w.err != nil {
+		return &writeError{t.Object.Oid, dlfilename, w.err}
+	}
 	if err != nil {
 		return fmt.Errorf("cannot write data to tempfile %q: %v", dlfilename, err)
 	}
@@ -203,14 +313,86 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb TransferProgressCallback
 		return fmt.Errorf("can't close tempfile %q: %v", dlfilename, err)
 	}
 
+	for _, c := range checks {
+		if err := c.verify(); err != nil {
+			os.Remove(dlfilename)
+			return errutil.NewRetriableError(fmt.Errorf("Corrupt download of %s: %s", t.Object.Oid, err))
+		}
+	}
+
 	if actual := hasher.Hash(); actual != t.Object.Oid {
-		return fmt.Errorf("Expected OID %s, got %s after %d bytes written", t.Object.Oid, actual, written)
+		err := fmt.Errorf("Expected OID %s, got %s after %d bytes written", t.Object.Oid, actual, written)
+		if fromByte+written < t.Object.Size {
+			// cut short, so the next attempt can resume it
+			return err
+		}
+
+		// Don't resume from bad data next time. If this download was
+		// resumed, the data from before may be what's bad, such as
+		// when an earlier process was killed mid-write, so it's retried.
+		os.Remove(dlfilename)
+		if fromByte > 0 {
+			return errutil.NewRetriableError(err)
+		}
+		return err
+	}
+
+	if err := finishDownload(t, dlfilename); err != nil {
+		return err
 	}
 
-	return tools.RenameFileCopyPermissions(dlfilename, t.Path)
+	t.Header = res.Header
+	return nil
 
 }
 
+// writeError is returned when downloaded content could not be written to its
+// temp file. Transient errors are retried by resuming the download from the
+// data already written.
+type writeError struct {
+	oid      string
+	filename string
+	err      error
+}
+
+func (e *writeError) Error() string {
+	switch e.cause() {
+	case syscall.ENOSPC:
+		return fmt.Sprintf("Not enough disk space to download %s to %q", e.oid, e.filename)
+	case syscall.EROFS:
+		return fmt.Sprintf("Cannot download %s: %q is on a read-only file system", e.oid, e.filename)
+	}
+	return fmt.Sprintf("cannot write data to tempfile %q: %v", e.filename, e.err)
+}
+
+// Persistent returns true if retrying the write will not help.
+func (e *writeError) Persistent() bool {
+	cause := e.cause()
+	return cause == syscall.ENOSPC || cause == syscall.EROFS
+}
+
+func (e *writeError) cause() error {
+	if perr, ok := e.err.(*os.PathError); ok {
+		return perr.Err
+	}
+	return e.err
+}
+
+// errorRecordingWriter keeps hold of the last write error, so that it can be
+// told apart from errors reading the response body.
+type errorRecordingWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *errorRecordingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
+
 func init() {
 	newfunc := func(name string, dir Direction) TransferAdapter {
 		switch dir {
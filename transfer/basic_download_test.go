@@ -0,0 +1,300 @@
+package transfer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/github/git-lfs/api"
+	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/errutil"
+	"github.com/github/git-lfs/localstorage"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingWriter fails once after writing failAfter bytes.
+type failingWriter struct {
+	w         io.Writer
+	failAfter int
+	err       error
+	written   int
+	failed    bool
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.failed || w.written+len(p) <= w.failAfter {
+		n, err := w.w.Write(p)
+		w.written += n
+		return n, err
+	}
+
+	w.failed = true
+	n, _ := w.w.Write(p[:w.failAfter-w.written])
+	w.written += n
+	return n, w.err
+}
+
+func TestBasicDownloadRetriesTransientWriteError(t *testing.T) {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.transfer.maxretries", "1")
+
+	content := bytes.Repeat([]byte("0123456789"), 1024)
+	attempts := 0
+	restore := setDownloadWriter(func(f *os.File) io.Writer {
+		attempts++
+		if attempts > 1 {
+			return f
+		}
+		return &failingWriter{w: f, failAfter: 4000, err: errors.New("transient")}
+	})
+	defer restore()
+
+	tr, cleanup := setupDownloadTest(t, content)
+	defer cleanup()
+
+	var progress int64
+	cb := func(name string, totalSize, readSoFar int64, readSinceLast int) error {
+		progress += int64(readSinceLast)
+		return nil
+	}
+
+	err := NewDownloadAdapter(BasicAdapterName).(*basicDownloadAdapter).DoTransfer(tr, cb, nil)
+	if assert.Nil(t, err) {
+		by, err := ioutil.ReadFile(tr.Path)
+		assert.Nil(t, err)
+		assert.Equal(t, content, by)
+	}
+	assert.Equal(t, 2, attempts)
+	// the bytes written before the error are only counted once
+	assert.True(t, progress <= int64(len(content)), "progress %d is more than %d bytes", progress, len(content))
+}
+
+func TestBasicDownloadLeavesWriteErrorToQueueByDefault(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1024)
+	attempts := 0
+	restore := setDownloadWriter(func(f *os.File) io.Writer {
+		attempts++
+		return &failingWriter{w: f, failAfter: 4000, err: errors.New("transient")}
+	})
+	defer restore()
+
+	tr, cleanup := setupDownloadTest(t, content)
+	defer cleanup()
+
+	err := NewDownloadAdapter(BasicAdapterName).(*basicDownloadAdapter).DoTransfer(tr, nil, nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "transient")
+		assert.True(t, errutil.IsRetriableError(err))
+	}
+	assert.Equal(t, 1, attempts)
+}
+
+func TestBasicDownloadFailsFastOnPersistentWriteError(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1024)
+	attempts := 0
+	restore := setDownloadWriter(func(f *os.File) io.Writer {
+		attempts++
+		return &failingWriter{w: f, failAfter: 4000, err: &os.PathError{Op: "write", Path: f.Name(), Err: syscall.ENOSPC}}
+	})
+	defer restore()
+
+	tr, cleanup := setupDownloadTest(t, content)
+	defer cleanup()
+
+	err := NewDownloadAdapter(BasicAdapterName).(*basicDownloadAdapter).DoTransfer(tr, nil, nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "Not enough disk space")
+		assert.False(t, errutil.IsRetriableError(err))
+	}
+	assert.Equal(t, 1, attempts)
+}
+
+func TestBasicDownloadAbortedByCallbackError(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1024)
+	tr, cleanup := setupDownloadTest(t, content)
+	defer cleanup()
+
+	deadline := time.Now().Add(-time.Second)
+	cb := deadlineCallback(nil, tr, deadline, time.Minute)
+
+	err := NewDownloadAdapter(BasicAdapterName).(*basicDownloadAdapter).DoTransfer(tr, cb, nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "lfs.transfer.timeout")
+	}
+	_, err = os.Stat(tr.Path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func setDownloadWriter(f func(*os.File) io.Writer) func() {
+	orig := downloadWriter
+	downloadWriter = f
+	return func() {
+		downloadWriter = orig
+	}
+}
+
+func TestBasicDownloadResumesPartialFile(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1024)
+	tr, cleanup := setupDownloadTest(t, content)
+	defer cleanup()
+
+	// as if an earlier process was killed midway through the download
+	a := NewDownloadAdapter(BasicAdapterName).(*basicDownloadAdapter)
+	assert.Nil(t, ioutil.WriteFile(a.downloadFilename(tr), content[:4000], 0644))
+
+	var resumedAt int64 = -1
+	cb := func(name string, total, read int64, current int) error {
+		if resumedAt < 0 {
+			resumedAt = read
+		}
+		return nil
+	}
+
+	err := a.DoTransfer(tr, cb, nil)
+	if assert.Nil(t, err) {
+		by, err := ioutil.ReadFile(tr.Path)
+		assert.Nil(t, err)
+		assert.Equal(t, content, by)
+	}
+	assert.Equal(t, int64(4000), resumedAt)
+}
+
+func TestBasicDownloadDiscardsBadPartialFile(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1024)
+	tr, cleanup := setupDownloadTest(t, content)
+	defer cleanup()
+
+	a := NewDownloadAdapter(BasicAdapterName).(*basicDownloadAdapter)
+	assert.Nil(t, ioutil.WriteFile(a.downloadFilename(tr), bytes.Repeat([]byte("x"), 4000), 0644))
+
+	err := a.DoTransfer(tr, nil, nil)
+	if assert.NotNil(t, err) {
+		assert.True(t, errutil.IsRetriableError(err))
+	}
+	_, err = os.Stat(a.downloadFilename(tr))
+	assert.True(t, os.IsNotExist(err), "the bad partial file is removed")
+
+	err = a.DoTransfer(tr, nil, nil)
+	if assert.Nil(t, err) {
+		by, err := ioutil.ReadFile(tr.Path)
+		assert.Nil(t, err)
+		assert.Equal(t, content, by)
+	}
+}
+
+func TestBasicDownloadDiscardsOversizedPartialFile(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1024)
+	tr, cleanup := setupDownloadTest(t, content)
+	defer cleanup()
+
+	a := NewDownloadAdapter(BasicAdapterName).(*basicDownloadAdapter)
+	assert.Nil(t, ioutil.WriteFile(a.downloadFilename(tr), append(content, 'x'), 0644))
+
+	err := a.DoTransfer(tr, nil, nil)
+	if assert.Nil(t, err) {
+		by, err := ioutil.ReadFile(tr.Path)
+		assert.Nil(t, err)
+		assert.Equal(t, content, by)
+	}
+}
+
+func TestBasicDownloadOfUnknownSize(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1024)
+	tr, cleanup := setupDownloadTest(t, content)
+	defer cleanup()
+
+	// sent chunked, without a Content-Length
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content[:4000])
+		w.(http.Flusher).Flush()
+		w.Write(content[4000:])
+	}))
+	defer srv.Close()
+	tr.Object.Actions["download"].Href = srv.URL + "/obj"
+	tr.Object.Size = 0
+
+	a := NewDownloadAdapter(BasicAdapterName).(*basicDownloadAdapter)
+	assert.Nil(t, ioutil.WriteFile(a.downloadFilename(tr), content[:4000], 0644))
+
+	err := a.DoTransfer(tr, nil, nil)
+	if assert.Nil(t, err) {
+		by, err := ioutil.ReadFile(tr.Path)
+		assert.Nil(t, err)
+		assert.Equal(t, content, by)
+	}
+	assert.Equal(t, int64(len(content)), tr.Object.Size)
+
+	// still verified by oid
+	tr.Object.Size = 0
+	tr.Object.Oid = strings.Repeat("0", 64)
+	tr.Path += ".corrupt"
+	err = a.DoTransfer(tr, nil, nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "Expected OID "+tr.Object.Oid)
+	}
+	_, err = os.Stat(tr.Path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+// setupDownloadTest creates a repository for the incomplete download
+// directory, and a server which serves content with Range support.
+func setupDownloadTest(t *testing.T, content []byte) (*Transfer, func()) {
+	return setupDownloadTestWithHeader(t, content, nil)
+}
+
+// setupDownloadTestWithHeader is like setupDownloadTest, and the server also
+// sends header with its responses.
+func setupDownloadTestWithHeader(t *testing.T, content []byte, header http.Header) (*Transfer, func()) {
+	dir, err := ioutil.TempDir("", "lfs-download-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldwd, _ := os.Getwd()
+	if err := exec.Command("git", "init", dir).Run(); err != nil {
+		t.Fatal(err)
+	}
+	os.Chdir(dir)
+	localstorage.ResolveDirs()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		http.ServeContent(w, r, "obj", time.Time{}, bytes.NewReader(content))
+	}))
+
+	sum := sha256.Sum256(content)
+	tr := &Transfer{
+		Name: "obj.dat",
+		Path: filepath.Join(dir, "obj.dat"),
+		Object: &api.ObjectResource{
+			Oid:  hex.EncodeToString(sum[:]),
+			Size: int64(len(content)),
+			Actions: map[string]*api.LinkRelation{
+				"download": &api.LinkRelation{
+					Href:   srv.URL + "/obj",
+					Header: map[string]string{"Authorization": "Basic dGVzdDp0ZXN0"},
+				},
+			},
+		},
+	}
+
+	return tr, func() {
+		srv.Close()
+		os.Chdir(oldwd)
+		os.RemoveAll(dir)
+	}
+}
@@ -1,17 +1,25 @@
 package transfer
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime/multipart"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 
 	"github.com/github/git-lfs/api"
+	"github.com/github/git-lfs/config"
 	"github.com/github/git-lfs/errutil"
 	"github.com/github/git-lfs/httputil"
 	"github.com/github/git-lfs/progress"
+	"github.com/github/git-lfs/tools"
+	"github.com/rubyist/tracerx"
 )
 
 const (
@@ -37,35 +45,88 @@ func (a *basicUploadAdapter) tempDir() string {
 	return d
 }
 
+// setUploadHeaders adds the headers from lfs.transfer.uploadheader.* that the
+// upload action didn't already include.
+func setUploadHeaders(req *http.Request) {
+	for name, value := range config.Config.UploadHeaders() {
+		if len(req.Header.Get(name)) == 0 {
+			req.Header.Set(name, value)
+		}
+	}
+}
+
 func (a *basicUploadAdapter) DoTransfer(t *Transfer, cb TransferProgressCallback, authOkFunc func()) error {
 	rel, ok := t.Object.Rel("upload")
 	if !ok {
 		return fmt.Errorf("No upload action for this object.")
 	}
 
-	req, err := httputil.NewHttpRequest("PUT", rel.Href, rel.Header)
+	var form *uploadForm
+	if rel.Form != nil {
+		uf, err := newUploadForm(rel.Form, t.Object.Oid)
+		if err != nil {
+			return errutil.Error(err)
+		}
+		form = uf
+	}
+
+	method := "PUT"
+	if form != nil {
+		method = "POST"
+	} else if config.Config.TransferMethodOverride() {
+		// For proxies which block PUT; the server must honour the header
+		method = "POST"
+	}
+
+	req, err := httputil.NewHttpRequest(method, rel.Href, rel.Header)
 	if err != nil {
 		return err
 	}
 
-	if len(req.Header.Get("Content-Type")) == 0 {
+	if method != "PUT" && form == nil {
+		req.Header.Set("X-HTTP-Method-Override", "PUT")
+	}
+
+	setUploadHeaders(req)
+
+	size := t.Object.Size
+	if form != nil {
+		req.Header.Set("Content-Type", form.contentType)
+		size += int64(len(form.head) + len(form.tail))
+	} else if len(req.Header.Get("Content-Type")) == 0 {
 		req.Header.Set("Content-Type", "application/octet-stream")
 	}
 
-	if req.Header.Get("Transfer-Encoding") == "chunked" {
+	chunked := req.Header.Get("Transfer-Encoding") == "chunked"
+	if chunked {
 		req.TransferEncoding = []string{"chunked"}
 	} else {
-		req.Header.Set("Content-Length", strconv.FormatInt(t.Object.Size, 10))
+		req.Header.Set("Content-Length", strconv.FormatInt(size, 10))
 	}
 
-	req.ContentLength = t.Object.Size
+	req.ContentLength = size
+
+	// Let the server refuse large uploads, such as for auth or policy,
+	// before their content is sent
+	if threshold := config.Config.TransferExpectContinue(); threshold > 0 && t.Object.Size >= threshold {
+		req.Header.Set("Expect", "100-continue")
+	}
 
-	f, err := os.OpenFile(t.Path, os.O_RDONLY, 0644)
+	f, err := config.Config.FileLimiter().OpenFile(t.Path, os.O_RDONLY, 0644)
 	if err != nil {
 		return errutil.Error(err)
 	}
 	defer f.Close()
 
+	// The body would be cut short or overrun its Content-Length if the
+	// object changed after its pointer was written, which servers reject
+	// with confusing errors.
+	if stat, err := f.Stat(); err != nil {
+		return errutil.Error(err)
+	} else if stat.Size() != t.Object.Size {
+		return errutil.Errorf(nil, "Unable to upload %s: it is %d bytes, but %d bytes were expected", t.Object.Oid, stat.Size(), t.Object.Size)
+	}
+
 	// Ensure progress callbacks made while uploading
 	// Wrap callback to give name context
 	ccb := func(totalSize int64, readSoFar int64, readSinceLast int) error {
@@ -74,13 +135,14 @@ func (a *basicUploadAdapter) DoTransfer(t *Transfer, cb TransferProgressCallback
 		}
 		return nil
 	}
-	var reader io.Reader
-	reader = &progress.CallbackReader{
+	cbr := &progress.CallbackReader{
 		C:         ccb,
 		TotalSize: t.Object.Size,
 		Reader:    f,
+		Throttle:  a.throttle,
 	}
 
+	var reader io.Reader = cbr
 	// Signal auth was ok on first read; this frees up other workers to start
 	if authOkFunc != nil {
 		reader = newStartCallbackReader(reader, func(*startCallbackReader) {
@@ -88,10 +150,25 @@ func (a *basicUploadAdapter) DoTransfer(t *Transfer, cb TransferProgressCallback
 		})
 	}
 
-	req.Body = ioutil.NopCloser(reader)
+	// The content is hashed as it's sent, so that content which no longer
+	// matches its oid fails the upload instead of being stored.
+	body := newUploadBody(reader, f.File, cbr, form)
+	body.verifyOid(t.Object.Oid)
+	req.Body = body
 
 	res, err := httputil.DoHttpRequest(req, true)
+	if body.mismatch != nil {
+		return errutil.Errorf(body.mismatch, "Unable to upload %s: %s", t.Object.Oid, body.mismatch)
+	}
+	if res != nil && res.StatusCode == 409 && config.Config.TransferUploadConflict() == "verify" {
+		return verifyConflictingUpload(t)
+	}
 	if err != nil {
+		// a transport error, such as a timeout or a reset connection,
+		// comes with a response of status 0
+		if (res == nil || res.StatusCode == 0 || res.StatusCode > 499) && form == nil && config.Config.TransferUploadRetry() == "verify" && uploadedDespiteError(t, rel) {
+			return api.VerifyUpload(t.Object)
+		}
 		return errutil.NewRetriableError(err)
 	}
 	httputil.LogTransfer("lfs.data.upload", res)
@@ -103,7 +180,7 @@ func (a *basicUploadAdapter) DoTransfer(t *Transfer, cb TransferProgressCallback
 	}
 
 	if res.StatusCode > 299 {
-		return errutil.Errorf(nil, "Invalid status for %s: %d", httputil.TraceHttpReq(req), res.StatusCode)
+		return invalidStatusError(req, res)
 	}
 
 	io.Copy(ioutil.Discard, res.Body)
@@ -112,6 +189,246 @@ func (a *basicUploadAdapter) DoTransfer(t *Transfer, cb TransferProgressCallback
 	return api.VerifyUpload(t.Object)
 }
 
+// invalidStatusError returns the error for a response to req with a status
+// that the adapters don't handle, including the message and request ID from
+// the body if the server sent them, as support will need those.
+func invalidStatusError(req *http.Request, res *http.Response) error {
+	defer res.Body.Close()
+
+	msg := fmt.Sprintf("Invalid status for %s: %d", httputil.TraceHttpReq(req), res.StatusCode)
+	if cliErr := httputil.DecodeErrorResponse(res); cliErr != nil {
+		if len(cliErr.Message) > 0 {
+			msg += ": " + cliErr.Message
+		}
+		if len(cliErr.RequestId) > 0 {
+			msg += "\nRequest ID: " + cliErr.RequestId
+		}
+	}
+
+	return errutil.Error(errors.New(msg))
+}
+
+// verifyConflictingUpload is called when the server refuses the upload of t
+// with 409 Conflict, which it may do when another client uploaded the same oid
+// at the same time. Objects are identified by their content, so the upload is
+// done if the server now has the object, which is checked with a batch
+// download request.
+func verifyConflictingUpload(t *Transfer) error {
+	tracerx.Printf("xfer: upload of %s conflicted, checking the server has it", t.Object.Oid)
+
+	obj, _, err := api.BatchOrLegacySingle(&api.ObjectResource{Oid: t.Object.Oid, Size: t.Object.Size}, "download", nil)
+	if err != nil {
+		return errutil.Errorf(err, "Upload of %s conflicted, and checking for it failed: %s", t.Object.Oid, err)
+	}
+
+	if obj.Error != nil {
+		return errutil.Errorf(nil, "Upload of %s conflicted, and the server doesn't have it: %s", t.Object.Oid, obj.Error)
+	}
+
+	if _, ok := obj.Rel("download"); !ok {
+		return errutil.Errorf(nil, "Upload of %s conflicted, and the server doesn't have it", t.Object.Oid)
+	}
+
+	tracerx.Printf("xfer: %s is already on the server", t.Object.Oid)
+	return nil
+}
+
+// uploadedDespiteError is called when the upload of t failed without a clear
+// answer from the server, which may have stored the object anyway, as when a
+// request times out after the object store has committed it. It returns
+// whether a HEAD request to the upload action's href finds an object of the
+// right size, so that it doesn't need uploading again.
+func uploadedDespiteError(t *Transfer, rel *api.LinkRelation) bool {
+	tracerx.Printf("xfer: upload of %s failed, checking the object store has it", t.Object.Oid)
+
+	req, err := httputil.NewHttpRequest("HEAD", rel.Href, rel.Header)
+	if err != nil {
+		return false
+	}
+
+	res, err := httputil.DoHttpRequest(req, true)
+	if err != nil {
+		tracerx.Printf("xfer: unable to check for %s: %s", t.Object.Oid, err)
+		return false
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+
+	if res.StatusCode != 200 || res.ContentLength != t.Object.Size {
+		return false
+	}
+
+	tracerx.Printf("xfer: %s is already in the object store", t.Object.Oid)
+	return true
+}
+
+// uploadForm is the multipart/form-data body of an upload to a presigned POST
+// form, without the file: head holds the form's fields and the file part's
+// header, and tail the closing boundary.
+type uploadForm struct {
+	contentType string
+	head        []byte
+	tail        []byte
+}
+
+// newUploadForm returns the form for uploading an object to a presigned POST
+// form with fields. Object stores need the file to be the last part, so it
+// comes after the fields, which are sorted by name.
+func newUploadForm(fields map[string]string, filename string) (*uploadForm, error) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+	for _, name := range names {
+		if err := w.WriteField(name, fields[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := w.CreateFormFile("file", filename); err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, buf.Len())
+	copy(head, buf.Bytes())
+	buf.Reset()
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return &uploadForm{
+		contentType: w.FormDataContentType(),
+		head:        head,
+		tail:        buf.Bytes(),
+	}, nil
+}
+
+// uploadBody is the body of an upload request: the file's content, inside the
+// form if there is one. It can be rewound, so that a failed request can be
+// retried, which rewinds its progress too. The file is closed by the upload,
+// not the request.
+type uploadBody struct {
+	io.Reader
+	content  io.Reader
+	file     *os.File
+	source   io.Reader // the file, as progress reads it
+	progress *progress.CallbackReader
+	form     *uploadForm
+
+	// oid is the oid the content is verified against as it's read, if
+	// it's set, and mismatch the error from the last read if it doesn't
+	// match.
+	oid      string
+	mismatch error
+}
+
+func newUploadBody(content io.Reader, file *os.File, cbr *progress.CallbackReader, form *uploadForm) *uploadBody {
+	b := &uploadBody{content: content, file: file, source: cbr.Reader, progress: cbr, form: form}
+	b.reset()
+	return b
+}
+
+// verifyOid makes reads of the body fail at the end of the content if it
+// doesn't hash to oid.
+func (b *uploadBody) verifyOid(oid string) {
+	b.oid = oid
+	b.reset()
+}
+
+func (b *uploadBody) reset() {
+	if len(b.oid) > 0 {
+		b.progress.Reader = newHashingReader(b.source, b.oid, b.progress.TotalSize)
+	}
+	b.mismatch = nil
+
+	if b.form == nil {
+		b.Reader = b.content
+		return
+	}
+
+	b.Reader = io.MultiReader(bytes.NewReader(b.form.head), b.content, bytes.NewReader(b.form.tail))
+}
+
+func (b *uploadBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	if _, ok := err.(*tools.OidMismatchError); ok {
+		b.mismatch = err
+	}
+	return n, err
+}
+
+func (b *uploadBody) Seek(offset int64, whence int) (int64, error) {
+	if b.form != nil && (offset != 0 || whence != 0) {
+		return 0, fmt.Errorf("A form upload can only be rewound to its start")
+	}
+	if len(b.oid) > 0 && (offset != 0 || whence != 0) {
+		return 0, fmt.Errorf("A verified upload can only be rewound to its start")
+	}
+
+	n, err := b.file.Seek(offset, whence)
+	if err == nil {
+		b.progress.ReadSize = n
+		b.reset()
+	}
+	return n, err
+}
+
+func (b *uploadBody) Close() error {
+	return nil
+}
+
+// hashingReader calculates the oid of an upload's content as it's read, and
+// checks it as soon as the expected size has been read, as well as at the end.
+// A mismatch is returned instead of the bytes which completed the content, so
+// that the server is never sent the whole of the wrong content. It reads below
+// the progress.CallbackReader, so withheld bytes aren't counted as progress.
+type hashingReader struct {
+	*tools.VerifyingReader
+	oid      string
+	size     int64
+	mismatch error
+}
+
+func newHashingReader(r io.Reader, oid string, size int64) *hashingReader {
+	return &hashingReader{
+		VerifyingReader: tools.NewVerifyingReader(r, oid, nil),
+		oid:             oid,
+		size:            size,
+	}
+}
+
+// skip hashes the first n bytes of the content without returning them, for
+// uploads which resume after content the server already has.
+func (r *hashingReader) skip(n int64) error {
+	_, err := io.CopyN(ioutil.Discard, r.VerifyingReader, n)
+	return err
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	if r.mismatch != nil {
+		return 0, r.mismatch
+	}
+
+	before := r.Size()
+	n, err := r.VerifyingReader.Read(p)
+	if before < r.size && r.Size() >= r.size {
+		if actual := r.Hash(); actual != r.oid {
+			err = &tools.OidMismatchError{Expected: r.oid, Actual: actual, Size: r.Size()}
+			n = 0
+		}
+	}
+
+	if _, ok := err.(*tools.OidMismatchError); ok {
+		r.mismatch = err
+	}
+	return n, err
+}
+
 // startCallbackReader is a reader wrapper which calls a function as soon as the
 // first Read() call is made. This callback is only made once
 type startCallbackReader struct {
@@ -0,0 +1,698 @@
+package transfer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/github/git-lfs/api"
+	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/errutil"
+	"github.com/github/git-lfs/progress"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBasicUploadMethodOverride(t *testing.T) {
+	defer config.Config.ResetConfig()
+
+	tests := map[string][]string{
+		"":      {"PUT", ""},
+		"false": {"PUT", ""},
+		"true":  {"POST", "PUT"},
+	}
+
+	for value, expected := range tests {
+		config.Config.SetConfig("lfs.transfer.methodoverride", value)
+
+		method, header, body := uploadTestObject(t, []byte("upload"), nil)
+		assert.Equal(t, expected[0], method, "lfs.transfer.methodoverride=%q", value)
+		assert.Equal(t, expected[1], header.Get("X-HTTP-Method-Override"), "lfs.transfer.methodoverride=%q", value)
+		assert.Equal(t, "upload", body, "lfs.transfer.methodoverride=%q", value)
+	}
+}
+
+func TestBasicUploadHeaders(t *testing.T) {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.transfer.uploadheader.x-amz-server-side-encryption", "aws:kms")
+	config.Config.SetConfig("lfs.transfer.uploadheader.x-amz-server-side-encryption-aws-kms-key-id", "Key/ID=1")
+	config.Config.SetConfig("lfs.transfer.uploadheader.x-amz-acl", "private")
+
+	_, header, _ := uploadTestObject(t, []byte("upload"), map[string]string{
+		"X-Amz-Acl": "bucket-owner-full-control",
+	})
+
+	assert.Equal(t, "aws:kms", header.Get("X-Amz-Server-Side-Encryption"))
+	assert.Equal(t, "Key/ID=1", header.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"))
+	assert.Equal(t, []string{"bucket-owner-full-control"}, header["X-Amz-Acl"], "action headers take precedence")
+}
+
+// uploadTestObject uploads content with the basic adapter, sending header as
+// the upload action's headers, returning the method, headers and body that the
+// server received.
+func uploadTestObject(t *testing.T, content []byte, header map[string]string) (method string, received http.Header, body string) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		received = r.Header
+		by, _ := ioutil.ReadAll(r.Body)
+		body = string(by)
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "lfs-upload-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "obj.dat")
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	actionHeader := map[string]string{"Authorization": "Basic dGVzdDp0ZXN0"}
+	for name, value := range header {
+		actionHeader[name] = value
+	}
+
+	tr := &Transfer{
+		Name: "obj.dat",
+		Path: path,
+		Object: &api.ObjectResource{
+			Oid:  testOid(content),
+			Size: int64(len(content)),
+			Actions: map[string]*api.LinkRelation{
+				"upload": &api.LinkRelation{
+					Href:   srv.URL + "/obj",
+					Header: actionHeader,
+				},
+			},
+		},
+	}
+
+	err = NewUploadAdapter(BasicAdapterName).(*basicUploadAdapter).DoTransfer(tr, nil, nil)
+	assert.Nil(t, err)
+	return
+}
+
+func TestBasicUploadConflict(t *testing.T) {
+	defer config.Config.ResetConfig()
+
+	tests := []struct {
+		Mode     string
+		Present  bool
+		Uploaded bool
+	}{
+		{"", true, true},
+		{"verify", true, true},
+		{"verify", false, false},
+		{"retry", true, false},
+	}
+
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "PUT" {
+				w.WriteHeader(409)
+				return
+			}
+
+			w.Header().Set("Content-Type", api.MediaType)
+			if tt.Present {
+				w.Write([]byte(`{"objects":[{"oid":"oid","size":6,"actions":{"download":{"href":"http://example.com/oid"}}}]}`))
+			} else {
+				w.Write([]byte(`{"objects":[{"oid":"oid","size":6,"error":{"code":404,"message":"Object does not exist"}}]}`))
+			}
+		}))
+
+		config.Config.SetConfig("lfs.url", srv.URL)
+		config.Config.SetConfig("lfs.transfer.uploadconflict", tt.Mode)
+
+		tr := &Transfer{
+			Name: "obj.dat",
+			Path: writeTestObject(t, []byte("upload")),
+			Object: &api.ObjectResource{
+				Oid:  testOid([]byte("upload")),
+				Size: 6,
+				Actions: map[string]*api.LinkRelation{
+					"upload": &api.LinkRelation{
+						Href:   srv.URL + "/obj",
+						Header: map[string]string{"Authorization": "Basic dGVzdDp0ZXN0"},
+					},
+				},
+			},
+		}
+
+		err := NewUploadAdapter(BasicAdapterName).(*basicUploadAdapter).DoTransfer(tr, nil, nil)
+		if tt.Uploaded {
+			assert.Nil(t, err, "lfs.transfer.uploadconflict=%q, present=%v", tt.Mode, tt.Present)
+		} else {
+			assert.NotNil(t, err, "lfs.transfer.uploadconflict=%q, present=%v", tt.Mode, tt.Present)
+		}
+
+		os.RemoveAll(filepath.Dir(tr.Path))
+		srv.Close()
+	}
+}
+
+// testOid returns the oid of content.
+func testOid(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeTestObject writes content to a file in a new temporary directory and
+// returns its path.
+func writeTestObject(t *testing.T, content []byte) string {
+	dir, err := ioutil.TempDir("", "lfs-upload-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "obj.dat")
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBasicUploadRetryVerify(t *testing.T) {
+	defer config.Config.ResetConfig()
+
+	tests := []struct {
+		Mode     string
+		Stored   string
+		Uploaded bool
+	}{
+		{"", "upload", false},
+		{"retry", "upload", false},
+		{"verify", "upload", true},
+		{"verify", "", false},
+		{"verify", "uploa", false},
+	}
+
+	for _, tt := range tests {
+		heads := 0
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case "PUT":
+				// the object store has committed the upload, if
+				// tt.Stored isn't empty, but the upload fails anyway
+				io.Copy(ioutil.Discard, r.Body)
+				w.WriteHeader(500)
+			case "HEAD":
+				heads++
+				if len(tt.Stored) == 0 {
+					w.WriteHeader(404)
+					return
+				}
+				w.Header().Set("Content-Length", strconv.Itoa(len(tt.Stored)))
+				w.WriteHeader(200)
+			}
+		}))
+
+		config.Config.SetConfig("lfs.transfer.uploadretry", tt.Mode)
+
+		tr := &Transfer{
+			Name: "obj.dat",
+			Path: writeTestObject(t, []byte("upload")),
+			Object: &api.ObjectResource{
+				Oid:  testOid([]byte("upload")),
+				Size: 6,
+				Actions: map[string]*api.LinkRelation{
+					"upload": &api.LinkRelation{
+						Href:   srv.URL + "/obj",
+						Header: map[string]string{"Authorization": "Basic dGVzdDp0ZXN0"},
+					},
+				},
+			},
+		}
+
+		err := NewUploadAdapter(BasicAdapterName).(*basicUploadAdapter).DoTransfer(tr, nil, nil)
+		if tt.Uploaded {
+			assert.Nil(t, err, "lfs.transfer.uploadretry=%q, stored=%q", tt.Mode, tt.Stored)
+		} else {
+			assert.NotNil(t, err, "lfs.transfer.uploadretry=%q, stored=%q", tt.Mode, tt.Stored)
+			assert.True(t, errutil.IsRetriableError(err), "lfs.transfer.uploadretry=%q, stored=%q", tt.Mode, tt.Stored)
+		}
+
+		if tt.Mode == "verify" {
+			assert.Equal(t, 1, heads, "lfs.transfer.uploadretry=%q, stored=%q", tt.Mode, tt.Stored)
+		} else {
+			assert.Equal(t, 0, heads, "lfs.transfer.uploadretry=%q, stored=%q", tt.Mode, tt.Stored)
+		}
+
+		os.RemoveAll(filepath.Dir(tr.Path))
+		srv.Close()
+	}
+}
+
+func TestBasicUploadRetryVerifyAfterConnectionError(t *testing.T) {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.transfer.uploadretry", "verify")
+
+	heads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+			// the object is stored, but the connection is lost
+			// before the response is sent
+			io.Copy(ioutil.Discard, r.Body)
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn.Close()
+		case "HEAD":
+			heads++
+			w.Header().Set("Content-Length", "6")
+			w.WriteHeader(200)
+		}
+	}))
+	defer srv.Close()
+
+	tr := &Transfer{
+		Name: "obj.dat",
+		Path: writeTestObject(t, []byte("upload")),
+		Object: &api.ObjectResource{
+			Oid:  testOid([]byte("upload")),
+			Size: 6,
+			Actions: map[string]*api.LinkRelation{
+				"upload": &api.LinkRelation{
+					Href:   srv.URL + "/obj",
+					Header: map[string]string{"Authorization": "Basic dGVzdDp0ZXN0"},
+				},
+			},
+		},
+	}
+	defer os.RemoveAll(filepath.Dir(tr.Path))
+
+	err := NewUploadAdapter(BasicAdapterName).(*basicUploadAdapter).DoTransfer(tr, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, heads)
+}
+
+func TestBasicUploadForm(t *testing.T) {
+	oid := testOid([]byte("upload"))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "", r.Header.Get("X-HTTP-Method-Override"))
+		assert.Equal(t, "Basic dGVzdDp0ZXN0", r.Header.Get("Authorization"))
+
+		by, err := ioutil.ReadAll(r.Body)
+		assert.Nil(t, err)
+		assert.Equal(t, r.ContentLength, int64(len(by)))
+
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		assert.Nil(t, err)
+
+		var names []string
+		values := make(map[string]string)
+		mr := multipart.NewReader(bytes.NewReader(by), params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if !assert.Nil(t, err) {
+				break
+			}
+
+			value, _ := ioutil.ReadAll(part)
+			names = append(names, part.FormName())
+			values[part.FormName()] = string(value)
+			if part.FormName() == "file" {
+				assert.Equal(t, oid, part.FileName())
+			}
+		}
+
+		assert.Equal(t, []string{"key", "policy", "x-amz-signature", "file"}, names)
+		assert.Equal(t, "objects/oid", values["key"])
+		assert.Equal(t, "cG9saWN5", values["policy"])
+		assert.Equal(t, "sig", values["x-amz-signature"])
+		assert.Equal(t, "upload", values["file"])
+
+		w.WriteHeader(204)
+	}))
+	defer srv.Close()
+
+	path := writeTestObject(t, []byte("upload"))
+	defer os.RemoveAll(filepath.Dir(path))
+
+	tr := &Transfer{
+		Name: "obj.dat",
+		Path: path,
+		Object: &api.ObjectResource{
+			Oid:  oid,
+			Size: 6,
+			Actions: map[string]*api.LinkRelation{
+				"upload": &api.LinkRelation{
+					Href:   srv.URL + "/bucket",
+					Header: map[string]string{"Authorization": "Basic dGVzdDp0ZXN0"},
+					Form: map[string]string{
+						"x-amz-signature": "sig",
+						"key":             "objects/oid",
+						"policy":          "cG9saWN5",
+					},
+				},
+			},
+		},
+	}
+
+	var progressTotal, progressRead int64
+	cb := func(name string, total, read int64, current int) error {
+		progressTotal = total
+		progressRead = read
+		return nil
+	}
+
+	err := NewUploadAdapter(BasicAdapterName).(*basicUploadAdapter).DoTransfer(tr, cb, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), progressTotal, "progress is reported on the file part")
+	assert.Equal(t, int64(6), progressRead, "progress is reported on the file part")
+}
+
+func TestUploadFormBodyRewinds(t *testing.T) {
+	path := writeTestObject(t, []byte("upload"))
+	defer os.RemoveAll(filepath.Dir(path))
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	form, err := newUploadForm(map[string]string{"key": "oid"}, "oid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cbr := &progress.CallbackReader{TotalSize: 6, Reader: f}
+	body := newUploadBody(cbr, f, cbr, form)
+
+	first, err := ioutil.ReadAll(body)
+	assert.Nil(t, err)
+	assert.Equal(t, len(form.head)+6+len(form.tail), len(first))
+	assert.Contains(t, string(first), "upload")
+
+	_, err = body.Seek(0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), cbr.ReadSize)
+
+	second, err := ioutil.ReadAll(body)
+	assert.Nil(t, err)
+	assert.Equal(t, first, second)
+
+	_, err = body.Seek(1, 0)
+	assert.NotNil(t, err)
+}
+
+func TestBasicUploadSizeMismatch(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		io.Copy(ioutil.Discard, r.Body)
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	// the object changed after its pointer was written
+	path := writeTestObject(t, []byte("upload, changed"))
+	defer os.RemoveAll(filepath.Dir(path))
+
+	tr := &Transfer{
+		Name: "obj.dat",
+		Path: path,
+		Object: &api.ObjectResource{
+			Oid:  "oid",
+			Size: 6,
+			Actions: map[string]*api.LinkRelation{
+				"upload": &api.LinkRelation{
+					Href:   srv.URL + "/obj",
+					Header: map[string]string{"Authorization": "Basic dGVzdDp0ZXN0"},
+				},
+			},
+		},
+	}
+
+	err := NewUploadAdapter(BasicAdapterName).(*basicUploadAdapter).DoTransfer(tr, nil, nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "15 bytes, but 6 bytes were expected")
+		assert.False(t, errutil.IsRetriableError(err))
+	}
+	assert.Equal(t, 0, requests, "nothing is sent")
+}
+
+func TestBasicUploadChunkedCorrupt(t *testing.T) {
+	tests := map[string]bool{
+		"upload": true,
+		"uploab": false,
+	}
+
+	sum := sha256.Sum256([]byte("upload"))
+	oid := hex.EncodeToString(sum[:])
+
+	for content, ok := range tests {
+		var received string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, []string{"chunked"}, r.TransferEncoding)
+			by, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				// the body was cut off before its end
+				return
+			}
+			received = string(by)
+			w.WriteHeader(200)
+		}))
+
+		path := writeTestObject(t, []byte(content))
+
+		tr := &Transfer{
+			Name: "obj.dat",
+			Path: path,
+			Object: &api.ObjectResource{
+				Oid:  oid,
+				Size: 6,
+				Actions: map[string]*api.LinkRelation{
+					"upload": &api.LinkRelation{
+						Href: srv.URL + "/obj",
+						Header: map[string]string{
+							"Authorization":     "Basic dGVzdDp0ZXN0",
+							"Transfer-Encoding": "chunked",
+						},
+					},
+				},
+			},
+		}
+
+		err := NewUploadAdapter(BasicAdapterName).(*basicUploadAdapter).DoTransfer(tr, nil, nil)
+		if ok {
+			assert.Nil(t, err, "content %q", content)
+			assert.Equal(t, content, received, "content %q", content)
+		} else if assert.NotNil(t, err, "content %q", content) {
+			assert.Contains(t, err.Error(), "Expected OID "+oid, "content %q", content)
+			assert.False(t, errutil.IsRetriableError(err), "content %q", content)
+			assert.Empty(t, received, "content %q", content)
+		}
+
+		os.RemoveAll(filepath.Dir(path))
+		srv.Close()
+	}
+}
+
+func TestBasicUploadCorrupt(t *testing.T) {
+	oid := testOid([]byte("upload"))
+
+	var requests int
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		by, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			// the body was cut off before its end
+			return
+		}
+		received = string(by)
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	path := writeTestObject(t, []byte("uploab"))
+	defer os.RemoveAll(filepath.Dir(path))
+
+	tr := &Transfer{
+		Name: "obj.dat",
+		Path: path,
+		Object: &api.ObjectResource{
+			Oid:  oid,
+			Size: 6,
+			Actions: map[string]*api.LinkRelation{
+				"upload": &api.LinkRelation{
+					Href:   srv.URL + "/obj",
+					Header: map[string]string{"Authorization": "Basic dGVzdDp0ZXN0"},
+				},
+			},
+		},
+	}
+
+	var progressRead int64
+	cb := func(name string, total, read int64, current int) error {
+		progressRead = read
+		return nil
+	}
+
+	err := NewUploadAdapter(BasicAdapterName).(*basicUploadAdapter).DoTransfer(tr, cb, nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "Expected OID "+oid)
+		assert.False(t, errutil.IsRetriableError(err))
+	}
+	assert.Empty(t, received, "the server never gets the whole body")
+	assert.True(t, progressRead < 6, "read %d bytes", progressRead)
+	assert.True(t, requests < 2, "the upload isn't retried")
+}
+
+func TestHashingReaderWithholdsMismatchedEnd(t *testing.T) {
+	oid := testOid([]byte("upload"))
+
+	r := newHashingReader(bytes.NewReader([]byte("uploab")), oid, 6)
+	buf := make([]byte, 4)
+	n, err := r.Read(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, n)
+
+	n, err = r.Read(buf)
+	assert.Equal(t, 0, n, "the last bytes aren't returned")
+	assert.Contains(t, err.Error(), "Expected OID "+oid)
+	assert.NotNil(t, r.mismatch)
+
+	// resuming hashes the content that was skipped
+	r = newHashingReader(bytes.NewReader([]byte("upload")), oid, 6)
+	assert.Nil(t, r.skip(4))
+	by, err := ioutil.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "ad", string(by))
+	assert.Nil(t, r.mismatch)
+}
+
+func TestBasicUploadExpectContinue(t *testing.T) {
+	defer config.Config.ResetConfig()
+
+	tests := []struct {
+		Threshold string
+		Reject    bool
+		Expect    string
+		Sent      int64
+	}{
+		{"", false, "", 6},
+		{"1k", false, "", 6},
+		{"6", false, "100-continue", 6},
+		{"6", true, "100-continue", 0},
+	}
+
+	for _, tt := range tests {
+		var expect, received string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			expect = r.Header.Get("Expect")
+			if tt.Reject {
+				// refused before reading the body, so Go's server
+				// doesn't send 100 Continue
+				w.WriteHeader(403)
+				return
+			}
+			by, _ := ioutil.ReadAll(r.Body)
+			received = string(by)
+			w.WriteHeader(200)
+		}))
+
+		config.Config.SetConfig("lfs.transfer.expectcontinue", tt.Threshold)
+
+		path := writeTestObject(t, []byte("upload"))
+		tr := &Transfer{
+			Name: "obj.dat",
+			Path: path,
+			Object: &api.ObjectResource{
+				Oid:  testOid([]byte("upload")),
+				Size: 6,
+				Actions: map[string]*api.LinkRelation{
+					"upload": &api.LinkRelation{
+						Href:   srv.URL + "/obj",
+						Header: map[string]string{"Authorization": "Basic dGVzdDp0ZXN0"},
+					},
+				},
+			},
+		}
+
+		var sent int64
+		cb := func(name string, total, read int64, current int) error {
+			sent = read
+			return nil
+		}
+
+		err := NewUploadAdapter(BasicAdapterName).(*basicUploadAdapter).DoTransfer(tr, cb, nil)
+		if tt.Reject {
+			if assert.NotNil(t, err, "lfs.transfer.expectcontinue=%q", tt.Threshold) {
+				assert.True(t, errutil.IsRetriableError(err), "lfs.transfer.expectcontinue=%q", tt.Threshold)
+			}
+		} else {
+			assert.Nil(t, err, "lfs.transfer.expectcontinue=%q", tt.Threshold)
+			assert.Equal(t, "upload", received, "lfs.transfer.expectcontinue=%q", tt.Threshold)
+		}
+		assert.Equal(t, tt.Expect, expect, "lfs.transfer.expectcontinue=%q", tt.Threshold)
+		assert.Equal(t, tt.Sent, sent, "lfs.transfer.expectcontinue=%q", tt.Threshold)
+
+		os.RemoveAll(filepath.Dir(path))
+		srv.Close()
+	}
+}
+
+func TestBasicUploadErrorBody(t *testing.T) {
+	tests := map[int]string{
+		300: "Invalid status",
+		422: "",
+	}
+
+	for status, prefix := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(ioutil.Discard, r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write([]byte(`{"code":422,"message":"storage quota exceeded","request_id":"req-1234"}`))
+		}))
+
+		path := writeTestObject(t, []byte("upload"))
+		tr := &Transfer{
+			Name: "obj.dat",
+			Path: path,
+			Object: &api.ObjectResource{
+				Oid:  testOid([]byte("upload")),
+				Size: 6,
+				Actions: map[string]*api.LinkRelation{
+					"upload": &api.LinkRelation{
+						Href:   srv.URL + "/obj",
+						Header: map[string]string{"Authorization": "Basic dGVzdDp0ZXN0"},
+					},
+				},
+			},
+		}
+
+		err := NewUploadAdapter(BasicAdapterName).(*basicUploadAdapter).DoTransfer(tr, nil, nil)
+		if assert.NotNil(t, err, "HTTP %d", status) {
+			assert.Contains(t, err.Error(), prefix, "HTTP %d", status)
+			assert.Contains(t, err.Error(), "storage quota exceeded", "HTTP %d", status)
+			assert.Contains(t, err.Error(), "Request ID: req-1234", "HTTP %d", status)
+		}
+
+		srv.Close()
+		os.RemoveAll(filepath.Dir(path))
+	}
+}
@@ -0,0 +1,61 @@
+package transfer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/subprocess"
+	"github.com/github/git-lfs/tools"
+	"github.com/rubyist/tracerx"
+)
+
+// finishDownload moves the downloaded content of t from tmpPath into t.Path,
+// once its content has been verified. Every download adapter finishes this
+// way, so that the download hook checks all of them. An object whose size
+// wasn't known, which is 0, gets the size of its content.
+func finishDownload(t *Transfer, tmpPath string) error {
+	if err := runDownloadHook(t.Object.Oid, tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if t.Object.Size == 0 {
+		if fi, err := os.Stat(tmpPath); err == nil && fi.Size() > 0 {
+			tracerx.Printf("xfer: %s was of unknown size, and is %d bytes", t.Object.Oid, fi.Size())
+			t.Object.Size = fi.Size()
+		}
+	}
+
+	return tools.RenameFileCopyPermissions(tmpPath, t.Path)
+}
+
+// runDownloadHook runs the command set by lfs.transfer.downloadhook as
+// `<command> <oid> <path>`, with nothing on stdin, to check a downloaded
+// object at path before it's stored. The object is rejected if the command
+// exits non-zero, or can't be run, with its stderr as the error.
+func runDownloadHook(oid, path string) error {
+	pieces := strings.Fields(config.Config.DownloadHook())
+	if len(pieces) == 0 {
+		return nil
+	}
+
+	args := append(pieces[1:], oid, path)
+	tracerx.Printf("run_command: '%s' %s", pieces[0], strings.Join(args, " "))
+
+	var stderr bytes.Buffer
+	cmd := subprocess.ExecCommand(pieces[0], args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) == 0 {
+			msg = err.Error()
+		}
+		return fmt.Errorf("Download of %s rejected by lfs.transfer.downloadhook: %s", oid, msg)
+	}
+
+	tracerx.Printf("xfer: download hook accepted %s", oid)
+	return nil
+}
@@ -0,0 +1,99 @@
+package transfer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/github/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupDownloadHook sets lfs.transfer.downloadhook to a script which rejects
+// content containing "EICAR", and records its arguments and stdin in the
+// returned file.
+func setupDownloadHook(t *testing.T) (string, func()) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test hook is a shell script")
+	}
+
+	dir, err := ioutil.TempDir("", "lfs-download-hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := filepath.Join(dir, "hook.log")
+	hook := filepath.Join(dir, "scan.sh")
+	script := `#!/bin/sh
+echo "$1 $(cat "$2") $(cat)" > "` + log + `"
+if grep -q EICAR "$2"; then
+  echo "$1: EICAR test signature found" >&2
+  exit 1
+fi
+`
+	if err := ioutil.WriteFile(hook, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	config.Config.SetConfig("lfs.transfer.downloadhook", hook)
+	return log, func() {
+		config.Config.ResetConfig()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestDownloadHookAcceptsObject(t *testing.T) {
+	log, restore := setupDownloadHook(t)
+	defer restore()
+
+	content := []byte("clean")
+	tr, cleanup := setupDownloadTest(t, content)
+	defer cleanup()
+
+	err := NewDownloadAdapter(BasicAdapterName).(*basicDownloadAdapter).DoTransfer(tr, nil, nil)
+	if assert.Nil(t, err) {
+		by, err := ioutil.ReadFile(tr.Path)
+		assert.Nil(t, err)
+		assert.Equal(t, content, by)
+	}
+
+	// run with the oid and the downloaded content, and nothing on stdin
+	by, err := ioutil.ReadFile(log)
+	assert.Nil(t, err)
+	assert.Equal(t, tr.Object.Oid+" clean ", strings.TrimSuffix(string(by), "\n"))
+}
+
+func TestDownloadHookRejectsObject(t *testing.T) {
+	_, restore := setupDownloadHook(t)
+	defer restore()
+
+	content := bytes.Repeat([]byte("EICAR"), 100)
+	tr, cleanup := setupDownloadTest(t, content)
+	defer cleanup()
+
+	a := NewDownloadAdapter(BasicAdapterName).(*basicDownloadAdapter)
+	err := a.DoTransfer(tr, nil, nil)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "Download of "+tr.Object.Oid+" rejected by lfs.transfer.downloadhook: "+tr.Object.Oid+": EICAR test signature found", err.Error())
+	}
+
+	// neither stored nor kept to resume
+	_, err = os.Stat(tr.Path)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(a.downloadFilename(tr))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDownloadHookWhichCantRun(t *testing.T) {
+	defer config.Config.ResetConfig()
+	config.Config.SetConfig("lfs.transfer.downloadhook", "lfs-no-such-download-hook --scan")
+
+	err := runDownloadHook("abc", "/tmp/abc")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "Download of abc rejected by lfs.transfer.downloadhook")
+	}
+}
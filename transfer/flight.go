@@ -0,0 +1,58 @@
+package transfer
+
+import "sync"
+
+// flightGroup coalesces concurrent transfers of the same oid, so that only one
+// of them actually runs and the others wait for it and share its result.
+type flightGroup struct {
+	mutex   sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is a transfer in progress for an oid.
+type flight struct {
+	wg   sync.WaitGroup
+	err  error
+	dups int // number of calls waiting for this one
+}
+
+// Do runs fn for oid, unless another call for oid is already running, in which
+// case it waits for that call instead. shared reports whether err came from
+// another call, so if that transfer failed, every waiter gets its error.
+func (g *flightGroup) Do(oid string, fn func() error) (shared bool, err error) {
+	g.mutex.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	if f, ok := g.flights[oid]; ok {
+		f.dups++
+		g.mutex.Unlock()
+		f.wg.Wait()
+		return true, f.err
+	}
+
+	f := &flight{}
+	f.wg.Add(1)
+	g.flights[oid] = f
+	g.mutex.Unlock()
+
+	f.err = fn()
+	f.wg.Done()
+
+	g.mutex.Lock()
+	delete(g.flights, oid)
+	g.mutex.Unlock()
+
+	return false, f.err
+}
+
+// waiting returns how many calls are waiting for the running call for oid.
+func (g *flightGroup) waiting(oid string) int {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if f, ok := g.flights[oid]; ok {
+		return f.dups
+	}
+	return 0
+}
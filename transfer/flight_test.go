@@ -0,0 +1,130 @@
+package transfer
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/github/git-lfs/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingTransfer counts transfers, and holds each one until release is
+// closed.
+type blockingTransfer struct {
+	calls   int32
+	release chan struct{}
+	err     error
+}
+
+func (b *blockingTransfer) DoTransfer(t *Transfer, cb TransferProgressCallback, authOkFunc func()) error {
+	atomic.AddInt32(&b.calls, 1)
+	if authOkFunc != nil {
+		authOkFunc()
+	}
+	<-b.release
+	if b.err == nil {
+		advanceCallbackProgress(cb, t, t.Object.Size)
+	}
+	return b.err
+}
+
+func waitForFlightWaiters(t *testing.T, g *flightGroup, oid string, n int) {
+	for start := time.Now(); g.waiting(oid) < n; time.Sleep(time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("timed out waiting for %d waiters on %s", n, oid)
+		}
+	}
+}
+
+func TestFlightGroupCoalescesConcurrentCalls(t *testing.T) {
+	var g flightGroup
+	var calls int32
+	release := make(chan struct{})
+	fn := func() error {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return errors.New("transfer failed")
+	}
+
+	var wg sync.WaitGroup
+	shared := make(chan bool, 3)
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			s, err := g.Do("abc", fn)
+			shared <- s
+			errs <- err
+			wg.Done()
+		}()
+	}
+
+	waitForFlightWaiters(t, &g, "abc", 2)
+	close(release)
+	wg.Wait()
+	close(shared)
+	close(errs)
+
+	assert.Equal(t, int32(1), calls)
+	sharedCount := 0
+	for s := range shared {
+		if s {
+			sharedCount++
+		}
+	}
+	assert.Equal(t, 2, sharedCount)
+	for err := range errs {
+		assert.EqualError(t, err, "transfer failed", "every waiter gets the error")
+	}
+
+	// once finished, the next call runs again
+	shared2, err := g.Do("abc", func() error { return nil })
+	assert.False(t, shared2)
+	assert.Nil(t, err)
+}
+
+func TestAdapterCoalescesConcurrentTransfersOfSameOid(t *testing.T) {
+	for _, transferErr := range []error{nil, errors.New("download failed")} {
+		impl := &blockingTransfer{release: make(chan struct{}), err: transferErr}
+		a := newAdapterBase("test", Download, impl)
+
+		var progressBytes int64
+		cb := func(name string, total, read int64, current int) error {
+			atomic.AddInt64(&progressBytes, int64(current))
+			return nil
+		}
+		results := make(chan TransferResult, 3)
+		assert.Nil(t, a.Begin(3, cb, results))
+
+		obj := &api.ObjectResource{Oid: "abc", Size: 10}
+		a.Add(NewTransfer("a.dat", obj, "a"))
+		a.Add(NewTransfer("b.dat", obj, "b"))
+		a.Add(NewTransfer("c.dat", obj, "c"))
+
+		waitForFlightWaiters(t, &a.flights, "abc", 2)
+		close(impl.release)
+		a.End()
+
+		assert.Equal(t, int32(1), impl.calls, "one transfer for the oid")
+
+		names := make(map[string]bool)
+		for res := range results {
+			names[res.Transfer.Name] = true
+			if transferErr == nil {
+				assert.Nil(t, res.Error)
+			} else {
+				assert.Equal(t, transferErr, res.Error)
+			}
+		}
+		assert.Equal(t, map[string]bool{"a.dat": true, "b.dat": true, "c.dat": true}, names, "a result for every file")
+
+		if transferErr == nil {
+			assert.Equal(t, int64(30), progressBytes)
+		} else {
+			assert.Equal(t, int64(0), progressBytes)
+		}
+	}
+}
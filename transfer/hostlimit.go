@@ -0,0 +1,82 @@
+package transfer
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/rubyist/tracerx"
+)
+
+// hostLimiter caps how many transfers an adapter's workers have in flight to
+// each host at once, for object stores and CDNs which allow fewer connections
+// than lfs.concurrenttransfers. A worker with a transfer for a host which is
+// at its limit waits for one of that host's transfers to finish, while the
+// other workers carry on. A nil *hostLimiter doesn't limit anything.
+type hostLimiter struct {
+	limit func(host string) int
+
+	mutex sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// newHostLimiter returns a hostLimiter which looks up each host's limit with
+// limit, where 0 means no limit.
+func newHostLimiter(limit func(host string) int) *hostLimiter {
+	return &hostLimiter{
+		limit: limit,
+		slots: make(map[string]chan struct{}),
+	}
+}
+
+// Acquire blocks until a transfer to host may start, and returns a func to
+// call when it has finished.
+func (h *hostLimiter) Acquire(host string) func() {
+	slots := h.hostSlots(host)
+	if slots == nil {
+		return func() {}
+	}
+
+	select {
+	case slots <- struct{}{}:
+	default:
+		tracerx.Printf("xfer: waiting for a connection to %s", host)
+		slots <- struct{}{}
+	}
+
+	return func() { <-slots }
+}
+
+func (h *hostLimiter) hostSlots(host string) chan struct{} {
+	if h == nil || len(host) == 0 {
+		return nil
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	slots, ok := h.slots[host]
+	if !ok {
+		if n := h.limit(host); n > 0 {
+			slots = make(chan struct{}, n)
+		}
+		// remember hosts without a limit too, to only look them up once
+		h.slots[host] = slots
+	}
+	return slots
+}
+
+// transferHost returns the lower case host, including any port, of the href
+// of t's action, or "" if it doesn't have one.
+func transferHost(t *Transfer, action string) string {
+	rel, ok := t.Object.Rel(action)
+	if !ok || rel == nil {
+		return ""
+	}
+
+	u, err := url.Parse(rel.Href)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
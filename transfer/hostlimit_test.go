@@ -0,0 +1,125 @@
+package transfer
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/github/git-lfs/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// inFlightTransfer records the most transfers it has had in flight at once to
+// each host.
+type inFlightTransfer struct {
+	mutex    sync.Mutex
+	inFlight map[string]int
+	max      map[string]int
+}
+
+func newInFlightTransfer() *inFlightTransfer {
+	return &inFlightTransfer{inFlight: make(map[string]int), max: make(map[string]int)}
+}
+
+func (f *inFlightTransfer) DoTransfer(t *Transfer, cb TransferProgressCallback, authOkFunc func()) error {
+	if authOkFunc != nil {
+		authOkFunc()
+	}
+
+	host := transferHost(t, "download")
+	f.mutex.Lock()
+	f.inFlight[host]++
+	if f.inFlight[host] > f.max[host] {
+		f.max[host] = f.inFlight[host]
+	}
+	f.mutex.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	f.mutex.Lock()
+	f.inFlight[host]--
+	f.mutex.Unlock()
+	return nil
+}
+
+func newHostTransfer(name, host string) *Transfer {
+	return NewTransfer(name, &api.ObjectResource{
+		Oid:  name,
+		Size: 1,
+		Actions: map[string]*api.LinkRelation{
+			"download": &api.LinkRelation{Href: "https://" + host + "/objects/" + name},
+		},
+	}, name)
+}
+
+func TestAdapterLimitsTransfersPerHost(t *testing.T) {
+	impl := newInFlightTransfer()
+	a := newAdapterBase("test", Download, impl)
+	a.hosts = newHostLimiter(func(host string) int {
+		if host == "cdn.example.com" {
+			return 2
+		}
+		return 0
+	})
+
+	results := make(chan TransferResult, 40)
+	assert.Nil(t, a.Begin(8, nil, results))
+	for i := 0; i < 20; i++ {
+		a.Add(newHostTransfer(fmt.Sprintf("cdn-%d", i), "cdn.example.com"))
+		a.Add(newHostTransfer(fmt.Sprintf("primary-%d", i), "primary.example.com"))
+	}
+	a.End()
+
+	count := 0
+	for res := range results {
+		assert.Nil(t, res.Error)
+		count++
+	}
+	assert.Equal(t, 40, count)
+
+	assert.True(t, impl.max["cdn.example.com"] <= 2, "%d in flight to the cdn at once", impl.max["cdn.example.com"])
+	assert.True(t, impl.max["primary.example.com"] > 2, "%d in flight to the primary at once", impl.max["primary.example.com"])
+}
+
+func TestHostLimiterAcquire(t *testing.T) {
+	h := newHostLimiter(func(host string) int {
+		if host == "cdn.example.com" {
+			return 1
+		}
+		return 0
+	})
+
+	release := h.Acquire("cdn.example.com")
+	acquired := make(chan struct{})
+	go func() {
+		h.Acquire("cdn.example.com")()
+		close(acquired)
+	}()
+
+	// other hosts aren't held up
+	h.Acquire("primary.example.com")()
+	h.Acquire("")()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquired a second connection to the cdn")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a connection to the cdn")
+	}
+
+	var none *hostLimiter
+	none.Acquire("cdn.example.com")()
+}
+
+func TestTransferHost(t *testing.T) {
+	assert.Equal(t, "cdn.example.com", transferHost(newHostTransfer("a", "CDN.example.com"), "download"))
+	assert.Equal(t, "cdn.example.com:8443", transferHost(newHostTransfer("a", "cdn.example.com:8443"), "download"))
+	assert.Equal(t, "", transferHost(newHostTransfer("a", "cdn.example.com"), "upload"))
+}
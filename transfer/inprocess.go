@@ -0,0 +1,134 @@
+package transfer
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/github/git-lfs/api"
+	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/errutil"
+	"github.com/github/git-lfs/progress"
+	"github.com/github/git-lfs/tools"
+	"github.com/rubyist/tracerx"
+)
+
+// InProcessAdapter is implemented by programs which embed git-lfs as a library
+// to transfer object content with Go code, instead of over HTTP. It's passed
+// to RegisterInProcessAdapter, which wraps it in a TransferAdapter, so the
+// transfer queue still calls the API, runs lfs.concurrenttransfers transfers
+// at once, retries failures and reports progress.
+//
+// Both methods are called on many goroutines at once, but never for the same
+// oid at the same time. action is the "upload" or "download" action of the
+// object from the batch response, which the server fills in for the adapter
+// it chose, and cb should be called as content is transferred. Errors created
+// with errutil.NewRetriableError are retried.
+type InProcessAdapter interface {
+	// Upload sends size bytes of the object oid, read from r.
+	Upload(oid string, size int64, action *api.LinkRelation, r io.Reader, cb progress.CopyCallback) error
+	// Download writes the content of the object oid to w. The content is
+	// checked against oid before it's stored.
+	Download(oid string, size int64, action *api.LinkRelation, w io.Writer, cb progress.CopyCallback) error
+}
+
+// RegisterInProcessAdapter registers impl as the upload and download adapter
+// called name, overriding any adapter already registered with that name,
+// including the built in "basic" and "tus" adapters.
+//
+// The names of all registered adapters are sent to the server in each batch
+// request, and the server picks the one used for the batch; git-lfs itself
+// has no preference between them. The basic adapter is used if the server
+// doesn't pick one or picks one that isn't registered, and is the only one
+// offered when lfs.basictransfersonly is set.
+func RegisterInProcessAdapter(name string, impl InProcessAdapter) {
+	newfunc := func(name string, dir Direction) TransferAdapter {
+		a := &inProcessAdapter{impl: impl}
+		a.adapterBase = newAdapterBase(name, dir, a)
+		return a
+	}
+	RegisterNewTransferAdapterFunc(name, Upload, newfunc)
+	RegisterNewTransferAdapterFunc(name, Download, newfunc)
+}
+
+// inProcessAdapter bridges an InProcessAdapter to the transfer queue.
+type inProcessAdapter struct {
+	*adapterBase
+	impl InProcessAdapter
+}
+
+// ClearTempStorage does nothing, since downloads which fail are removed
+// rather than resumed.
+func (a *inProcessAdapter) ClearTempStorage() error {
+	return nil
+}
+
+func (a *inProcessAdapter) DoTransfer(t *Transfer, cb TransferProgressCallback, authOkFunc func()) error {
+	// There's no login to wait for, so the other workers can start
+	if authOkFunc != nil {
+		authOkFunc()
+	}
+
+	ccb := func(totalSize int64, readSoFar int64, readSinceLast int) error {
+		if cb != nil {
+			return cb(t.Name, totalSize, readSoFar, readSinceLast)
+		}
+		return nil
+	}
+
+	if a.direction == Upload {
+		return a.upload(t, ccb)
+	}
+	return a.download(t, ccb)
+}
+
+func (a *inProcessAdapter) upload(t *Transfer, cb progress.CopyCallback) error {
+	rel, ok := t.Object.Rel("upload")
+	if !ok {
+		return fmt.Errorf("No upload action for this object.")
+	}
+
+	f, err := config.Config.FileLimiter().OpenFile(t.Path, os.O_RDONLY, 0644)
+	if err != nil {
+		return errutil.Error(err)
+	}
+	defer f.Close()
+
+	tracerx.Printf("xfer: adapter %q uploading %s", a.Name(), t.Object.Oid)
+	if err := a.impl.Upload(t.Object.Oid, t.Object.Size, rel, f, cb); err != nil {
+		return err
+	}
+
+	return api.VerifyUpload(t.Object)
+}
+
+func (a *inProcessAdapter) download(t *Transfer, cb progress.CopyCallback) error {
+	rel, ok := t.Object.Rel("download")
+	if !ok {
+		return fmt.Errorf("No download action for this object.")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(t.Path), t.Object.Oid+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	tracerx.Printf("xfer: adapter %q downloading %s", a.Name(), t.Object.Oid)
+	hasher := tools.NewLfsContentHash()
+	err = a.impl.Download(t.Object.Oid, t.Object.Size, rel, io.MultiWriter(tmp, hasher), cb)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if actual := fmt.Sprintf("%x", hasher.Sum(nil)); actual != t.Object.Oid {
+		return fmt.Errorf("Expected OID %s, got %s from adapter %q", t.Object.Oid, actual, a.Name())
+	}
+
+	return finishDownload(t, tmp.Name())
+}
@@ -0,0 +1,120 @@
+package transfer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/github/git-lfs/api"
+	"github.com/github/git-lfs/progress"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryAdapter is an InProcessAdapter which stores objects in memory, under
+// the href of their actions.
+type memoryAdapter struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (m *memoryAdapter) Upload(oid string, size int64, action *api.LinkRelation, r io.Reader, cb progress.CopyCallback) error {
+	by, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	cb(size, int64(len(by)), len(by))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[action.Href] = by
+	return nil
+}
+
+func (m *memoryAdapter) Download(oid string, size int64, action *api.LinkRelation, w io.Writer, cb progress.CopyCallback) error {
+	m.mu.Lock()
+	by := m.objects[action.Href]
+	m.mu.Unlock()
+
+	n, err := w.Write(by)
+	cb(size, int64(n), n)
+	return err
+}
+
+func TestInProcessAdapterRoundTrip(t *testing.T) {
+	mem := &memoryAdapter{objects: make(map[string][]byte)}
+	RegisterInProcessAdapter("memory", mem)
+
+	assert.Contains(t, GetUploadAdapterNames(), "memory")
+	assert.Contains(t, GetDownloadAdapterNames(), "memory")
+
+	dir, err := ioutil.TempDir("", "lfs-inprocess-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content := []byte("in process")
+	sum := sha256.Sum256(content)
+	obj := &api.ObjectResource{
+		Oid:  hex.EncodeToString(sum[:]),
+		Size: int64(len(content)),
+		Actions: map[string]*api.LinkRelation{
+			"upload":   &api.LinkRelation{Href: "mem://objects/1"},
+			"download": &api.LinkRelation{Href: "mem://objects/1"},
+		},
+	}
+
+	src := filepath.Join(dir, "src.dat")
+	if err := ioutil.WriteFile(src, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var read int64
+	cb := func(name string, total, readSoFar int64, current int) error {
+		assert.Equal(t, "obj.dat", name)
+		read = readSoFar
+		return nil
+	}
+
+	assert.Nil(t, runTransfer(NewUploadAdapter("memory"), cb, NewTransfer("obj.dat", obj, src)))
+	assert.Equal(t, content, mem.objects["mem://objects/1"])
+	assert.Equal(t, int64(len(content)), read)
+
+	dest := filepath.Join(dir, "dest.dat")
+	assert.Nil(t, runTransfer(NewDownloadAdapter("memory"), cb, NewTransfer("obj.dat", obj, dest)))
+	by, err := ioutil.ReadFile(dest)
+	assert.Nil(t, err)
+	assert.Equal(t, content, by)
+
+	// corrupt content isn't stored
+	mem.objects["mem://objects/1"] = []byte("in progress")
+	os.Remove(dest)
+	err = runTransfer(NewDownloadAdapter("memory"), nil, NewTransfer("obj.dat", obj, dest))
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "Expected OID "+obj.Oid)
+	}
+	_, err = os.Stat(dest)
+	assert.True(t, os.IsNotExist(err))
+
+	files, _ := ioutil.ReadDir(dir)
+	assert.Len(t, files, 1, "temp files are removed")
+}
+
+// runTransfer runs t with adapter, and returns its error.
+func runTransfer(adapter TransferAdapter, cb TransferProgressCallback, t *Transfer) error {
+	results := make(chan TransferResult, 1)
+	adapter.Begin(1, cb, results)
+	adapter.Add(t)
+	adapter.End()
+
+	var err error
+	for res := range results {
+		err = res.Error
+	}
+	return err
+}
@@ -0,0 +1,171 @@
+package transfer
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// NewIntegrityCheckFunc returns a hash to check downloaded content with, and
+// the digest the content should have, from the value of an integrity header.
+// It returns a nil hash if the value uses nothing it can check, such as an
+// unknown algorithm. Code that wishes to check other integrity headers should
+// pass an implementation of this function to RegisterIntegrityHeader.
+type NewIntegrityCheckFunc func(value string) (h hash.Hash, digest []byte, err error)
+
+var (
+	integrityMutex   sync.Mutex
+	integrityHeaders = make(map[string]integrityHeader)
+)
+
+// integrityHeader is a registered integrity header, keeping the name it was
+// registered with for messages.
+type integrityHeader struct {
+	name    string
+	newFunc NewIntegrityCheckFunc
+}
+
+// RegisterIntegrityHeader registers a function for checking downloads against
+// the response header called header. If a function for that header is already
+// registered, it is overridden.
+func RegisterIntegrityHeader(header string, f NewIntegrityCheckFunc) {
+	integrityMutex.Lock()
+	defer integrityMutex.Unlock()
+
+	integrityHeaders[http.CanonicalHeaderKey(header)] = integrityHeader{header, f}
+}
+
+// integrityCheck checks downloaded content against the digest an integrity
+// header gives. Content written to it is hashed.
+type integrityCheck struct {
+	header string
+	hash   hash.Hash
+	digest []byte
+}
+
+func (c *integrityCheck) Write(p []byte) (int, error) {
+	return c.hash.Write(p)
+}
+
+// verify returns an error if the content written doesn't have the digest its
+// header gave.
+func (c *integrityCheck) verify() error {
+	if actual := c.hash.Sum(nil); !bytes.Equal(actual, c.digest) {
+		return fmt.Errorf("%s header expected %x, got %x", c.header, c.digest, actual)
+	}
+	return nil
+}
+
+// newIntegrityChecks returns a check for each registered integrity header
+// that header has.
+func newIntegrityChecks(header http.Header) ([]*integrityCheck, error) {
+	integrityMutex.Lock()
+	defer integrityMutex.Unlock()
+
+	var checks []*integrityCheck
+	for key, ih := range integrityHeaders {
+		name := ih.name
+		value := header.Get(key)
+		if len(value) == 0 {
+			continue
+		}
+
+		h, digest, err := ih.newFunc(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s header %q: %s", name, value, err)
+		}
+		if h == nil {
+			continue
+		}
+
+		checks = append(checks, &integrityCheck{header: name, hash: h, digest: digest})
+	}
+	return checks, nil
+}
+
+// integrityWriter returns a writer which hashes content for every check, or
+// nil if there are none.
+func integrityWriter(checks []*integrityCheck) io.Writer {
+	if len(checks) == 0 {
+		return nil
+	}
+
+	writers := make([]io.Writer, 0, len(checks))
+	for _, c := range checks {
+		writers = append(writers, c)
+	}
+	return io.MultiWriter(writers...)
+}
+
+// newContentMD5Check checks the base64 MD5 of a Content-MD5 header.
+func newContentMD5Check(value string) (hash.Hash, []byte, error) {
+	digest, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, nil, err
+	}
+	return md5.New(), digest, nil
+}
+
+// newContentSha256Check checks the hex SHA-256 of an X-Content-Sha256 header.
+func newContentSha256Check(value string) (hash.Hash, []byte, error) {
+	digest, err := hex.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, nil, err
+	}
+	return sha256.New(), digest, nil
+}
+
+// digestAlgorithms are the algorithms of RFC 3230 Digest headers which can be
+// checked, strongest first.
+var digestAlgorithms = []struct {
+	name string
+	new  func() hash.Hash
+}{
+	{"sha-512", sha512.New},
+	{"sha-256", sha256.New},
+	{"sha", sha1.New},
+	{"md5", md5.New},
+}
+
+// newDigestCheck checks the strongest algorithm it knows of a Digest header,
+// such as "SHA-256=<base64>,MD5=<base64>".
+func newDigestCheck(value string) (hash.Hash, []byte, error) {
+	digests := make(map[string]string)
+	for _, instance := range strings.Split(value, ",") {
+		eq := strings.Index(instance, "=")
+		if eq < 1 {
+			return nil, nil, fmt.Errorf("%q has no algorithm", strings.TrimSpace(instance))
+		}
+		digests[strings.ToLower(strings.TrimSpace(instance[:eq]))] = strings.TrimSpace(instance[eq+1:])
+	}
+
+	for _, alg := range digestAlgorithms {
+		encoded, ok := digests[alg.name]
+		if !ok {
+			continue
+		}
+
+		digest, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, nil, err
+		}
+		return alg.new(), digest, nil
+	}
+	return nil, nil, nil
+}
+
+func init() {
+	RegisterIntegrityHeader("Content-MD5", newContentMD5Check)
+	RegisterIntegrityHeader("X-Content-Sha256", newContentSha256Check)
+	RegisterIntegrityHeader("Digest", newDigestCheck)
+}
@@ -0,0 +1,93 @@
+package transfer
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBasicDownloadIntegrityHeaders(t *testing.T) {
+	content := []byte("integrity content")
+	other := []byte("other content")
+
+	md5sum := func(by []byte) string {
+		sum := md5.Sum(by)
+		return base64.StdEncoding.EncodeToString(sum[:])
+	}
+	sha256hex := func(by []byte) string {
+		sum := sha256.Sum256(by)
+		return hex.EncodeToString(sum[:])
+	}
+	sha256sum := func(by []byte) string {
+		sum := sha256.Sum256(by)
+		return base64.StdEncoding.EncodeToString(sum[:])
+	}
+	sha512sum := func(by []byte) string {
+		sum := sha512.Sum512(by)
+		return base64.StdEncoding.EncodeToString(sum[:])
+	}
+
+	for desc, test := range map[string]struct {
+		header string
+		value  string
+		err    string
+	}{
+		"Content-MD5 match":              {"Content-MD5", md5sum(content), ""},
+		"Content-MD5 mismatch":           {"Content-MD5", md5sum(other), "Content-MD5 header expected"},
+		"Content-MD5 invalid":            {"Content-MD5", "not base64!", "Invalid Content-MD5 header"},
+		"X-Content-Sha256 match":         {"X-Content-Sha256", sha256hex(content), ""},
+		"X-Content-Sha256 mismatch":      {"X-Content-Sha256", sha256hex(other), "X-Content-Sha256 header expected"},
+		"Digest SHA-256 match":           {"Digest", "SHA-256=" + sha256sum(content), ""},
+		"Digest SHA-256 mismatch":        {"Digest", "SHA-256=" + sha256sum(other), "Digest header expected"},
+		"Digest MD5 match":               {"Digest", "MD5=" + md5sum(content), ""},
+		"Digest MD5 mismatch":            {"Digest", "md5=" + md5sum(other), "Digest header expected"},
+		"Digest uses strongest":          {"Digest", "MD5=" + md5sum(other) + ", SHA-512=" + sha512sum(content), ""},
+		"Digest strongest mismatch":      {"Digest", "MD5=" + md5sum(content) + ", SHA-512=" + sha512sum(other), "Digest header expected"},
+		"Digest with unknown algorithms": {"Digest", "UNIXsum=1234", ""},
+	} {
+		tr, cleanup := setupDownloadTestWithHeader(t, content, http.Header{test.header: []string{test.value}})
+
+		err := NewDownloadAdapter(BasicAdapterName).(*basicDownloadAdapter).DoTransfer(tr, nil, nil)
+		if len(test.err) == 0 {
+			if assert.Nil(t, err, desc) {
+				by, err := ioutil.ReadFile(tr.Path)
+				assert.Nil(t, err, desc)
+				assert.Equal(t, content, by, desc)
+			}
+		} else if assert.NotNil(t, err, desc) {
+			assert.Contains(t, err.Error(), test.err, desc)
+			_, err := os.Stat(tr.Path)
+			assert.True(t, os.IsNotExist(err), desc)
+		}
+
+		cleanup()
+	}
+}
+
+func TestRegisterIntegrityHeader(t *testing.T) {
+	content := []byte("integrity content")
+	sum := sha256.Sum256([]byte("other content"))
+
+	RegisterIntegrityHeader("X-Test-Integrity", newContentSha256Check)
+	defer func() {
+		integrityMutex.Lock()
+		delete(integrityHeaders, "X-Test-Integrity")
+		integrityMutex.Unlock()
+	}()
+
+	tr, cleanup := setupDownloadTestWithHeader(t, content, http.Header{"X-Test-Integrity": []string{hex.EncodeToString(sum[:])}})
+	defer cleanup()
+
+	err := NewDownloadAdapter(BasicAdapterName).(*basicDownloadAdapter).DoTransfer(tr, nil, nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "X-Test-Integrity header expected")
+	}
+}
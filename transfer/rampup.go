@@ -0,0 +1,90 @@
+package transfer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rubyist/tracerx"
+)
+
+// rampUp lets an adapter's workers start gradually instead of all at once, so
+// that a cold server or proxy isn't hit with every transfer together. The
+// first few workers start straight away, and each of the rest waits to be
+// admitted, which happens at even intervals over the ramp-up window, or sooner
+// when a transfer succeeds. A nil *rampUp admits every worker immediately.
+type rampUp struct {
+	start   int
+	pending int
+	admit   chan struct{}
+	done    chan struct{}
+	mutex   sync.Mutex
+}
+
+// newRampUp returns a rampUp for workers workers, start of which begin
+// immediately while the rest are admitted over window. It returns nil if there
+// is nothing to ramp up.
+func newRampUp(workers, start int, window time.Duration) *rampUp {
+	if start < 1 {
+		start = 1
+	}
+
+	if window <= 0 || workers <= start {
+		return nil
+	}
+
+	r := &rampUp{
+		start:   start,
+		pending: workers - start,
+		admit:   make(chan struct{}, workers-start),
+		done:    make(chan struct{}),
+	}
+
+	go r.tick(window / time.Duration(r.pending))
+	return r
+}
+
+// Wait blocks worker workerNum until it is admitted.
+func (r *rampUp) Wait(workerNum int) {
+	if r == nil || workerNum < r.start {
+		return
+	}
+
+	<-r.admit
+	tracerx.Printf("xfer: ramp-up admitted worker %d", workerNum)
+}
+
+// Release admits another worker, if any are still waiting. Workers call this
+// when a transfer succeeds, and when they stop, so that waiting workers don't
+// hold up the end of a batch.
+func (r *rampUp) Release() {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.pending == 0 {
+		return
+	}
+
+	r.pending--
+	r.admit <- struct{}{}
+	if r.pending == 0 {
+		close(r.done)
+	}
+}
+
+func (r *rampUp) tick(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.Release()
+		case <-r.done:
+			return
+		}
+	}
+}
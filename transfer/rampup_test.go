@@ -0,0 +1,155 @@
+package transfer
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/github/git-lfs/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// startedTransfer reports each transfer as it starts, and holds it until a
+// value is sent on release.
+type startedTransfer struct {
+	calls   int32
+	started chan string
+	release chan struct{}
+}
+
+func newStartedTransfer(n int) *startedTransfer {
+	return &startedTransfer{started: make(chan string, n), release: make(chan struct{})}
+}
+
+func (s *startedTransfer) DoTransfer(t *Transfer, cb TransferProgressCallback, authOkFunc func()) error {
+	atomic.AddInt32(&s.calls, 1)
+	if authOkFunc != nil {
+		authOkFunc()
+	}
+	s.started <- t.Name
+	<-s.release
+	return nil
+}
+
+func waitForStart(t *testing.T, s *startedTransfer) string {
+	select {
+	case name := <-s.started:
+		return name
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a transfer to start")
+		return ""
+	}
+}
+
+func refuteStart(t *testing.T, s *startedTransfer, wait time.Duration) {
+	select {
+	case name := <-s.started:
+		t.Fatalf("%s started before it was admitted", name)
+	case <-time.After(wait):
+	}
+}
+
+func addTransfers(a *adapterBase, n int) {
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("%d.dat", i)
+		a.Add(NewTransfer(name, &api.ObjectResource{Oid: name, Size: 1}, name))
+	}
+}
+
+func TestAdapterStartsAllWorkersWithoutRampUp(t *testing.T) {
+	impl := newStartedTransfer(3)
+	a := newAdapterBase("test", Download, impl)
+	a.rampWindow = 0
+
+	assert.Nil(t, a.Begin(3, nil, nil))
+	addTransfers(a, 3)
+
+	for i := 0; i < 3; i++ {
+		waitForStart(t, impl)
+	}
+	close(impl.release)
+	a.End()
+}
+
+func TestAdapterRampsUpAsTransfersSucceed(t *testing.T) {
+	impl := newStartedTransfer(4)
+	a := newAdapterBase("test", Download, impl)
+	a.rampStart = 1
+	a.rampWindow = time.Hour
+
+	assert.Nil(t, a.Begin(3, nil, nil))
+	addTransfers(a, 4)
+
+	waitForStart(t, impl)
+	refuteStart(t, impl, 100*time.Millisecond)
+
+	// the first success admits a second worker, so the next two transfers
+	// run together
+	impl.release <- struct{}{}
+	waitForStart(t, impl)
+	waitForStart(t, impl)
+	refuteStart(t, impl, 100*time.Millisecond)
+
+	close(impl.release)
+	a.End()
+	assert.Equal(t, int32(4), impl.calls)
+}
+
+func TestAdapterRampsUpOverWindow(t *testing.T) {
+	impl := newStartedTransfer(4)
+	a := newAdapterBase("test", Download, impl)
+	a.rampStart = 2
+	a.rampWindow = 400 * time.Millisecond
+
+	assert.Nil(t, a.Begin(4, nil, nil))
+	addTransfers(a, 4)
+
+	began := time.Now()
+	waitForStart(t, impl)
+	waitForStart(t, impl)
+	assert.True(t, time.Since(began) < 150*time.Millisecond, "the first rampupstart workers start at once")
+
+	// the other two are admitted 200ms apart, even though nothing finished
+	waitForStart(t, impl)
+	third := time.Since(began)
+	waitForStart(t, impl)
+	fourth := time.Since(began)
+	assert.True(t, third >= 150*time.Millisecond, "third transfer started after %s", third)
+	assert.True(t, fourth >= 350*time.Millisecond, "fourth transfer started after %s", fourth)
+
+	close(impl.release)
+	a.End()
+}
+
+func TestAdapterEndDoesNotWaitForRampUp(t *testing.T) {
+	impl := newStartedTransfer(1)
+	a := newAdapterBase("test", Download, impl)
+	a.rampWindow = time.Hour
+	close(impl.release)
+
+	assert.Nil(t, a.Begin(4, nil, nil))
+	addTransfers(a, 1)
+
+	ended := make(chan struct{})
+	go func() {
+		a.End()
+		close(ended)
+	}()
+
+	select {
+	case <-ended:
+	case <-time.After(5 * time.Second):
+		t.Fatal("End() waited for workers that were never admitted")
+	}
+}
+
+func TestNewRampUpDisabled(t *testing.T) {
+	assert.Nil(t, newRampUp(3, 1, 0))
+	assert.Nil(t, newRampUp(3, 3, time.Second))
+	assert.Nil(t, newRampUp(1, 0, time.Second))
+
+	var r *rampUp
+	r.Wait(2)
+	r.Release()
+}
@@ -3,6 +3,7 @@
 package transfer
 
 import (
+	"net/http"
 	"sync"
 
 	"github.com/github/git-lfs/config"
@@ -79,11 +80,15 @@ type Transfer struct {
 	// Path for uploads is the source of data to send, for downloads is the
 	// location to place the final result
 	Path string
+	// Header holds the headers of the response a download's content came
+	// from, once it has succeeded. Adapters which don't download over HTTP
+	// leave it nil.
+	Header http.Header
 }
 
 // NewTransfer creates a new Transfer instance
 func NewTransfer(name string, obj *api.ObjectResource, path string) *Transfer {
-	return &Transfer{name, obj, path}
+	return &Transfer{Name: name, Object: obj, Path: path}
 }
 
 // Result of a transfer returned through CompletionChannel()
@@ -8,6 +8,7 @@ import (
 	"strconv"
 
 	"github.com/github/git-lfs/api"
+	"github.com/github/git-lfs/config"
 	"github.com/github/git-lfs/errutil"
 	"github.com/github/git-lfs/httputil"
 	"github.com/github/git-lfs/progress"
@@ -69,20 +70,24 @@ func (a *tusUploadAdapter) DoTransfer(t *Transfer, cb TransferProgressCallback,
 	}
 
 	// Open file for uploading
-	f, err := os.OpenFile(t.Path, os.O_RDONLY, 0644)
+	f, err := config.Config.FileLimiter().OpenFile(t.Path, os.O_RDONLY, 0644)
 	if err != nil {
 		return errutil.Error(err)
 	}
 	defer f.Close()
 
+	// The content is hashed as it's sent, including the part the server
+	// already has, so that content which no longer matches its oid fails
+	// the upload instead of being stored.
+	hr := newHashingReader(f, t.Object.Oid, t.Object.Size)
+
 	// Upload-Offset=0 means start from scratch, but still send PATCH
 	if offset == 0 {
 		tracerx.Printf("xfer: tus.io uploading %q from start", t.Object.Oid)
 	} else {
 		tracerx.Printf("xfer: tus.io resuming upload %q from %d", t.Object.Oid, offset)
 		advanceCallbackProgress(cb, t, offset)
-		_, err := f.Seek(offset, os.SEEK_CUR)
-		if err != nil {
+		if err := hr.skip(offset); err != nil {
 			return errutil.Error(err)
 		}
 	}
@@ -100,6 +105,7 @@ func (a *tusUploadAdapter) DoTransfer(t *Transfer, cb TransferProgressCallback,
 	req.Header.Set("Tus-Resumable", TusVersion)
 	req.Header.Set("Upload-Offset", strconv.FormatInt(offset, 10))
 	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	setUploadHeaders(req)
 	req.Header.Set("Content-Length", strconv.FormatInt(t.Object.Size-offset, 10))
 	req.ContentLength = t.Object.Size - offset
 
@@ -115,7 +121,8 @@ func (a *tusUploadAdapter) DoTransfer(t *Transfer, cb TransferProgressCallback,
 	reader = &progress.CallbackReader{
 		C:         ccb,
 		TotalSize: t.Object.Size,
-		Reader:    f,
+		Reader:    hr,
+		Throttle:  a.throttle,
 	}
 
 	// Signal auth was ok on first read; this frees up other workers to start
@@ -128,6 +135,12 @@ func (a *tusUploadAdapter) DoTransfer(t *Transfer, cb TransferProgressCallback,
 	req.Body = ioutil.NopCloser(reader)
 
 	res, err = httputil.DoHttpRequest(req, false)
+	if hr.mismatch != nil {
+		return errutil.Errorf(hr.mismatch, "Unable to upload %s: %s", t.Object.Oid, hr.mismatch)
+	}
+	if res != nil && res.StatusCode == 409 && config.Config.TransferUploadConflict() == "verify" {
+		return verifyConflictingUpload(t)
+	}
 	if err != nil {
 		return errutil.NewRetriableError(err)
 	}
@@ -140,7 +153,7 @@ func (a *tusUploadAdapter) DoTransfer(t *Transfer, cb TransferProgressCallback,
 	}
 
 	if res.StatusCode > 299 {
-		return errutil.Errorf(nil, "Invalid status for %s: %d", httputil.TraceHttpReq(req), res.StatusCode)
+		return invalidStatusError(req, res)
 	}
 
 	io.Copy(ioutil.Discard, res.Body)